@@ -0,0 +1,333 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// protectedResourceMetadataPath is the well-known path of the OAuth 2.0
+// protected resource metadata document (RFC 9728).
+const protectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// Authorization errors.
+var (
+	// ErrInvalidToken is returned by a TokenVerifier when an access token is
+	// malformed, expired, or not issued for this server.
+	ErrInvalidToken = errors.New("invalid access token")
+
+	// ErrInsufficientScope is reported when a request lacks the scopes a tool requires.
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// TokenInfo describes a verified access token.
+type TokenInfo struct {
+	// Subject is the resource owner the token was issued for.
+	Subject string
+
+	// ClientID is the OAuth client the token was issued to.
+	ClientID string
+
+	// Scopes are the scopes granted by the token.
+	Scopes []string
+
+	// ExpiresAt is the expiry time of the token, zero if it does not expire.
+	ExpiresAt time.Time
+
+	// Claims holds all the claims of the token, if the verifier exposes them.
+	Claims map[string]interface{}
+}
+
+// HasScopes reports whether the token grants all the given scopes.
+func (t *TokenInfo) HasScopes(scopes ...string) bool {
+	return len(t.missingScopes(scopes)) == 0
+}
+
+// missingScopes returns the scopes that the token does not grant.
+func (t *TokenInfo) missingScopes(scopes []string) []string {
+	var missing []string
+	for _, scope := range scopes {
+		granted := false
+		if t != nil {
+			for _, s := range t.Scopes {
+				if s == scope {
+					granted = true
+					break
+				}
+			}
+		}
+		if !granted {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// TokenVerifier verifies the bearer access tokens of incoming requests.
+type TokenVerifier interface {
+	// VerifyToken checks the token and returns what it grants. It returns an
+	// error wrapping ErrInvalidToken if the token must be rejected.
+	VerifyToken(ctx context.Context, token string) (*TokenInfo, error)
+}
+
+// TokenVerifierFunc adapts a function to the TokenVerifier interface.
+type TokenVerifierFunc func(ctx context.Context, token string) (*TokenInfo, error)
+
+// VerifyToken implements TokenVerifier.
+func (f TokenVerifierFunc) VerifyToken(ctx context.Context, token string) (*TokenInfo, error) {
+	return f(ctx, token)
+}
+
+// ProtectedResourceMetadata is the OAuth 2.0 protected resource metadata
+// document (RFC 9728) telling clients which authorization servers issue tokens
+// for the server.
+type ProtectedResourceMetadata struct {
+	// Resource is the URL identifying the server. When empty, it is derived
+	// from the request the document is served for, but is not the audience
+	// of the tokens: JWTVerifier then rejects every token unless it is given
+	// an audience with WithJWTAudience.
+	Resource string `json:"resource"`
+
+	// AuthorizationServers lists the issuer URLs of the authorization servers.
+	AuthorizationServers []string `json:"authorization_servers,omitempty"`
+
+	// ScopesSupported lists the scopes the server understands.
+	ScopesSupported []string `json:"scopes_supported,omitempty"`
+
+	// BearerMethodsSupported lists how bearer tokens may be sent, "header" by default.
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+
+	// ResourceName is a human-readable name of the server.
+	ResourceName string `json:"resource_name,omitempty"`
+
+	// ResourceDocumentation is the URL of the server's documentation.
+	ResourceDocumentation string `json:"resource_documentation,omitempty"`
+}
+
+// tokenInfoKey is the context key of the verified token of a request.
+type tokenInfoKey struct{}
+
+// withTokenInfo adds the verified token of a request to the context.
+func withTokenInfo(ctx context.Context, info *TokenInfo) context.Context {
+	return context.WithValue(ctx, tokenInfoKey{}, info)
+}
+
+// TokenInfoFromContext returns the verified access token of the request being
+// handled. It is only set when the server requires authorization.
+//
+// Example:
+//
+//	func handler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+//	    if info, ok := mcp.TokenInfoFromContext(ctx); ok {
+//	        log.Printf("called by %s", info.Subject)
+//	    }
+//	    ...
+//	}
+func TokenInfoFromContext(ctx context.Context) (*TokenInfo, bool) {
+	info, ok := ctx.Value(tokenInfoKey{}).(*TokenInfo)
+	return info, ok && info != nil
+}
+
+// resourceURLKey is the context key of the URL of the resource a token is verified for.
+type resourceURLKey struct{}
+
+// withResource adds the URL of the resource a token is verified for to the context.
+func withResource(ctx context.Context, resource string) context.Context {
+	return context.WithValue(ctx, resourceURLKey{}, resource)
+}
+
+// resourceFromContext returns the URL of the resource a token is verified for.
+func resourceFromContext(ctx context.Context) string {
+	resource, _ := ctx.Value(resourceURLKey{}).(string)
+	return resource
+}
+
+// authorizer guards an HTTP transport with bearer token authorization and
+// serves its protected resource metadata.
+type authorizer struct {
+	verifier TokenVerifier
+	metadata *ProtectedResourceMetadata
+	// resourcePath is the path of the MCP endpoint, used when metadata.Resource is empty.
+	resourcePath string
+	// trustProxyHeaders is whether the X-Forwarded-Proto header of a trusted
+	// reverse proxy gives the scheme of the requests.
+	trustProxyHeaders bool
+}
+
+// newAuthorizer creates an authorizer. metadata may be nil, in which case no
+// metadata document is served.
+func newAuthorizer(verifier TokenVerifier, metadata *ProtectedResourceMetadata, resourcePath string) *authorizer {
+	return &authorizer{
+		verifier:     verifier,
+		metadata:     metadata,
+		resourcePath: resourcePath,
+	}
+}
+
+// serveMetadata serves the metadata document if r requests it, and reports
+// whether it did.
+func (a *authorizer) serveMetadata(w http.ResponseWriter, r *http.Request) bool {
+	if a.metadata == nil || (r.URL.Path != protectedResourceMetadataPath && r.URL.Path != a.metadataPath()) {
+		return false
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return true
+	}
+
+	metadata := *a.metadata
+	metadata.Resource = a.resource(r)
+	if len(metadata.BearerMethodsSupported) == 0 {
+		metadata.BearerMethodsSupported = []string{"header"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=3600")
+	_ = json.NewEncoder(w).Encode(metadata)
+	return true
+}
+
+// authorize verifies the bearer token of r for the resource of the server. It
+// returns r with the token attached to its context, or writes a 401 response
+// and returns false.
+func (a *authorizer) authorize(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	token, ok := bearerToken(r)
+	if !ok {
+		a.writeUnauthorized(w, r, nil)
+		return nil, false
+	}
+	// The resource derived from the request is not trusted: the client
+	// chooses its Host header, and could replay a token of another server.
+	var resource string
+	if a.metadata != nil {
+		resource = a.metadata.Resource
+	}
+	info, err := a.verifier.VerifyToken(withResource(r.Context(), resource), token)
+	if err != nil {
+		a.writeUnauthorized(w, r, err)
+		return nil, false
+	}
+	if info == nil {
+		info = &TokenInfo{}
+	}
+	return r.WithContext(withTokenInfo(r.Context(), info)), true
+}
+
+// writeUnauthorized writes a 401 response whose WWW-Authenticate header points
+// the client to the metadata document (RFC 9728 section 5.1).
+func (a *authorizer) writeUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	challenge := "Bearer"
+	var params []string
+	if a.metadata != nil {
+		params = append(params, fmt.Sprintf("resource_metadata=%q", a.metadataURL(r)))
+	}
+	if err != nil {
+		params = append(params, `error="invalid_token"`,
+			fmt.Sprintf("error_description=%q", strings.ReplaceAll(err.Error(), `"`, "'")))
+	}
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// writeInsufficientScope writes a 403 response whose WWW-Authenticate header
+// tells the client the scopes the request requires (RFC 6750 section 3.1).
+func (a *authorizer) writeInsufficientScope(w http.ResponseWriter, r *http.Request, scopes []string) {
+	params := []string{`error="insufficient_scope"`, fmt.Sprintf("scope=%q", strings.Join(scopes, " "))}
+	if a.metadata != nil {
+		params = append(params, fmt.Sprintf("resource_metadata=%q", a.metadataURL(r)))
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
+
+// resource returns the URL identifying the server, the resource of the
+// metadata or else the URL of the MCP endpoint r was sent to.
+func (a *authorizer) resource(r *http.Request) string {
+	if a.metadata != nil && a.metadata.Resource != "" {
+		return a.metadata.Resource
+	}
+	return a.requestOrigin(r) + a.resourcePath
+}
+
+// metadataURL returns the URL of the metadata document: the well-known path
+// inserted before the path of the resource.
+func (a *authorizer) metadataURL(r *http.Request) string {
+	if a.metadata.Resource != "" {
+		if u, err := url.Parse(a.metadata.Resource); err == nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host + a.metadataPath()
+		}
+	}
+	return a.requestOrigin(r) + a.metadataPath()
+}
+
+// metadataPath returns the path of the metadata document of the resource.
+func (a *authorizer) metadataPath() string {
+	resourcePath := a.resourcePath
+	if a.metadata.Resource != "" {
+		if u, err := url.Parse(a.metadata.Resource); err == nil && u.Host != "" {
+			resourcePath = u.Path
+		}
+	}
+	return protectedResourceMetadataPath + strings.TrimSuffix(resourcePath, "/")
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, token != ""
+}
+
+// requestOrigin returns the scheme and host r was sent to. The scheme of the
+// X-Forwarded-Proto header is only used behind a trusted proxy, as clients
+// could otherwise choose it.
+func (a *authorizer) requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); a.trustProxyHeaders && (proto == "http" || proto == "https") {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// toolScopesAllowed reports whether the request of ctx may use the tool.
+// Tools without required scopes are always allowed; the others need a token
+// granting all their scopes.
+func toolScopesAllowed(ctx context.Context, tool *Tool) bool {
+	if tool == nil || len(tool.RequiredScopes) == 0 {
+		return true
+	}
+	info, _ := TokenInfoFromContext(ctx)
+	return info.HasScopes(tool.RequiredScopes...)
+}
+
+// filterToolsByScopes removes the tools the request of ctx may not use.
+func filterToolsByScopes(ctx context.Context, tools []*Tool) []*Tool {
+	filtered := tools[:0:0]
+	for _, tool := range tools {
+		if toolScopesAllowed(ctx, tool) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,484 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for RS256, PS256 and ES256.
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512.
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultJWTLeeway is the default clock skew tolerated when checking the time claims of a token.
+const defaultJWTLeeway = time.Minute
+
+// JWTVerifier is a TokenVerifier for JWT access tokens (RFC 9068) signed with
+// the keys of a JSON Web Key Set. It accepts RS*, PS*, ES* and EdDSA
+// signatures, requires an exp claim, and reads the granted scopes from the
+// scope claim, or the scp claim used by some authorization servers.
+type JWTVerifier struct {
+	issuer    string
+	audiences []string
+	leeway    time.Duration
+	now       func() time.Time
+
+	// jwksFile is the file the key set is loaded from, empty if given inline.
+	jwksFile string
+	jwks     []byte
+
+	mu   sync.RWMutex
+	keys []*jsonWebKey
+	// modTime is the modification time of jwksFile when it was last loaded.
+	modTime time.Time
+}
+
+// JWTVerifierOption configures a JWTVerifier.
+type JWTVerifierOption func(*JWTVerifier)
+
+// WithJWKSFile loads the key set from a JSON Web Key Set file. The file is
+// loaded again when it changes and a token is signed with an unknown key, so
+// that keys can be rotated without restarting the server.
+func WithJWKSFile(path string) JWTVerifierOption {
+	return func(v *JWTVerifier) {
+		v.jwksFile = path
+	}
+}
+
+// WithJWKS sets the key set from the JSON of a JSON Web Key Set.
+func WithJWKS(jwks []byte) JWTVerifierOption {
+	return func(v *JWTVerifier) {
+		v.jwks = jwks
+	}
+}
+
+// WithJWTIssuer only accepts tokens whose iss claim is issuer.
+func WithJWTIssuer(issuer string) JWTVerifierOption {
+	return func(v *JWTVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithJWTAudience only accepts tokens whose aud claim contains one of the
+// audiences, typically the URL of the server. By default, the audience is the
+// Resource of the ProtectedResourceMetadata of the server the token is sent
+// to. Without either, every token is rejected: the audience is never derived
+// from the request, whose Host header the client chooses.
+func WithJWTAudience(audiences ...string) JWTVerifierOption {
+	return func(v *JWTVerifier) {
+		v.audiences = append(v.audiences, audiences...)
+	}
+}
+
+// WithJWTLeeway sets the clock skew tolerated when checking the exp and nbf
+// claims, one minute by default.
+func WithJWTLeeway(leeway time.Duration) JWTVerifierOption {
+	return func(v *JWTVerifier) {
+		v.leeway = leeway
+	}
+}
+
+// NewJWTVerifier creates a JWT verifier. The key set must be given with
+// WithJWKSFile or WithJWKS.
+func NewJWTVerifier(options ...JWTVerifierOption) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		leeway: defaultJWTLeeway,
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(v)
+	}
+
+	switch {
+	case v.jwksFile != "":
+		if _, err := v.reloadKeys(); err != nil {
+			return nil, err
+		}
+	case v.jwks != nil:
+		keys, err := parseJWKS(v.jwks)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	default:
+		return nil, errors.New("JWT verifier requires a key set")
+	}
+	return v, nil
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// VerifyToken implements TokenVerifier. Tokens are rejected unless their aud
+// claim matches the audience set with WithJWTAudience or, by default, the
+// resource of the server verifying them.
+func (v *JWTVerifier) VerifyToken(ctx context.Context, token string) (*TokenInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT claims", ErrInvalidToken)
+	}
+	return v.checkClaims(ctx, claims)
+}
+
+// verifySignature checks the signature with the keys matching the header.
+func (v *JWTVerifier) verifySignature(header jwtHeader, signed, signature []byte) error {
+	keys := v.keysFor(header)
+	if len(keys) == 0 && header.Kid != "" && v.jwksFile != "" {
+		// The key may have been rotated in.
+		if reloaded, err := v.reloadKeys(); err == nil && reloaded {
+			keys = v.keysFor(header)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%w: no key for kid %q and alg %q", ErrInvalidToken, header.Kid, header.Alg)
+	}
+	for _, key := range keys {
+		if key.verify(header.Alg, signed, signature) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+}
+
+// keysFor returns the keys that may have signed a token with the header.
+func (v *JWTVerifier) keysFor(header jwtHeader) []*jsonWebKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var keys []*jsonWebKey
+	for _, key := range v.keys {
+		if header.Kid != "" && key.Kid != header.Kid {
+			continue
+		}
+		if key.supports(header.Alg) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// reloadKeys loads the key set file if it changed, and reports whether it did.
+func (v *JWTVerifier) reloadKeys() (bool, error) {
+	info, err := os.Stat(v.jwksFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys != nil && info.ModTime().Equal(v.modTime) {
+		return false, nil
+	}
+	data, err := os.ReadFile(v.jwksFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return false, err
+	}
+	v.keys = keys
+	v.modTime = info.ModTime()
+	return true, nil
+}
+
+// checkClaims validates the registered claims and builds the token info.
+func (v *JWTVerifier) checkClaims(ctx context.Context, claims map[string]interface{}) (*TokenInfo, error) {
+	now := v.now()
+
+	exp, ok := numericDateClaim(claims, "exp")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(exp.Add(v.leeway)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericDateClaim(claims, "nbf"); ok && now.Add(v.leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+		}
+	}
+	audiences := v.audiences
+	if len(audiences) == 0 {
+		if resource := resourceFromContext(ctx); resource != "" {
+			audiences = []string{resource}
+		}
+	}
+	if len(audiences) == 0 {
+		return nil, fmt.Errorf("%w: no audience to check the token against", ErrInvalidToken)
+	}
+	if !containsAny(stringsClaim(claims, "aud"), audiences) {
+		return nil, fmt.Errorf("%w: token not issued for this audience", ErrInvalidToken)
+	}
+
+	info := &TokenInfo{
+		ExpiresAt: exp,
+		Claims:    claims,
+	}
+	info.Subject, _ = claims["sub"].(string)
+	if info.ClientID, _ = claims["client_id"].(string); info.ClientID == "" {
+		info.ClientID, _ = claims["azp"].(string)
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		info.Scopes = strings.Fields(scope)
+	} else {
+		info.Scopes = stringsClaim(claims, "scp")
+	}
+	return info, nil
+}
+
+// decodeJWTSegment decodes a base64url JSON segment of a token.
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// numericDateClaim returns a NumericDate claim as a time.
+func numericDateClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// stringsClaim returns a claim that is either a string, or an array of
+// strings, as a slice. A space-separated string is split.
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// containsAny reports whether values and candidates have a value in common.
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	publicKey crypto.PublicKey
+}
+
+// parseJWKS parses the signing keys of a JSON Web Key Set. Keys of unknown
+// types are skipped.
+func parseJWKS(data []byte) ([]*jsonWebKey, error) {
+	var set struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	keys := make([]*jsonWebKey, 0, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.parsePublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWK %q: %w", key.Kid, err)
+		}
+		if publicKey == nil {
+			continue
+		}
+		key.publicKey = publicKey
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parsePublicKey builds the public key of the JWK, nil if its type is unknown.
+func (k *jsonWebKey) parsePublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// decodeJWKInt decodes a base64url big-endian integer of a JWK.
+func decodeJWKInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// jwtHashes maps the digits of RS*, PS* and ES* algorithms to their hash.
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// jwtCurves maps the ES* algorithms to the curve of their keys.
+var jwtCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// supports reports whether the key can verify signatures of the algorithm.
+func (k *jsonWebKey) supports(alg string) bool {
+	if k.Alg != "" && k.Alg != alg {
+		return false
+	}
+	switch key := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		return (strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")) && jwtHashes[alg[2:]] != 0
+	case *ecdsa.PublicKey:
+		return jwtCurves[alg] == key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// verify checks a signature made with the algorithm, which the key supports.
+func (k *jsonWebKey) verify(alg string, signed, signature []byte) bool {
+	if key, ok := k.publicKey.(ed25519.PublicKey); ok {
+		return ed25519.Verify(key, signed, signature)
+	}
+
+	hash := jwtHashes[alg[2:]]
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
@@ -0,0 +1,382 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSigner signs JWTs for tests.
+type testSigner struct {
+	kid string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSATestSigner(t *testing.T, kid string) *testSigner {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &testSigner{kid: kid, rsa: key}
+}
+
+func newECTestSigner(t *testing.T, kid string) *testSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testSigner{kid: kid, ec: key}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (s *testSigner) jwk() map[string]interface{} {
+	if s.rsa != nil {
+		return map[string]interface{}{
+			"kty": "RSA", "kid": s.kid, "use": "sig",
+			"n": b64(s.rsa.N.Bytes()), "e": b64(big.NewInt(int64(s.rsa.E)).Bytes()),
+		}
+	}
+	return map[string]interface{}{
+		"kty": "EC", "kid": s.kid, "crv": "P-256",
+		"x": b64(s.ec.X.FillBytes(make([]byte, 32))), "y": b64(s.ec.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func testJWKS(t *testing.T, signers ...*testSigner) []byte {
+	keys := make([]interface{}, 0, len(signers))
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func (s *testSigner) sign(t *testing.T, claims map[string]interface{}) string {
+	alg := "RS256"
+	if s.ec != nil {
+		alg = "ES256"
+	}
+	header, err := json.Marshal(map[string]interface{}{"alg": alg, "kid": s.kid, "typ": "at+jwt"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if s.rsa != nil {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	} else {
+		r, sv, err := ecdsa.Sign(rand.Reader, s.ec, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), sv.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func testClaims(scope string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":       "https://auth.example.com",
+		"aud":       "https://mcp.example.com/mcp",
+		"sub":       "alice",
+		"client_id": "test-client",
+		"scope":     scope,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaSigner := newRSATestSigner(t, "rsa-1")
+	ecSigner := newECTestSigner(t, "ec-1")
+	verifier, err := NewJWTVerifier(
+		WithJWKS(testJWKS(t, rsaSigner, ecSigner)),
+		WithJWTIssuer("https://auth.example.com"),
+		WithJWTAudience("https://mcp.example.com/mcp"),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, signer := range []*testSigner{rsaSigner, ecSigner} {
+		info, err := verifier.VerifyToken(ctx, signer.sign(t, testClaims("tools:read tools:write")))
+		require.NoError(t, err, signer.kid)
+		assert.Equal(t, "alice", info.Subject)
+		assert.Equal(t, "test-client", info.ClientID)
+		assert.Equal(t, []string{"tools:read", "tools:write"}, info.Scopes)
+		assert.True(t, info.HasScopes("tools:write"))
+		assert.False(t, info.HasScopes("admin"))
+	}
+
+	invalid := map[string]func(claims map[string]interface{}){
+		"expired":      func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no exp":       func(c map[string]interface{}) { delete(c, "exp") },
+		"not yet":      func(c map[string]interface{}) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong issuer": func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"wrong aud":    func(c map[string]interface{}) { c["aud"] = []string{"https://other.example.com"} },
+	}
+	for name, mutate := range invalid {
+		claims := testClaims("tools:read")
+		mutate(claims)
+		_, err := verifier.VerifyToken(ctx, rsaSigner.sign(t, claims))
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	// Tokens signed with unknown keys or tampered with are rejected.
+	_, err = verifier.VerifyToken(ctx, newRSATestSigner(t, "rsa-1").sign(t, testClaims("")))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	token := rsaSigner.sign(t, testClaims("tools:read"))
+	parts := strings.Split(token, ".")
+	payload, err := json.Marshal(testClaims("admin"))
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(ctx, parts[0]+"."+b64(payload)+"."+parts[2])
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = verifier.VerifyToken(ctx, "not-a-jwt")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Without an audience, tokens must be issued for the resource verifying them.
+	verifier, err = NewJWTVerifier(WithJWKS(testJWKS(t, rsaSigner)))
+	require.NoError(t, err)
+	token = rsaSigner.sign(t, testClaims("tools:read"))
+	_, err = verifier.VerifyToken(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = verifier.VerifyToken(withResource(ctx, "https://other.example.com/mcp"), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = verifier.VerifyToken(withResource(ctx, "https://mcp.example.com/mcp"), token)
+	assert.NoError(t, err)
+}
+
+func TestJWTVerifier_JWKSFileRotation(t *testing.T) {
+	oldSigner := newRSATestSigner(t, "old")
+	newSigner := newECTestSigner(t, "new")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, testJWKS(t, oldSigner), 0o600))
+
+	verifier, err := NewJWTVerifier(WithJWKSFile(path), WithJWTAudience("https://mcp.example.com/mcp"))
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(context.Background(), oldSigner.sign(t, testClaims("")))
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(context.Background(), newSigner.sign(t, testClaims("")))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	require.NoError(t, os.WriteFile(path, testJWKS(t, oldSigner, newSigner), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	_, err = verifier.VerifyToken(context.Background(), newSigner.sign(t, testClaims("")))
+	assert.NoError(t, err)
+
+	_, err = NewJWTVerifier(WithJWKSFile(filepath.Join(t.TempDir(), "missing.json")))
+	assert.Error(t, err)
+	_, err = NewJWTVerifier()
+	assert.Error(t, err)
+}
+
+func TestServer_Authorization(t *testing.T) {
+	signer := newRSATestSigner(t, "rsa-1")
+	verifier, err := NewJWTVerifier(WithJWKS(testJWKS(t, signer)), WithJWTAudience("https://mcp.example.com/mcp"))
+	require.NoError(t, err)
+
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithGetSSEEnabled(false),
+		WithAuthorization(verifier, &ProtectedResourceMetadata{
+			AuthorizationServers: []string{"https://auth.example.com"},
+			ScopesSupported:      []string{"tools:read", "tools:admin"},
+		}))
+	server.RegisterTool(NewTool("whoami"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		info, ok := TokenInfoFromContext(ctx)
+		if !ok {
+			return NewErrorResult("no token"), nil
+		}
+		return NewTextResult(info.Subject), nil
+	})
+	server.RegisterTool(NewTool("admin", WithToolRequiredScopes("tools:admin")),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			return NewTextResult("admin done"), nil
+		})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	// Requests without a valid token are rejected with a metadata hint.
+	for _, header := range []string{"", "Bearer invalid"} {
+		req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		challenge := resp.Header.Get("WWW-Authenticate")
+		assert.Contains(t, challenge,
+			`resource_metadata="`+httpServer.URL+`/.well-known/oauth-protected-resource/mcp"`)
+		if header != "" {
+			assert.Contains(t, challenge, `error="invalid_token"`)
+		}
+	}
+
+	// The metadata document is served without a token, at its exact path
+	// only. The scheme of the resource ignores X-Forwarded-Proto, which is
+	// only trusted behind a proxy.
+	req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/.well-known/oauth-protected-resource/mcp", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var metadata ProtectedResourceMetadata
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+	assert.Equal(t, httpServer.URL+"/mcp", metadata.Resource)
+	assert.Equal(t, []string{"https://auth.example.com"}, metadata.AuthorizationServers)
+	assert.Equal(t, []string{"header"}, metadata.BearerMethodsSupported)
+	resp, err = http.Get(httpServer.URL + "/.well-known/oauth-protected-resource/mcp-other")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Tokens must be issued for the audience of the verifier, not for the
+	// resource derived from the request, whose Host the client chooses.
+	claims := testClaims("tools:read")
+	claims["aud"] = httpServer.URL + "/mcp"
+	req, err = http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signer.sign(t, claims))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	newAuthorizedClient := func(scope string) *Client {
+		claims := testClaims(scope)
+		headers := http.Header{}
+		headers.Set("Authorization", "Bearer "+signer.sign(t, claims))
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+			WithClientGetSSEEnabled(false), WithHTTPHeaders(headers))
+		require.NoError(t, err)
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+		return client
+	}
+	callTool := func(client *Client, name string) (*CallToolResult, error) {
+		req := &CallToolRequest{}
+		req.Params.Name = name
+		return client.CallTool(context.Background(), req)
+	}
+	toolNames := func(client *Client) []string {
+		result, err := client.ListTools(context.Background(), &ListToolsRequest{})
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	reader := newAuthorizedClient("tools:read")
+	defer reader.Close()
+	result, err := callTool(reader, "whoami")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result.Content[0].(TextContent).Text)
+	assert.Equal(t, []string{"whoami"}, toolNames(reader))
+	_, err = callTool(reader, "admin")
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+	assert.Contains(t, err.Error(), "tools:admin")
+
+	admin := newAuthorizedClient("tools:read tools:admin")
+	defer admin.Close()
+	assert.ElementsMatch(t, []string{"whoami", "admin"}, toolNames(admin))
+	result, err = callTool(admin, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "admin done", result.Content[0].(TextContent).Text)
+}
+
+func TestServer_AuthorizationInsufficientScope(t *testing.T) {
+	verifier := TokenVerifierFunc(func(ctx context.Context, token string) (*TokenInfo, error) {
+		return &TokenInfo{Subject: "bob", Scopes: []string{"tools:read"}}, nil
+	})
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithGetSSEEnabled(false),
+		WithAuthorization(verifier, &ProtectedResourceMetadata{Resource: "https://mcp.example.com/mcp"}))
+	server.RegisterTool(NewTool("admin", WithToolRequiredScopes("tools:admin")),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			return NewTextResult("admin done"), nil
+		})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer token")
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false), WithHTTPHeaders(headers))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"admin"}}`
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", client.GetSessionID())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="tools:admin", `+
+		`resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`,
+		resp.Header.Get("WWW-Authenticate"))
+}
+
+func TestSSEServer_Authorization(t *testing.T) {
+	verifier := TokenVerifierFunc(func(ctx context.Context, token string) (*TokenInfo, error) {
+		if token != "secret" {
+			return nil, ErrInvalidToken
+		}
+		return &TokenInfo{Subject: "bob"}, nil
+	})
+	server := NewSSEServer("Test-Server", "1.0.0", WithSSEAuthorization(verifier, &ProtectedResourceMetadata{
+		Resource:             "https://mcp.example.com/sse",
+		AuthorizationServers: []string{"https://auth.example.com"},
+	}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/sse")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/sse"`,
+		resp.Header.Get("WWW-Authenticate"))
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret")
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithHTTPHeaders(headers))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+}
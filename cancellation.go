@@ -0,0 +1,306 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	icontext "trpc.group/trpc-go/trpc-mcp-go/internal/context"
+)
+
+// cancelNotificationTimeout bounds sending a cancellation notification after
+// the request context is already done.
+const cancelNotificationTimeout = 5 * time.Second
+
+// defaultServerRequestTimeout bounds the requests sent to a client with a
+// context without deadline.
+const defaultServerRequestTimeout = 30 * time.Second
+
+// errRequestCancelled is returned by request handlers when the peer cancelled
+// the request. No response must be sent for such requests.
+var errRequestCancelled = errors.New("request cancelled by peer")
+
+// CancelledNotificationParams describes the params of a notifications/cancelled message.
+type CancelledNotificationParams struct {
+	// RequestID is the ID of the request to cancel.
+	RequestID RequestId `json:"requestId"`
+
+	// Reason is an optional description of why the request was cancelled.
+	Reason string `json:"reason,omitempty"`
+}
+
+// newCancelledNotification creates a notifications/cancelled notification.
+func newCancelledNotification(requestID RequestId, reason string) *JSONRPCNotification {
+	params := map[string]interface{}{
+		"requestId": requestID,
+	}
+	if reason != "" {
+		params["reason"] = reason
+	}
+	return NewJSONRPCNotificationFromMap(MethodNotificationsCancelled, params)
+}
+
+// parseCancelledNotification extracts the params of a cancellation notification.
+func parseCancelledNotification(notification *JSONRPCNotification) (*CancelledNotificationParams, bool) {
+	if notification == nil || notification.Method != MethodNotificationsCancelled {
+		return nil, false
+	}
+	requestID, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok || requestID == nil {
+		return nil, false
+	}
+	reason, _ := notification.Params.AdditionalFields["reason"].(string)
+	return &CancelledNotificationParams{RequestID: requestID, Reason: reason}, true
+}
+
+// inFlightRequest is a request that is currently being handled.
+type inFlightRequest struct {
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+}
+
+// inFlightRegistry tracks in-flight requests per session so that they can be
+// cancelled by a notifications/cancelled message, and waited for on shutdown.
+// The zero value is ready to use.
+type inFlightRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]*inFlightRequest
+	// active counts the requests that have not completed, including the ones
+	// removed from sessions by a cancellation.
+	active pendingCounter
+}
+
+// begin registers a request and returns a cancellable context for handling it.
+// The returned function must be called once the request completes and reports
+// whether the request was cancelled by the peer, in which case the response
+// must be dropped.
+func (r *inFlightRegistry) begin(
+	ctx context.Context,
+	sessionID string,
+	requestID RequestId,
+) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	req := &inFlightRequest{cancel: cancel}
+	key := stringOrNumberKey(requestID)
+
+	r.mu.Lock()
+	if r.sessions == nil {
+		r.sessions = make(map[string]map[string]*inFlightRequest)
+	}
+	requests, ok := r.sessions[sessionID]
+	if !ok {
+		requests = make(map[string]*inFlightRequest)
+		r.sessions[sessionID] = requests
+	}
+	requests[key] = req
+	r.mu.Unlock()
+	r.active.add()
+
+	return ctx, func() bool {
+		r.mu.Lock()
+		if requests, ok := r.sessions[sessionID]; ok && requests[key] == req {
+			delete(requests, key)
+			if len(requests) == 0 {
+				delete(r.sessions, sessionID)
+			}
+		}
+		r.mu.Unlock()
+		r.active.done()
+		cancel()
+		return req.cancelled.Load()
+	}
+}
+
+// cancel cancels an in-flight request. It reports whether the request was found.
+func (r *inFlightRegistry) cancel(sessionID string, requestID RequestId) bool {
+	r.mu.Lock()
+	req, ok := r.sessions[sessionID][stringOrNumberKey(requestID)]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	req.cancelled.Store(true)
+	req.cancel()
+	return true
+}
+
+// cancelSession cancels all in-flight requests of a session.
+func (r *inFlightRegistry) cancelSession(sessionID string) {
+	r.mu.Lock()
+	requests := r.sessions[sessionID]
+	delete(r.sessions, sessionID)
+	r.mu.Unlock()
+
+	for _, req := range requests {
+		req.cancel()
+	}
+}
+
+// cancelAll cancels the in-flight requests of all sessions.
+func (r *inFlightRegistry) cancelAll() {
+	r.mu.Lock()
+	sessions := r.sessions
+	r.sessions = nil
+	r.mu.Unlock()
+
+	for _, requests := range sessions {
+		for _, req := range requests {
+			req.cancel()
+		}
+	}
+}
+
+// wait blocks until no request is in flight, or ctx is done.
+func (r *inFlightRegistry) wait(ctx context.Context) error {
+	return r.active.wait(ctx)
+}
+
+// pendingCounter counts pending operations. Unlike a sync.WaitGroup, it can
+// be waited for while operations are still added. The zero value is ready to use.
+type pendingCounter struct {
+	mu      sync.Mutex
+	pending int
+	// idle is closed when pending drops to zero, nil if nobody waits.
+	idle chan struct{}
+}
+
+// add records the start of an operation.
+func (c *pendingCounter) add() {
+	c.mu.Lock()
+	c.pending++
+	c.mu.Unlock()
+}
+
+// done records the end of an operation.
+func (c *pendingCounter) done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending--
+	if c.pending == 0 && c.idle != nil {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// wait blocks until no operation is pending, or ctx is done.
+func (c *pendingCounter) wait(ctx context.Context) error {
+	c.mu.Lock()
+	if c.pending == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+	}
+	idle := c.idle
+	c.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withServerRequestTimeout bounds a request sent to a client by
+// defaultServerRequestTimeout, unless ctx already has a deadline.
+func withServerRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, defaultServerRequestTimeout)
+}
+
+// handleCancelledNotification cancels the request named by a cancellation notification.
+func (r *inFlightRegistry) handleCancelledNotification(sessionID string, notification *JSONRPCNotification) {
+	params, ok := parseCancelledNotification(notification)
+	if !ok {
+		return
+	}
+	r.cancel(sessionID, params.RequestID)
+}
+
+// sendCancelledNotification notifies the peer that a request was abandoned
+// because its context is done. It is a no-op when ctx is still active.
+func sendCancelledNotification(
+	ctx context.Context,
+	requestID RequestId,
+	send func(ctx context.Context, notification *JSONRPCNotification) error,
+) {
+	if ctx.Err() == nil {
+		return
+	}
+	sendCtx, cancel := context.WithTimeout(icontext.WithoutCancel(ctx), cancelNotificationTimeout)
+	defer cancel()
+	_ = send(sendCtx, newCancelledNotification(requestID, ctx.Err().Error()))
+}
+
+// serve returns the response of handle to a request of the peer. handle runs
+// with a context cancelled when the peer cancels the request, in which case
+// nil is returned and no response must be sent.
+func (r *inFlightRegistry) serve(
+	ctx context.Context,
+	request *JSONRPCRequest,
+	handle func(ctx context.Context) JSONRPCMessage,
+) JSONRPCMessage {
+	ctx, done := r.begin(ctx, "", request.ID)
+	response := handle(ctx)
+	if done() {
+		return nil
+	}
+	return response
+}
+
+// handleServerRequest returns the response of handle to a request of the
+// server. handle runs with a context cancelled when the server cancels the
+// request, in which case nil is returned and no response must be sent.
+func (c *Client) handleServerRequest(
+	ctx context.Context,
+	request *JSONRPCRequest,
+	handle func(ctx context.Context) JSONRPCMessage,
+) JSONRPCMessage {
+	if c == nil {
+		return handle(ctx)
+	}
+	return c.serverRequests.serve(ctx, request, handle)
+}
+
+// dispatchCancelled cancels the request of the server named by a
+// notifications/cancelled notification.
+func (c *Client) dispatchCancelled(notification *JSONRPCNotification) {
+	if c == nil {
+		return
+	}
+	c.serverRequests.handleCancelledNotification("", notification)
+}
+
+// handleServerRequest returns the response of handle to a request of the
+// server, like Client.handleServerRequest.
+func (c *StdioClient) handleServerRequest(
+	ctx context.Context,
+	request *JSONRPCRequest,
+	handle func(ctx context.Context) JSONRPCMessage,
+) JSONRPCMessage {
+	if c == nil {
+		return handle(ctx)
+	}
+	return c.serverRequests.serve(ctx, request, handle)
+}
+
+// dispatchCancelled cancels the request of the server named by a
+// notifications/cancelled notification.
+func (c *StdioClient) dispatchCancelled(notification *JSONRPCNotification) {
+	if c == nil {
+		return
+	}
+	c.serverRequests.handleCancelledNotification("", notification)
+}
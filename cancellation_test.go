@@ -0,0 +1,290 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingTool blocks until its context is cancelled and reports that on started and cancelled.
+func blockingTool(started, cancelled chan struct{}) toolHandler {
+	return func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func TestInFlightRegistry(t *testing.T) {
+	var registry inFlightRegistry
+
+	ctx, done := registry.begin(context.Background(), "s1", int64(1))
+	assert.False(t, registry.cancel("s2", int64(1)), "requests are scoped to their session")
+	assert.True(t, registry.cancel("s1", float64(1)), "numeric IDs match regardless of type")
+	assert.Error(t, ctx.Err())
+	assert.True(t, done())
+	assert.False(t, registry.cancel("s1", int64(1)), "completed requests are forgotten")
+
+	ctx, done = registry.begin(context.Background(), "s1", "a")
+	registry.cancelSession("s1")
+	assert.Error(t, ctx.Err())
+	assert.False(t, done(), "session termination is not a peer cancellation")
+}
+
+func TestWithServerRequestTimeout(t *testing.T) {
+	// Without a deadline, the request is bounded by the default timeout.
+	ctx, cancel := withServerRequestTimeout(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(defaultServerRequestTimeout), deadline, time.Second)
+
+	// A deadline of the caller is kept.
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer parentCancel()
+	ctx, cancel = withServerRequestTimeout(parent)
+	defer cancel()
+	assert.Equal(t, parent, ctx)
+}
+
+func TestMCPHandler_CancelledNotification(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	toolManager := newToolManager()
+	toolManager.registerTool(NewTool("block"), blockingTool(started, cancelled))
+	handler := newMCPHandler(withToolManager(toolManager))
+	session := newSession()
+
+	type result struct {
+		resp JSONRPCMessage
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		req := newJSONRPCRequest(int64(7), MethodToolsCall, map[string]interface{}{"name": "block"})
+		resp, err := handler.handleRequest(context.Background(), req, session)
+		results <- result{resp, err}
+	}()
+
+	<-started
+	notification := newCancelledNotification(float64(7), "user abort")
+	require.NoError(t, handler.handleNotification(context.Background(), notification, session))
+
+	select {
+	case r := <-results:
+		assert.Nil(t, r.resp)
+		assert.ErrorIs(t, r.err, errRequestCancelled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("request was not cancelled")
+	}
+	<-cancelled
+}
+
+func TestClient_SendsCancelledNotification(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	received := make(chan *CancelledNotificationParams, 1)
+
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("block"), blockingTool(started, cancelled))
+	server.RegisterNotificationHandler(MethodNotificationsCancelled,
+		func(ctx context.Context, notification *JSONRPCNotification) error {
+			params, ok := parseCancelledNotification(notification)
+			require.True(t, ok)
+			received <- params
+			return nil
+		})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	req := &CallToolRequest{}
+	req.Params.Name = "block"
+	_, err = client.CallTool(ctx, req)
+	require.Error(t, err)
+
+	select {
+	case params := <-received:
+		assert.NotNil(t, params.RequestID)
+		assert.Equal(t, context.Canceled.Error(), params.Reason)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not receive notifications/cancelled")
+	}
+	<-cancelled
+}
+
+func TestStdioServer_CancelledNotification(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("block"), blockingTool(started, cancelled))
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := newStdioTransport(server.internal)
+	go func() {
+		_ = transport.listen(ctx, stdinReader, stdoutWriter)
+	}()
+
+	write := func(msg string) {
+		_, err := stdinWriter.Write([]byte(msg + "\n"))
+		require.NoError(t, err)
+	}
+	write(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block"}}`)
+	<-started
+	write(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"abort"}}`)
+	<-cancelled
+
+	// The cancelled request gets no response, so the ping response comes first.
+	write(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	scanner := bufio.NewScanner(stdoutReader)
+	require.True(t, scanner.Scan())
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+	assert.Equal(t, float64(2), msg["id"])
+}
+
+func TestServerRequest_Cancelled(t *testing.T) {
+	// timeoutSamplingTool sends a sampling request abandoned after a short timeout.
+	timeoutSamplingTool := func(createMessage func(ctx context.Context, sessionID string) error) toolHandler {
+		return func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			err := createMessage(ctx, ClientSessionFromContext(ctx).GetID())
+			return NewTextResult(fmt.Sprint(err)), nil
+		}
+	}
+	// blockingSamplingHandler blocks until the server cancels the request.
+	blockingSamplingHandler := func(cancelled chan struct{}) SamplingHandler {
+		return SamplingHandlerFunc(func(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		})
+	}
+	assertCancelled := func(t *testing.T, client Connector, cancelled chan struct{}) {
+		t.Helper()
+		result := callSamplingTool(t, client)
+		assert.Contains(t, result.Content[0].(TextContent).Text, context.DeadlineExceeded.Error())
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("client did not cancel the request")
+		}
+	}
+
+	t.Run("streamable", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		server := NewServer("Test-Server", "1.0.0")
+		server.RegisterTool(NewTool("sample"), timeoutSamplingTool(func(ctx context.Context, sessionID string) error {
+			_, err := server.CreateMessage(ctx, sessionID, newCreateMessageRequest("hi"))
+			return err
+		}))
+		httpServer := httptest.NewServer(server.HTTPHandler())
+		defer httpServer.Close()
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+		require.NoError(t, err)
+		defer client.Close()
+		client.SetSamplingHandler(blockingSamplingHandler(cancelled))
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+
+		assertCancelled(t, client, cancelled)
+	})
+
+	t.Run("sse", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		server := NewSSEServer("Test-Server", "1.0.0")
+		server.RegisterTool(NewTool("sample"), timeoutSamplingTool(func(ctx context.Context, sessionID string) error {
+			_, err := server.CreateMessage(ctx, sessionID, newCreateMessageRequest("hi"))
+			return err
+		}))
+		httpServer := httptest.NewServer(server)
+		defer httpServer.Close()
+		client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+		require.NoError(t, err)
+		defer client.Close()
+		client.SetSamplingHandler(blockingSamplingHandler(cancelled))
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+
+		assertCancelled(t, client, cancelled)
+	})
+
+	t.Run("stdio", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		client, err := NewStdioClient(StdioTransportConfig{
+			ServerParams: StdioServerParameters{
+				Command: os.Args[0],
+				Args:    []string{"-test.run=^TestStdioCancellationServerHelper$"},
+				Env:     map[string]string{"TRPC_MCP_CANCELLATION_SERVER": "1"},
+			},
+			Timeout: 10 * time.Second,
+		}, Implementation{Name: "Test-Client", Version: "1.0.0"})
+		require.NoError(t, err)
+		defer client.Close()
+		client.SetSamplingHandler(blockingSamplingHandler(cancelled))
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+
+		assertCancelled(t, client, cancelled)
+	})
+
+	t.Run("in-memory", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		server := NewServer("Test-Server", "1.0.0")
+		server.RegisterTool(NewTool("sample"), timeoutSamplingTool(func(ctx context.Context, sessionID string) error {
+			_, err := server.CreateMessage(ctx, sessionID, newCreateMessageRequest("hi"))
+			return err
+		}))
+		client := newInMemoryClient(t, server, func(c *Client) {
+			c.SetSamplingHandler(blockingSamplingHandler(cancelled))
+		})
+
+		assertCancelled(t, client, cancelled)
+	})
+}
+
+func TestStdioCancellationServerHelper(t *testing.T) {
+	if os.Getenv("TRPC_MCP_CANCELLATION_SERVER") != "1" {
+		return
+	}
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("sample"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := server.CreateMessage(ctx, newCreateMessageRequest("hi"))
+		return NewTextResult(fmt.Sprint(err)), nil
+	})
+	_ = server.Start()
+	os.Exit(0)
+}
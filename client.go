@@ -8,12 +8,14 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"trpc.group/trpc-go/trpc-mcp-go/internal/errors"
 	"trpc.group/trpc-go/trpc-mcp-go/internal/retry"
@@ -46,6 +48,8 @@ type Connector interface {
 	Close() error
 	// GetState returns the current client state.
 	GetState() State
+	// Ping checks that the server is responsive.
+	Ping(ctx context.Context) error
 	// ListTools retrieves all available tools from the server.
 	ListTools(ctx context.Context, req *ListToolsRequest) (*ListToolsResult, error)
 	// CallTool executes a specific tool with given parameters.
@@ -68,6 +72,18 @@ type Connector interface {
 	SendRootsListChangedNotification(ctx context.Context) error
 }
 
+// Pinger is the part of Connector checking that the server is responsive,
+// for code that only pings a client, such as a health check.
+type Pinger interface {
+	// Ping checks that the server is responsive.
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Pinger = (*Client)(nil)
+	_ Pinger = (*StdioClient)(nil)
+)
+
 // SessionClient extends Connector with session management capabilities.
 // This is primarily for HTTP-based transports that support sessions.
 type SessionClient interface {
@@ -116,10 +132,10 @@ type Client struct {
 	transport        httpTransport          // transport layer.
 	clientInfo       Implementation         // Client information.
 	protocolVersion  string                 // Protocol version.
-	initialized      bool                   // Whether the client is initialized.
+	initialized      atomic.Bool            // Whether the client is initialized.
 	requestID        atomic.Int64           // Atomic counter for request IDs.
 	capabilities     map[string]interface{} // Capabilities.
-	state            State                  // State.
+	state            stateTracker           // State.
 	transportOptions []transportOption
 
 	// transport configuration.
@@ -134,11 +150,57 @@ type Client struct {
 	rootsProvider RootsProvider // Provider for roots information.
 	rootsMu       sync.RWMutex  // Mutex for protecting the rootsProvider.
 
+	// Sampling support.
+	samplingHandler SamplingHandler // Handler for sampling/createMessage requests.
+	samplingMu      sync.RWMutex    // Mutex for protecting the samplingHandler.
+
+	// Elicitation support.
+	elicitationHandler ElicitationHandler // Handler for elicitation/create requests.
+	elicitationMu      sync.RWMutex       // Mutex for protecting the elicitationHandler.
+
+	// Logging support.
+	logMessageHandler LogMessageHandler // Handler for notifications/message notifications.
+	logMu             sync.RWMutex      // Mutex for protecting the logMessageHandler.
+
+	// Resource subscriptions.
+	resourceUpdatedHandlers map[string]ResourceUpdatedHandler // Handlers of subscribed resources by URI.
+	subscriptionsMu         sync.RWMutex                      // Mutex for protecting the resourceUpdatedHandlers.
+
 	// HTTP before-request function.
 	httpBeforeRequestFunc HTTPBeforeRequestFunc
 
 	// Whether to include "arguments": {} for tool calls with no arguments.
 	sendEmptyToolArguments bool
+
+	// Output schemas of the tools validating structured content, nil if disabled.
+	outputSchemas *toolOutputSchemas
+
+	// Routes progress notifications to per-call handlers.
+	progress progressRouter
+
+	// Requests of the server being handled, cancelled by its notifications/cancelled.
+	serverRequests inFlightRegistry
+
+	listChangedHandlers map[string]func(ctx context.Context) // Handlers of list_changed notifications by method.
+	listChangedMu       sync.RWMutex                         // Mutex for protecting the listChangedHandlers.
+
+	// Receives the server's requests and notifications instead of the handlers
+	// above, when the client is used by a proxy. Set before Initialize.
+	forwarder clientForwarder
+
+	// Pings the server periodically after initialization, nil if disabled.
+	healthCheck *healthChecker
+	// Request of the last Initialize, sent again when the session is lost.
+	initRequest atomic.Pointer[InitializeRequest]
+}
+
+// clientForwarder receives the requests and notifications a server sends to a
+// client, to forward them to another peer.
+type clientForwarder interface {
+	// forwardRequest returns the response message to a server request.
+	forwardRequest(ctx context.Context, request *JSONRPCRequest) interface{}
+	// forwardNotification forwards a server notification.
+	forwardNotification(notification *JSONRPCNotification)
 }
 
 // ClientOption client option function
@@ -153,14 +215,7 @@ func NewClient(serverURL string, clientInfo Implementation, options ...ClientOpt
 	}
 
 	// Create client.
-	client := &Client{
-		clientInfo:       clientInfo,
-		protocolVersion:  ProtocolVersion_2025_03_26, // Default compatible version.
-		capabilities:     make(map[string]interface{}),
-		state:            StateDisconnected,
-		transportOptions: []transportOption{},
-		transportConfig:  newDefaultTransportConfig(),
-	}
+	client := newClient(clientInfo)
 
 	// set server URL.
 	client.transportConfig.serverURL = parsedURL
@@ -188,6 +243,18 @@ func NewClient(serverURL string, clientInfo Implementation, options ...ClientOpt
 	return client, nil
 }
 
+// newClient creates a client without a transport.
+func newClient(clientInfo Implementation) *Client {
+	return &Client{
+		clientInfo:       clientInfo,
+		protocolVersion:  ProtocolVersion_2025_06_18, // Default to the latest version.
+		capabilities:     make(map[string]interface{}),
+		state:            stateTracker{state: StateDisconnected},
+		transportOptions: []transportOption{},
+		transportConfig:  newDefaultTransportConfig(),
+	}
+}
+
 // transportConfig includes transport layer configuration.
 type transportConfig struct {
 	serverURL    *url.URL // server URL
@@ -209,6 +276,14 @@ type transportConfig struct {
 	// These options are typically not used by the default handler, but may be used by custom
 	// implementations that replace the default NewHTTPReqHandler function for extensibility.
 	httpReqHandlerOptions []HTTPReqHandlerOption
+
+	// Source of the bearer tokens of HTTP requests, nil if requests are not authorized.
+	tokenSource TokenSource
+
+	// Time between the pings sent on a WebSocket connection, zero or less disables them.
+	webSocketPingInterval time.Duration
+	// Maximum size of a message read from a WebSocket connection, zero or less for no limit.
+	webSocketMaxMessageSize int64
 }
 
 // newDefaultTransportConfig creates a default transport configuration.
@@ -221,6 +296,9 @@ func newDefaultTransportConfig() *transportConfig {
 		httpReqHandlerOptions: []HTTPReqHandlerOption{},
 		enableGetSSE:          true,
 		path:                  "",
+
+		webSocketPingInterval:   defaultWebSocketPingInterval,
+		webSocketMaxMessageSize: defaultWebSocketMaxMessageSize,
 	}
 }
 
@@ -325,6 +403,44 @@ func WithHTTPBeforeRequest(fn HTTPBeforeRequestFunc) ClientOption {
 	}
 }
 
+// WithClientWebSocketPingInterval sets the time between the pings a
+// WebSocket client sends. The connection is closed when the server answers
+// nothing for two intervals. Zero disables pings. Defaults to 30 seconds.
+func WithClientWebSocketPingInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.transportConfig.webSocketPingInterval = interval
+	}
+}
+
+// WithClientWebSocketMaxMessageSize sets the maximum size of a message a
+// WebSocket client reads, in bytes. A larger message closes the connection.
+// Zero means the maximum, 1 GiB. Defaults to 4 MiB.
+func WithClientWebSocketMaxMessageSize(size int64) ClientOption {
+	return func(c *Client) {
+		c.transportConfig.webSocketMaxMessageSize = size
+	}
+}
+
+// WithHealthCheck enables a health check pinging the server every interval
+// once the client is initialized, until it is closed. When the server no
+// longer knows the session, for example after a restart, the client is
+// initialized again with the request of the last Initialize, which also
+// reopens the GET SSE connection. A server that cannot be reached sets the
+// state to StateDisconnected until a ping succeeds.
+func WithHealthCheck(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.healthCheck = newHealthChecker(interval, c.checkHealth)
+	}
+}
+
+// WithStateChangeHandler sets a handler called when the state of the client
+// changes.
+func WithStateChangeHandler(handler StateChangeHandler) ClientOption {
+	return func(c *Client) {
+		c.state.setHandler(handler)
+	}
+}
+
 // WithSendEmptyToolArguments sets whether CallTool sends "arguments": {} when no
 // tool arguments are provided. This is disabled by default because the MCP
 // schema marks arguments as optional.
@@ -360,27 +476,39 @@ func (c *Client) applyHTTPBeforeRequest(ctx context.Context, req *http.Request)
 
 // GetState returns the current client state.
 func (c *Client) GetState() State {
-	return c.state
+	return c.state.get()
 }
 
 // setState sets the client state.
 func (c *Client) setState(state State) {
-	c.state = state
+	c.state.set(state)
+}
+
+// sendRequest sends a request and waits for its response. If ctx is done before
+// the response arrives, the server is told to stop processing the request.
+func (c *Client) sendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	rawResp, err := c.transport.sendRequest(ctx, req)
+	if err != nil {
+		sendCancelledNotification(ctx, req.ID, c.transport.sendNotification)
+	}
+	return rawResp, err
 }
 
 // Initialize initializes the client connection.
 func (c *Client) Initialize(ctx context.Context, initReq *InitializeRequest) (*InitializeResult, error) {
 	// Check if already initialized.
-	if c.initialized {
+	if c.initialized.Load() {
 		return nil, errors.ErrAlreadyInitialized
 	}
+	c.initRequest.Store(initReq)
 
 	// Create request.
 	requestID := c.requestID.Add(1)
 	req := newJSONRPCRequest(requestID, MethodInitialize, map[string]interface{}{
 		"protocolVersion": c.protocolVersion,
 		"clientInfo":      c.clientInfo,
-		"capabilities":    c.capabilities,
+		"capabilities": declareClientCapabilities(c.capabilities,
+			c.getSamplingHandler() != nil, c.getElicitationHandler() != nil),
 	})
 
 	if initReq != nil && !isZeroStruct(initReq.Params) {
@@ -416,6 +544,16 @@ func (c *Client) Initialize(ctx context.Context, initReq *InitializeRequest) (*I
 		return nil, fmt.Errorf("failed to parse initialization response: %w", err)
 	}
 
+	// The server may answer with an older version; refuse versions we cannot speak.
+	if !IsProtocolVersionSupported(initResult.ProtocolVersion) {
+		c.setState(StateDisconnected)
+		return nil, fmt.Errorf("%w: %s", errors.ErrUnsupportedProtocolVersion, initResult.ProtocolVersion)
+	}
+	if t, ok := c.transport.(*streamableHTTPClientTransport); ok {
+		t.setProtocolVersion(initResult.ProtocolVersion)
+	}
+	c.outputSchemas.setListChanged(initResult.Capabilities)
+
 	// Send initialized notification.
 	if err := c.SendInitialized(ctx); err != nil {
 		c.setState(StateDisconnected)
@@ -423,7 +561,7 @@ func (c *Client) Initialize(ctx context.Context, initReq *InitializeRequest) (*I
 	}
 
 	// Update state and initialized flag
-	c.initialized = true
+	c.initialized.Store(true)
 	c.setState(StateInitialized)
 
 	// Try to establish GET SSE connection if transport supports it
@@ -433,6 +571,7 @@ func (c *Client) Initialize(ctx context.Context, initReq *InitializeRequest) (*I
 		go t.establishGetSSEConnection(ctx)
 	}
 
+	c.healthCheck.start(ctx)
 	return initResult, nil
 }
 
@@ -445,7 +584,7 @@ func (c *Client) SendInitialized(ctx context.Context) error {
 // ListTools lists available tools.
 func (c *Client) ListTools(ctx context.Context, listToolsReq *ListToolsRequest) (*ListToolsResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, errors.ErrNotInitialized
 	}
 
@@ -460,7 +599,7 @@ func (c *Client) ListTools(ctx context.Context, listToolsReq *ListToolsRequest)
 		Params: listToolsReq.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("list tools request failed: %v", err)
 	}
@@ -476,16 +615,25 @@ func (c *Client) ListTools(ctx context.Context, listToolsReq *ListToolsRequest)
 	}
 
 	// Parse response using specialized parser
-	return parseListToolsResultFromJSON(rawResp)
+	result, err := parseListToolsResultFromJSON(rawResp)
+	if err != nil {
+		return nil, err
+	}
+	c.outputSchemas.record(result.Tools)
+	return result, nil
 }
 
 // CallTool calls a tool.
 func (c *Client) CallTool(ctx context.Context, callToolReq *CallToolRequest) (*CallToolResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, errors.ErrNotInitialized
 	}
 
+	// Ask for progress updates if the context carries a progress handler.
+	callToolReq, done := c.progress.prepareCallTool(ctx, callToolReq)
+	defer done()
+
 	// Create request
 	requestID := c.requestID.Add(1)
 	req := &JSONRPCRequest{
@@ -497,7 +645,7 @@ func (c *Client) CallTool(ctx context.Context, callToolReq *CallToolRequest) (*C
 		Params: callToolParams(callToolReq, c.sendEmptyToolArguments),
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("tool call request failed: %w", err)
 	}
@@ -512,20 +660,40 @@ func (c *Client) CallTool(ctx context.Context, callToolReq *CallToolRequest) (*C
 			errResp.Error.Message, errResp.Error.Code)
 	}
 
-	return parseCallToolResult(rawResp)
+	result, err := parseCallToolResult(rawResp)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validateToolResult(ctx, callToolReq.Params.Name, result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // Close closes the client connection and cleans up resources.
 func (c *Client) Close() error {
+	c.healthCheck.stop()
 	if c.transport != nil {
 		err := c.transport.close()
 		c.setState(StateDisconnected)
-		c.initialized = false
+		c.initialized.Store(false)
 		return err
 	}
 	return nil
 }
 
+// resetSession forgets the session of the client after the server lost it,
+// so that the client can be initialized again.
+func (c *Client) resetSession() {
+	c.initialized.Store(false)
+	c.setState(StateDisconnected)
+	if t, ok := c.transport.(*streamableHTTPClientTransport); ok {
+		t.resetSession()
+	} else if t, ok := c.transport.(*customClientTransport); ok {
+		t.resetSession()
+	}
+}
+
 // GetSessionID gets the session ID.
 func (c *Client) GetSessionID() string {
 	return c.transport.getSessionID()
@@ -533,6 +701,7 @@ func (c *Client) GetSessionID() string {
 
 // TerminateSession terminates the session.
 func (c *Client) TerminateSession(ctx context.Context) error {
+	c.healthCheck.stop()
 	return c.transport.terminateSession(ctx)
 }
 
@@ -542,6 +711,8 @@ func (c *Client) RegisterNotificationHandler(method string, handler Notification
 		httpTransport.registerNotificationHandler(method, handler)
 	} else if stdioTransport, ok := c.transport.(*stdioClientTransport); ok {
 		stdioTransport.registerNotificationHandler(method, handler)
+	} else if customTransport, ok := c.transport.(*customClientTransport); ok {
+		customTransport.registerNotificationHandler(method, handler)
 	}
 }
 
@@ -551,13 +722,15 @@ func (c *Client) UnregisterNotificationHandler(method string) {
 		httpTransport.unregisterNotificationHandler(method)
 	} else if stdioTransport, ok := c.transport.(*stdioClientTransport); ok {
 		stdioTransport.unregisterNotificationHandler(method)
+	} else if customTransport, ok := c.transport.(*customClientTransport); ok {
+		customTransport.unregisterNotificationHandler(method)
 	}
 }
 
 // ListPrompts lists available prompts.
 func (c *Client) ListPrompts(ctx context.Context, listPromptsReq *ListPromptsRequest) (*ListPromptsResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, errors.ErrNotInitialized
 	}
 
@@ -572,7 +745,7 @@ func (c *Client) ListPrompts(ctx context.Context, listPromptsReq *ListPromptsReq
 		Params: listPromptsReq.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("list prompts request failed: %w", err)
 	}
@@ -594,7 +767,7 @@ func (c *Client) ListPrompts(ctx context.Context, listPromptsReq *ListPromptsReq
 // GetPrompt gets a specific prompt.
 func (c *Client) GetPrompt(ctx context.Context, getPromptReq *GetPromptRequest) (*GetPromptResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, errors.ErrNotInitialized
 	}
 
@@ -609,7 +782,7 @@ func (c *Client) GetPrompt(ctx context.Context, getPromptReq *GetPromptRequest)
 		Params: getPromptReq.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("get prompt request failed: %v", err)
 	}
@@ -631,7 +804,7 @@ func (c *Client) GetPrompt(ctx context.Context, getPromptReq *GetPromptRequest)
 // ListResources lists available resources.
 func (c *Client) ListResources(ctx context.Context, listResourcesReq *ListResourcesRequest) (*ListResourcesResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, fmt.Errorf("%w", errors.ErrNotInitialized)
 	}
 
@@ -646,7 +819,7 @@ func (c *Client) ListResources(ctx context.Context, listResourcesReq *ListResour
 		Params: listResourcesReq.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("list resources request failed: %v", err)
 	}
@@ -665,10 +838,50 @@ func (c *Client) ListResources(ctx context.Context, listResourcesReq *ListResour
 	return parseListResourcesResultFromJSON(rawResp)
 }
 
+// ListResourceTemplates lists available resource templates.
+func (c *Client) ListResourceTemplates(
+	ctx context.Context,
+	listTemplatesReq *ListResourceTemplatesRequest,
+) (*ListResourceTemplatesResult, error) {
+	// Check if initialized.
+	if !c.initialized.Load() {
+		return nil, fmt.Errorf("%w", errors.ErrNotInitialized)
+	}
+
+	// Create request.
+	requestID := c.requestID.Add(1)
+	req := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		ID:      requestID,
+		Request: Request{
+			Method: MethodResourcesTemplatesList,
+		},
+		Params: listTemplatesReq.Params,
+	}
+
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("list resource templates request failed: %v", err)
+	}
+
+	// Check for error response
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return nil, fmt.Errorf("list resource templates error: %s (code: %d)",
+			errResp.Error.Message, errResp.Error.Code)
+	}
+
+	// Parse response using specialized parser
+	return parseListResourceTemplatesResultFromJSON(rawResp)
+}
+
 // ReadResource reads a specific resource.
 func (c *Client) ReadResource(ctx context.Context, readResourceReq *ReadResourceRequest) (*ReadResourceResult, error) {
 	// Check if initialized.
-	if !c.initialized {
+	if !c.initialized.Load() {
 		return nil, fmt.Errorf("%w", errors.ErrNotInitialized)
 	}
 
@@ -683,7 +896,7 @@ func (c *Client) ReadResource(ctx context.Context, readResourceReq *ReadResource
 		Params: readResourceReq.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, req)
+	rawResp, err := c.sendRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("read resource request failed: %v", err)
 	}
@@ -702,6 +915,140 @@ func (c *Client) ReadResource(ctx context.Context, readResourceReq *ReadResource
 	return parseReadResourceResultFromJSON(rawResp)
 }
 
+// Complete requests completion values for a prompt argument or a resource template variable.
+func (c *Client) Complete(ctx context.Context, completeReq *CompleteRequest) (*CompleteResult, error) {
+	// Check if initialized.
+	if !c.initialized.Load() {
+		return nil, fmt.Errorf("%w", errors.ErrNotInitialized)
+	}
+
+	// Create request.
+	requestID := c.requestID.Add(1)
+	req := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		ID:      requestID,
+		Request: Request{
+			Method: MethodCompletionComplete,
+		},
+		Params: completeReq.Params,
+	}
+
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("complete request failed: %v", err)
+	}
+
+	// Check for error response
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return nil, fmt.Errorf("complete error: %s (code: %d)",
+			errResp.Error.Message, errResp.Error.Code)
+	}
+
+	// Parse response using specialized parser
+	return parseCompleteResultFromJSON(rawResp)
+}
+
+// ListAllTools lists available tools, following pagination cursors until every page is fetched.
+func (c *Client) ListAllTools(ctx context.Context) (*ListToolsResult, error) {
+	result := &ListToolsResult{Tools: []Tool{}}
+	err := listAllPages(func(cursor Cursor) (Cursor, error) {
+		req := &ListToolsRequest{}
+		req.Params.Cursor = cursor
+		page, err := c.ListTools(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		result.Tools = append(result.Tools, page.Tools...)
+		return page.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListAllPrompts lists available prompts, following pagination cursors until every page is fetched.
+func (c *Client) ListAllPrompts(ctx context.Context) (*ListPromptsResult, error) {
+	result := &ListPromptsResult{Prompts: []Prompt{}}
+	err := listAllPages(func(cursor Cursor) (Cursor, error) {
+		req := &ListPromptsRequest{}
+		req.Params.Cursor = cursor
+		page, err := c.ListPrompts(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		result.Prompts = append(result.Prompts, page.Prompts...)
+		return page.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListAllResources lists available resources, following pagination cursors until every page is fetched.
+func (c *Client) ListAllResources(ctx context.Context) (*ListResourcesResult, error) {
+	result := &ListResourcesResult{Resources: []Resource{}}
+	err := listAllPages(func(cursor Cursor) (Cursor, error) {
+		req := &ListResourcesRequest{}
+		req.Params.Cursor = cursor
+		page, err := c.ListResources(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		result.Resources = append(result.Resources, page.Resources...)
+		return page.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListAllResourceTemplates lists available resource templates, following pagination
+// cursors until every page is fetched.
+func (c *Client) ListAllResourceTemplates(ctx context.Context) (*ListResourceTemplatesResult, error) {
+	result := &ListResourceTemplatesResult{ResourceTemplates: []ResourceTemplate{}}
+	err := listAllPages(func(cursor Cursor) (Cursor, error) {
+		req := &ListResourceTemplatesRequest{}
+		req.Params.Cursor = cursor
+		page, err := c.ListResourceTemplates(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		result.ResourceTemplates = append(result.ResourceTemplates, page.ResourceTemplates...)
+		return page.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listAllPages calls fetchPage with each next cursor, starting without one,
+// until a page without a next cursor is returned.
+func listAllPages(fetchPage func(cursor Cursor) (Cursor, error)) error {
+	var cursor Cursor
+	for {
+		next, err := fetchPage(cursor)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		// Guard against servers that keep returning the same page.
+		if next == cursor {
+			return fmt.Errorf("server returned the same cursor twice: %s", next)
+		}
+		cursor = next
+	}
+}
+
 // SetRootsProvider sets the provider for responding to server's roots/list requests.
 func (c *Client) SetRootsProvider(provider RootsProvider) {
 	c.rootsMu.Lock()
@@ -709,6 +1056,195 @@ func (c *Client) SetRootsProvider(provider RootsProvider) {
 	c.rootsProvider = provider
 }
 
+// SetSamplingHandler sets the handler for responding to server's sampling/createMessage
+// requests. The sampling capability is declared when the handler is set before Initialize.
+func (c *Client) SetSamplingHandler(handler SamplingHandler) {
+	c.samplingMu.Lock()
+	defer c.samplingMu.Unlock()
+	c.samplingHandler = handler
+}
+
+// getSamplingHandler returns the sampling handler, if any.
+func (c *Client) getSamplingHandler() SamplingHandler {
+	c.samplingMu.RLock()
+	defer c.samplingMu.RUnlock()
+	return c.samplingHandler
+}
+
+// SetLogLevel asks the server to only send log messages of the given level or more severe ones.
+func (c *Client) SetLogLevel(ctx context.Context, level LoggingLevel) error {
+	// Check if initialized.
+	if !c.initialized.Load() {
+		return fmt.Errorf("%w", errors.ErrNotInitialized)
+	}
+
+	// Create request.
+	requestID := c.requestID.Add(1)
+	req := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		ID:      requestID,
+		Request: Request{
+			Method: MethodLoggingSetLevel,
+		},
+		Params: map[string]interface{}{
+			"level": level,
+		},
+	}
+
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("set log level request failed: %v", err)
+	}
+
+	// Check for error response
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return fmt.Errorf("set log level error: %s (code: %d)",
+			errResp.Error.Message, errResp.Error.Code)
+	}
+	return nil
+}
+
+// SetLogMessageHandler sets the handler receiving the log messages sent by the server
+// as notifications/message. Handlers registered with RegisterNotificationHandler for
+// the same method are still called.
+func (c *Client) SetLogMessageHandler(handler LogMessageHandler) {
+	c.logMu.Lock()
+	defer c.logMu.Unlock()
+	c.logMessageHandler = handler
+}
+
+// dispatchLogMessage delivers a notifications/message notification to the log message handler.
+func (c *Client) dispatchLogMessage(notification *JSONRPCNotification) {
+	if notification == nil || notification.Method != NotificationMethodMessage {
+		return
+	}
+	c.logMu.RLock()
+	handler := c.logMessageHandler
+	c.logMu.RUnlock()
+	if handler == nil {
+		return
+	}
+	params, err := parseLoggingMessageParams(notification)
+	if err != nil {
+		return
+	}
+	handler(params)
+}
+
+// Subscribe subscribes to updates of a resource. The handler is called with every
+// notifications/resources/updated notification the server sends for the URI,
+// until Unsubscribe is called. Subscribing again replaces the handler.
+func (c *Client) Subscribe(ctx context.Context, uri string, handler ResourceUpdatedHandler) error {
+	// Dispatch first, the server may send updates before responding.
+	c.subscriptionsMu.Lock()
+	if c.resourceUpdatedHandlers == nil {
+		c.resourceUpdatedHandlers = make(map[string]ResourceUpdatedHandler)
+	}
+	previous, subscribed := c.resourceUpdatedHandlers[uri]
+	c.resourceUpdatedHandlers[uri] = handler
+	c.subscriptionsMu.Unlock()
+
+	if err := c.sendSubscriptionRequest(ctx, MethodResourcesSubscribe, uri); err != nil {
+		// Keep the handler of a previous subscription, which still holds.
+		c.subscriptionsMu.Lock()
+		if subscribed {
+			c.resourceUpdatedHandlers[uri] = previous
+		} else {
+			delete(c.resourceUpdatedHandlers, uri)
+		}
+		c.subscriptionsMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe cancels the subscription to updates of a resource.
+func (c *Client) Unsubscribe(ctx context.Context, uri string) error {
+	// Stop dispatching first, updates received while unsubscribing are dropped.
+	c.subscriptionsMu.Lock()
+	delete(c.resourceUpdatedHandlers, uri)
+	c.subscriptionsMu.Unlock()
+
+	return c.sendSubscriptionRequest(ctx, MethodResourcesUnsubscribe, uri)
+}
+
+// sendSubscriptionRequest sends a resources/subscribe or resources/unsubscribe request.
+func (c *Client) sendSubscriptionRequest(ctx context.Context, method string, uri string) error {
+	// Check if initialized.
+	if !c.initialized.Load() {
+		return fmt.Errorf("%w", errors.ErrNotInitialized)
+	}
+
+	// Create request.
+	requestID := c.requestID.Add(1)
+	req := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		ID:      requestID,
+		Request: Request{
+			Method: method,
+		},
+		Params: map[string]interface{}{
+			"uri": uri,
+		},
+	}
+
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %v", method, err)
+	}
+
+	// Check for error response
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return fmt.Errorf("%s error: %s (code: %d)",
+			method, errResp.Error.Message, errResp.Error.Code)
+	}
+	return nil
+}
+
+// dispatchResourceUpdated delivers a notifications/resources/updated notification
+// to the handler of the subscribed resource.
+func (c *Client) dispatchResourceUpdated(notification *JSONRPCNotification) {
+	if notification == nil || notification.Method != NotificationMethodResourcesUpdated {
+		return
+	}
+	uri, ok := notification.Params.AdditionalFields["uri"].(string)
+	if !ok {
+		return
+	}
+	c.subscriptionsMu.RLock()
+	handler := c.resourceUpdatedHandlers[uri]
+	c.subscriptionsMu.RUnlock()
+	if handler == nil {
+		return
+	}
+	updated := &ResourceUpdatedNotification{Notification: notification.Notification}
+	updated.Params.URI = uri
+	handler(updated)
+}
+
+// SetElicitationHandler sets the handler for responding to server's elicitation/create
+// requests. The elicitation capability is declared when the handler is set before Initialize.
+func (c *Client) SetElicitationHandler(handler ElicitationHandler) {
+	c.elicitationMu.Lock()
+	defer c.elicitationMu.Unlock()
+	c.elicitationHandler = handler
+}
+
+// getElicitationHandler returns the elicitation handler, if any.
+func (c *Client) getElicitationHandler() ElicitationHandler {
+	c.elicitationMu.RLock()
+	defer c.elicitationMu.RUnlock()
+	return c.elicitationHandler
+}
+
 // SendRootsListChangedNotification notifies server that roots changed.
 func (c *Client) SendRootsListChangedNotification(ctx context.Context) error {
 	// Create roots list changed notification.
@@ -716,6 +1252,25 @@ func (c *Client) SendRootsListChangedNotification(ctx context.Context) error {
 	return c.transport.sendNotification(ctx, notification)
 }
 
+// declareClientCapabilities returns a copy of capabilities that also declares
+// the capabilities backed by the handlers set on the client.
+func declareClientCapabilities(
+	capabilities map[string]interface{},
+	sampling, elicitation bool,
+) map[string]interface{} {
+	result := make(map[string]interface{}, len(capabilities)+2)
+	for k, v := range capabilities {
+		result[k] = v
+	}
+	if _, ok := result["sampling"]; !ok && sampling {
+		result["sampling"] = map[string]interface{}{}
+	}
+	if _, ok := result["elicitation"]; !ok && elicitation {
+		result["elicitation"] = map[string]interface{}{}
+	}
+	return result
+}
+
 func isZeroStruct(x interface{}) bool {
 	return reflect.ValueOf(x).IsZero()
 }
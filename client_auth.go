@@ -0,0 +1,239 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUnauthorized is returned by a TokenSource that cannot obtain a token accepted by the server.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// OAuthToken is an OAuth 2.0 access token, as returned by a token endpoint.
+type OAuthToken struct {
+	// AccessToken is the token sent to the server.
+	AccessToken string `json:"access_token"`
+
+	// TokenType is the type of the token, "Bearer".
+	TokenType string `json:"token_type,omitempty"`
+
+	// RefreshToken is used to obtain a new access token once it expires.
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+
+	// Scope lists the scopes granted, space-separated.
+	Scope string `json:"scope,omitempty"`
+
+	// Expiry is the time the access token expires, zero if unknown.
+	Expiry time.Time `json:"-"`
+}
+
+// expired reports whether the token is expired, or about to.
+func (t *OAuthToken) expired(now time.Time) bool {
+	return !t.Expiry.IsZero() && now.Add(10*time.Second).After(t.Expiry)
+}
+
+// AuthChallenge is a parsed WWW-Authenticate challenge of a 401 response.
+type AuthChallenge struct {
+	// Scheme is the authentication scheme, "Bearer".
+	Scheme string
+
+	// Params holds the parameters of the challenge, such as resource_metadata,
+	// error and scope.
+	Params map[string]string
+
+	// RequestURL is the URL of the rejected request.
+	RequestURL string
+}
+
+// ResourceMetadataURL returns the URL of the server's protected resource
+// metadata, empty if the challenge does not name one.
+func (c *AuthChallenge) ResourceMetadataURL() string {
+	if c == nil {
+		return ""
+	}
+	return c.Params["resource_metadata"]
+}
+
+// TokenSource provides the bearer tokens of a client's HTTP requests. Use it
+// with WithTokenSource.
+type TokenSource interface {
+	// Token returns the token to send, refreshing it first if it expired. It
+	// returns a nil token when none has been obtained yet; the request is then
+	// sent without one, and HandleUnauthorized is called when it is rejected.
+	Token(ctx context.Context) (*OAuthToken, error)
+
+	// HandleUnauthorized is called when the server rejects a request with 401
+	// Unauthorized. rejected is the access token that was sent, empty if none.
+	// The source obtains a new token, unless the current one differs from the
+	// rejected one, and the request is retried once if it returns nil.
+	HandleUnauthorized(ctx context.Context, rejected string, challenge *AuthChallenge) error
+}
+
+// staticTokenSource is a TokenSource of a fixed access token.
+type staticTokenSource struct {
+	token *OAuthToken
+}
+
+// StaticTokenSource returns a TokenSource that always sends the given access token.
+func StaticTokenSource(accessToken string) TokenSource {
+	return &staticTokenSource{token: &OAuthToken{AccessToken: accessToken, TokenType: "Bearer"}}
+}
+
+// Token implements TokenSource.
+func (s *staticTokenSource) Token(ctx context.Context) (*OAuthToken, error) {
+	return s.token, nil
+}
+
+// HandleUnauthorized implements TokenSource.
+func (s *staticTokenSource) HandleUnauthorized(ctx context.Context, rejected string, challenge *AuthChallenge) error {
+	return fmt.Errorf("%w: static token rejected", ErrUnauthorized)
+}
+
+// WithTokenSource authorizes the client's HTTP requests with bearer tokens
+// from the source. When the server answers 401 Unauthorized, the source is
+// asked for a new token and the request is retried once. It applies to the
+// clients created by NewClient and NewSSEClient.
+//
+// Example:
+//
+//	source := mcp.NewOAuthTokenSource(&mcp.OAuthConfig{
+//	    GrantType:    mcp.OAuthGrantClientCredentials,
+//	    ClientID:     "my-client",
+//	    ClientSecret: os.Getenv("CLIENT_SECRET"),
+//	})
+//	client, err := mcp.NewClient(serverURL, clientInfo, mcp.WithTokenSource(source))
+func WithTokenSource(source TokenSource) ClientOption {
+	return func(c *Client) {
+		c.transportConfig.tokenSource = source
+	}
+}
+
+// authHTTPReqHandler wraps an HTTPReqHandler to authorize its requests with
+// the tokens of a TokenSource.
+type authHTTPReqHandler struct {
+	next   HTTPReqHandler
+	source TokenSource
+}
+
+// newAuthHTTPReqHandler wraps next, or returns it unchanged if source is nil.
+func newAuthHTTPReqHandler(next HTTPReqHandler, source TokenSource) HTTPReqHandler {
+	if source == nil {
+		return next
+	}
+	return &authHTTPReqHandler{next: next, source: source}
+}
+
+// Handle implements HTTPReqHandler.
+func (h *authHTTPReqHandler) Handle(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	sent, err := h.authorize(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.next.Handle(ctx, client, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The body of the request must be sent again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	challenge := parseAuthChallenge(resp.Header.Get("WWW-Authenticate"))
+	challenge.RequestURL = req.URL.String()
+	if err := h.source.HandleUnauthorized(ctx, sent, challenge); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := h.authorize(ctx, retry); err != nil {
+		return nil, err
+	}
+	return h.next.Handle(ctx, client, retry)
+}
+
+// authorize sets the Authorization header of req, and returns the access token sent.
+func (h *authHTTPReqHandler) authorize(ctx context.Context, req *http.Request) (string, error) {
+	token, err := h.source.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if token == nil || token.AccessToken == "" {
+		req.Header.Del("Authorization")
+		return "", nil
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return token.AccessToken, nil
+}
+
+// insufficientScopeError returns an error wrapping ErrInsufficientScope if the
+// server refused a request for lacking scopes, nil otherwise.
+func insufficientScopeError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden {
+		return nil
+	}
+	challenge := parseAuthChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge.Params["error"] != "insufficient_scope" {
+		return nil
+	}
+	return fmt.Errorf("%w: %w: requires scopes %s", ErrHTTPRequestFailed, ErrInsufficientScope, challenge.Params["scope"])
+}
+
+// parseAuthChallenge parses the first challenge of a WWW-Authenticate header.
+func parseAuthChallenge(header string) *AuthChallenge {
+	challenge := &AuthChallenge{Params: make(map[string]string)}
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	challenge.Scheme = scheme
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			// Quoted string, with backslash escapes.
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			challenge.Params[key] = b.String()
+			if i < len(value) {
+				i++
+			}
+			rest = value[i:]
+		} else {
+			end := strings.IndexByte(value, ',')
+			if end < 0 {
+				end = len(value)
+			}
+			challenge.Params[key] = strings.TrimSpace(value[:end])
+			rest = value[end:]
+		}
+	}
+	return challenge
+}
@@ -0,0 +1,594 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuthGrantType is the grant an OAuthTokenSource obtains tokens with.
+type OAuthGrantType string
+
+// Supported grant types.
+const (
+	// OAuthGrantAuthorizationCode is the authorization code grant with PKCE,
+	// for clients acting on behalf of a user.
+	OAuthGrantAuthorizationCode OAuthGrantType = "authorization_code"
+
+	// OAuthGrantClientCredentials is the client credentials grant, for
+	// clients acting on their own behalf.
+	OAuthGrantClientCredentials OAuthGrantType = "client_credentials"
+)
+
+// oauthGrantRefreshToken is the grant type of token refreshes.
+const oauthGrantRefreshToken = "refresh_token"
+
+// AuthorizationCodeHandler runs the user-facing part of the authorization code
+// grant: it sends the user to authURL, typically by opening a browser, and
+// returns the code and state the authorization server redirected back with.
+type AuthorizationCodeHandler func(ctx context.Context, authURL string) (code, state string, err error)
+
+// OAuthConfig configures an OAuthTokenSource.
+type OAuthConfig struct {
+	// GrantType is the grant tokens are obtained with, the authorization code
+	// grant by default.
+	GrantType OAuthGrantType
+
+	// ClientID is the ID of the client at the authorization server. When
+	// empty, the client registers itself dynamically (RFC 7591).
+	ClientID string
+
+	// ClientSecret is the secret of a confidential client.
+	ClientSecret string
+
+	// ClientName is the name the client registers with.
+	ClientName string
+
+	// RedirectURL is the URL the authorization server redirects the user to
+	// with the authorization code.
+	RedirectURL string
+
+	// Scopes are the scopes requested. When empty, the scopes named by the
+	// server's challenge, or advertised by its metadata, are requested.
+	Scopes []string
+
+	// AuthorizationServerURL is the issuer URL of the authorization server.
+	// When empty, it is discovered from the server's protected resource
+	// metadata (RFC 9728).
+	AuthorizationServerURL string
+
+	// AuthorizationCodeHandler is required by the authorization code grant.
+	AuthorizationCodeHandler AuthorizationCodeHandler
+
+	// HTTPClient sends the requests to the authorization server,
+	// http.DefaultClient by default.
+	HTTPClient *http.Client
+}
+
+// AuthorizationServerMetadata is the metadata of an OAuth 2.0 authorization
+// server (RFC 8414).
+type AuthorizationServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+}
+
+// OAuthTokenSource is a TokenSource running the OAuth 2.1 authorization flow
+// of MCP. When the server first rejects a request, it discovers the
+// authorization server from the server's protected resource metadata,
+// registers the client if it has no client ID, and obtains a token with the
+// authorization code grant with PKCE or the client credentials grant. Expired
+// tokens are refreshed with their refresh token when there is one.
+type OAuthTokenSource struct {
+	config     OAuthConfig
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu    sync.Mutex
+	token *OAuthToken
+	// flow is the flow in progress, nil if none. The fields below are only
+	// used by the flow in progress, which runs without holding mu.
+	flow *oauthFlow
+
+	clientID     string
+	clientSecret string
+	// resource is the resource indicator (RFC 8707) of the server.
+	resource string
+	// serverMetadata is the metadata of the authorization server, nil until discovered.
+	serverMetadata *AuthorizationServerMetadata
+	// scopes are the scopes requested when the configuration names none.
+	scopes []string
+}
+
+// oauthFlow is an authorization flow, or a refresh, run for the requests
+// rejected or made meanwhile.
+type oauthFlow struct {
+	// done is closed when the flow ends.
+	done chan struct{}
+	err  error
+}
+
+// NewOAuthTokenSource creates an OAuth token source.
+func NewOAuthTokenSource(config *OAuthConfig) *OAuthTokenSource {
+	s := &OAuthTokenSource{
+		config:       *config,
+		httpClient:   config.HTTPClient,
+		now:          time.Now,
+		clientID:     config.ClientID,
+		clientSecret: config.ClientSecret,
+	}
+	if s.config.GrantType == "" {
+		s.config.GrantType = OAuthGrantAuthorizationCode
+	}
+	if s.httpClient == nil {
+		s.httpClient = http.DefaultClient
+	}
+	return s
+}
+
+// Token implements TokenSource. An expired token is refreshed with its
+// refresh token, without holding up the calls made meanwhile, which wait for
+// the refresh.
+func (s *OAuthTokenSource) Token(ctx context.Context) (*OAuthToken, error) {
+	s.mu.Lock()
+	token := s.token
+	if token == nil || !token.expired(s.now()) {
+		s.mu.Unlock()
+		return token, nil
+	}
+	if flow := s.flow; flow != nil {
+		s.mu.Unlock()
+		select {
+		case <-flow.done:
+			return s.CurrentToken(), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	flow := s.startFlowLocked()
+	s.mu.Unlock()
+
+	// When the refresh fails, the server rejects the request, which starts
+	// a new flow.
+	refreshed, _ := s.refresh(ctx, token)
+	s.endFlow(flow, refreshed, nil)
+	return refreshed, nil
+}
+
+// HandleUnauthorized implements TokenSource. A single authorization flow runs
+// at a time, the requests rejected meanwhile wait for its token.
+func (s *OAuthTokenSource) HandleUnauthorized(ctx context.Context, rejected string, challenge *AuthChallenge) error {
+	s.mu.Lock()
+	for {
+		// Another request already replaced the rejected token.
+		if s.token != nil && s.token.AccessToken != rejected {
+			s.mu.Unlock()
+			return nil
+		}
+		flow := s.flow
+		if flow == nil {
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-flow.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if flow.err != nil {
+			return flow.err
+		}
+		s.mu.Lock()
+	}
+	token := s.token
+	flow := s.startFlowLocked()
+	s.mu.Unlock()
+
+	token, err := s.authorizeClient(ctx, token, challenge)
+	s.endFlow(flow, token, err)
+	return err
+}
+
+// startFlowLocked records the start of a flow. The fields of the client and
+// of the authorization server are only used by the flow until it ends.
+func (s *OAuthTokenSource) startFlowLocked() *oauthFlow {
+	s.flow = &oauthFlow{done: make(chan struct{})}
+	return s.flow
+}
+
+// endFlow sets the token obtained by a flow and wakes up its waiters.
+func (s *OAuthTokenSource) endFlow(flow *oauthFlow, token *OAuthToken, err error) {
+	s.mu.Lock()
+	s.token = token
+	s.flow = nil
+	flow.err = err
+	s.mu.Unlock()
+	close(flow.done)
+}
+
+// authorizeClient returns a token replacing the current one, refreshing it or
+// running the authorization flow.
+func (s *OAuthTokenSource) authorizeClient(
+	ctx context.Context,
+	current *OAuthToken,
+	challenge *AuthChallenge,
+) (*OAuthToken, error) {
+	if current != nil && current.RefreshToken != "" {
+		if token, err := s.refresh(ctx, current); err == nil {
+			return token, nil
+		}
+	}
+	if err := s.discover(ctx, challenge); err != nil {
+		return nil, err
+	}
+	if err := s.register(ctx); err != nil {
+		return nil, err
+	}
+	return s.grant(ctx)
+}
+
+// SetToken sets the current token, for instance one saved by a previous run.
+func (s *OAuthTokenSource) SetToken(token *OAuthToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// CurrentToken returns the current token, nil if none was obtained.
+func (s *OAuthTokenSource) CurrentToken() *OAuthToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// discover finds the authorization server of the MCP server.
+func (s *OAuthTokenSource) discover(ctx context.Context, challenge *AuthChallenge) error {
+	if challenge != nil && challenge.Params["scope"] != "" {
+		s.scopes = strings.Fields(challenge.Params["scope"])
+	}
+	if s.serverMetadata != nil {
+		return nil
+	}
+
+	issuer := s.config.AuthorizationServerURL
+	if metadata := s.fetchResourceMetadata(ctx, challenge); metadata != nil {
+		s.resource = metadata.Resource
+		if len(s.scopes) == 0 {
+			s.scopes = metadata.ScopesSupported
+		}
+		if issuer == "" && len(metadata.AuthorizationServers) > 0 {
+			issuer = metadata.AuthorizationServers[0]
+		}
+	}
+	if issuer == "" {
+		return errors.New("no authorization server found for the server")
+	}
+
+	metadata, err := s.fetchServerMetadata(ctx, issuer)
+	if err != nil {
+		return err
+	}
+	s.serverMetadata = metadata
+	return nil
+}
+
+// fetchResourceMetadata fetches the protected resource metadata of the server
+// from the URL named by the challenge or, failing that, from the well-known
+// URLs of the rejected request. It returns nil if there is none.
+func (s *OAuthTokenSource) fetchResourceMetadata(ctx context.Context, challenge *AuthChallenge) *ProtectedResourceMetadata {
+	var candidates []string
+	if metadataURL := challenge.ResourceMetadataURL(); metadataURL != "" {
+		candidates = append(candidates, metadataURL)
+	} else if challenge != nil && challenge.RequestURL != "" {
+		if u, err := url.Parse(challenge.RequestURL); err == nil {
+			origin := u.Scheme + "://" + u.Host
+			if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+				candidates = append(candidates, origin+protectedResourceMetadataPath+path)
+			}
+			candidates = append(candidates, origin+protectedResourceMetadataPath)
+		}
+	}
+	for _, candidate := range candidates {
+		var metadata ProtectedResourceMetadata
+		if err := s.getJSON(ctx, candidate, &metadata); err == nil && sameOrigin(metadata.Resource, challenge) {
+			return &metadata
+		}
+	}
+	return nil
+}
+
+// sameOrigin reports whether the resource is on the origin of the rejected
+// request, so that a server cannot obtain tokens meant for another one.
+func sameOrigin(resource string, challenge *AuthChallenge) bool {
+	if resource == "" || challenge == nil || challenge.RequestURL == "" {
+		return true
+	}
+	resourceURL, err := url.Parse(resource)
+	if err != nil {
+		return false
+	}
+	requestURL, err := url.Parse(challenge.RequestURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(resourceURL.Scheme, requestURL.Scheme) && strings.EqualFold(resourceURL.Host, requestURL.Host)
+}
+
+// fetchServerMetadata fetches the metadata of an authorization server from
+// the OAuth (RFC 8414) or the OpenID Connect well-known URL.
+func (s *OAuthTokenSource) fetchServerMetadata(ctx context.Context, issuer string) (*AuthorizationServerMetadata, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization server URL: %w", err)
+	}
+	path := strings.TrimSuffix(issuerURL.Path, "/")
+	origin := issuerURL.Scheme + "://" + issuerURL.Host
+	candidates := []string{
+		origin + "/.well-known/oauth-authorization-server" + path,
+		origin + "/.well-known/openid-configuration" + path,
+		origin + path + "/.well-known/openid-configuration",
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		var metadata AuthorizationServerMetadata
+		if lastErr = s.getJSON(ctx, candidate, &metadata); lastErr == nil {
+			if metadata.TokenEndpoint == "" {
+				return nil, errors.New("authorization server metadata has no token endpoint")
+			}
+			return &metadata, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to fetch authorization server metadata: %w", lastErr)
+}
+
+// register registers the client dynamically if it has no client ID.
+func (s *OAuthTokenSource) register(ctx context.Context) error {
+	if s.clientID != "" {
+		return nil
+	}
+	if s.serverMetadata.RegistrationEndpoint == "" {
+		return errors.New("no client ID and the authorization server does not support dynamic registration")
+	}
+
+	registration := map[string]interface{}{
+		"client_name": s.config.ClientName,
+		"grant_types": []string{string(s.config.GrantType), oauthGrantRefreshToken},
+	}
+	if s.config.GrantType == OAuthGrantAuthorizationCode {
+		registration["redirect_uris"] = []string{s.config.RedirectURL}
+		registration["response_types"] = []string{"code"}
+		registration["token_endpoint_auth_method"] = "none"
+	} else {
+		registration["token_endpoint_auth_method"] = "client_secret_basic"
+	}
+	if scopes := s.requestedScopes(); len(scopes) > 0 {
+		registration["scope"] = strings.Join(scopes, " ")
+	}
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.serverMetadata.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var client struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := s.doJSON(req, &client); err != nil {
+		return fmt.Errorf("dynamic client registration failed: %w", err)
+	}
+	if client.ClientID == "" {
+		return errors.New("dynamic client registration returned no client ID")
+	}
+	s.clientID, s.clientSecret = client.ClientID, client.ClientSecret
+	return nil
+}
+
+// grant obtains a token with the configured grant.
+func (s *OAuthTokenSource) grant(ctx context.Context) (*OAuthToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", string(s.config.GrantType))
+	if scopes := s.requestedScopes(); len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	if s.resource != "" {
+		form.Set("resource", s.resource)
+	}
+
+	switch s.config.GrantType {
+	case OAuthGrantClientCredentials:
+	case OAuthGrantAuthorizationCode:
+		code, verifier, err := s.authorize(ctx, form.Get("scope"))
+		if err != nil {
+			return nil, err
+		}
+		form.Set("code", code)
+		form.Set("code_verifier", verifier)
+		form.Set("redirect_uri", s.config.RedirectURL)
+	default:
+		return nil, fmt.Errorf("unsupported grant type %q", s.config.GrantType)
+	}
+	return s.requestToken(ctx, form)
+}
+
+// authorize runs the authorization request of the authorization code
+// grant, and returns the code and the PKCE code verifier.
+func (s *OAuthTokenSource) authorize(ctx context.Context, scope string) (string, string, error) {
+	if s.config.AuthorizationCodeHandler == nil {
+		return "", "", errors.New("the authorization code grant requires an AuthorizationCodeHandler")
+	}
+	if s.serverMetadata.AuthorizationEndpoint == "" {
+		return "", "", errors.New("authorization server metadata has no authorization endpoint")
+	}
+	if !containsAny(s.serverMetadata.CodeChallengeMethodsSupported, []string{"S256"}) {
+		return "", "", errors.New("the authorization server does not support PKCE with S256")
+	}
+
+	verifier, err := randomURLString(32)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := randomURLString(16)
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := url.Parse(s.serverMetadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", s.clientID)
+	query.Set("redirect_uri", s.config.RedirectURL)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	if s.resource != "" {
+		query.Set("resource", s.resource)
+	}
+	authURL.RawQuery = query.Encode()
+
+	code, returnedState, err := s.config.AuthorizationCodeHandler(ctx, authURL.String())
+	if err != nil {
+		return "", "", fmt.Errorf("authorization failed: %w", err)
+	}
+	if returnedState != state {
+		return "", "", errors.New("authorization failed: state mismatch")
+	}
+	return code, verifier, nil
+}
+
+// refresh returns a token replacing token, obtained with its refresh token.
+func (s *OAuthTokenSource) refresh(ctx context.Context, token *OAuthToken) (*OAuthToken, error) {
+	if token.RefreshToken == "" || s.serverMetadata == nil {
+		return nil, errors.New("token cannot be refreshed")
+	}
+	form := url.Values{}
+	form.Set("grant_type", oauthGrantRefreshToken)
+	form.Set("refresh_token", token.RefreshToken)
+	if s.resource != "" {
+		form.Set("resource", s.resource)
+	}
+	refreshed, err := s.requestToken(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	// The refresh token may not be rotated.
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	return refreshed, nil
+}
+
+// requestToken sends a token request to the token endpoint.
+func (s *OAuthTokenSource) requestToken(ctx context.Context, form url.Values) (*OAuthToken, error) {
+	if s.clientSecret == "" {
+		form.Set("client_id", s.clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.serverMetadata.TokenEndpoint,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if s.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	}
+
+	var token OAuthToken
+	if err := s.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = s.now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+// requestedScopes returns the scopes to request.
+func (s *OAuthTokenSource) requestedScopes() []string {
+	if len(s.config.Scopes) > 0 {
+		return s.config.Scopes
+	}
+	return s.scopes
+}
+
+// getJSON fetches a JSON document.
+func (s *OAuthTokenSource) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	return s.doJSON(req, v)
+}
+
+// doJSON sends a request and decodes its JSON response. OAuth error responses
+// are returned as errors.
+func (s *OAuthTokenSource) doJSON(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s: %s (status %d)", oauthErr.Error, oauthErr.ErrorDescription, resp.StatusCode)
+		}
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Redacted())
+	}
+	return json.Unmarshal(body, v)
+}
+
+// randomURLString returns n random bytes encoded in base64url.
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,417 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthServer is a minimal OAuth 2.1 authorization server.
+type testAuthServer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu            sync.Mutex
+	counter       int
+	clients       map[string]string // client ID to secret
+	codes         map[string]string // code to PKCE challenge
+	accessTokens  map[string]bool
+	refreshTokens map[string]bool
+	grants        map[string]int
+	// challengeMethods are the PKCE code challenge methods advertised.
+	challengeMethods []string
+	// tokenRequested, when not nil, receives the token requests, which wait
+	// for a value of tokenRelease.
+	tokenRequested chan struct{}
+	tokenRelease   chan struct{}
+}
+
+func newTestAuthServer(t *testing.T) *testAuthServer {
+	as := &testAuthServer{
+		t:             t,
+		clients:       map[string]string{"static-client": "static-secret"},
+		codes:         make(map[string]string),
+		accessTokens:  make(map[string]bool),
+		refreshTokens: make(map[string]bool),
+		grants:        make(map[string]int),
+
+		challengeMethods: []string{"S256"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+			Issuer:                        as.server.URL,
+			AuthorizationEndpoint:         as.server.URL + "/authorize",
+			TokenEndpoint:                 as.server.URL + "/token",
+			RegistrationEndpoint:          as.server.URL + "/register",
+			CodeChallengeMethodsSupported: as.challengeMethods,
+		})
+	})
+	mux.HandleFunc("/register", as.handleRegister)
+	mux.HandleFunc("/token", as.handleToken)
+	as.server = httptest.NewServer(mux)
+	t.Cleanup(as.server.Close)
+	return as
+}
+
+func (as *testAuthServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var registration map[string]interface{}
+	require.NoError(as.t, json.NewDecoder(r.Body).Decode(&registration))
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.counter++
+	clientID := fmt.Sprintf("registered-%d", as.counter)
+	as.clients[clientID] = ""
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"client_id": clientID})
+}
+
+// authorize simulates the user approving the authorization request.
+func (as *testAuthServer) authorize(ctx context.Context, authURL string) (string, string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("response_type") != "code" {
+		return "", "", fmt.Errorf("unexpected authorization request: %s", authURL)
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if _, ok := as.clients[query.Get("client_id")]; !ok {
+		return "", "", fmt.Errorf("unknown client %q", query.Get("client_id"))
+	}
+	as.counter++
+	code := fmt.Sprintf("code-%d", as.counter)
+	as.codes[code] = query.Get("code_challenge")
+	return code, query.Get("state"), nil
+}
+
+func (as *testAuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	require.NoError(as.t, r.ParseForm())
+	if as.tokenRequested != nil {
+		as.tokenRequested <- struct{}{}
+		<-as.tokenRelease
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	tokenError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	grantType := r.PostForm.Get("grant_type")
+	switch grantType {
+	case "client_credentials":
+		id, secret, ok := r.BasicAuth()
+		if !ok || as.clients[id] != secret || secret == "" {
+			tokenError("invalid_client")
+			return
+		}
+	case "authorization_code":
+		challenge, ok := as.codes[r.PostForm.Get("code")]
+		delete(as.codes, r.PostForm.Get("code"))
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			tokenError("invalid_grant")
+			return
+		}
+	case "refresh_token":
+		if !as.refreshTokens[r.PostForm.Get("refresh_token")] {
+			tokenError("invalid_grant")
+			return
+		}
+	default:
+		tokenError("unsupported_grant_type")
+		return
+	}
+	as.grants[grantType]++
+	as.counter++
+	token := OAuthToken{
+		AccessToken: fmt.Sprintf("access-%d", as.counter),
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+	}
+	as.accessTokens[token.AccessToken] = true
+	if grantType != "client_credentials" {
+		token.RefreshToken = fmt.Sprintf("refresh-%d", as.counter)
+		as.refreshTokens[token.RefreshToken] = true
+	}
+	_ = json.NewEncoder(w).Encode(token)
+}
+
+// revokeAccessTokens simulates the expiry of all issued access tokens.
+func (as *testAuthServer) revokeAccessTokens() {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.accessTokens = make(map[string]bool)
+}
+
+func (as *testAuthServer) grantCount(grantType string) int {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return as.grants[grantType]
+}
+
+func (as *testAuthServer) verifier() TokenVerifier {
+	return TokenVerifierFunc(func(ctx context.Context, token string) (*TokenInfo, error) {
+		as.mu.Lock()
+		defer as.mu.Unlock()
+		if !as.accessTokens[token] {
+			return nil, ErrInvalidToken
+		}
+		return &TokenInfo{Subject: "user"}, nil
+	})
+}
+
+func newOAuthTestServer(as *testAuthServer) *httptest.Server {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithGetSSEEnabled(false),
+		WithAuthorization(as.verifier(), &ProtectedResourceMetadata{
+			AuthorizationServers: []string{as.server.URL},
+		}))
+	server.RegisterTool(NewTool("echo"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return NewTextResult("ok"), nil
+	})
+	return httptest.NewServer(server.HTTPHandler())
+}
+
+func callEchoTool(t *testing.T, client *Client) {
+	req := &CallToolRequest{}
+	req.Params.Name = "echo"
+	result, err := client.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Content[0].(TextContent).Text)
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	challenge := parseAuthChallenge(
+		`Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource", ` +
+			`error="invalid_token", error_description="token \"expired\"", scope=read`)
+	assert.Equal(t, "Bearer", challenge.Scheme)
+	assert.Equal(t, "https://mcp.example.com/.well-known/oauth-protected-resource", challenge.ResourceMetadataURL())
+	assert.Equal(t, "invalid_token", challenge.Params["error"])
+	assert.Equal(t, `token "expired"`, challenge.Params["error_description"])
+	assert.Equal(t, "read", challenge.Params["scope"])
+
+	assert.Empty(t, parseAuthChallenge("").ResourceMetadataURL())
+}
+
+func TestOAuthTokenSource_AuthorizationCode(t *testing.T) {
+	as := newTestAuthServer(t)
+	httpServer := newOAuthTestServer(as)
+	defer httpServer.Close()
+
+	source := NewOAuthTokenSource(&OAuthConfig{
+		ClientName:               "Test-Client",
+		RedirectURL:              "http://localhost/callback",
+		AuthorizationCodeHandler: as.authorize,
+	})
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false), WithTokenSource(source))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The first request is rejected, which registers the client and runs the grant.
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, as.grantCount("authorization_code"))
+	callEchoTool(t, client)
+
+	// A rejected token is refreshed once and the request retried.
+	as.revokeAccessTokens()
+	callEchoTool(t, client)
+	assert.Equal(t, 1, as.grantCount("refresh_token"))
+	assert.Equal(t, 1, as.grantCount("authorization_code"))
+	assert.NotEmpty(t, source.CurrentToken().RefreshToken)
+
+	// Terminating the session is authorized too.
+	as.revokeAccessTokens()
+	require.NoError(t, client.TerminateSession(context.Background()))
+	assert.Equal(t, 2, as.grantCount("refresh_token"))
+}
+
+func TestOAuthTokenSource_SingleFlow(t *testing.T) {
+	as := newTestAuthServer(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	source := NewOAuthTokenSource(&OAuthConfig{
+		ClientName:             "Test-Client",
+		RedirectURL:            "http://localhost/callback",
+		AuthorizationServerURL: as.server.URL,
+		AuthorizationCodeHandler: func(ctx context.Context, authURL string) (string, string, error) {
+			close(started)
+			<-release
+			return as.authorize(ctx, authURL)
+		},
+	})
+
+	errs := make(chan error, 2)
+	go func() { errs <- source.HandleUnauthorized(context.Background(), "", &AuthChallenge{}) }()
+	<-started
+	go func() { errs <- source.HandleUnauthorized(context.Background(), "", &AuthChallenge{}) }()
+
+	// The source is not locked while the user authorizes the client.
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, 1, as.grantCount("authorization_code"))
+	assert.NotNil(t, source.CurrentToken())
+}
+
+func TestOAuthTokenSource_NotLockedDuringRequests(t *testing.T) {
+	as := newTestAuthServer(t)
+	as.tokenRequested = make(chan struct{})
+	as.tokenRelease = make(chan struct{})
+	source := NewOAuthTokenSource(&OAuthConfig{
+		GrantType:              OAuthGrantClientCredentials,
+		ClientID:               "static-client",
+		ClientSecret:           "static-secret",
+		AuthorizationServerURL: as.server.URL,
+	})
+
+	errs := make(chan error, 1)
+	go func() { errs <- source.HandleUnauthorized(context.Background(), "", &AuthChallenge{}) }()
+	<-as.tokenRequested
+
+	// Token does not wait for the token request of the flow.
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	as.tokenRelease <- struct{}{}
+	require.NoError(t, <-errs)
+	token = source.CurrentToken()
+	require.NotNil(t, token)
+
+	// Nor for the refresh of an expired token run by another call.
+	expired := *token
+	expired.RefreshToken = "refresh-0"
+	expired.Expiry = time.Now().Add(-time.Minute)
+	source.SetToken(&expired)
+	go func() {
+		_, err := source.Token(context.Background())
+		errs <- err
+	}()
+	<-as.tokenRequested
+	assert.Equal(t, &expired, source.CurrentToken())
+	as.tokenRelease <- struct{}{}
+	require.NoError(t, <-errs)
+}
+
+func TestOAuthTokenSource_RequiresS256(t *testing.T) {
+	as := newTestAuthServer(t)
+	as.challengeMethods = []string{"plain"}
+	source := NewOAuthTokenSource(&OAuthConfig{
+		ClientName:             "Test-Client",
+		RedirectURL:            "http://localhost/callback",
+		AuthorizationServerURL: as.server.URL,
+		AuthorizationCodeHandler: func(ctx context.Context, authURL string) (string, string, error) {
+			t.Error("authorization requested without PKCE support")
+			return "", "", nil
+		},
+	})
+	err := source.HandleUnauthorized(context.Background(), "", &AuthChallenge{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "S256")
+}
+
+func TestOAuthTokenSource_ClientCredentials(t *testing.T) {
+	as := newTestAuthServer(t)
+	httpServer := newOAuthTestServer(as)
+	defer httpServer.Close()
+
+	source := NewOAuthTokenSource(&OAuthConfig{
+		GrantType:    OAuthGrantClientCredentials,
+		ClientID:     "static-client",
+		ClientSecret: "static-secret",
+	})
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false), WithTokenSource(source))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	callEchoTool(t, client)
+
+	// Without a refresh token, the grant runs again.
+	as.revokeAccessTokens()
+	callEchoTool(t, client)
+	assert.Equal(t, 2, as.grantCount("client_credentials"))
+
+	// Wrong credentials fail the request instead of retrying forever.
+	as.revokeAccessTokens()
+	bad := NewOAuthTokenSource(&OAuthConfig{
+		GrantType:    OAuthGrantClientCredentials,
+		ClientID:     "static-client",
+		ClientSecret: "wrong",
+	})
+	badClient, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false), WithTokenSource(bad))
+	require.NoError(t, err)
+	defer badClient.Close()
+	_, err = badClient.Initialize(context.Background(), &InitializeRequest{})
+	assert.Error(t, err)
+}
+
+func TestOAuthTokenSource_SSEClient(t *testing.T) {
+	as := newTestAuthServer(t)
+	server := NewSSEServer("Test-Server", "1.0.0", WithSSEAuthorization(as.verifier(), &ProtectedResourceMetadata{
+		AuthorizationServers: []string{as.server.URL},
+	}))
+	server.RegisterTool(NewTool("echo"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return NewTextResult("ok"), nil
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	source := NewOAuthTokenSource(&OAuthConfig{
+		GrantType:    OAuthGrantClientCredentials,
+		ClientID:     "static-client",
+		ClientSecret: "static-secret",
+	})
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithTokenSource(source))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	as.revokeAccessTokens()
+	callEchoTool(t, client)
+	assert.Equal(t, 2, as.grantCount("client_credentials"))
+}
+
+func TestStaticTokenSource(t *testing.T) {
+	as := newTestAuthServer(t)
+	httpServer := newOAuthTestServer(as)
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false), WithTokenSource(StaticTokenSource("unknown")))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUnauthorized.Error())
+}
@@ -0,0 +1,179 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// WithClientToolOutputValidation sets whether CallTool validates the
+// structured content of successful results against the output schema the
+// server declares for the tool. The output schemas are taken from the tools
+// returned by ListTools. The client lists all tools the first time it calls a
+// tool it has not listed, and again after the server notifies that its tool
+// list changed. Servers that do not advertise tools.listChanged are asked for
+// their tools on every call of a tool missing from the last list, but a
+// schema changed on such a server after the client listed the tool is not
+// seen, and results are validated against the schema listed first. Disabled
+// by default.
+func WithClientToolOutputValidation(enabled bool) ClientOption {
+	return func(c *Client) {
+		if enabled {
+			c.outputSchemas = &toolOutputSchemas{}
+		} else {
+			c.outputSchemas = nil
+		}
+	}
+}
+
+// CallToolTyped calls a tool and decodes the structured content of its result
+// into a value of type O. It fails if the tool reports an error or returns no
+// structured content. The result is returned along with the value, and with
+// the error reported by the tool.
+//
+// With WithClientToolOutputValidation, the structured content is validated
+// against the output schema of the tool before it is decoded.
+//
+// Example usage:
+//
+//	weather, _, err := mcp.CallToolTyped[WeatherOutput](ctx, client, req)
+func CallToolTyped[O any](ctx context.Context, c *Client, req *CallToolRequest) (O, *CallToolResult, error) {
+	var output O
+	result, err := c.CallTool(ctx, req)
+	if err != nil {
+		return output, nil, err
+	}
+	if result.IsError {
+		return output, result, fmt.Errorf("tool %s failed: %s", req.Params.Name, resultText(result))
+	}
+	if result.StructuredContent == nil {
+		return output, result, fmt.Errorf("tool %s returned no structured content", req.Params.Name)
+	}
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return output, result, fmt.Errorf("failed to marshal structured content: %w", err)
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return output, result, fmt.Errorf("failed to unmarshal structured content into %T: %w", output, err)
+	}
+	return output, result, nil
+}
+
+// resultText returns the text content of a tool result.
+func resultText(result *CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// validateToolResult validates the structured content of a successful tool
+// result against the output schema of the tool, if output validation is
+// enabled.
+func (c *Client) validateToolResult(ctx context.Context, name string, result *CallToolResult) error {
+	if c.outputSchemas == nil || result.IsError {
+		return nil
+	}
+	schema, err := c.outputSchemas.get(ctx, c, name)
+	if err != nil {
+		return fmt.Errorf("failed to get the output schema of tool %s: %w", name, err)
+	}
+	if schema == nil {
+		return nil
+	}
+	if violations := validateStructuredContent(schema, result.StructuredContent); len(violations) > 0 {
+		return fmt.Errorf("structured content of tool %s does not conform to its output schema: %w",
+			name, &SchemaValidationError{Violations: violations})
+	}
+	return nil
+}
+
+// toolOutputSchemas caches the output schemas of the tools of the server,
+// compiled for validation. A nil cache caches nothing.
+type toolOutputSchemas struct {
+	mu          sync.Mutex
+	schemas     map[string]*openapi3.Schema // Output schemas by tool name, nil for unknown tools and tools without one.
+	listChanged bool                        // Whether the server notifies that its tool list changed.
+}
+
+// setListChanged records whether the server notifies that its tool list
+// changed, as advertised in its capabilities.
+func (s *toolOutputSchemas) setListChanged(capabilities ServerCapabilities) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listChanged = capabilities.Tools != nil && capabilities.Tools.ListChanged
+}
+
+// record caches the output schemas of listed tools.
+func (s *toolOutputSchemas) record(tools []Tool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.schemas == nil {
+		s.schemas = make(map[string]*openapi3.Schema)
+	}
+	for _, tool := range tools {
+		s.schemas[tool.Name] = compileSchema(tool.OutputSchema)
+	}
+}
+
+// get returns the output schema of a tool, listing all tools of the server
+// if the tool is not cached. It returns nil for unknown tools and tools
+// without an output schema. If the server notifies that its tool list
+// changed, unknown tools are cached too, so that calling them does not list
+// the tools again until it does. Otherwise the notification never comes, and
+// every call of an unknown tool lists the tools again to find it.
+func (s *toolOutputSchemas) get(ctx context.Context, c *Client, name string) (*openapi3.Schema, error) {
+	if schema, ok := s.lookup(name); ok {
+		return schema, nil
+	}
+	if _, err := listAllUpstreamTools(ctx, c); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schema, ok := s.schemas[name]
+	if !ok && s.listChanged {
+		if s.schemas == nil {
+			s.schemas = make(map[string]*openapi3.Schema)
+		}
+		s.schemas[name] = nil
+	}
+	return schema, nil
+}
+
+// lookup returns the cached output schema of a tool.
+func (s *toolOutputSchemas) lookup(name string) (*openapi3.Schema, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schema, ok := s.schemas[name]
+	return schema, ok
+}
+
+// reset drops the cached output schemas.
+func (s *toolOutputSchemas) reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas = nil
+}
@@ -0,0 +1,121 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStructuredOutputClient(t *testing.T, options ...ClientOption) (*Server, *Client) {
+	t.Helper()
+	server, url := newSessionRegistryServer(t)
+	registerAddressTool(server, "address")
+	client, err := NewClient(url, Implementation{Name: "Test-Client", Version: "1.0.0"}, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestClient_ToolOutputValidation(t *testing.T) {
+	server, client := newStructuredOutputClient(t, WithClientToolOutputValidation(true))
+
+	_, err := callToolWithArguments(client, "address", map[string]interface{}{
+		"content": map[string]interface{}{"city": 1},
+	})
+	var validationErr *SchemaValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	assert.Equal(t, "/city", validationErr.Violations[0].Pointer)
+
+	// A tool registered after the tools were listed is validated too.
+	registerAddressTool(server, "other")
+	_, err = callToolWithArguments(client, "other", nil)
+	assert.True(t, errors.As(err, &validationErr), err)
+
+	_, err = callToolWithArguments(client, "echo", nil)
+	assert.NoError(t, err)
+}
+
+func TestClient_ToolOutputSchemasCache(t *testing.T) {
+	server, client := newStructuredOutputClient(t, WithClientToolOutputValidation(true))
+
+	// A tool missing from the list is cached as having no schema, until the
+	// tool list changes.
+	ctx := context.Background()
+	schema, err := client.outputSchemas.get(ctx, client, "later")
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+	registerAddressTool(server, "later")
+	schema, err = client.outputSchemas.get(ctx, client, "later")
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+
+	client.dispatchListChanged(NewJSONRPCNotificationFromMap(NotificationMethodToolsListChanged, nil))
+	schema, err = client.outputSchemas.get(ctx, client, "later")
+	require.NoError(t, err)
+	assert.NotNil(t, schema)
+}
+
+func TestClient_ToolOutputSchemasCacheWithoutListChanged(t *testing.T) {
+	server, url := newSessionRegistryServer(t, WithListChangedEnabled(false))
+	client, err := NewClient(url, Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientToolOutputValidation(true))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	// The server never notifies that its tool list changed, so a tool missing
+	// from the list is looked up again on the next call.
+	ctx := context.Background()
+	schema, err := client.outputSchemas.get(ctx, client, "later")
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+	registerAddressTool(server, "later")
+	schema, err = client.outputSchemas.get(ctx, client, "later")
+	require.NoError(t, err)
+	assert.NotNil(t, schema)
+}
+
+func TestClient_ToolOutputValidationDisabled(t *testing.T) {
+	_, client := newStructuredOutputClient(t)
+
+	result, err := callToolWithArguments(client, "address", map[string]interface{}{
+		"content": map[string]interface{}{"city": 1},
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, result.StructuredContent)
+}
+
+func TestCallToolTyped(t *testing.T) {
+	_, client := newStructuredOutputClient(t, WithClientToolOutputValidation(true))
+	ctx := context.Background()
+
+	req := &CallToolRequest{}
+	req.Params.Name = "address"
+	req.Params.Arguments = map[string]interface{}{"content": map[string]interface{}{"city": "Paris"}}
+	address, result, err := CallToolTyped[validationAddress](ctx, client, req)
+	require.NoError(t, err)
+	assert.Equal(t, validationAddress{City: "Paris"}, address)
+	assert.Equal(t, "address", result.Content[0].(TextContent).Text)
+
+	req.Params.Arguments = map[string]interface{}{"content": "error"}
+	_, result, err = CallToolTyped[validationAddress](ctx, client, req)
+	assert.EqualError(t, err, "tool address failed: failed")
+	assert.True(t, result.IsError)
+
+	req.Params.Name = "echo"
+	req.Params.Arguments = nil
+	_, _, err = CallToolTyped[validationAddress](ctx, client, req)
+	assert.EqualError(t, err, "tool echo returned no structured content")
+}
@@ -0,0 +1,272 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/retry"
+)
+
+// ClientTransport carries the JSON-RPC messages between a Client and a
+// server. It is used with NewClientWithTransport to connect a client through
+// a protocol other than the built-in ones.
+//
+// A transport that holds a session assigned by the server also implements
+// ClientSessionTransport.
+type ClientTransport interface {
+	// Start connects the transport. It is called once, before the first
+	// message is sent, with the context of that message. The requests and
+	// notifications the server sends to the client from then on are passed to
+	// handler. Start is called again if it failed, and when the client
+	// initializes again after losing its session.
+	Start(ctx context.Context, handler ClientTransportHandler) error
+
+	// SendRequest sends a request and waits for its response. It returns the
+	// result of a successful response, or the complete message of an error
+	// response.
+	SendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error)
+
+	// SendNotification sends a notification.
+	SendNotification(ctx context.Context, notification *JSONRPCNotification) error
+
+	// SendResponse sends the response to a request of the server.
+	SendResponse(ctx context.Context, resp *JSONRPCResponse) error
+
+	// Close disconnects the transport.
+	Close() error
+}
+
+// ClientSessionTransport is a ClientTransport holding a session assigned by
+// the server on initialization.
+type ClientSessionTransport interface {
+	ClientTransport
+
+	// SessionID returns the ID of the session, or an empty string before
+	// initialization.
+	SessionID() string
+
+	// TerminateSession ends the session on the server.
+	TerminateSession(ctx context.Context) error
+}
+
+// ClientTransportHandler handles the requests and notifications a server
+// sends to a client. A ClientTransport receives it in Start.
+type ClientTransportHandler interface {
+	// HandleRequest handles a request and returns the response to send back
+	// to the server, a *JSONRPCResponse or a *JSONRPCError, or nil if the
+	// server cancelled the request and no response must be sent. It may block
+	// for long, for example while the user answers an elicitation, so the
+	// transport must keep receiving messages while it runs.
+	HandleRequest(ctx context.Context, req *JSONRPCRequest) JSONRPCMessage
+
+	// HandleNotification handles a notification.
+	HandleNotification(ctx context.Context, notification *JSONRPCNotification)
+}
+
+// NewClientWithTransport creates a new MCP client exchanging messages through
+// transport. Options configuring the HTTP transport are ignored.
+func NewClientWithTransport(
+	transport ClientTransport,
+	clientInfo Implementation,
+	options ...ClientOption,
+) (*Client, error) {
+	if transport == nil {
+		return nil, stderrors.New("client transport is required")
+	}
+
+	client := newClient(clientInfo)
+	for _, option := range options {
+		option(client)
+	}
+	client.transport = newCustomClientTransport(transport, client)
+	return client, nil
+}
+
+// customClientTransport adapts a ClientTransport to the transport used by the
+// Client.
+type customClientTransport struct {
+	transport ClientTransport
+	handler   *clientTransportHandler
+
+	startMu sync.Mutex
+	started bool
+}
+
+// newCustomClientTransport creates the transport of client sending messages
+// through transport.
+func newCustomClientTransport(transport ClientTransport, client *Client) *customClientTransport {
+	return &customClientTransport{
+		transport: transport,
+		handler:   &clientTransportHandler{client: client},
+	}
+}
+
+// start starts the transport unless it is started already.
+func (t *customClientTransport) start(ctx context.Context) error {
+	t.startMu.Lock()
+	defer t.startMu.Unlock()
+	if t.started {
+		return nil
+	}
+	if err := t.transport.Start(ctx, t.handler); err != nil {
+		return fmt.Errorf("failed to start transport: %w", err)
+	}
+	t.started = true
+	return nil
+}
+
+// resetSession makes the next message start the transport again, to connect
+// again after the session was lost.
+func (t *customClientTransport) resetSession() {
+	t.startMu.Lock()
+	defer t.startMu.Unlock()
+	t.started = false
+}
+
+func (t *customClientTransport) sendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	if err := t.start(ctx); err != nil {
+		return nil, err
+	}
+	return t.transport.SendRequest(ctx, req)
+}
+
+func (t *customClientTransport) sendNotification(ctx context.Context, notification *JSONRPCNotification) error {
+	if err := t.start(ctx); err != nil {
+		return err
+	}
+	return t.transport.SendNotification(ctx, notification)
+}
+
+func (t *customClientTransport) sendResponse(ctx context.Context, resp *JSONRPCResponse) error {
+	if err := t.start(ctx); err != nil {
+		return err
+	}
+	return t.transport.SendResponse(ctx, resp)
+}
+
+func (t *customClientTransport) close() error {
+	return t.transport.Close()
+}
+
+// setRetryConfig is a no-op, retrying is up to the transport.
+func (t *customClientTransport) setRetryConfig(config *retry.Config) {}
+
+func (t *customClientTransport) getSessionID() string {
+	if transport, ok := t.transport.(ClientSessionTransport); ok {
+		return transport.SessionID()
+	}
+	return ""
+}
+
+// setSessionID is a no-op, the session is held by the transport.
+func (t *customClientTransport) setSessionID(sessionID string) {}
+
+func (t *customClientTransport) terminateSession(ctx context.Context) error {
+	if transport, ok := t.transport.(ClientSessionTransport); ok {
+		return transport.TerminateSession(ctx)
+	}
+	return stderrors.New("transport has no session")
+}
+
+// registerNotificationHandler registers a handler of the notifications with
+// the given method.
+func (t *customClientTransport) registerNotificationHandler(method string, handler NotificationHandler) {
+	t.handler.mu.Lock()
+	defer t.handler.mu.Unlock()
+	if t.handler.notificationHandlers == nil {
+		t.handler.notificationHandlers = make(map[string]NotificationHandler)
+	}
+	t.handler.notificationHandlers[method] = handler
+}
+
+// unregisterNotificationHandler removes the handler of the notifications with
+// the given method.
+func (t *customClientTransport) unregisterNotificationHandler(method string) {
+	t.handler.mu.Lock()
+	defer t.handler.mu.Unlock()
+	delete(t.handler.notificationHandlers, method)
+}
+
+// clientTransportHandler handles the messages a ClientTransport receives from
+// the server on behalf of the client.
+type clientTransportHandler struct {
+	client *Client
+
+	mu                   sync.RWMutex
+	notificationHandlers map[string]NotificationHandler
+}
+
+// HandleRequest implements ClientTransportHandler.
+func (h *clientTransportHandler) HandleRequest(ctx context.Context, req *JSONRPCRequest) JSONRPCMessage {
+	return h.client.handleServerRequest(ctx, req, func(ctx context.Context) JSONRPCMessage {
+		return h.handleRequest(ctx, req)
+	})
+}
+
+// handleRequest returns the response to a request of the server.
+func (h *clientTransportHandler) handleRequest(ctx context.Context, req *JSONRPCRequest) JSONRPCMessage {
+	c := h.client
+	if c.forwarder != nil {
+		return c.forwarder.forwardRequest(ctx, req)
+	}
+
+	switch req.Method {
+	case MethodPing:
+		return newJSONRPCResponse(req.ID, struct{}{})
+	case MethodRootsList:
+		c.rootsMu.RLock()
+		provider := c.rootsProvider
+		c.rootsMu.RUnlock()
+		roots := []Root{}
+		if provider != nil {
+			if provided := provider.GetRoots(); provided != nil {
+				roots = provided
+			}
+		}
+		return newJSONRPCResponse(req.ID, &ListRootsResult{Roots: roots})
+	case MethodSamplingCreateMessage:
+		return handleCreateMessageRequest(ctx, c.getSamplingHandler(), req)
+	case MethodElicitationCreate:
+		return handleElicitRequest(ctx, c.getElicitationHandler(), req)
+	default:
+		return newJSONRPCErrorResponse(req.ID, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", req.Method), nil)
+	}
+}
+
+// HandleNotification implements ClientTransportHandler.
+func (h *clientTransportHandler) HandleNotification(ctx context.Context, notification *JSONRPCNotification) {
+	c := h.client
+	c.dispatchCancelled(notification)
+	if c.forwarder != nil {
+		c.forwarder.forwardNotification(notification)
+		return
+	}
+	c.progress.dispatch(notification)
+	c.dispatchLogMessage(notification)
+	c.dispatchResourceUpdated(notification)
+	c.dispatchListChanged(notification)
+
+	h.mu.RLock()
+	handler := h.notificationHandlers[notification.Method]
+	h.mu.RUnlock()
+	if notification.Method == NotificationMethodMessage {
+		if requestHandler := requestLogHandlerFromContext(ctx); requestHandler != nil {
+			handler = requestHandler
+		}
+	}
+	if handler == nil {
+		return
+	}
+	if err := handler(notification); err != nil && c.logger != nil {
+		c.logger.Debugf("Failed to handle notification %s: %v", notification.Method, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClientTransport answers requests with canned results and records the
+// messages it is asked to send.
+type fakeClientTransport struct {
+	starts        int
+	handler       ClientTransportHandler
+	results       map[string]interface{}
+	notifications []string
+	closed        bool
+}
+
+func (f *fakeClientTransport) Start(ctx context.Context, handler ClientTransportHandler) error {
+	f.starts++
+	f.handler = handler
+	return nil
+}
+
+func (f *fakeClientTransport) SendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	result, ok := f.results[req.Method]
+	var message interface{} = result
+	if !ok {
+		message = newJSONRPCErrorResponse(req.ID, ErrCodeMethodNotFound, "method not found", nil)
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(data)
+	return &raw, nil
+}
+
+func (f *fakeClientTransport) SendNotification(ctx context.Context, notification *JSONRPCNotification) error {
+	f.notifications = append(f.notifications, notification.Method)
+	return nil
+}
+
+func (f *fakeClientTransport) SendResponse(ctx context.Context, resp *JSONRPCResponse) error {
+	return nil
+}
+
+func (f *fakeClientTransport) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestNewClientWithTransport(t *testing.T) {
+	_, err := NewClientWithTransport(nil, Implementation{Name: "Test-Client", Version: "1.0.0"})
+	assert.Error(t, err)
+
+	transport := &fakeClientTransport{results: map[string]interface{}{
+		MethodInitialize: &InitializeResult{
+			ProtocolVersion: ProtocolVersion_2025_06_18,
+			ServerInfo:      Implementation{Name: "Fake-Server", Version: "1.0.0"},
+		},
+		MethodToolsList: &ListToolsResult{Tools: []Tool{*NewTool("echo")}},
+	}}
+	client, err := NewClientWithTransport(transport, Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	result, err := client.Initialize(ctx, &InitializeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "Fake-Server", result.ServerInfo.Name)
+	assert.Equal(t, []string{MethodNotificationsInitialized}, transport.notifications)
+
+	tools, err := client.ListTools(ctx, &ListToolsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "echo", tools.Tools[0].Name)
+	_, err = client.ListPrompts(ctx, &ListPromptsRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, transport.starts, "the transport is started once")
+
+	assert.Empty(t, client.GetSessionID())
+	assert.Error(t, client.TerminateSession(ctx))
+	require.NoError(t, client.Close())
+	assert.True(t, transport.closed)
+}
+
+func TestClientTransportHandler(t *testing.T) {
+	transport := &fakeClientTransport{results: map[string]interface{}{}}
+	client, err := NewClientWithTransport(transport, Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = client.Initialize(ctx, &InitializeRequest{})
+	assert.Error(t, err)
+	require.NotNil(t, transport.handler)
+
+	client.SetRootsProvider(memoryRoots{{URI: "file:///workspace"}})
+	resp := transport.handler.HandleRequest(ctx, newJSONRPCRequest(1, MethodRootsList, nil))
+	require.IsType(t, &JSONRPCResponse{}, resp)
+	assert.Equal(t, []Root{{URI: "file:///workspace"}}, resp.(*JSONRPCResponse).Result.(*ListRootsResult).Roots)
+
+	resp = transport.handler.HandleRequest(ctx, newJSONRPCRequest(2, MethodPing, nil))
+	assert.IsType(t, &JSONRPCResponse{}, resp)
+
+	resp = transport.handler.HandleRequest(ctx, newJSONRPCRequest(3, "unknown/method", nil))
+	require.IsType(t, &JSONRPCError{}, resp)
+	assert.Equal(t, ErrCodeMethodNotFound, resp.(*JSONRPCError).Error.Code)
+
+	received := make(chan string, 1)
+	client.RegisterNotificationHandler("notifications/custom", func(notification *JSONRPCNotification) error {
+		received <- notification.Method
+		return nil
+	})
+	transport.handler.HandleNotification(ctx, NewJSONRPCNotificationFromMap("notifications/custom", nil))
+	assert.Equal(t, "notifications/custom", <-received)
+
+	client.UnregisterNotificationHandler("notifications/custom")
+	transport.handler.HandleNotification(ctx, NewJSONRPCNotificationFromMap("notifications/custom", nil))
+	assert.Empty(t, received)
+}
@@ -102,6 +102,10 @@ func ExecuteTestTool(t *testing.T, c *mcp.Client, toolName string, args map[stri
 	callToolReq := &mcp.CallToolRequest{}
 	callToolReq.Params.Name = toolName
 	callToolReq.Params.Arguments = args
+	// Progress is only reported for requests carrying a progress token.
+	callToolReq.Params.Meta = &struct {
+		ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
+	}{ProgressToken: toolName}
 	result, err := c.CallTool(ctx, callToolReq)
 	require.NoError(t, err, "failed to call tool %s", toolName)
 	require.NotNil(t, result, "tool call result should not be nil")
@@ -177,6 +181,10 @@ func ExecuteSSETestTool(t *testing.T, c *mcp.Client, toolName string, args map[s
 	callToolReq := &mcp.CallToolRequest{}
 	callToolReq.Params.Name = toolName
 	callToolReq.Params.Arguments = args
+	// Progress is only reported for requests carrying a progress token.
+	callToolReq.Params.Meta = &struct {
+		ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
+	}{ProgressToken: toolName}
 	result, err := c.CallTool(ctx, callToolReq)
 	require.NoError(t, err, "failed to call tool %s", toolName)
 	require.NotNil(t, result, "tool call stream result should not be nil")
@@ -0,0 +1,122 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+// Dynamic STDIO server for proxy catalog refresh and restart integration testing.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type callParams struct {
+	Name string `json:"name"`
+}
+
+func main() {
+	tools := []string{"add-tool", "crash"}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		if req.ID == nil {
+			continue
+		}
+
+		var result interface{}
+		var notification string
+		switch req.Method {
+		case "initialize":
+			result = map[string]interface{}{
+				"protocolVersion": "2025-03-26",
+				"serverInfo": map[string]string{
+					"name":    "dynamic-stdio-server",
+					"version": "1.0.0",
+				},
+				"capabilities": map[string]interface{}{
+					"tools": map[string]interface{}{"listChanged": true},
+				},
+			}
+		case "tools/list":
+			list := make([]map[string]interface{}, 0, len(tools))
+			for _, name := range tools {
+				list = append(list, tool(name))
+			}
+			result = map[string]interface{}{"tools": list}
+		case "tools/call":
+			var params callParams
+			_ = json.Unmarshal(req.Params, &params)
+			switch params.Name {
+			case "crash":
+				os.Exit(1)
+			case "add-tool":
+				tools = append(tools, fmt.Sprintf("extra-%d", len(tools)-1))
+				notification = "notifications/tools/list_changed"
+			}
+			result = map[string]interface{}{
+				"content": []map[string]string{{
+					"type": "text",
+					"text": params.Name + " ok",
+				}},
+			}
+		case "ping":
+			result = map[string]interface{}{}
+		default:
+			writeJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"error": map[string]interface{}{
+					"code":    -32601,
+					"message": "method not found",
+				},
+			})
+			continue
+		}
+
+		writeJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  result,
+		})
+		if notification != "" {
+			writeJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  notification,
+			})
+		}
+	}
+}
+
+func tool(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"description": fmt.Sprintf("%s description", name),
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}
+}
+
+func writeJSON(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+}
@@ -113,12 +113,8 @@ func TestSSEProgress(t *testing.T) {
 		logNotifications := collector.GetLogNotifications()
 		require.Len(t, logNotifications, 5, "Should have 5 log messages")
 		assert.Equal(t, "info", logNotifications[0].Params.AdditionalFields["level"], "Log level should be info")
-		dataMap, ok := logNotifications[0].Params.AdditionalFields["data"].(map[string]interface{})
-		assert.True(t, ok, "Data parameter should be map[string]interface{}")
-		var messageStr string
-		var isString bool
-		messageStr, isString = dataMap["message"].(string)
-		assert.True(t, isString, "Message should be a string in data map")
+		messageStr, isString := logNotifications[0].Params.AdditionalFields["data"].(string)
+		assert.True(t, isString, "Data parameter should be the message string")
 		assert.Contains(t, messageStr, "Finished", "Log message should contain 'Finished'")
 	})
 }
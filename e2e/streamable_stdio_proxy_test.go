@@ -118,3 +118,108 @@ func toolNames(tools []mcp.Tool) []string {
 	}
 	return names
 }
+
+func TestStreamableServerWithStdio_ShutdownClosesProcess(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	proxyServer, proxy, err := mcp.NewStreamableServerWithStdio(ctx, mcp.StreamableStdioProxyConfig{
+		ServerName:    "stdio-proxy",
+		ServerVersion: "1.0.0",
+		Stdio: mcp.StdioTransportConfig{
+			ServerParams: mcp.StdioServerParameters{
+				Command: "go",
+				Args:    []string{"run", "./test_server/main.go"},
+			},
+			Timeout: 10 * time.Second,
+		},
+		DiscoveryTimeout: 20 * time.Second,
+	})
+	require.NoError(t, err)
+	defer proxy.Close()
+	require.Equal(t, mcp.StateInitialized, proxy.Client().GetState())
+
+	require.NoError(t, proxyServer.Shutdown(ctx))
+	assert.Equal(t, mcp.StateDisconnected, proxy.Client().GetState())
+}
+
+func TestStreamableServerWithStdio_RefreshesCatalogAndRestarts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	proxyServer, proxy, err := mcp.NewStreamableServerWithStdio(ctx, mcp.StreamableStdioProxyConfig{
+		ServerName:    "dynamic-stdio-proxy",
+		ServerVersion: "1.0.0",
+		Stdio: mcp.StdioTransportConfig{
+			ServerParams: mcp.StdioServerParameters{
+				Command: "go",
+				Args:    []string{"run", "./dynamic_stdio_server/main.go"},
+			},
+			Timeout: 10 * time.Second,
+		},
+		DiscoveryTimeout: 20 * time.Second,
+		RestartMinDelay:  10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	httpServer := httptest.NewServer(proxyServer.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := mcp.NewClient(httpServer.URL+"/mcp", mcp.Implementation{
+		Name:    "streamable-proxy-refresh-test-client",
+		Version: "1.0.0",
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	listChanged := make(chan struct{}, 16)
+	client.RegisterNotificationHandler(mcp.NotificationMethodToolsListChanged,
+		func(*mcp.JSONRPCNotification) error {
+			listChanged <- struct{}{}
+			return nil
+		})
+	_, err = client.Initialize(ctx, &mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	listTools := func() []string {
+		tools, err := client.ListTools(ctx, &mcp.ListToolsRequest{})
+		require.NoError(t, err)
+		return toolNames(tools.Tools)
+	}
+	callTool := func(name string) (*mcp.CallToolResult, error) {
+		return client.CallTool(ctx, &mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name}})
+	}
+
+	// A list_changed notification of the stdio server re-syncs the tools.
+	_, err = callTool("add-tool")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"add-tool", "crash", "extra-1"}, listTools())
+	}, 10*time.Second, 20*time.Millisecond)
+	result, err := callTool("extra-1")
+	require.NoError(t, err)
+	assert.Equal(t, "extra-1 ok", result.Content[0].(mcp.TextContent).Text)
+
+	// A crashed process is restarted and the tools are re-synced again.
+	pid := proxy.Client().GetProcessID()
+	result, err = callTool("crash")
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	for len(listChanged) > 0 {
+		<-listChanged
+	}
+	assert.Eventually(t, func() bool {
+		return proxy.Client().GetState() == mcp.StateInitialized &&
+			assert.ObjectsAreEqual([]string{"add-tool", "crash"}, listTools())
+	}, 30*time.Second, 50*time.Millisecond)
+	assert.NotEqual(t, pid, proxy.Client().GetProcessID())
+	select {
+	case <-listChanged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("list_changed notification not received after restart")
+	}
+
+	result, err = callTool("add-tool")
+	require.NoError(t, err)
+	assert.Equal(t, "add-tool ok", result.Content[0].(mcp.TextContent).Text)
+}
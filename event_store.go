@@ -0,0 +1,243 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultMaxEventsPerStream is the default number of events kept per stream.
+	defaultMaxEventsPerStream = 1000
+
+	// defaultMaxStreams is the default number of streams kept by the in-memory event store.
+	defaultMaxStreams = 10000
+)
+
+// ErrEventNotFound is returned by an EventStore when the events following an
+// event ID are no longer, or were never, available.
+var ErrEventNotFound = errors.New("event not found")
+
+// EventStore records the messages sent on the SSE streams of the streamable
+// HTTP transport so that a client can resume a stream after its connection
+// drops. Every event is stored before it is written to the connection; when
+// the client reconnects with a Last-Event-ID header, the events that followed
+// that ID on the same stream are replayed.
+//
+// Implementations must be safe for concurrent use. Event IDs must be unique
+// across streams and identify the stream they belong to.
+type EventStore interface {
+	// StoreEvent records a message sent on the stream and returns its event ID.
+	// Event IDs of a stream must be monotonically increasing.
+	StoreEvent(ctx context.Context, streamID string, message []byte) (eventID string, err error)
+
+	// ReplayEventsAfter calls send, in order, for every event stored after
+	// lastEventID on its stream, and returns the ID of that stream. It returns
+	// ErrEventNotFound if the events following lastEventID are not available.
+	ReplayEventsAfter(
+		ctx context.Context,
+		lastEventID string,
+		send func(eventID string, message []byte) error,
+	) (streamID string, err error)
+
+	// DeleteStream removes the events of a stream, which can no longer be
+	// resumed. It is called once an ended stream was fully delivered to the
+	// client, and for the remaining streams of a session when it ends.
+	// Deleting an unknown stream is not an error.
+	DeleteStream(ctx context.Context, streamID string) error
+}
+
+// InMemoryEventStore is an EventStore that keeps the latest events of every
+// stream in a ring buffer. Once a stream's buffer is full, its oldest events
+// are dropped, and once the maximum number of streams is reached, the oldest
+// stream is dropped; such streams can no longer be resumed from the dropped
+// events.
+type InMemoryEventStore struct {
+	maxEventsPerStream int
+	maxStreams         int
+
+	mu      sync.Mutex
+	streams map[string]*eventRing
+	// order holds the stream IDs from the oldest to the newest.
+	order []string
+}
+
+// InMemoryEventStoreOption configures an InMemoryEventStore.
+type InMemoryEventStoreOption func(*InMemoryEventStore)
+
+// WithMaxEventsPerStream sets the number of events kept per stream.
+func WithMaxEventsPerStream(n int) InMemoryEventStoreOption {
+	return func(s *InMemoryEventStore) {
+		if n > 0 {
+			s.maxEventsPerStream = n
+		}
+	}
+}
+
+// WithMaxStreams sets the number of streams kept by the store.
+func WithMaxStreams(n int) InMemoryEventStoreOption {
+	return func(s *InMemoryEventStore) {
+		if n > 0 {
+			s.maxStreams = n
+		}
+	}
+}
+
+// NewInMemoryEventStore creates an in-memory event store.
+//
+// Example:
+//
+//	server := mcp.NewServer("name", "1.0.0",
+//	    mcp.WithEventStore(mcp.NewInMemoryEventStore(mcp.WithMaxEventsPerStream(500))),
+//	)
+func NewInMemoryEventStore(options ...InMemoryEventStoreOption) *InMemoryEventStore {
+	s := &InMemoryEventStore{
+		maxEventsPerStream: defaultMaxEventsPerStream,
+		maxStreams:         defaultMaxStreams,
+		streams:            make(map[string]*eventRing),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// storedEvent is an event of an eventRing.
+type storedEvent struct {
+	seq     uint64
+	message []byte
+}
+
+// eventRing is a fixed-size ring buffer of the latest events of a stream.
+type eventRing struct {
+	events []storedEvent
+	// start is the index of the oldest event.
+	start int
+	count int
+	// lastSeq is the sequence number of the latest event.
+	lastSeq uint64
+}
+
+// push appends an event, overwriting the oldest one if the ring is full.
+func (r *eventRing) push(message []byte) uint64 {
+	r.lastSeq++
+	event := storedEvent{seq: r.lastSeq, message: message}
+	if r.count < len(r.events) {
+		r.events[(r.start+r.count)%len(r.events)] = event
+		r.count++
+	} else {
+		r.events[r.start] = event
+		r.start = (r.start + 1) % len(r.events)
+	}
+	return r.lastSeq
+}
+
+// after returns the events following seq, or false if some were dropped.
+func (r *eventRing) after(seq uint64) ([]storedEvent, bool) {
+	if seq > r.lastSeq {
+		return nil, false
+	}
+	missing := int(r.lastSeq - seq)
+	if missing > r.count {
+		return nil, false
+	}
+	events := make([]storedEvent, 0, missing)
+	for i := r.count - missing; i < r.count; i++ {
+		events = append(events, r.events[(r.start+i)%len(r.events)])
+	}
+	return events, true
+}
+
+// StoreEvent implements EventStore.
+func (s *InMemoryEventStore) StoreEvent(ctx context.Context, streamID string, message []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ring, ok := s.streams[streamID]
+	if !ok {
+		if len(s.order) >= s.maxStreams {
+			delete(s.streams, s.order[0])
+			s.order = s.order[1:]
+		}
+		ring = &eventRing{events: make([]storedEvent, s.maxEventsPerStream)}
+		s.streams[streamID] = ring
+		s.order = append(s.order, streamID)
+	}
+	// The message is copied as callers may reuse the buffer.
+	seq := ring.push(append([]byte(nil), message...))
+	return formatEventID(streamID, seq), nil
+}
+
+// ReplayEventsAfter implements EventStore.
+func (s *InMemoryEventStore) ReplayEventsAfter(
+	ctx context.Context,
+	lastEventID string,
+	send func(eventID string, message []byte) error,
+) (string, error) {
+	streamID, seq, err := parseEventID(lastEventID)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	ring, ok := s.streams[streamID]
+	var events []storedEvent
+	if ok {
+		events, ok = ring.after(seq)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrEventNotFound, lastEventID)
+	}
+
+	for _, event := range events {
+		if err := send(formatEventID(streamID, event.seq), event.message); err != nil {
+			return "", err
+		}
+	}
+	return streamID, nil
+}
+
+// DeleteStream implements EventStore.
+func (s *InMemoryEventStore) DeleteStream(ctx context.Context, streamID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[streamID]; !ok {
+		return nil
+	}
+	delete(s.streams, streamID)
+	for i, id := range s.order {
+		if id == streamID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// formatEventID builds the ID of the seq-th event of a stream.
+func formatEventID(streamID string, seq uint64) string {
+	return streamID + "_" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID splits an event ID built by formatEventID.
+func parseEventID(eventID string) (string, uint64, error) {
+	i := strings.LastIndex(eventID, "_")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+	}
+	seq, err := strconv.ParseUint(eventID[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s", ErrEventNotFound, eventID)
+	}
+	return eventID[:i], seq, nil
+}
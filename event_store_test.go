@@ -0,0 +1,473 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+)
+
+// replayAll collects the events replayed after lastEventID.
+func replayAll(t *testing.T, store EventStore, lastEventID string) (string, []string, error) {
+	t.Helper()
+	var messages []string
+	streamID, err := store.ReplayEventsAfter(context.Background(), lastEventID, func(eventID string, message []byte) error {
+		messages = append(messages, string(message))
+		return nil
+	})
+	return streamID, messages, err
+}
+
+func TestInMemoryEventStore_Replay(t *testing.T) {
+	store := NewInMemoryEventStore()
+	ctx := context.Background()
+
+	first, err := store.StoreEvent(ctx, "s1", []byte("a"))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "s2", []byte("other"))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "s1", []byte("b"))
+	require.NoError(t, err)
+	last, err := store.StoreEvent(ctx, "s1", []byte("c"))
+	require.NoError(t, err)
+
+	streamID, messages, err := replayAll(t, store, first)
+	require.NoError(t, err)
+	assert.Equal(t, "s1", streamID)
+	assert.Equal(t, []string{"b", "c"}, messages)
+
+	_, messages, err = replayAll(t, store, last)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	_, _, err = replayAll(t, store, "unknown_1")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	_, _, err = replayAll(t, store, "garbage")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestInMemoryEventStore_Limits(t *testing.T) {
+	store := NewInMemoryEventStore(WithMaxEventsPerStream(2), WithMaxStreams(2))
+	ctx := context.Background()
+
+	var ids []string
+	for _, message := range []string{"a", "b", "c", "d"} {
+		id, err := store.StoreEvent(ctx, "s1", []byte(message))
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// Only the last two events are kept.
+	_, messages, err := replayAll(t, store, ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, messages)
+	_, _, err = replayAll(t, store, ids[0])
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	// Adding a third stream drops the oldest one.
+	_, err = store.StoreEvent(ctx, "s2", []byte("x"))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "s3", []byte("y"))
+	require.NoError(t, err)
+	_, _, err = replayAll(t, store, ids[2])
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestInMemoryEventStore_DeleteStream(t *testing.T) {
+	store := NewInMemoryEventStore(WithMaxStreams(2))
+	ctx := context.Background()
+
+	first, err := store.StoreEvent(ctx, "s1", []byte("a"))
+	require.NoError(t, err)
+	other, err := store.StoreEvent(ctx, "s2", []byte("b"))
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteStream(ctx, "s1"))
+	require.NoError(t, store.DeleteStream(ctx, "unknown"))
+	_, _, err = replayAll(t, store, first)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	// The deleted stream no longer counts towards the limit.
+	_, err = store.StoreEvent(ctx, "s3", []byte("c"))
+	require.NoError(t, err)
+	_, _, err = replayAll(t, store, other)
+	assert.NoError(t, err)
+}
+
+// sseTestEvent is an event read from an SSE response.
+type sseTestEvent struct {
+	id   string
+	data string
+}
+
+// readSSETestEvent reads the next event of an SSE response.
+func readSSETestEvent(t *testing.T, reader *bufio.Reader) sseTestEvent {
+	t.Helper()
+	var event sseTestEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" && event.data != "":
+			return event
+		case strings.HasPrefix(line, "id:"):
+			event.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			event.data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+// openTestGetSSE opens a GET SSE stream of the session.
+func openTestGetSSE(t *testing.T, url, sessionID, lastEventID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set(httputil.AcceptHeader, httputil.ContentTypeSSE)
+	req.Header.Set(httputil.SessionIDHeader, sessionID)
+	if lastEventID != "" {
+		req.Header.Set(httputil.LastEventIDHeader, lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp
+}
+
+// waitForGetSSE waits until the server has the session's GET SSE connection.
+func waitForGetSSE(t *testing.T, server *Server, sessionID string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		conn, ok := server.httpHandler.getSSEConnections[sessionID]
+		return ok && conn.ctx.Err() == nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamResumption_GetStream(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	sessionID := client.GetSessionID()
+
+	resp := openTestGetSSE(t, httpServer.URL+"/mcp", sessionID, "")
+	waitForGetSSE(t, server, sessionID)
+	require.NoError(t, server.SendNotification(sessionID, "test/event", map[string]interface{}{"n": 1}))
+	first := readSSETestEvent(t, bufio.NewReader(resp.Body))
+	assert.Contains(t, first.data, `"n":1`)
+	resp.Body.Close()
+
+	// Messages sent while the client is disconnected are kept for the resumed stream.
+	require.Eventually(t, func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		return server.httpHandler.getSSEConnections[sessionID].ctx.Err() != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, server.SendNotification(sessionID, "test/event", map[string]interface{}{"n": 2}))
+
+	resp = openTestGetSSE(t, httpServer.URL+"/mcp", sessionID, first.id)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	second := readSSETestEvent(t, reader)
+	assert.Contains(t, second.data, `"n":2`)
+
+	// The resumed stream goes on receiving new messages.
+	waitForGetSSE(t, server, sessionID)
+	require.NoError(t, server.SendNotification(sessionID, "test/event", map[string]interface{}{"n": 3}))
+	third := readSSETestEvent(t, reader)
+	assert.Contains(t, third.data, `"n":3`)
+}
+
+func TestStreamResumption_OtherSessionRejected(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	var sessionIDs []string
+	for i := 0; i < 2; i++ {
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+			WithClientGetSSEEnabled(false))
+		require.NoError(t, err)
+		defer client.Close()
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+		sessionIDs = append(sessionIDs, client.GetSessionID())
+	}
+
+	resp := openTestGetSSE(t, httpServer.URL+"/mcp", sessionIDs[0], "")
+	waitForGetSSE(t, server, sessionIDs[0])
+	require.NoError(t, server.SendNotification(sessionIDs[0], "test/event", map[string]interface{}{"n": 1}))
+	first := readSSETestEvent(t, bufio.NewReader(resp.Body))
+	require.NoError(t, server.SendNotification(sessionIDs[0], "test/event", map[string]interface{}{"n": 2}))
+	resp.Body.Close()
+
+	// Another session cannot resume the stream, it gets a new one instead.
+	resp = openTestGetSSE(t, httpServer.URL+"/mcp", sessionIDs[1], first.id)
+	defer resp.Body.Close()
+	waitForGetSSE(t, server, sessionIDs[1])
+	require.NoError(t, server.SendNotification(sessionIDs[1], "test/event", map[string]interface{}{"n": 3}))
+	event := readSSETestEvent(t, bufio.NewReader(resp.Body))
+	assert.Contains(t, event.data, `"n":3`)
+}
+
+// droppingReqHandler sends requests with the default client and, when armed,
+// cuts the next SSE response after its first event as a dropped connection would.
+type droppingReqHandler struct {
+	method string
+	armed  atomic.Bool
+}
+
+func (h *droppingReqHandler) Handle(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil || req.Method != h.method ||
+		!strings.Contains(resp.Header.Get(httputil.ContentTypeHeader), httputil.ContentTypeSSE) ||
+		!h.armed.CompareAndSwap(true, false) {
+		return resp, err
+	}
+	resp.Body = &droppedBody{body: resp.Body, reader: bufio.NewReader(resp.Body)}
+	return resp, nil
+}
+
+// droppedBody yields the first event of an SSE body and then fails.
+type droppedBody struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	buf    bytes.Buffer
+	read   bool
+}
+
+func (b *droppedBody) Read(p []byte) (int, error) {
+	if !b.read {
+		b.read = true
+		for {
+			line, err := b.reader.ReadString('\n')
+			b.buf.WriteString(line)
+			if err != nil || strings.TrimSpace(line) == "" && strings.Contains(b.buf.String(), "data:") {
+				break
+			}
+		}
+		b.body.Close()
+	}
+	if b.buf.Len() > 0 {
+		return b.buf.Read(p)
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+func (b *droppedBody) Close() error {
+	return b.body.Close()
+}
+
+func TestStreamResumption_ClientResumesPostStream(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	server.RegisterTool(NewTool("slow-tool"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		logger := ClientLoggerFromContext(ctx)
+		if err := logger.Log(LoggingLevelInfo, "step 1"); err != nil {
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+		if err := logger.Log(LoggingLevelInfo, "step 2"); err != nil {
+			return nil, err
+		}
+		return NewTextResult("done"), nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	reqHandler := &droppingReqHandler{method: http.MethodPost}
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false), WithHTTPReqHandler(reqHandler))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var messages []interface{}
+	client.SetLogMessageHandler(func(params *LoggingMessageParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, params.Data)
+	})
+
+	reqHandler.armed.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &CallToolRequest{}
+	req.Params.Name = "slow-tool"
+	result, err := client.CallTool(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, reqHandler.armed.Load())
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []interface{}{"step 1", "step 2"}, messages)
+}
+
+func TestStreamResumption_ClientReconnectsGetStream(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	reqHandler := &droppingReqHandler{method: http.MethodGet}
+	reqHandler.armed.Store(true)
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(true), WithHTTPReqHandler(reqHandler))
+	require.NoError(t, err)
+	defer client.Close()
+
+	var mu sync.Mutex
+	var received []float64
+	client.RegisterNotificationHandler("test/event", func(notification *JSONRPCNotification) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, notification.Params.AdditionalFields["n"].(float64))
+		return nil
+	})
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	sessionID := client.GetSessionID()
+
+	waitForGetSSE(t, server, sessionID)
+	for n := 1; n <= 3; n++ {
+		require.NoError(t, server.SendNotification(sessionID, "test/event", map[string]interface{}{"n": n}))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 3*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []float64{1, 2, 3}, received)
+}
+
+// postTestToolCall sends a tools/call request of the session accepting an SSE
+// response, resuming after lastEventID if set.
+func postTestToolCall(t *testing.T, url, sessionID, name, lastEventID string) *http.Response {
+	t.Helper()
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + name + `"}}`
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(httputil.ContentTypeHeader, httputil.ContentTypeJSON)
+	req.Header.Set(httputil.AcceptHeader, httputil.ContentTypeJSON+", "+httputil.ContentTypeSSE)
+	req.Header.Set(httputil.SessionIDHeader, sessionID)
+	if lastEventID != "" {
+		req.Header.Set(httputil.LastEventIDHeader, lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp
+}
+
+// storedTestStreams returns the number of streams of the server in its event store.
+func storedTestStreams(server *Server) int {
+	server.httpHandler.eventStreamsLock.RLock()
+	defer server.httpHandler.eventStreamsLock.RUnlock()
+	return len(server.httpHandler.storedStreams)
+}
+
+func TestStreamResumption_PostWithLastEventID(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	server.RegisterTool(NewTool("slow-tool"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		calls.Add(1)
+		logger := ClientLoggerFromContext(ctx)
+		if err := logger.Log(LoggingLevelInfo, "step 1"); err != nil {
+			return nil, err
+		}
+		<-release
+		if err := logger.Log(LoggingLevelInfo, "step 2"); err != nil {
+			return nil, err
+		}
+		return NewTextResult("done"), nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	sessionID := client.GetSessionID()
+
+	resp := postTestToolCall(t, httpServer.URL+"/mcp", sessionID, "slow-tool", "")
+	first := readSSETestEvent(t, bufio.NewReader(resp.Body))
+	assert.Contains(t, first.data, "step 1")
+	resp.Body.Close()
+
+	// Sending the request again after the first event resumes its stream
+	// rather than calling the tool again.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	resp = postTestToolCall(t, httpServer.URL+"/mcp", sessionID, "slow-tool", first.id)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	assert.Contains(t, readSSETestEvent(t, reader).data, "step 2")
+	assert.Contains(t, readSSETestEvent(t, reader).data, "done")
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The stream is deleted once fully delivered.
+	require.Eventually(t, func() bool { return storedTestStreams(server) == 0 }, 2*time.Second, 10*time.Millisecond)
+	_, _, err = replayAll(t, server.httpHandler.eventStore, first.id)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestStreamResumption_StreamsDeletedWithSession(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithEventStore(NewInMemoryEventStore()))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	sessionID := client.GetSessionID()
+
+	resp := openTestGetSSE(t, httpServer.URL+"/mcp", sessionID, "")
+	waitForGetSSE(t, server, sessionID)
+	require.NoError(t, server.SendNotification(sessionID, "test/event", map[string]interface{}{"n": 1}))
+	first := readSSETestEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	assert.Equal(t, 1, storedTestStreams(server))
+
+	// Terminating the session deletes its streams from the event store.
+	require.NoError(t, client.TerminateSession(context.Background()))
+	require.Eventually(t, func() bool { return storedTestStreams(server) == 0 }, 2*time.Second, 10*time.Millisecond)
+	_, _, err = replayAll(t, server.httpHandler.eventStore, first.id)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
@@ -113,11 +113,6 @@ func handleNotification(notification *mcp.JSONRPCNotification) error {
 			if message, exists := dataMap["message"].(string); exists {
 				log.Printf("  Chat system message [%s]: %s", dataMap["timestamp"], message)
 			}
-		case "log_message":
-			// Handle log message
-			if message, exists := dataMap["message"].(string); exists {
-				log.Printf("  Log message: %s", message)
-			}
 		default:
 			log.Printf("  Unknown notification data type: %s", notificationType)
 		}
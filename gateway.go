@@ -0,0 +1,536 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// GatewayUpstream is an upstream MCP server aggregated by a gateway.
+type GatewayUpstream struct {
+	// Name identifies the upstream in errors, and names the logger of the log
+	// messages it sends that have none. It must be unique within a gateway.
+	Name string
+	// Client connects to the upstream server. It is a *StdioClient, or a
+	// *Client created by NewClient or NewSSEClient, that is not initialized
+	// yet. The gateway initializes it, and closes it when it is closed.
+	Client Connector
+	// InitializeRequest optionally overrides the initialize params sent to the
+	// upstream server.
+	InitializeRequest *InitializeRequest
+	// ToolPrefix is prepended to the names of the upstream's tools.
+	ToolPrefix string
+	// PromptPrefix is prepended to the names of the upstream's prompts.
+	PromptPrefix string
+	// ResourcePrefix is prepended to the URIs of the upstream's resources, and
+	// to the names and URI templates of its resource templates. For example
+	// "github+" exposes file:///README.md as github+file:///README.md.
+	ResourcePrefix string
+}
+
+// GatewayConfig configures a Streamable HTTP server that aggregates several
+// upstream MCP servers.
+type GatewayConfig struct {
+	// ServerName is the public Streamable HTTP MCP server name.
+	ServerName string
+	// ServerVersion is the public Streamable HTTP MCP server version.
+	ServerVersion string
+	// Upstreams are the aggregated MCP servers.
+	Upstreams []GatewayUpstream
+	// DiscoveryTimeout bounds the initialization and the capability discovery
+	// of each upstream. Defaults to 30 seconds when unset.
+	DiscoveryTimeout time.Duration
+	// ServerOptions are passed to NewServer for the public Streamable HTTP server.
+	ServerOptions []ServerOption
+}
+
+// Gateway routes the requests of a Streamable HTTP server to the upstream MCP
+// servers it aggregates. The tools, prompts, resources and resource templates
+// of the upstreams are registered on the server under their prefixes, and
+// re-synced when an upstream sends a list_changed notification. Progress and
+// cancellations of the forwarded requests are relayed between the client and
+// the upstream, and so are the log messages an upstream sends while handling
+// a request, when its transport tells which request they relate to, as
+// Streamable HTTP does. Other log messages of the upstreams are dropped.
+// Shutting down the server closes the gateway.
+type Gateway struct {
+	server    *Server
+	logger    Logger
+	timeout   time.Duration
+	upstreams []*gatewayUpstream
+
+	// Upstreams owning the registered tools, prompts and resources, by
+	// gatewayItemKey, so that upstreams cannot overwrite each other's items.
+	mu     sync.Mutex
+	owners map[string]*gatewayUpstream
+}
+
+// gatewayUpstream is an upstream of a gateway.
+type gatewayUpstream struct {
+	GatewayUpstream
+	gateway      *Gateway
+	capabilities ServerCapabilities
+
+	// Exposed names and URIs of the items registered for the upstream,
+	// replaced by refreshes, which refreshMu serializes.
+	refreshMu         sync.Mutex
+	tools             []string
+	prompts           []string
+	resources         []string
+	resourceTemplates []string
+}
+
+// Kinds of items registered by a gateway.
+const (
+	gatewayItemTool     = "tool"
+	gatewayItemPrompt   = "prompt"
+	gatewayItemResource = "resource"
+	gatewayItemTemplate = "resource template"
+)
+
+// gatewayItemKey is the key of an item in Gateway.owners.
+func gatewayItemKey(kind, name string) string {
+	return kind + ":" + name
+}
+
+// NewGateway creates a Streamable HTTP MCP server that aggregates the
+// upstream MCP servers of the config. Each upstream is initialized, and its
+// tools, prompts and resources are registered on the server under their
+// prefixes. If an upstream cannot be initialized, all upstream clients are
+// closed and an error is returned.
+//
+// Example:
+//
+//	server, gateway, err := mcp.NewGateway(ctx, mcp.GatewayConfig{
+//	    ServerName:    "gateway",
+//	    ServerVersion: "1.0.0",
+//	    Upstreams: []mcp.GatewayUpstream{
+//	        {Name: "files", Client: filesClient, ToolPrefix: "files_", ResourcePrefix: "files+"},
+//	        {Name: "github", Client: githubClient, ToolPrefix: "github_", PromptPrefix: "github_"},
+//	    },
+//	})
+func NewGateway(ctx context.Context, config GatewayConfig) (*Server, *Gateway, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config.ServerName == "" {
+		return nil, nil, fmt.Errorf("server name cannot be empty")
+	}
+	if config.ServerVersion == "" {
+		return nil, nil, fmt.Errorf("server version cannot be empty")
+	}
+	names := make(map[string]bool, len(config.Upstreams))
+	for _, upstream := range config.Upstreams {
+		if upstream.Name == "" {
+			return nil, nil, fmt.Errorf("upstream name cannot be empty")
+		}
+		if names[upstream.Name] {
+			return nil, nil, fmt.Errorf("duplicate upstream name %q", upstream.Name)
+		}
+		if upstream.Client == nil {
+			return nil, nil, fmt.Errorf("upstream %q has no client", upstream.Name)
+		}
+		names[upstream.Name] = true
+	}
+
+	timeout := config.DiscoveryTimeout
+	if timeout <= 0 {
+		timeout = defaultStdioProxyDiscoveryTimeout
+	}
+	server := NewServer(config.ServerName, config.ServerVersion, config.ServerOptions...)
+	gateway := &Gateway{
+		server:  server,
+		logger:  server.logger,
+		timeout: timeout,
+		owners:  make(map[string]*gatewayUpstream),
+	}
+	if gateway.logger == nil {
+		gateway.logger = GetDefaultLogger()
+	}
+	for _, config := range config.Upstreams {
+		gateway.upstreams = append(gateway.upstreams, &gatewayUpstream{
+			GatewayUpstream: config,
+			gateway:         gateway,
+		})
+	}
+
+	for _, upstream := range gateway.upstreams {
+		if err := upstream.start(ctx); err != nil {
+			_ = gateway.Close()
+			return nil, nil, fmt.Errorf("upstream %q: %w", upstream.Name, err)
+		}
+	}
+	server.onShutdown(gateway.Close)
+
+	return server, gateway, nil
+}
+
+// Refresh re-syncs the server with the tools, prompts and resources of all
+// upstreams, for upstreams that cannot send list_changed notifications.
+func (g *Gateway) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, upstream := range g.upstreams {
+		if err := upstream.refresh(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %q: %w", upstream.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the clients of all upstreams.
+func (g *Gateway) Close() error {
+	if g == nil {
+		return nil
+	}
+	var errs []error
+	for _, upstream := range g.upstreams {
+		if err := upstream.Client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %q: %w", upstream.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// start initializes the upstream and registers its items.
+func (u *gatewayUpstream) start(ctx context.Context) error {
+	u.Client.RegisterNotificationHandler(NotificationMethodToolsListChanged, u.handleListChanged(u.refreshTools))
+	u.Client.RegisterNotificationHandler(NotificationMethodPromptsListChanged, u.handleListChanged(u.refreshPrompts))
+	u.Client.RegisterNotificationHandler(NotificationMethodResourcesListChanged,
+		u.handleListChanged(u.refreshResources))
+
+	ctx, cancel := context.WithTimeout(ctx, u.gateway.timeout)
+	defer cancel()
+
+	initReq := u.InitializeRequest
+	if initReq == nil {
+		initReq = &InitializeRequest{}
+	}
+	result, err := u.Client.Initialize(ctx, initReq)
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	u.capabilities = result.Capabilities
+	return u.refresh(ctx)
+}
+
+// refresh re-syncs all kinds of items the upstream supports.
+func (u *gatewayUpstream) refresh(ctx context.Context) error {
+	if u.capabilities.Tools != nil {
+		if err := u.refreshTools(ctx); err != nil {
+			return fmt.Errorf("refresh tools: %w", err)
+		}
+	}
+	if u.capabilities.Prompts != nil {
+		if err := u.refreshPrompts(ctx); err != nil {
+			return fmt.Errorf("refresh prompts: %w", err)
+		}
+	}
+	if u.capabilities.Resources != nil {
+		if err := u.refreshResources(ctx); err != nil {
+			return fmt.Errorf("refresh resources: %w", err)
+		}
+	}
+	return nil
+}
+
+// handleListChanged returns a notification handler running refresh in the
+// background, as notification handlers must not block the client.
+func (u *gatewayUpstream) handleListChanged(refresh func(ctx context.Context) error) NotificationHandler {
+	return func(notification *JSONRPCNotification) error {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), u.gateway.timeout)
+			defer cancel()
+			if err := refresh(ctx); err != nil {
+				u.gateway.logger.Errorf("Gateway upstream %s: failed to handle %s: %v",
+					u.Name, notification.Method, err)
+			}
+		}()
+		return nil
+	}
+}
+
+// refreshTools registers the upstream's tools and unregisters the ones it no
+// longer has.
+func (u *gatewayUpstream) refreshTools(ctx context.Context) error {
+	result, err := listAllUpstreamTools(ctx, u.Client)
+	if err != nil {
+		return err
+	}
+
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+	var names []string
+	for _, tool := range result.Tools {
+		exposed := u.ToolPrefix + tool.Name
+		if !u.gateway.claim(gatewayItemTool, exposed, u) {
+			continue
+		}
+		toolCopy := tool
+		toolCopy.Name = exposed
+		u.gateway.server.RegisterTool(&toolCopy, u.callTool(tool.Name))
+		names = append(names, exposed)
+	}
+	u.tools = u.gateway.release(gatewayItemTool, u.tools, names, func(names ...string) {
+		_ = u.gateway.server.UnregisterTools(names...)
+	})
+	return nil
+}
+
+// refreshPrompts registers the upstream's prompts and unregisters the ones it
+// no longer has.
+func (u *gatewayUpstream) refreshPrompts(ctx context.Context) error {
+	result, err := listAllUpstreamPrompts(ctx, u.Client)
+	if err != nil {
+		return err
+	}
+
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+	var names []string
+	for _, prompt := range result.Prompts {
+		exposed := u.PromptPrefix + prompt.Name
+		if !u.gateway.claim(gatewayItemPrompt, exposed, u) {
+			continue
+		}
+		promptCopy := prompt
+		promptCopy.Name = exposed
+		u.gateway.server.RegisterPrompt(&promptCopy, u.getPrompt(prompt.Name))
+		names = append(names, exposed)
+	}
+	u.prompts = u.gateway.release(gatewayItemPrompt, u.prompts, names, func(names ...string) {
+		_ = u.gateway.server.UnregisterPrompts(names...)
+	})
+	return nil
+}
+
+// refreshResources registers the upstream's resources and resource templates,
+// and unregisters the ones it no longer has.
+func (u *gatewayUpstream) refreshResources(ctx context.Context) error {
+	result, err := listAllUpstreamResources(ctx, u.Client)
+	if err != nil {
+		return err
+	}
+	u.registerResources(result.Resources)
+	u.refreshResourceTemplates(ctx)
+	return nil
+}
+
+// registerResources registers the upstream's resources and unregisters the
+// ones it no longer has.
+func (u *gatewayUpstream) registerResources(resources []Resource) {
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+	var uris []string
+	for _, resource := range resources {
+		exposed := u.ResourcePrefix + resource.URI
+		if !u.gateway.claim(gatewayItemResource, exposed, u) {
+			continue
+		}
+		resourceCopy := resource
+		resourceCopy.URI = exposed
+		u.gateway.server.RegisterResources(&resourceCopy, u.readResource(resource.URI))
+		uris = append(uris, exposed)
+	}
+	u.resources = u.gateway.release(gatewayItemResource, u.resources, uris, func(uris ...string) {
+		_ = u.gateway.server.UnregisterResources(uris...)
+	})
+}
+
+// resourceTemplateLister is implemented by the clients listing resource
+// templates, such as *Client and *StdioClient.
+type resourceTemplateLister interface {
+	ListResourceTemplates(ctx context.Context, req *ListResourceTemplatesRequest) (*ListResourceTemplatesResult, error)
+}
+
+// refreshResourceTemplates registers the upstream's resource templates and
+// unregisters the ones it no longer has. An upstream failing to list them
+// keeps the ones registered before, as servers exposing resources need not
+// have templates.
+func (u *gatewayUpstream) refreshResourceTemplates(ctx context.Context) {
+	lister, ok := u.Client.(resourceTemplateLister)
+	if !ok {
+		return
+	}
+	var templates []ResourceTemplate
+	req := &ListResourceTemplatesRequest{}
+	for {
+		page, err := lister.ListResourceTemplates(ctx, req)
+		if err != nil {
+			u.gateway.logger.Warnf("Gateway upstream %s: failed to list resource templates: %v", u.Name, err)
+			return
+		}
+		templates = append(templates, page.ResourceTemplates...)
+		if page.NextCursor == "" {
+			break
+		}
+		req.Params.Cursor = page.NextCursor
+	}
+
+	u.refreshMu.Lock()
+	defer u.refreshMu.Unlock()
+	var names []string
+	for _, template := range templates {
+		if template.URITemplate == nil {
+			continue
+		}
+		exposed := u.ResourcePrefix + template.Name
+		uriTemplate, err := uritemplate.New(u.ResourcePrefix + template.URITemplate.Raw())
+		if err != nil {
+			u.gateway.logger.Errorf("Gateway upstream %s: invalid prefixed URI template of %s: %v",
+				u.Name, template.Name, err)
+			continue
+		}
+		if !u.gateway.claim(gatewayItemTemplate, exposed, u) {
+			continue
+		}
+		templateCopy := template
+		templateCopy.Name = exposed
+		templateCopy.URITemplate = &URITemplate{Template: uriTemplate}
+		u.gateway.server.RegisterResourceTemplate(&templateCopy, u.readResourceTemplate())
+		names = append(names, exposed)
+	}
+	u.resourceTemplates = u.gateway.release(gatewayItemTemplate, u.resourceTemplates, names,
+		func(names ...string) {
+			_ = u.gateway.server.UnregisterResourceTemplates(names...)
+		})
+}
+
+// claim makes upstream the owner of an item. It reports false, and logs an
+// error, if another upstream already owns the item.
+func (g *Gateway) claim(kind, name string, upstream *gatewayUpstream) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := gatewayItemKey(kind, name)
+	if owner, ok := g.owners[key]; ok && owner != upstream {
+		g.logger.Errorf("Gateway upstream %s: %s %s is already exposed by upstream %s",
+			upstream.Name, kind, name, owner.Name)
+		return false
+	}
+	g.owners[key] = upstream
+	return true
+}
+
+// release unregisters the items of previous missing from current, and returns current.
+func (g *Gateway) release(kind string, previous, current []string, unregister func(names ...string)) []string {
+	kept := make(map[string]bool, len(current))
+	for _, name := range current {
+		kept[name] = true
+	}
+	var removed []string
+	g.mu.Lock()
+	for _, name := range previous {
+		if !kept[name] {
+			delete(g.owners, gatewayItemKey(kind, name))
+			removed = append(removed, name)
+		}
+	}
+	g.mu.Unlock()
+	if len(removed) > 0 {
+		unregister(removed...)
+	}
+	return current
+}
+
+// callTool returns the handler forwarding calls to the upstream tool name.
+func (u *gatewayUpstream) callTool(name string) toolHandler {
+	return func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		ctx = u.forward(ctx)
+		upstreamReq := &CallToolRequest{}
+		upstreamReq.Params.Name = name
+		upstreamReq.Params.Arguments = req.Params.Arguments
+		return u.Client.CallTool(ctx, upstreamReq)
+	}
+}
+
+// getPrompt returns the handler forwarding requests to the upstream prompt name.
+func (u *gatewayUpstream) getPrompt(name string) promptHandler {
+	return func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+		ctx = u.forward(ctx)
+		upstreamReq := &GetPromptRequest{}
+		upstreamReq.Params.Name = name
+		upstreamReq.Params.Arguments = req.Params.Arguments
+		return u.Client.GetPrompt(ctx, upstreamReq)
+	}
+}
+
+// readResource returns the handler forwarding reads to the upstream resource
+// uri.
+func (u *gatewayUpstream) readResource(uri string) resourcesHandler {
+	return func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+		return u.read(ctx, uri)
+	}
+}
+
+// readResourceTemplate returns the handler forwarding reads of the URIs
+// matching a resource template of the upstream, without their prefix.
+func (u *gatewayUpstream) readResourceTemplate() resourceTemplateHandler {
+	return func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+		return u.read(ctx, strings.TrimPrefix(req.Params.URI, u.ResourcePrefix))
+	}
+}
+
+// read reads the upstream resource uri. The URIs of the contents are prefixed
+// like the resource's.
+func (u *gatewayUpstream) read(ctx context.Context, uri string) ([]ResourceContents, error) {
+	ctx = u.forward(ctx)
+	upstreamReq := &ReadResourceRequest{}
+	upstreamReq.Params.URI = uri
+	result, err := u.Client.ReadResource(ctx, upstreamReq)
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]ResourceContents, 0, len(result.Contents))
+	for _, content := range result.Contents {
+		switch c := content.(type) {
+		case TextResourceContents:
+			c.URI = u.ResourcePrefix + c.URI
+			content = c
+		case BlobResourceContents:
+			c.URI = u.ResourcePrefix + c.URI
+			content = c
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// forward prepares the context of a request forwarded to the upstream: the
+// progress the upstream reports is relayed to the client, and so are the log
+// messages it sends while handling the request. Cancelling the request
+// cancels the upstream request, as the clients send notifications/cancelled
+// when the request context is done.
+func (u *gatewayUpstream) forward(ctx context.Context) context.Context {
+	client := ClientLoggerFromContext(ctx)
+	if reporter, ok := ProgressReporterFromContext(ctx); ok {
+		ctx = WithProgressHandler(ctx, func(params *ProgressNotificationParams) {
+			if err := reporter.Report(params.Progress, params.Total, params.Message); err != nil {
+				u.gateway.logger.Debugf("Gateway upstream %s: failed to relay progress: %v", u.Name, err)
+			}
+		})
+	}
+	return withRequestLogHandler(ctx, func(notification *JSONRPCNotification) error {
+		params, err := parseLoggingMessageParams(notification)
+		if err != nil {
+			return err
+		}
+		name := params.Logger
+		if name == "" {
+			name = u.Name
+		}
+		if err := client.WithName(name).Log(params.Level, params.Data); err != nil {
+			u.gateway.logger.Debugf("Gateway upstream %s: failed to relay log message: %v", u.Name, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,287 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGatewayUpstream starts an upstream server with an echo tool, a log tool,
+// a greeting prompt, a README resource and a docs resource template, and
+// returns it with an uninitialized client.
+func newGatewayUpstream(t *testing.T, name string) (*Server, *Client) {
+	t.Helper()
+	server := NewServer(name, "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("echo", WithString("text")),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			text, _ := req.Params.Arguments["text"].(string)
+			return NewTextResult(name + ":" + text), nil
+		})
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+	server.RegisterTool(NewTool("log"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		if err := ClientLoggerFromContext(ctx).Log(LoggingLevelInfo, name+" log"); err != nil {
+			return nil, err
+		}
+		delay, _ := req.Params.Arguments["delay"].(float64)
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+		return NewTextResult("logged"), nil
+	})
+	server.RegisterPrompt(&Prompt{Name: "greeting"},
+		func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+			return &GetPromptResult{
+				Messages: []PromptMessage{{
+					Role:    RoleUser,
+					Content: NewTextContent("hello from " + name + " to " + req.Params.Arguments["who"]),
+				}},
+			}, nil
+		})
+	server.RegisterResource(&Resource{URI: "file:///README.md", Name: "README"},
+		func(ctx context.Context, req *ReadResourceRequest) (ResourceContents, error) {
+			return TextResourceContents{URI: req.Params.URI, Text: name + " readme"}, nil
+		})
+	server.RegisterResourceTemplate(NewResourceTemplate("file:///docs/{name}", "docs"),
+		func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+			text := name + " doc " + req.Params.Arguments["name"].(string)
+			return []ResourceContents{TextResourceContents{URI: req.Params.URI, Text: text}}, nil
+		})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Gateway", Version: "1.0.0"})
+	require.NoError(t, err)
+	return server, client
+}
+
+// newTestGateway aggregates two upstreams, a and b, behind a gateway and
+// returns the upstream servers and the URL of the gateway.
+func newTestGateway(t *testing.T) (*Server, *Server, string) {
+	t.Helper()
+	upstreamA, clientA := newGatewayUpstream(t, "a")
+	upstreamB, clientB := newGatewayUpstream(t, "b")
+	server, gateway, err := NewGateway(context.Background(), GatewayConfig{
+		ServerName:    "Gateway",
+		ServerVersion: "1.0.0",
+		Upstreams: []GatewayUpstream{
+			{Name: "a", Client: clientA, ToolPrefix: "a_", PromptPrefix: "a_", ResourcePrefix: "a+"},
+			{Name: "b", Client: clientB, ToolPrefix: "b_", PromptPrefix: "b_", ResourcePrefix: "b+"},
+		},
+		ServerOptions: []ServerOption{WithServerPath("/mcp")},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = gateway.Close() })
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+	return upstreamA, upstreamB, httpServer.URL
+}
+
+// newTestGatewayClient returns an initialized client of the gateway served at
+// serverURL.
+func newTestGatewayClient(t *testing.T, serverURL string) *Client {
+	t.Helper()
+	client, err := NewClient(serverURL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	return client
+}
+
+func gatewayToolNames(t *testing.T, client *Client) []string {
+	t.Helper()
+	result, err := client.ListTools(context.Background(), &ListToolsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestNewGateway_InvalidConfig(t *testing.T) {
+	_, client := newGatewayUpstream(t, "a")
+	tests := []struct {
+		name   string
+		config GatewayConfig
+	}{
+		{"missing server name", GatewayConfig{ServerVersion: "1.0.0"}},
+		{"missing upstream name", GatewayConfig{ServerName: "Gateway", ServerVersion: "1.0.0",
+			Upstreams: []GatewayUpstream{{Client: client}}}},
+		{"missing upstream client", GatewayConfig{ServerName: "Gateway", ServerVersion: "1.0.0",
+			Upstreams: []GatewayUpstream{{Name: "a"}}}},
+		{"duplicate upstream", GatewayConfig{ServerName: "Gateway", ServerVersion: "1.0.0",
+			Upstreams: []GatewayUpstream{{Name: "a", Client: client}, {Name: "a", Client: client}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewGateway(context.Background(), tt.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGateway_RoutesRequests(t *testing.T) {
+	_, _, serverURL := newTestGateway(t)
+	client := newTestGatewayClient(t, serverURL)
+	ctx := context.Background()
+
+	assert.ElementsMatch(t, []string{"a_echo", "a_log", "a_progress-tool", "b_echo", "b_log", "b_progress-tool"},
+		gatewayToolNames(t, client))
+
+	req := &CallToolRequest{}
+	req.Params.Name = "b_echo"
+	req.Params.Arguments = map[string]interface{}{"text": "hi"}
+	result, err := client.CallTool(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "b:hi", result.Content[0].(TextContent).Text)
+
+	prompts, err := client.ListPrompts(ctx, &ListPromptsRequest{})
+	require.NoError(t, err)
+	require.Len(t, prompts.Prompts, 2)
+	promptReq := &GetPromptRequest{}
+	promptReq.Params.Name = "a_greeting"
+	promptReq.Params.Arguments = map[string]string{"who": "you"}
+	prompt, err := client.GetPrompt(ctx, promptReq)
+	require.NoError(t, err)
+	assert.Equal(t, "hello from a to you", prompt.Messages[0].Content.(TextContent).Text)
+
+	resources, err := client.ListResources(ctx, &ListResourcesRequest{})
+	require.NoError(t, err)
+	var uris []string
+	for _, resource := range resources.Resources {
+		uris = append(uris, resource.URI)
+	}
+	assert.ElementsMatch(t, []string{"a+file:///README.md", "b+file:///README.md"}, uris)
+	readReq := &ReadResourceRequest{}
+	readReq.Params.URI = "b+file:///README.md"
+	read, err := client.ReadResource(ctx, readReq)
+	require.NoError(t, err)
+	require.Len(t, read.Contents, 1)
+	content := read.Contents[0].(TextResourceContents)
+	assert.Equal(t, "b+file:///README.md", content.URI)
+	assert.Equal(t, "b readme", content.Text)
+
+	templates, err := client.ListResourceTemplates(ctx, &ListResourceTemplatesRequest{})
+	require.NoError(t, err)
+	var uriTemplates []string
+	for _, template := range templates.ResourceTemplates {
+		uriTemplates = append(uriTemplates, template.URITemplate.Raw())
+	}
+	assert.ElementsMatch(t, []string{"a+file:///docs/{name}", "b+file:///docs/{name}"}, uriTemplates)
+	readReq.Params.URI = "a+file:///docs/intro"
+	read, err = client.ReadResource(ctx, readReq)
+	require.NoError(t, err)
+	require.Len(t, read.Contents, 1)
+	content = read.Contents[0].(TextResourceContents)
+	assert.Equal(t, "a+file:///docs/intro", content.URI)
+	assert.Equal(t, "a doc intro", content.Text)
+}
+
+// logCollector collects the log messages delivered to a client.
+type logCollector struct {
+	mu       sync.Mutex
+	messages []*LoggingMessageParams
+}
+
+func (c *logCollector) handle(params *LoggingMessageParams) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, params)
+}
+
+func (c *logCollector) get() []*LoggingMessageParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*LoggingMessageParams(nil), c.messages...)
+}
+
+func TestGateway_ForwardsLogMessages(t *testing.T) {
+	_, _, serverURL := newTestGateway(t)
+	first, second := newTestGatewayClient(t, serverURL), newTestGatewayClient(t, serverURL)
+	firstLogs, secondLogs := &logCollector{}, &logCollector{}
+	first.SetLogMessageHandler(firstLogs.handle)
+	second.SetLogMessageHandler(secondLogs.handle)
+
+	// The log message of a request only reaches its client, even while a
+	// request of another client is forwarded to the same upstream.
+	done := make(chan error, 1)
+	go func() {
+		_, err := callToolWithArguments(second, "a_log", map[string]interface{}{"delay": 300})
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_, err := callTool(first, "a_log")
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	for _, logs := range []*logCollector{firstLogs, secondLogs} {
+		messages := logs.get()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "a", messages[0].Logger)
+		assert.Equal(t, "a log", messages[0].Data)
+	}
+}
+
+func TestGateway_ForwardsProgress(t *testing.T) {
+	_, _, serverURL := newTestGateway(t)
+	client := newTestGatewayClient(t, serverURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	collector := &progressCollector{}
+	req := &CallToolRequest{}
+	req.Params.Name = "a_progress-tool"
+	result, err := client.CallTool(WithProgressHandler(ctx, collector.handle), req)
+	require.NoError(t, err)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+}
+
+func TestGateway_RefreshesOnListChanged(t *testing.T) {
+	upstreamA, _, serverURL := newTestGateway(t)
+	client := newTestGatewayClient(t, serverURL)
+
+	upstreamA.RegisterTool(NewTool("added"),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			return NewTextResult("added"), nil
+		})
+	require.NoError(t, upstreamA.UnregisterTools("echo"))
+
+	// The GET SSE stream of the upstream client is opened in the background,
+	// so the notification is repeated until the gateway has seen it.
+	assert.Eventually(t, func() bool {
+		_, _ = upstreamA.BroadcastNotification(NotificationMethodToolsListChanged, nil)
+		names := gatewayToolNames(t, client)
+		return len(names) == 6 && containsAll(names, "a_added", "a_log", "a_progress-tool", "b_echo", "b_log",
+			"b_progress-tool")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	req := &CallToolRequest{}
+	req.Params.Name = "a_added"
+	result, err := client.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "added", result.Content[0].(TextContent).Text)
+}
+
+func containsAll(names []string, wanted ...string) bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	for _, name := range wanted {
+		if !set[name] {
+			return false
+		}
+	}
+	return true
+}
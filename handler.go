@@ -34,6 +34,15 @@ type handler interface {
 	handleNotification(ctx context.Context, notification *JSONRPCNotification, session Session) error
 }
 
+// toolScopeChecker is implemented by the handlers that can tell, before
+// handling a request, that it calls a tool the token of the request lacks the
+// scopes of, so that HTTP transports refuse it with 403 Forbidden.
+type toolScopeChecker interface {
+	// deniedToolScopes returns the scopes required by the tool a tools/call
+	// request calls if the token of ctx does not grant them all, nil otherwise.
+	deniedToolScopes(ctx context.Context, req *JSONRPCRequest, session Session) []string
+}
+
 // mcpHandler implements the default MCP protocol handler
 type mcpHandler struct {
 	// Tool manager
@@ -53,6 +62,9 @@ type mcpHandler struct {
 
 	// Middleware chain for request processing.
 	middlewares []Middleware
+
+	// In-flight requests that can be cancelled by the client.
+	inFlight inFlightRegistry
 }
 
 // serverNotificationDispatcher defines the interface for dispatching notifications to handlers.
@@ -152,12 +164,35 @@ func (h *mcpHandler) requestDispatchTable() map[string]requestHandlerFunc {
 		MethodPromptsList:            h.handlePromptsList,
 		MethodPromptsGet:             h.handlePromptsGet,
 		MethodCompletionComplete:     h.handleCompletionComplete,
+		MethodLoggingSetLevel:        h.handleLoggingSetLevel,
 	}
 }
 
 // handleRequest processes a JSON-RPC request with optional middleware support.
 // If middlewares are registered, it adapts the request to use the simplified HandlerFunc signature.
+// It returns errRequestCancelled if the client cancelled the request while it was
+// being handled, in which case no response must be sent.
 func (h *mcpHandler) handleRequest(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
+	// Track the request so that notifications/cancelled can abort it. The
+	// initialize request must not be cancelled, as required by the specification.
+	if session != nil && req.Method != MethodInitialize {
+		var done func() bool
+		ctx, done = h.inFlight.begin(ctx, session.GetID(), req.ID)
+		result, err := h.handleRequestWithMiddlewares(ctx, req, session)
+		if done() {
+			return nil, errRequestCancelled
+		}
+		return result, err
+	}
+	return h.handleRequestWithMiddlewares(ctx, req, session)
+}
+
+// handleRequestWithMiddlewares runs the middleware chain and dispatches the request.
+func (h *mcpHandler) handleRequestWithMiddlewares(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	session Session,
+) (JSONRPCMessage, error) {
 	// If middlewares are registered, use the middleware chain.
 	if len(h.middlewares) > 0 {
 		// Create core handler that adapts from HandlerFunc (2 params) to internal handler (3 params).
@@ -187,7 +222,12 @@ func (h *mcpHandler) handleRequest(ctx context.Context, req *JSONRPCRequest, ses
 func (h *mcpHandler) dispatchRequest(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
 	dispatchTable := h.requestDispatchTable()
 	if handler, ok := dispatchTable[req.Method]; ok {
-		return handler(ctx, req, session)
+		result, err := handler(ctx, req, session)
+		if err != nil || req.Method == MethodInitialize {
+			return result, err
+		}
+		// Shape the result for the protocol version negotiated with the client.
+		return adaptResultToProtocolVersion(result, protocolVersionFromSession(session)), nil
 	}
 	return newJSONRPCErrorResponse(req.ID, ErrCodeMethodNotFound, "method not found", nil), nil
 }
@@ -226,12 +266,20 @@ func (h *mcpHandler) handleToolsCall(ctx context.Context, req *JSONRPCRequest, s
 	return h.toolManager.handleCallTool(ctx, req, session)
 }
 
+// deniedToolScopes implements toolScopeChecker.
+func (h *mcpHandler) deniedToolScopes(ctx context.Context, req *JSONRPCRequest, session Session) []string {
+	if req.Method != MethodToolsCall {
+		return nil
+	}
+	return h.toolManager.deniedToolScopes(ctx, req, session)
+}
+
 func (h *mcpHandler) handleResourcesList(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.resourceManager.handleListResources(ctx, req)
+	return h.resourceManager.handleListResources(ctx, req, session)
 }
 
 func (h *mcpHandler) handleResourcesRead(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.resourceManager.handleReadResource(ctx, req)
+	return h.resourceManager.handleReadResource(ctx, req, session)
 }
 
 func (h *mcpHandler) handleResourcesTemplatesList(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
@@ -239,23 +287,27 @@ func (h *mcpHandler) handleResourcesTemplatesList(ctx context.Context, req *JSON
 }
 
 func (h *mcpHandler) handleResourcesSubscribe(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.resourceManager.handleSubscribe(ctx, req)
+	return h.resourceManager.handleSubscribe(ctx, req, session)
 }
 
 func (h *mcpHandler) handleResourcesUnsubscribe(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.resourceManager.handleUnsubscribe(ctx, req)
+	return h.resourceManager.handleUnsubscribe(ctx, req, session)
 }
 
 func (h *mcpHandler) handlePromptsList(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.promptManager.handleListPrompts(ctx, req)
+	return h.promptManager.handleListPrompts(ctx, req, session)
 }
 
 func (h *mcpHandler) handlePromptsGet(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.promptManager.handleGetPrompt(ctx, req)
+	return h.promptManager.handleGetPrompt(ctx, req, session)
 }
 
 func (h *mcpHandler) handleCompletionComplete(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
-	return h.promptManager.handleCompletionComplete(ctx, req)
+	return handleCompletionComplete(ctx, req, h.promptManager, h.resourceManager)
+}
+
+func (h *mcpHandler) handleLoggingSetLevel(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
+	return handleSetLevel(ctx, req, session)
 }
 
 // handleNotification implements the handler interface's handleNotification method
@@ -272,6 +324,14 @@ func (h *mcpHandler) handleNotification(ctx context.Context, notification *JSONR
 			return h.server.handleServerNotification(ctx, notification)
 		}
 		return nil
+	case MethodNotificationsCancelled:
+		if session != nil {
+			h.inFlight.handleCancelledNotification(session.GetID(), notification)
+		}
+		if h.server != nil {
+			return h.server.handleServerNotification(ctx, notification)
+		}
+		return nil
 	default:
 		// For other notifications, dispatch to server if available.
 		if h.server != nil {
@@ -283,6 +343,17 @@ func (h *mcpHandler) handleNotification(ctx context.Context, notification *JSONR
 
 // onSessionTerminated implements the sessionEventNotifier interface's OnSessionTerminated method
 func (h *mcpHandler) onSessionTerminated(sessionID string) {
+	// Abort requests still running for the session.
+	h.inFlight.cancelSession(sessionID)
+
+	// Drop the session's resource subscriptions.
+	h.resourceManager.unsubscribeSession(sessionID)
+
+	// Drop the tools, prompts and resources registered for the session.
+	h.toolManager.sessionTools.removeSession(sessionID)
+	h.promptManager.sessionPrompts.removeSession(sessionID)
+	h.resourceManager.sessionResources.removeSession(sessionID)
+
 	// Notify lifecycle manager that session has terminated
 	h.lifecycleManager.onSessionTerminated(sessionID)
 }
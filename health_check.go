@@ -0,0 +1,228 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	icontext "trpc.group/trpc-go/trpc-mcp-go/internal/context"
+)
+
+// StateChangeHandler is called when the state of a client changes, with the
+// previous and the new state.
+type StateChangeHandler func(from, to State)
+
+// stateTracker holds the state of a client and reports its changes.
+type stateTracker struct {
+	mu      sync.Mutex
+	state   State
+	handler StateChangeHandler
+}
+
+// get returns the current state.
+func (s *stateTracker) get() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// set changes the state, calling the handler if it differs from the current one.
+func (s *stateTracker) set(state State) {
+	s.mu.Lock()
+	from := s.state
+	s.state = state
+	handler := s.handler
+	s.mu.Unlock()
+	if handler != nil && from != state {
+		handler(from, state)
+	}
+}
+
+// setHandler sets the handler of the state changes.
+func (s *stateTracker) setHandler(handler StateChangeHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+// healthChecker runs a health check of a client periodically in the
+// background. A nil checker is disabled.
+type healthChecker struct {
+	interval time.Duration
+	check    func(ctx context.Context)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newHealthChecker creates a checker running check every interval, or nil if
+// interval is not positive.
+func newHealthChecker(interval time.Duration, check func(ctx context.Context)) *healthChecker {
+	if interval <= 0 {
+		return nil
+	}
+	return &healthChecker{interval: interval, check: check}
+}
+
+// start starts the checks unless they are running already. The checks run
+// with the values of ctx until stop is called.
+func (h *healthChecker) start(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return
+	}
+	ctx, h.cancel = context.WithCancel(icontext.WithoutCancel(ctx))
+	h.done = make(chan struct{})
+	go h.run(ctx, h.done)
+}
+
+// run runs the checks until ctx is cancelled.
+func (h *healthChecker) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+// stop stops the checks and waits for the running one to return.
+func (h *healthChecker) stop() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	cancel, done := h.cancel, h.done
+	h.cancel, h.done = nil, nil
+	h.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// isSessionLost reports whether a request failed because the server no longer
+// knows the session of the client, or the connection holding it is closed.
+func isSessionLost(err error) bool {
+	return errors.Is(err, ErrSessionNotFound) || errors.Is(err, errWebSocketClosed)
+}
+
+// Ping checks that the server is responsive. It fails with an error wrapping
+// ErrSessionNotFound when the server no longer knows the session.
+func (c *Client) Ping(ctx context.Context) error {
+	req := newJSONRPCRequest(c.requestID.Add(1), MethodPing, nil)
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("ping request failed: %w", err)
+	}
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return fmt.Errorf("ping error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+	}
+	return nil
+}
+
+// checkHealth pings the server, and initializes the client again if the
+// session was lost. A server that cannot be reached leaves the client
+// disconnected until a ping succeeds.
+func (c *Client) checkHealth(ctx context.Context) {
+	logger := c.transportConfig.logger
+	if c.initialized.Load() {
+		pingCtx, cancel := context.WithTimeout(ctx, c.healthCheck.interval)
+		err := c.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			c.setState(StateInitialized)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !isSessionLost(err) {
+			logger.Warnf("Health check failed: %v", err)
+			c.setState(StateDisconnected)
+			return
+		}
+		logger.Infof("Session %s lost (%v), initializing again", c.GetSessionID(), err)
+		c.resetSession()
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, c.healthCheck.interval)
+	defer cancel()
+	if _, err := c.Initialize(initCtx, c.initRequest.Load()); err != nil && ctx.Err() == nil {
+		logger.Warnf("Failed to initialize again: %v", err)
+	}
+}
+
+// Ping checks that the server is responsive.
+func (c *StdioClient) Ping(ctx context.Context) error {
+	req := newJSONRPCRequest(c.requestID.Add(1), MethodPing, nil)
+	rawResp, err := c.sendRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("ping request failed: %w", err)
+	}
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return fmt.Errorf("ping error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+	}
+	return nil
+}
+
+// checkHealth pings the server, and restarts the server process and
+// initializes the client again if the process exited.
+func (c *StdioClient) checkHealth(ctx context.Context) {
+	if c.initialized.Load() {
+		pingCtx, cancel := context.WithTimeout(ctx, c.healthCheck.interval)
+		err := c.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			c.setState(StateInitialized)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if c.IsProcessRunning() {
+			c.logger.Warnf("Health check failed: %v", err)
+			c.setState(StateDisconnected)
+			return
+		}
+	}
+
+	if !c.IsProcessRunning() {
+		c.logger.Infof("Server process is not running, restarting it")
+		if err := c.RestartProcess(ctx); err != nil {
+			c.logger.Warnf("Failed to restart the server process: %v", err)
+			return
+		}
+	}
+	initCtx, cancel := context.WithTimeout(ctx, c.healthCheck.interval)
+	defer cancel()
+	if _, err := c.Initialize(initCtx, c.initRequest.Load()); err != nil && ctx.Err() == nil {
+		c.logger.Warnf("Failed to initialize again: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const healthCheckInterval = 50 * time.Millisecond
+
+// stateRecorder records the state changes of a client.
+type stateRecorder struct {
+	mu      sync.Mutex
+	changes [][2]State
+}
+
+func (r *stateRecorder) record(from, to State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, [2]State{from, to})
+}
+
+func (r *stateRecorder) get() [][2]State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][2]State(nil), r.changes...)
+}
+
+// waitReinitialized waits until the client changes from StateDisconnected to
+// StateInitialized.
+func (r *stateRecorder) waitReinitialized(t *testing.T) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		disconnected := false
+		for _, change := range r.get() {
+			if change[1] == StateDisconnected {
+				disconnected = true
+			} else if disconnected && change[1] == StateInitialized {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestClient_Ping(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	client := newInMemoryClient(t, server)
+	var connector Connector = client
+	assert.NoError(t, connector.Ping(context.Background()))
+
+	// The server no longer knows an expired session.
+	require.True(t, server.httpHandler.sessionManager.terminateSession(client.GetSessionID()))
+	assert.ErrorIs(t, client.Ping(context.Background()), ErrSessionNotFound)
+}
+
+func TestClient_HealthCheckServerRestart(t *testing.T) {
+	newTestServer := func() *Server {
+		server := NewServer("Test-Server", "1.0.0")
+		server.RegisterTool(NewTool("echo"), textTool("echo"))
+		return server
+	}
+	var handler atomic.Value
+	handler.Store(newTestServer().HTTPHandler())
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Load().(http.Handler).ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	recorder := &stateRecorder{}
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithHealthCheck(healthCheckInterval), WithStateChangeHandler(recorder.record))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	sessionID := client.GetSessionID()
+	assert.Equal(t, [][2]State{
+		{StateDisconnected, StateConnected},
+		{StateConnected, StateInitialized},
+	}, recorder.get())
+
+	// The restarted server does not know the session, so the client
+	// initializes again.
+	handler.Store(newTestServer().HTTPHandler())
+	recorder.waitReinitialized(t)
+	assert.NotEqual(t, sessionID, client.GetSessionID())
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
+
+func TestClient_HealthCheckWebSocket(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	recorder := &stateRecorder{}
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server),
+		WithHealthCheck(healthCheckInterval), WithStateChangeHandler(recorder.record))
+	sessionID := client.GetSessionID()
+
+	// The server closes the connection of an expired session, the client
+	// connects again.
+	h := server.httpHandler
+	session, ok := h.sessionManager.getSession(sessionID)
+	require.True(t, ok)
+	require.True(t, h.sessionManager.terminateSession(sessionID))
+	h.closeSession(context.Background(), session, SessionCloseReasonExpired)
+
+	recorder.waitReinitialized(t)
+	assert.NotEqual(t, sessionID, client.GetSessionID())
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
+
+func TestClient_HealthCheckStoppedOnClose(t *testing.T) {
+	recorder := &stateRecorder{}
+	client := newInMemoryClient(t, NewServer("Test-Server", "1.0.0"),
+		WithHealthCheck(healthCheckInterval), WithStateChangeHandler(recorder.record))
+	time.Sleep(3 * healthCheckInterval)
+	require.NoError(t, client.Close())
+	changes := len(recorder.get())
+
+	time.Sleep(3 * healthCheckInterval)
+	assert.Len(t, recorder.get(), changes)
+	assert.Equal(t, StateDisconnected, client.GetState())
+}
+
+func TestStdioClient_HealthCheckProcessExit(t *testing.T) {
+	recorder := &stateRecorder{}
+	client, err := NewStdioClient(StdioTransportConfig{
+		ServerParams: StdioServerParameters{
+			Command: os.Args[0],
+			Args:    []string{"-test.run=^TestStdioHealthCheckServerHelper$"},
+			Env:     map[string]string{"TRPC_MCP_HEALTH_CHECK_SERVER": "1"},
+		},
+		Timeout: 10 * time.Second,
+	}, Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithStdioHealthCheck(healthCheckInterval), WithStdioStateChangeHandler(recorder.record))
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+	_, err = client.Initialize(ctx, &InitializeRequest{})
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx))
+	pid := client.GetProcessID()
+
+	// The process exits, the client restarts it and initializes again.
+	_, _ = client.CallTool(ctx, &CallToolRequest{Params: CallToolParams{Name: "exit"}})
+	recorder.waitReinitialized(t)
+	assert.NotEqual(t, pid, client.GetProcessID())
+	assert.NoError(t, client.Ping(ctx))
+}
+
+func TestStdioHealthCheckServerHelper(t *testing.T) {
+	if os.Getenv("TRPC_MCP_HEALTH_CHECK_SERVER") != "1" {
+		return
+	}
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("exit"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		os.Exit(1)
+		return nil, nil
+	})
+	_ = server.Start()
+	os.Exit(0)
+}
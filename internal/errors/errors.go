@@ -38,7 +38,8 @@ var (
 	ErrToolExecutionFailed   = errors.New("tool execution failed")
 
 	// Resource manager errors
-	ErrEmptyResourceURI = errors.New("resource URI cannot be empty")
+	ErrEmptyResourceURI            = errors.New("resource URI cannot be empty")
+	ErrSubscriptionRequiresSession = errors.New("resource subscriptions require a session")
 
 	// Prompt manager errors
 	ErrEmptyPromptName = errors.New("prompt name cannot be empty")
@@ -50,9 +51,12 @@ var (
 	// Parameter errors
 	ErrInvalidParams = errors.New("invalid parameters")
 	ErrMissingParams = errors.New("missing required parameters")
+	ErrInvalidCursor = errors.New("invalid cursor")
 
 	// Client errors
 	ErrAlreadyInitialized = errors.New("client already initialized")
 	ErrNotInitialized     = errors.New("client not initialized")
 	ErrInvalidServerURL   = errors.New("invalid server URL")
+
+	ErrUnsupportedProtocolVersion = errors.New("unsupported protocol version")
 )
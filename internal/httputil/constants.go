@@ -18,6 +18,10 @@ const (
 	// SessionIDHeader is the MCP session ID header
 	SessionIDHeader = "Mcp-Session-Id"
 
+	// ProtocolVersionHeader is the MCP protocol version header, sent on every
+	// request after initialization since protocol version 2025-06-18
+	ProtocolVersionHeader = "MCP-Protocol-Version"
+
 	// LastEventIDHeader is the SSE Last-Event-ID header
 	LastEventIDHeader = "Last-Event-ID"
 )
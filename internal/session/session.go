@@ -85,22 +85,60 @@ type SessionManager struct {
 	// Session mapping
 	sessions map[string]*Session
 
-	// Expiry time in seconds
-	expirySeconds int
+	// Time a session can be idle before it expires, zero or less for no limit
+	idleTimeout time.Duration
+
+	// Time a session can live regardless of activity, zero or less for no limit
+	maxLifetime time.Duration
+
+	// Function called with each expired session
+	onExpired func(*Session)
 
 	// Mutex for concurrent access
 	mu sync.RWMutex
 }
 
+// Option configures a SessionManager.
+type Option func(*SessionManager)
+
+// WithIdleTimeout sets the time a session can be idle before it expires,
+// overriding the expiry given to NewSessionManager. Zero or less disables it.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(m *SessionManager) {
+		m.idleTimeout = timeout
+	}
+}
+
+// WithMaxLifetime sets the time a session can live regardless of its activity.
+// Zero or less, the default, disables it.
+func WithMaxLifetime(lifetime time.Duration) Option {
+	return func(m *SessionManager) {
+		m.maxLifetime = lifetime
+	}
+}
+
+// WithExpiryHandler sets a function called with each session that expires,
+// after it has been removed.
+func WithExpiryHandler(handler func(*Session)) Option {
+	return func(m *SessionManager) {
+		m.onExpired = handler
+	}
+}
+
 // NewSessionManager creates a session manager
-func NewSessionManager(expirySeconds int) *SessionManager {
+func NewSessionManager(expirySeconds int, options ...Option) *SessionManager {
 	manager := &SessionManager{
-		sessions:      make(map[string]*Session),
-		expirySeconds: expirySeconds,
+		sessions:    make(map[string]*Session),
+		idleTimeout: time.Duration(expirySeconds) * time.Second,
+	}
+	for _, option := range options {
+		option(manager)
 	}
 
 	// Start goroutine to clean up expired sessions
-	go manager.cleanupExpiredSessions()
+	if manager.idleTimeout > 0 || manager.maxLifetime > 0 {
+		go manager.cleanupExpiredSessions()
+	}
 
 	return manager
 }
@@ -117,10 +155,18 @@ func (m *SessionManager) CreateSession() *Session {
 
 // GetSession retrieves a session
 func (m *SessionManager) GetSession(id string) (*Session, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
+	m.mu.Lock()
 	session, ok := m.sessions[id]
+	if ok && m.isExpired(session, time.Now()) {
+		// Expired sessions are removed when they are next used, without
+		// waiting for the periodic cleanup.
+		delete(m.sessions, id)
+		m.mu.Unlock()
+		m.expired(session)
+		return nil, false
+	}
+	m.mu.Unlock()
+
 	if ok {
 		session.UpdateActivity()
 	}
@@ -152,22 +198,58 @@ func (m *SessionManager) TerminateSession(id string) bool {
 	return false
 }
 
+// isExpired reports whether the session expired at the given time.
+func (m *SessionManager) isExpired(session *Session, now time.Time) bool {
+	if m.maxLifetime > 0 && now.Sub(session.CreatedAt) > m.maxLifetime {
+		return true
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return m.idleTimeout > 0 && now.Sub(session.LastActivity) > m.idleTimeout
+}
+
+// expired calls the expiry handler for a removed session.
+func (m *SessionManager) expired(session *Session) {
+	if m.onExpired != nil {
+		m.onExpired(session)
+	}
+}
+
+// cleanupInterval returns how often expired sessions are looked for: half the
+// shortest timeout, between 10 milliseconds and a minute.
+func (m *SessionManager) cleanupInterval() time.Duration {
+	interval := time.Minute
+	for _, timeout := range []time.Duration{m.idleTimeout, m.maxLifetime} {
+		if timeout > 0 && timeout/2 < interval {
+			interval = timeout / 2
+		}
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	return interval
+}
+
 // cleanupExpiredSessions cleans up expired sessions
 func (m *SessionManager) cleanupExpiredSessions() {
-	ticker := time.NewTicker(time.Minute)
+	ticker := time.NewTicker(m.cleanupInterval())
 	defer ticker.Stop()
 
 	for range ticker.C {
+		var expired []*Session
 		m.mu.Lock()
 		now := time.Now()
 		for id, session := range m.sessions {
-			session.mu.RLock()
-			if now.Sub(session.LastActivity) > time.Duration(m.expirySeconds)*time.Second {
+			if m.isExpired(session, now) {
 				delete(m.sessions, id)
+				expired = append(expired, session)
 			}
-			session.mu.RUnlock()
 		}
 		m.mu.Unlock()
+
+		for _, session := range expired {
+			m.expired(session)
+		}
 	}
 }
 
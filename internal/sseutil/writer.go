@@ -23,7 +23,9 @@ const (
 type Event struct {
 	ID   string
 	Data []byte // Pre-serialized data
-	// Event string // Optional: for named events, not used by MCP currently
+	// Event is the optional event name. Named events without an ID are not
+	// resumable, and carry no JSON-RPC message.
+	Event string
 }
 
 // Writer provides basic SSE writing capabilities.
@@ -49,7 +51,7 @@ func (sw *Writer) GenerateEventID() string {
 // It requires data to be pre-serialized.
 // The function will automatically flush the response if the http.ResponseWriter implements http.Flusher.
 func (sw *Writer) WriteEvent(w http.ResponseWriter, event Event) error {
-	if event.ID == "" {
+	if event.ID == "" && event.Event == "" {
 		// Consider returning a predefined error from this package if this becomes common
 		return fmt.Errorf("SSE event ID cannot be empty")
 	}
@@ -58,8 +60,17 @@ func (sw *Writer) WriteEvent(w http.ResponseWriter, event Event) error {
 	// For now, this writer expects data to be typically non-nil based on MCP usage.
 
 	// Write event ID
-	if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
-		return fmt.Errorf("failed to write SSE event ID: %w", err)
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return fmt.Errorf("failed to write SSE event ID: %w", err)
+		}
+	}
+
+	// Write event name
+	if event.Event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event.Event); err != nil {
+			return fmt.Errorf("failed to write SSE event name: %w", err)
+		}
 	}
 
 	// Write event data with proper SSE formatting
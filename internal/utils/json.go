@@ -66,6 +66,7 @@ func ParseResourceContent(contentMap map[string]interface{}) (string, string, st
 // parsedToolItem represents a parsed tool item with all its components
 type parsedToolItem struct {
 	Name            string
+	Title           string
 	Description     string
 	RawInputSchema  json.RawMessage
 	RawOutputSchema json.RawMessage
@@ -119,6 +120,7 @@ func ParseToolItem(toolMap map[string]interface{}) (*parsedToolItem, error) {
 
 	return &parsedToolItem{
 		Name:            name,
+		Title:           ExtractString(toolMap, "title"),
 		Description:     description,
 		RawInputSchema:  rawInputSchema,
 		RawOutputSchema: rawOutputSchema,
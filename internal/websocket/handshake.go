@@ -0,0 +1,253 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Handshake headers.
+const (
+	headerKey         = "Sec-WebSocket-Key"
+	headerVersion     = "Sec-WebSocket-Version"
+	headerAccept      = "Sec-WebSocket-Accept"
+	headerSubprotocol = "Sec-WebSocket-Protocol"
+	version           = "13"
+)
+
+// HandshakeError reports a failed opening handshake.
+type HandshakeError struct {
+	// StatusCode is the HTTP status of the response, for a client handshake.
+	StatusCode int
+	Reason     string
+}
+
+// Error implements the error interface.
+func (e *HandshakeError) Error() string {
+	return "websocket: handshake failed: " + e.Reason
+}
+
+// UpgradeOptions configures the server side of the opening handshake.
+type UpgradeOptions struct {
+	// Subprotocols supported by the server, in order of preference. If the
+	// client requests subprotocols, one of them must be supported. A client
+	// requesting none is accepted without a subprotocol.
+	Subprotocols []string
+
+	// CheckOrigin reports whether the Origin of a request is allowed. By
+	// default, requests with an Origin header are only allowed from the same
+	// host.
+	CheckOrigin func(r *http.Request) bool
+
+	// Header holds additional headers sent in the handshake response.
+	Header http.Header
+}
+
+// IsUpgradeRequest reports whether r asks to upgrade to WebSocket.
+func IsUpgradeRequest(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade performs the server side of the opening handshake and returns the
+// connection. On failure, an HTTP error has been sent to the client.
+func Upgrade(w http.ResponseWriter, r *http.Request, options UpgradeOptions) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, upgradeError(w, http.StatusMethodNotAllowed, "method is not GET")
+	}
+	if !IsUpgradeRequest(r) {
+		return nil, upgradeError(w, http.StatusBadRequest, "not a WebSocket upgrade request")
+	}
+	if r.Header.Get(headerVersion) != version {
+		w.Header().Set(headerVersion, version)
+		return nil, upgradeError(w, http.StatusUpgradeRequired, "unsupported WebSocket version")
+	}
+	key := r.Header.Get(headerKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, upgradeError(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := options.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, upgradeError(w, http.StatusForbidden, "origin not allowed")
+	}
+
+	requested := headerTokens(r.Header, headerSubprotocol)
+	subprotocol := selectSubprotocol(requested, options.Subprotocols)
+	if len(requested) > 0 && subprotocol == "" {
+		return nil, upgradeError(w, http.StatusBadRequest, "unsupported subprotocol")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, upgradeError(w, http.StatusInternalServerError, "connection cannot be hijacked")
+	}
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString(headerAccept + ": " + computeAccept(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString(headerSubprotocol + ": " + subprotocol + "\r\n")
+	}
+	for name, values := range options.Header {
+		for _, value := range values {
+			b.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: failed to write handshake response: %w", err)
+	}
+	return newConn(netConn, brw.Reader, false, subprotocol), nil
+}
+
+// NewClientRequest creates the HTTP request of the client side of the opening
+// handshake, for a ws, wss, http or https URL. The request is sent with an
+// http.Client, and its response passed to NewClientConn along with key.
+func NewClientRequest(ctx context.Context, rawURL string, subprotocols []string) (req *http.Request, key string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("websocket: invalid URL: %w", err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, "", fmt.Errorf("websocket: unsupported URL scheme %q", u.Scheme)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", fmt.Errorf("websocket: failed to generate key: %w", err)
+	}
+	key = base64.StdEncoding.EncodeToString(nonce)
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set(headerVersion, version)
+	req.Header.Set(headerKey, key)
+	if len(subprotocols) > 0 {
+		req.Header.Set(headerSubprotocol, strings.Join(subprotocols, ", "))
+	}
+	return req, key, nil
+}
+
+// NewClientConn validates the response to a handshake request created by
+// NewClientRequest, and returns the connection. The server must select one of
+// the requested subprotocols, if any. The body of the response is closed on
+// failure.
+func NewClientConn(resp *http.Response, key string, subprotocols []string) (*Conn, error) {
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, &HandshakeError{StatusCode: resp.StatusCode, Reason: "unexpected status " + resp.Status}
+	}
+	fail := func(reason string) (*Conn, error) {
+		resp.Body.Close()
+		return nil, &HandshakeError{StatusCode: resp.StatusCode, Reason: reason}
+	}
+	if !headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") {
+		return fail("missing upgrade headers")
+	}
+	if resp.Header.Get(headerAccept) != computeAccept(key) {
+		return fail("invalid Sec-WebSocket-Accept")
+	}
+	subprotocol := resp.Header.Get(headerSubprotocol)
+	if len(subprotocols) > 0 && !containsString(subprotocols, subprotocol) {
+		return fail(fmt.Sprintf("server selected subprotocol %q, requested %s",
+			subprotocol, strings.Join(subprotocols, ", ")))
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return fail("response body is not writable")
+	}
+	return newConn(rwc, nil, true, subprotocol), nil
+}
+
+// upgradeError sends an HTTP error for a failed upgrade and returns it.
+func upgradeError(w http.ResponseWriter, status int, reason string) error {
+	http.Error(w, http.StatusText(status)+": "+reason, status)
+	return &HandshakeError{StatusCode: status, Reason: reason}
+}
+
+// sameOrigin reports whether the request has no Origin header or one whose host
+// is the host of the request.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// selectSubprotocol returns the first supported subprotocol that was requested.
+func selectSubprotocol(requested, supported []string) string {
+	for _, protocol := range supported {
+		if containsString(requested, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// headerTokens returns the comma-separated tokens of a header.
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether a header contains a token, ignoring case.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
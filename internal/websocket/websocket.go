@@ -0,0 +1,421 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+// Package websocket implements the subset of the WebSocket protocol (RFC 6455)
+// used by the MCP WebSocket transport: the opening handshake with subprotocol
+// negotiation, text and binary messages, fragmentation, ping/pong keepalive,
+// message size limits and the closing handshake. Extensions are not supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types, the opcodes of their frames.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes defined by RFC 6455.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+// DefaultReadLimit is the default maximum size of a message read, in bytes.
+const DefaultReadLimit = 4 << 20
+
+// maxReadLimit is the maximum size of a message read, in bytes, whatever the
+// read limit.
+const maxReadLimit = 1 << 30
+
+// maxControlPayload is the maximum size of the payload of a control frame.
+const maxControlPayload = 125
+
+// acceptGUID is appended to the key of the handshake to compute its accept value.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrMessageTooLarge is returned when a message exceeds the read limit.
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+
+	// ErrClosed is returned when the connection is used after it is closed.
+	ErrClosed = errors.New("websocket: connection closed")
+
+	// ErrKeepAliveTimeout is the reason a connection is closed when its peer
+	// stops answering pings.
+	ErrKeepAliveTimeout = errors.New("websocket: keepalive timeout")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with code %d: %s", e.Code, e.Text)
+}
+
+// Conn is a WebSocket connection. ReadMessage must be called from a single
+// goroutine; the other methods can be called concurrently.
+type Conn struct {
+	rwc         io.ReadWriteCloser
+	br          *bufio.Reader
+	client      bool // Whether this is the client end, which masks its frames.
+	subprotocol string
+	readLimit   atomic.Int64
+
+	writeMu sync.Mutex
+
+	// lastRead is the time the last frame was read, in Unix nanoseconds.
+	lastRead atomic.Int64
+
+	closeSent atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+	done      chan struct{}
+}
+
+// newConn creates a connection over rwc, reading through br if not nil.
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool, subprotocol string) *Conn {
+	if br == nil {
+		br = bufio.NewReader(rwc)
+	}
+	c := &Conn{
+		rwc:         rwc,
+		br:          br,
+		client:      client,
+		subprotocol: subprotocol,
+		done:        make(chan struct{}),
+	}
+	c.readLimit.Store(DefaultReadLimit)
+	c.lastRead.Store(time.Now().UnixNano())
+	return c
+}
+
+// Subprotocol returns the subprotocol negotiated in the handshake, empty if none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetReadLimit sets the maximum size of a message read, in bytes. A larger
+// message closes the connection with CloseMessageTooBig. Zero or less, or a
+// limit above 1 GiB, means 1 GiB.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit.Store(limit)
+}
+
+// Done returns a channel closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered and
+// pongs are consumed while reading. When the peer closes the connection, the
+// closing handshake is completed and a *CloseError is returned.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.lastRead.Store(time.Now().UnixNano())
+
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := parseClosePayload(payload)
+			code := closeErr.Code
+			if code == CloseNoStatusReceived {
+				code = CloseNormalClosure
+			}
+			_ = c.CloseWithReason(code, "")
+			return 0, nil, closeErr
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message before the previous one ended")
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation frame without a message")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		data = append(data, payload...)
+		if int64(len(data)) > c.maxMessageSize() {
+			_ = c.CloseWithReason(CloseMessageTooBig, "message too large")
+			return 0, nil, ErrMessageTooLarge
+		}
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8 in text message")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// readFrame reads a frame and returns its unmasked payload.
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	opcode = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		if c.client {
+			return false, 0, nil, c.fail(CloseProtocolError, "masked frame from server")
+		}
+		return false, 0, nil, c.fail(CloseProtocolError, "unmasked frame from client")
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+		if length>>63 != 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, "invalid payload length")
+		}
+	}
+	if opcode >= CloseMessage && (!fin || length > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(c.maxMessageSize()) {
+		_ = c.CloseWithReason(CloseMessageTooBig, "message too large")
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+// maxMessageSize returns the maximum size of a message read: the read limit,
+// capped to maxReadLimit.
+func (c *Conn) maxMessageSize() int64 {
+	if limit := c.readLimit.Load(); limit > 0 && limit < maxReadLimit {
+		return limit
+	}
+	return maxReadLimit
+}
+
+// WriteMessage writes a text or binary message in a single frame.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping. The peer answers it with a pong, consumed by ReadMessage.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: ping payload too large")
+	}
+	return c.writeFrame(PingMessage, data)
+}
+
+// writeFrame writes a final frame, masked if this is the client end.
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|byte(opcode))
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return fmt.Errorf("websocket: failed to generate mask: %w", err)
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.rwc.Write(frame); err != nil {
+		return fmt.Errorf("websocket: write failed: %w", err)
+	}
+	return nil
+}
+
+// KeepAlive sends a ping every interval until the connection is closed. The
+// connection is closed with CloseGoingAway when nothing, not even a pong, is
+// read from the peer for two intervals.
+func (c *Conn) KeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+			}
+			if time.Since(time.Unix(0, c.lastRead.Load())) > 2*interval {
+				_ = c.CloseWithReason(CloseGoingAway, ErrKeepAliveTimeout.Error())
+				return
+			}
+			if err := c.Ping(nil); err != nil {
+				_ = c.Close()
+				return
+			}
+		}
+	}()
+}
+
+// CloseWithReason sends a close frame with the given code and reason, unless
+// one was sent already, and closes the connection.
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	if c.closeSent.CompareAndSwap(false, true) {
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		if len(reason) > maxControlPayload-2 {
+			reason = reason[:maxControlPayload-2]
+		}
+		payload = append(payload, reason...)
+		_ = c.writeFrame(CloseMessage, payload)
+	}
+	return c.Close()
+}
+
+// Close closes the underlying connection without a closing handshake.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.closeErr = c.rwc.Close()
+	})
+	return c.closeErr
+}
+
+// fail closes the connection with a close code after a protocol violation of
+// the peer, and returns the error describing it.
+func (c *Conn) fail(code int, reason string) error {
+	_ = c.CloseWithReason(code, reason)
+	return fmt.Errorf("websocket: %s", reason)
+}
+
+// readError translates the error of a read from the underlying connection.
+func (c *Conn) readError(err error) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return err
+}
+
+// parseClosePayload parses the payload of a close frame.
+func parseClosePayload(payload []byte) *CloseError {
+	if len(payload) < 2 {
+		return &CloseError{Code: CloseNoStatusReceived}
+	}
+	return &CloseError{Code: int(binary.BigEndian.Uint16(payload)), Text: string(payload[2:])}
+}
+
+// maskBytes applies a masking key to data, which also unmasks it.
+func maskBytes(mask [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
+
+// computeAccept returns the Sec-WebSocket-Accept value answering a key.
+func computeAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,191 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package websocket
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoServer starts a server echoing the messages of its connections.
+func newEchoServer(t *testing.T, options UpgradeOptions, readLimit int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, options)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(readLimit)
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dial(t *testing.T, url string, subprotocols []string) (*Conn, error) {
+	t.Helper()
+	req, key, err := NewClientRequest(context.Background(), strings.Replace(url, "http", "ws", 1), subprotocols)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	conn, err := NewClientConn(resp, key, subprotocols)
+	if err == nil {
+		t.Cleanup(func() { _ = conn.Close() })
+	}
+	return conn, err
+}
+
+func TestConn_Echo(t *testing.T) {
+	server := newEchoServer(t, UpgradeOptions{Subprotocols: []string{"mcp"}}, 0)
+	conn, err := dial(t, server.URL, []string{"mcp"})
+	require.NoError(t, err)
+	assert.Equal(t, "mcp", conn.Subprotocol())
+
+	for _, size := range []int{0, 10, 200, 70000} {
+		message := strings.Repeat("a", size)
+		require.NoError(t, conn.WriteMessage(TextMessage, []byte(message)))
+		messageType, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, messageType)
+		assert.Equal(t, message, string(data))
+	}
+
+	require.NoError(t, conn.CloseWithReason(CloseNormalClosure, "bye"))
+	_, _, err = conn.ReadMessage()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestUpgrade_Subprotocols(t *testing.T) {
+	server := newEchoServer(t, UpgradeOptions{Subprotocols: []string{"mcp"}}, 0)
+
+	conn, err := dial(t, server.URL, nil)
+	require.NoError(t, err)
+	assert.Empty(t, conn.Subprotocol())
+
+	_, err = dial(t, server.URL, []string{"other"})
+	var handshakeErr *HandshakeError
+	require.ErrorAs(t, err, &handshakeErr)
+	assert.Equal(t, http.StatusBadRequest, handshakeErr.StatusCode)
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = Upgrade(w, r, UpgradeOptions{})
+	}))
+	defer plain.Close()
+	_, err = dial(t, plain.URL, []string{"other"})
+	assert.Error(t, err)
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpgrade_CheckOrigin(t *testing.T) {
+	server := newEchoServer(t, UpgradeOptions{}, 0)
+	req, key, err := NewClientRequest(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://elsewhere.example")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_, err = NewClientConn(resp, key, nil)
+	var handshakeErr *HandshakeError
+	require.ErrorAs(t, err, &handshakeErr)
+	assert.Equal(t, http.StatusForbidden, handshakeErr.StatusCode)
+}
+
+func TestConn_ReadLimit(t *testing.T) {
+	server := newEchoServer(t, UpgradeOptions{}, 100)
+	conn, err := dial(t, server.URL, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteMessage(BinaryMessage, make([]byte, 101)))
+	_, _, err = conn.ReadMessage()
+	var closeErr *CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, CloseMessageTooBig, closeErr.Code)
+}
+
+func TestConn_ReadFrameLength(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		length uint64
+		code   int
+	}{
+		{name: "most significant bit set", length: 1 << 63, code: CloseProtocolError},
+		{name: "above the maximum", length: maxReadLimit + 1, code: CloseMessageTooBig},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			conn := newConn(server, nil, false, "")
+			defer conn.Close()
+			peer := newConn(client, nil, true, "")
+			defer peer.Close()
+			// Without a limit, a length is still capped before its payload
+			// is allocated.
+			conn.SetReadLimit(0)
+
+			header := []byte{0x80 | BinaryMessage, 0x80 | 127}
+			header = binary.BigEndian.AppendUint64(header, tt.length)
+			errs := make(chan error, 1)
+			go func() {
+				_, _, err := conn.ReadMessage()
+				errs <- err
+			}()
+			_, err := client.Write(header)
+			require.NoError(t, err)
+			_, _, err = peer.ReadMessage()
+			var closeErr *CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, tt.code, closeErr.Code)
+			assert.Error(t, <-errs)
+		})
+	}
+}
+
+func TestConn_KeepAlive(t *testing.T) {
+	server := newEchoServer(t, UpgradeOptions{}, 0)
+	conn, err := dial(t, server.URL, nil)
+	require.NoError(t, err)
+
+	// The pongs of the server are read while waiting for a message.
+	conn.KeepAlive(20 * time.Millisecond)
+	received := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		received <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, conn.WriteMessage(TextMessage, []byte("alive")))
+	assert.NoError(t, <-received)
+
+	// Without reading, the pongs are not seen and the connection times out.
+	select {
+	case <-conn.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed by keepalive")
+	}
+	assert.True(t, errors.Is(conn.WriteMessage(TextMessage, nil), ErrClosed))
+}
@@ -0,0 +1,213 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultListChangedDelay is the default time list changes are collected
+	// for before a list_changed notification is sent.
+	defaultListChangedDelay = 100 * time.Millisecond
+
+	// listChangedRefetchTimeout bounds refetching a list after the server
+	// notified that it changed.
+	listChangedRefetchTimeout = 30 * time.Second
+)
+
+// listChangedNotifier sends a list_changed notification when the tools,
+// prompts or resources of a server change, for all sessions or for a single
+// session. The changes made within delay of the first one are coalesced into
+// one notification per list and session, so that bulk registration sends a
+// single notification. A nil notifier sends nothing.
+type listChangedNotifier struct {
+	delay time.Duration
+	send  func(sessionID, method string)
+
+	mu      sync.Mutex
+	pending map[listChangedKey]*time.Timer // Timers of the scheduled notifications.
+	stopped bool
+}
+
+// listChangedKey identifies a scheduled notification. An empty session ID
+// stands for all sessions.
+type listChangedKey struct {
+	sessionID string
+	method    string
+}
+
+// newListChangedNotifier creates a notifier sending notifications with send.
+func newListChangedNotifier(delay time.Duration, send func(sessionID, method string)) *listChangedNotifier {
+	return &listChangedNotifier{
+		delay:   delay,
+		send:    send,
+		pending: make(map[listChangedKey]*time.Timer),
+	}
+}
+
+// notify schedules a notification with the given method for a session, or
+// all sessions if sessionID is empty, unless one is already scheduled.
+func (n *listChangedNotifier) notify(sessionID, method string) {
+	if n == nil {
+		return
+	}
+	key := listChangedKey{sessionID: sessionID, method: method}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped || n.pending[key] != nil {
+		return
+	}
+	n.pending[key] = time.AfterFunc(n.delay, func() {
+		n.mu.Lock()
+		delete(n.pending, key)
+		stopped := n.stopped
+		n.mu.Unlock()
+		if !stopped {
+			n.send(sessionID, method)
+		}
+	})
+}
+
+// stop drops the scheduled notifications, and makes notify a no-op.
+func (n *listChangedNotifier) stop() error {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stopped = true
+	for key, timer := range n.pending {
+		timer.Stop()
+		delete(n.pending, key)
+	}
+	return nil
+}
+
+// ToolListChangedHandler receives the tools of the server after it notified
+// that its tool list changed. tools is nil when the client does not refetch
+// the list, and err reports a failed refetch.
+type ToolListChangedHandler func(tools []Tool, err error)
+
+// PromptListChangedHandler receives the prompts of the server after it
+// notified that its prompt list changed. prompts is nil when the client does
+// not refetch the list, and err reports a failed refetch.
+type PromptListChangedHandler func(prompts []Prompt, err error)
+
+// ResourceListChangedHandler receives the resources of the server after it
+// notified that its resource list changed. resources is nil when the client
+// does not refetch the list, and err reports a failed refetch.
+type ResourceListChangedHandler func(resources []Resource, err error)
+
+// SetToolListChangedHandler sets the handler called when the server notifies
+// that its tool list changed. If refetch is true, the client lists all tools
+// first and passes them to the handler. A nil handler removes the handler.
+func (c *Client) SetToolListChangedHandler(handler ToolListChangedHandler, refetch bool) {
+	if handler == nil {
+		c.setListChangedHandler(NotificationMethodToolsListChanged, nil)
+		return
+	}
+	c.setListChangedHandler(NotificationMethodToolsListChanged, func(ctx context.Context) {
+		if !refetch {
+			handler(nil, nil)
+			return
+		}
+		result, err := listAllUpstreamTools(ctx, c)
+		if err != nil {
+			handler(nil, err)
+			return
+		}
+		handler(result.Tools, nil)
+	})
+}
+
+// SetPromptListChangedHandler sets the handler called when the server
+// notifies that its prompt list changed. If refetch is true, the client lists
+// all prompts first and passes them to the handler. A nil handler removes the
+// handler.
+func (c *Client) SetPromptListChangedHandler(handler PromptListChangedHandler, refetch bool) {
+	if handler == nil {
+		c.setListChangedHandler(NotificationMethodPromptsListChanged, nil)
+		return
+	}
+	c.setListChangedHandler(NotificationMethodPromptsListChanged, func(ctx context.Context) {
+		if !refetch {
+			handler(nil, nil)
+			return
+		}
+		result, err := listAllUpstreamPrompts(ctx, c)
+		if err != nil {
+			handler(nil, err)
+			return
+		}
+		handler(result.Prompts, nil)
+	})
+}
+
+// SetResourceListChangedHandler sets the handler called when the server
+// notifies that its resource list changed. If refetch is true, the client
+// lists all resources first and passes them to the handler. A nil handler
+// removes the handler.
+func (c *Client) SetResourceListChangedHandler(handler ResourceListChangedHandler, refetch bool) {
+	if handler == nil {
+		c.setListChangedHandler(NotificationMethodResourcesListChanged, nil)
+		return
+	}
+	c.setListChangedHandler(NotificationMethodResourcesListChanged, func(ctx context.Context) {
+		if !refetch {
+			handler(nil, nil)
+			return
+		}
+		result, err := listAllUpstreamResources(ctx, c)
+		if err != nil {
+			handler(nil, err)
+			return
+		}
+		handler(result.Resources, nil)
+	})
+}
+
+// setListChangedHandler sets the function handling the list_changed
+// notifications with the given method, or removes it if fn is nil.
+func (c *Client) setListChangedHandler(method string, fn func(ctx context.Context)) {
+	c.listChangedMu.Lock()
+	defer c.listChangedMu.Unlock()
+	if fn == nil {
+		delete(c.listChangedHandlers, method)
+		return
+	}
+	if c.listChangedHandlers == nil {
+		c.listChangedHandlers = make(map[string]func(ctx context.Context))
+	}
+	c.listChangedHandlers[method] = fn
+}
+
+// dispatchListChanged delivers a list_changed notification to its handler. The
+// handler runs in the background, as refetching the list must not block the
+// transport delivering the response. A tool list change also drops the cached
+// output schemas of the tools.
+func (c *Client) dispatchListChanged(notification *JSONRPCNotification) {
+	if notification == nil {
+		return
+	}
+	if notification.Method == NotificationMethodToolsListChanged {
+		c.outputSchemas.reset()
+	}
+	c.listChangedMu.RLock()
+	fn := c.listChangedHandlers[notification.Method]
+	c.listChangedMu.RUnlock()
+	if fn == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), listChangedRefetchTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
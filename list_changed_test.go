@@ -0,0 +1,197 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newListChangedClient starts a server and returns it with a client whose
+// GET SSE stream is open, so that it receives list_changed notifications.
+func newListChangedClient(t *testing.T, setup func(client *Client), options ...ServerOption) (*Server, *Client) {
+	t.Helper()
+	server := NewServer("Test-Server", "1.0.0", append([]ServerOption{WithServerPath("/mcp")}, options...)...)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	setup(client)
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		_, ok := server.httpHandler.getSSEConnections[client.GetSessionID()]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	return server, client
+}
+
+func TestServer_ListChangedNotificationsAreDebounced(t *testing.T) {
+	var notifications atomic.Int32
+	refetched := make(chan []Tool, 10)
+	prompts := make(chan []Prompt, 10)
+	server, _ := newListChangedClient(t, func(client *Client) {
+		client.RegisterNotificationHandler(NotificationMethodToolsListChanged,
+			func(notification *JSONRPCNotification) error {
+				notifications.Add(1)
+				return nil
+			})
+		client.SetToolListChangedHandler(func(tools []Tool, err error) {
+			assert.NoError(t, err)
+			refetched <- tools
+		}, true)
+		client.SetPromptListChangedHandler(func(list []Prompt, err error) {
+			assert.NoError(t, err)
+			prompts <- list
+		}, false)
+	})
+
+	for i := 0; i < 5; i++ {
+		server.RegisterTool(NewTool(fmt.Sprintf("tool-%d", i)),
+			func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+				return NewTextResult("ok"), nil
+			})
+	}
+	select {
+	case tools := <-refetched:
+		assert.Len(t, tools, 5)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools list_changed not received")
+	}
+	time.Sleep(3 * defaultListChangedDelay)
+	assert.Equal(t, int32(1), notifications.Load())
+	assert.Empty(t, refetched)
+
+	require.NoError(t, server.UnregisterTools("tool-0", "tool-1"))
+	select {
+	case tools := <-refetched:
+		assert.Len(t, tools, 3)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools list_changed not received")
+	}
+
+	server.RegisterPrompt(&Prompt{Name: "greeting"},
+		func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+			return &GetPromptResult{}, nil
+		})
+	select {
+	case list := <-prompts:
+		assert.Nil(t, list)
+	case <-time.After(5 * time.Second):
+		t.Fatal("prompts list_changed not received")
+	}
+}
+
+func TestServer_ListChangedCapability(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []ServerOption
+		listChanged bool
+	}{
+		{"enabled by default", nil, true},
+		{"disabled", []ServerOption{WithListChangedEnabled(false)}, false},
+		{"stateless", []ServerOption{WithStatelessMode(true)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer("Test-Server", "1.0.0", append([]ServerOption{WithServerPath("/mcp")}, tt.options...)...)
+			httpServer := httptest.NewServer(server.HTTPHandler())
+			defer httpServer.Close()
+
+			client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+			require.NoError(t, err)
+			defer client.Close()
+			result, err := client.Initialize(context.Background(), &InitializeRequest{})
+			require.NoError(t, err)
+			require.NotNil(t, result.Capabilities.Tools)
+			assert.Equal(t, tt.listChanged, result.Capabilities.Tools.ListChanged)
+		})
+	}
+}
+
+func TestSSEServer_ListChangedNotifications(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0")
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	refetched := make(chan []Tool, 10)
+	client.SetToolListChangedHandler(func(tools []Tool, err error) {
+		assert.NoError(t, err)
+		refetched <- tools
+	}, true)
+	result, err := client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.Capabilities.Tools.ListChanged)
+
+	for i := 0; i < 3; i++ {
+		server.RegisterTool(NewTool(fmt.Sprintf("tool-%d", i)),
+			func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+				return NewTextResult("ok"), nil
+			})
+	}
+	select {
+	case tools := <-refetched:
+		assert.Len(t, tools, 3)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools list_changed not received")
+	}
+
+	disabled := NewSSEServer("Test-Server", "1.0.0", WithSSEListChangedEnabled(false))
+	assert.Nil(t, disabled.listChanged)
+	assert.False(t, disabled.mcpHandler.lifecycleManager.listChanged)
+}
+
+func TestStdioServer_ListChangedNotifications(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	write, read, _ := listenStdio(t, ctx, server)
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	result := read()["result"].(map[string]interface{})
+	tools := result["capabilities"].(map[string]interface{})["tools"].(map[string]interface{})
+	assert.Equal(t, true, tools["listChanged"])
+	write(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// Changes are notified once the session is initialized.
+	require.Eventually(t, func() bool {
+		session := server.session.Load()
+		return session != nil && session.Initialized()
+	}, 2*time.Second, 10*time.Millisecond)
+	server.RegisterPrompt(&Prompt{Name: "a"}, func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+		return &GetPromptResult{}, nil
+	})
+	server.RegisterPrompt(&Prompt{Name: "b"}, func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+		return &GetPromptResult{}, nil
+	})
+	assert.Equal(t, NotificationMethodPromptsListChanged, read()["method"])
+
+	// Stopping the server drops the notifications still scheduled.
+	server.stopped()
+	server.listChanged.mu.Lock()
+	assert.True(t, server.listChanged.stopped)
+	server.listChanged.mu.Unlock()
+
+	disabled := NewStdioServer("Test-Server", "1.0.0", WithStdioListChangedEnabled(false))
+	assert.Nil(t, disabled.listChanged)
+	assert.False(t, disabled.lifecycleManager.listChanged)
+}
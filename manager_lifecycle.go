@@ -8,11 +8,18 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
 
 	"trpc.group/trpc-go/trpc-mcp-go/internal/errors"
 )
 
+// clientCapabilitiesKey is the session data key of the client's declared capabilities.
+const clientCapabilitiesKey = "clientCapabilities"
+
+// clientInfoKey is the session data key of the client's implementation information.
+const clientInfoKey = "clientInfo"
+
 // lifecycleManager is responsible for managing the MCP protocol lifecycle
 type lifecycleManager struct {
 	// Logger for this lifecycle manager.
@@ -44,6 +51,12 @@ type lifecycleManager struct {
 	// Whether in stateless mode.
 	isStateless bool
 
+	// Whether list_changed notifications are sent
+	listChanged bool
+
+	// Hooks called as sessions are initialized, nil if none
+	sessionHooks *SessionHooks
+
 	// Mutex for concurrent access
 	mu sync.RWMutex
 }
@@ -53,14 +66,19 @@ func newLifecycleManager(serverInfo Implementation) *lifecycleManager {
 	return &lifecycleManager{
 		logger:                 GetDefaultLogger(), // Use default logger if not set.
 		serverInfo:             serverInfo,
-		defaultProtocolVersion: ProtocolVersion_2025_03_26,
-		supportedVersions:      []string{ProtocolVersion_2024_11_05, ProtocolVersion_2025_03_26},
+		defaultProtocolVersion: ProtocolVersion_2025_06_18,
+		supportedVersions: []string{
+			ProtocolVersion_2024_11_05,
+			ProtocolVersion_2025_03_26,
+			ProtocolVersion_2025_06_18,
+		},
 		capabilities: map[string]interface{}{
 			"tools": map[string]interface{}{
 				"listChanged": true,
 			},
 		},
 		sessionStates: make(map[string]bool),
+		listChanged:   true,
 	}
 }
 
@@ -112,6 +130,19 @@ func (m *lifecycleManager) withStatelessMode(isStateless bool) *lifecycleManager
 	return m
 }
 
+// withListChanged sets whether list_changed notifications are sent, which the
+// listChanged capabilities advertise.
+func (m *lifecycleManager) withListChanged(enabled bool) *lifecycleManager {
+	m.listChanged = enabled
+	return m
+}
+
+// withSessionHooks sets the hooks called as sessions are initialized.
+func (m *lifecycleManager) withSessionHooks(hooks *SessionHooks) *lifecycleManager {
+	m.sessionHooks = hooks
+	return m
+}
+
 // updateCapabilities updates the server capability information
 func (m *lifecycleManager) updateCapabilities() {
 	// Use map as an intermediate variable
@@ -119,23 +150,34 @@ func (m *lifecycleManager) updateCapabilities() {
 
 	// Basic tool capabilities always exist
 	capMap["tools"] = map[string]interface{}{
-		"listChanged": true,
+		"listChanged": m.listChanged,
 	}
 
+	// Logging is always supported through logging/setLevel and notifications/message
+	capMap["logging"] = map[string]interface{}{}
+
 	// If there is a resource manager and resources are registered, add resource capabilities
 	if m.resourceManager != nil && len(m.resourceManager.getResources()) > 0 {
 		capMap["resources"] = map[string]interface{}{
-			"listChanged": true,
+			"listChanged": m.listChanged,
+			// Updates are delivered to sessions, which stateless servers do not keep
+			"subscribe": !m.isStateless,
 		}
 	}
 
 	// If there is a prompt manager and prompts are registered, add prompt capabilities
 	if m.promptManager != nil && len(m.promptManager.getPrompts()) > 0 {
 		capMap["prompts"] = map[string]interface{}{
-			"listChanged": true,
+			"listChanged": m.listChanged,
 		}
 	}
 
+	// If any completion provider is registered, add completions capabilities
+	if (m.promptManager != nil && m.promptManager.hasCompletionProviders()) ||
+		(m.resourceManager != nil && m.resourceManager.hasCompletionProviders()) {
+		capMap["completions"] = map[string]interface{}{}
+	}
+
 	// Preserve existing experimental features
 	if exp, ok := m.capabilities["experimental"]; ok {
 		capMap["experimental"] = exp
@@ -156,6 +198,8 @@ func (m *lifecycleManager) handleInitialize(ctx context.Context, req *JSONRPCReq
 	supportedVersion := m.selectSupportedVersion(protocolVersion)
 	m.logProtocolVersion(protocolVersion, supportedVersion)
 	m.saveSessionState(session, supportedVersion)
+	saveClientCapabilities(session, paramsMap["capabilities"])
+	saveClientInfo(session, paramsMap["clientInfo"])
 	m.updateCapabilities()
 	response := m.buildInitializeResponse(supportedVersion)
 	return response, nil
@@ -198,24 +242,91 @@ func (m *lifecycleManager) logProtocolVersion(requested, selected string) {
 // saveSessionState marks session initialization and saves protocol version
 func (m *lifecycleManager) saveSessionState(session Session, protocolVersion string) {
 	if session != nil {
-		m.mu.Lock()
-		m.sessionStates[session.GetID()] = false // Initialization started but not completed
-		m.mu.Unlock()
+		if _, ok := session.(initializationTracker); !ok {
+			m.mu.Lock()
+			m.sessionStates[session.GetID()] = false // Initialization started but not completed
+			m.mu.Unlock()
+		}
 		// Save protocol version to session data
-		session.SetData("protocolVersion", protocolVersion)
+		session.SetData(protocolVersionKey, protocolVersion)
+	}
+}
+
+// saveClientCapabilities saves the capabilities declared by the client to session data.
+func saveClientCapabilities(session Session, capabilities interface{}) {
+	if session == nil {
+		return
+	}
+	var clientCapabilities ClientCapabilities
+	if capabilities != nil {
+		data, err := json.Marshal(capabilities)
+		if err != nil {
+			return
+		}
+		if err := json.Unmarshal(data, &clientCapabilities); err != nil {
+			return
+		}
+	}
+	session.SetData(clientCapabilitiesKey, clientCapabilities)
+}
+
+// saveClientInfo saves the implementation information sent by the client to session data.
+func saveClientInfo(session Session, info interface{}) {
+	if session == nil || info == nil {
+		return
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	var clientInfo Implementation
+	if err := json.Unmarshal(data, &clientInfo); err != nil {
+		return
 	}
+	session.SetData(clientInfoKey, clientInfo)
+}
+
+// clientInfoFromSession returns the implementation information the client sent during initialization.
+func clientInfoFromSession(session Session) (Implementation, bool) {
+	if session == nil {
+		return Implementation{}, false
+	}
+	value, ok := session.GetData(clientInfoKey)
+	if !ok {
+		return Implementation{}, false
+	}
+	info, ok := value.(Implementation)
+	return info, ok
+}
+
+// clientCapabilitiesFromSession returns the capabilities the client declared during initialization.
+func clientCapabilitiesFromSession(session Session) (ClientCapabilities, bool) {
+	if session == nil {
+		return ClientCapabilities{}, false
+	}
+	value, ok := session.GetData(clientCapabilitiesKey)
+	if !ok {
+		return ClientCapabilities{}, false
+	}
+	capabilities, ok := value.(ClientCapabilities)
+	return capabilities, ok
 }
 
 // buildInitializeResponse creates the initialization response
 func (m *lifecycleManager) buildInitializeResponse(protocolVersion string) InitializeResult {
+	serverInfo := Implementation{
+		Name:    m.serverInfo.Name,
+		Version: m.serverInfo.Version,
+	}
+	// The title field was introduced in 2025-06-18.
+	if protocolVersionAtLeast(protocolVersion, ProtocolVersion_2025_06_18) {
+		serverInfo.Title = m.serverInfo.Title
+	}
 	return InitializeResult{
 		ProtocolVersion: protocolVersion,
-		ServerInfo: Implementation{
-			Name:    m.serverInfo.Name,
-			Version: m.serverInfo.Version,
-		},
-		Capabilities: convertToServerCapabilities(m.capabilities),
-		Instructions: "MCP server is ready",
+		ServerInfo:      serverInfo,
+		Capabilities:    convertToServerCapabilities(m.capabilities),
+		Instructions:    "MCP server is ready",
 	}
 }
 
@@ -286,6 +397,26 @@ func (m *lifecycleManager) handleInitialized(ctx context.Context, notification *
 		// Or handle as a global initialized event if applicable
 		return nil
 	}
+	if err := m.markInitialized(session); err != nil {
+		return err
+	}
+	m.logger.Infof("Session %s initialized.", session.GetID())
+	m.sessionHooks.sessionInitialized(ctx, session)
+	return nil
+}
+
+// markInitialized records that the client of the session sent notifications/initialized.
+func (m *lifecycleManager) markInitialized(session Session) error {
+	if tracker, ok := session.(initializationTracker); ok {
+		first, err := tracker.markInitialized()
+		if err != nil {
+			return err
+		}
+		if !first {
+			return errors.ErrSessionAlreadyInitialized
+		}
+		return nil
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, exists := m.sessionStates[session.GetID()]; !exists {
@@ -296,10 +427,17 @@ func (m *lifecycleManager) handleInitialized(ctx context.Context, notification *
 		return errors.ErrSessionAlreadyInitialized
 	}
 	m.sessionStates[session.GetID()] = true
-	m.logger.Infof("Session %s initialized.", session.GetID())
 	return nil
 }
 
+// initializationTracker is implemented by sessions that keep their
+// initialization state themselves, such as the sessions of a SessionStore,
+// which may have been initialized on another server.
+type initializationTracker interface {
+	// markInitialized marks the session initialized, and returns false if it already was.
+	markInitialized() (bool, error)
+}
+
 // isInitialized checks if a session is initialized
 func (m *lifecycleManager) isInitialized(sessionID string) bool {
 	m.mu.RLock()
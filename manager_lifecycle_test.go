@@ -52,6 +52,11 @@ func TestHandleInitialize(t *testing.T) {
 			protocolVersion: ProtocolVersion_2024_11_05,
 			expectError:     false,
 		},
+		{
+			name:            "Valid protocol version 2025-06-18",
+			protocolVersion: ProtocolVersion_2025_06_18,
+			expectError:     false,
+		},
 		{
 			name:            "Invalid protocol version",
 			protocolVersion: "2023-01-01",
@@ -95,7 +100,7 @@ func TestHandleInitialize(t *testing.T) {
 				require.True(t, ok, "Expected InitializeResult but got different type")
 
 				if tc.protocolVersion == "2023-01-01" {
-					assert.Equal(t, ProtocolVersion_2025_03_26, initResp.ProtocolVersion)
+					assert.Equal(t, ProtocolVersion_2025_06_18, initResp.ProtocolVersion)
 				} else {
 					assert.Equal(t, tc.protocolVersion, initResp.ProtocolVersion)
 				}
@@ -109,7 +114,7 @@ func TestHandleInitialize(t *testing.T) {
 				require.True(t, ok)
 
 				if tc.protocolVersion == "2023-01-01" {
-					assert.Equal(t, ProtocolVersion_2025_03_26, storedVersion)
+					assert.Equal(t, ProtocolVersion_2025_06_18, storedVersion)
 				} else {
 					assert.Equal(t, tc.protocolVersion, storedVersion)
 				}
@@ -39,6 +39,15 @@ type promptManager struct {
 
 	// Prompt list filter function
 	promptListFilter PromptListFilter
+
+	// Paginator for prompts/list, nil if pagination is disabled
+	paginator *paginator
+
+	// Completion providers by prompt name and argument name
+	completionProviders map[string]map[string]CompletionProvider
+
+	// Prompts registered for individual sessions
+	sessionPrompts sessionItems[*registeredPrompt]
 }
 
 // newPromptManager creates a new prompt manager
@@ -57,6 +66,12 @@ func (m *promptManager) withPromptListFilter(filter PromptListFilter) *promptMan
 	return m
 }
 
+// withPaginator sets the paginator used for prompts/list.
+func (m *promptManager) withPaginator(p *paginator) *promptManager {
+	m.paginator = p
+	return m
+}
+
 // registerPrompt registers a prompt
 func (m *promptManager) registerPrompt(prompt *Prompt, handler promptHandler) {
 	m.mu.Lock()
@@ -77,6 +92,113 @@ func (m *promptManager) registerPrompt(prompt *Prompt, handler promptHandler) {
 	}
 }
 
+// unregisterPrompts removes multiple prompts by names and returns the count of unregistered prompts.
+func (m *promptManager) unregisterPrompts(names ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unregisteredCount := 0
+	for _, name := range names {
+		if _, exists := m.prompts[name]; !exists {
+			continue
+		}
+		delete(m.prompts, name)
+		unregisteredCount++
+
+		for i, promptName := range m.promptsOrder {
+			if promptName == name {
+				m.promptsOrder = append(m.promptsOrder[:i], m.promptsOrder[i+1:]...)
+				break
+			}
+		}
+	}
+	return unregisteredCount
+}
+
+// registerSessionPrompt registers a prompt for a single session. It replaces
+// the prompt with the same name registered for all sessions.
+func (m *promptManager) registerSessionPrompt(sessionID string, prompt *Prompt, handler promptHandler) {
+	if prompt == nil || prompt.Name == "" {
+		return
+	}
+	m.sessionPrompts.add(sessionID, prompt.Name, &registeredPrompt{
+		Prompt:  prompt,
+		Handler: handler,
+	})
+}
+
+// unregisterSessionPrompts removes prompts registered for a session and
+// returns the count of unregistered prompts.
+func (m *promptManager) unregisterSessionPrompts(sessionID string, names ...string) int {
+	return m.sessionPrompts.remove(sessionID, names...)
+}
+
+// findPrompt returns the prompt a session gets by name, looking up the
+// prompts of the session first.
+func (m *promptManager) findPrompt(session Session, name string) (*registeredPrompt, bool) {
+	if registeredPrompt, ok := m.sessionPrompts.get(sessionIDOf(session), name); ok {
+		return registeredPrompt, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	registeredPrompt, ok := m.prompts[name]
+	return registeredPrompt, ok
+}
+
+// getSessionPrompts retrieves the prompts registered for all sessions merged
+// with the prompts of the session.
+func (m *promptManager) getSessionPrompts(session Session) []*Prompt {
+	var sessionPrompts []*Prompt
+	for _, registeredPrompt := range m.sessionPrompts.list(sessionIDOf(session)) {
+		sessionPrompts = append(sessionPrompts, registeredPrompt.Prompt)
+	}
+	return mergeSessionItems(m.getPrompts(), sessionPrompts, promptKey)
+}
+
+// promptVisible reports whether the prompt list filter keeps the prompt, so
+// that a prompt hidden from the list cannot be got either.
+func (m *promptManager) promptVisible(ctx context.Context, prompt *Prompt) bool {
+	if m.promptListFilter == nil {
+		return true
+	}
+	for _, visible := range m.promptListFilter(ctx, []*Prompt{prompt}) {
+		if visible != nil && visible.Name == prompt.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// registerCompletionProvider registers a completion provider for a prompt argument
+func (m *promptManager) registerCompletionProvider(promptName, argumentName string, provider CompletionProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.completionProviders == nil {
+		m.completionProviders = make(map[string]map[string]CompletionProvider)
+	}
+	if m.completionProviders[promptName] == nil {
+		m.completionProviders[promptName] = make(map[string]CompletionProvider)
+	}
+	m.completionProviders[promptName][argumentName] = provider
+}
+
+// getCompletionProvider retrieves the completion provider of a prompt argument
+func (m *promptManager) getCompletionProvider(promptName, argumentName string) CompletionProvider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.completionProviders[promptName][argumentName]
+}
+
+// hasCompletionProviders reports whether any completion provider is registered
+func (m *promptManager) hasCompletionProviders() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.completionProviders) > 0
+}
+
 // getPrompt retrieves a prompt
 func (m *promptManager) getPrompt(name string) (*Prompt, bool) {
 	m.mu.RLock()
@@ -101,16 +223,34 @@ func (m *promptManager) getPrompts() []*Prompt {
 	return prompts
 }
 
+// promptKey returns the key prompts are paginated by.
+func promptKey(prompt *Prompt) string {
+	if prompt == nil {
+		return ""
+	}
+	return prompt.Name
+}
+
 // handleListPrompts handles listing prompts requests
-func (m *promptManager) handleListPrompts(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
-	// Get all prompts
-	promptPtrs := m.getPrompts()
+func (m *promptManager) handleListPrompts(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	session Session,
+) (JSONRPCMessage, error) {
+	// Get all prompts of the session
+	promptPtrs := m.getSessionPrompts(session)
 
 	// Apply filter if available
 	if m.promptListFilter != nil {
 		promptPtrs = m.promptListFilter(ctx, promptPtrs)
 	}
 
+	// Select the requested page after filtering
+	promptPtrs, nextCursor, err := paginate(m.paginator, cursorListPrompts, promptPtrs, promptKey, cursorFromRequest(req))
+	if err != nil {
+		return invalidCursorResponse(req, err), nil
+	}
+
 	// Convert []*mcp.Prompt to []mcp.Prompt for the result
 	resultPrompts := make([]Prompt, len(promptPtrs))
 	for i, prompt := range promptPtrs {
@@ -122,6 +262,7 @@ func (m *promptManager) handleListPrompts(ctx context.Context, req *JSONRPCReque
 	result := &ListPromptsResult{
 		Prompts: resultPrompts,
 	}
+	result.NextCursor = nextCursor
 
 	return result, nil
 }
@@ -172,13 +313,17 @@ func buildPromptMessages(prompt *Prompt, arguments map[string]interface{}) []Pro
 }
 
 // Refactored: handleGetPrompt with logic unchanged, now using helpers
-func (m *promptManager) handleGetPrompt(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
+func (m *promptManager) handleGetPrompt(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	session Session,
+) (JSONRPCMessage, error) {
 	name, arguments, errResp, ok := parseGetPromptParams(req)
 	if !ok {
 		return errResp, nil
 	}
-	registeredPrompt, exists := m.prompts[name]
-	if !exists {
+	registeredPrompt, exists := m.findPrompt(session, name)
+	if !exists || !m.promptVisible(ctx, registeredPrompt.Prompt) {
 		return newJSONRPCErrorResponse(
 			req.ID,
 			ErrCodeMethodNotFound,
@@ -225,40 +370,3 @@ func (m *promptManager) handleGetPrompt(ctx context.Context, req *JSONRPCRequest
 	}
 	return result, nil
 }
-
-// Helper: Parse and validate parameters for CompletionComplete
-func parseCompletionCompleteParams(req *JSONRPCRequest) (promptName string, errResp JSONRPCMessage, ok bool) {
-	paramsMap, ok := req.Params.(map[string]interface{})
-	if !ok {
-		return "", newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrInvalidParams.Error(), nil), false
-	}
-	ref, ok := paramsMap["ref"].(map[string]interface{})
-	if !ok {
-		return "", newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil), false
-	}
-	refType, ok := ref["type"].(string)
-	if !ok || refType != "ref/prompt" {
-		return "", newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrInvalidParams.Error(), nil), false
-	}
-	promptName, ok = ref["name"].(string)
-	if !ok {
-		return "", newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil), false
-	}
-	return promptName, nil, true
-}
-
-// Refactored: handleCompletionComplete with logic unchanged, now using helpers
-func (m *promptManager) handleCompletionComplete(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
-	promptName, errResp, ok := parseCompletionCompleteParams(req)
-	if !ok {
-		return errResp, nil
-	}
-	// Business logic remains unchanged, can be further split if needed
-	return m.handlePromptCompletion(ctx, promptName, req)
-}
-
-// Helper: Handle prompt completion business logic (can be further split if needed)
-func (m *promptManager) handlePromptCompletion(ctx context.Context, promptName string, req *JSONRPCRequest) (JSONRPCMessage, error) {
-	// Original handleCompletionComplete business logic placeholder
-	return newJSONRPCErrorResponse(req.ID, ErrCodeMethodNotFound, "not implemented", nil), nil
-}
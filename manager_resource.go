@@ -12,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/yosida95/uritemplate/v3"
+
 	"trpc.group/trpc-go/trpc-mcp-go/internal/errors"
 )
 
@@ -38,10 +40,10 @@ type resourceManager struct {
 	// Mutex
 	mu sync.RWMutex
 
-	// Subscriber mapping table
-	subscribers map[string][]chan *JSONRPCNotification
+	// Subscribed sessions by resource URI and session ID
+	subscriptions map[string]map[string]Session
 
-	// Subscriber mutex
+	// Subscription mutex
 	subMu sync.RWMutex
 
 	// Order of resources
@@ -49,6 +51,15 @@ type resourceManager struct {
 
 	// Resource list filter function
 	resourceListFilter ResourceListFilter
+
+	// Paginator for resources/list and resources/templates/list, nil if pagination is disabled
+	paginator *paginator
+
+	// Completion providers by URI template and variable name
+	completionProviders map[string]map[string]CompletionProvider
+
+	// Resources registered for individual sessions
+	sessionResources sessionItems[*registeredResource]
 }
 
 // newResourceManager creates a new resource manager
@@ -57,9 +68,9 @@ type resourceManager struct {
 // it is only enabled when the first resource is added.
 func newResourceManager() *resourceManager {
 	return &resourceManager{
-		resources:   make(map[string]*registeredResource),
-		templates:   make(map[string]*registerResourceTemplate),
-		subscribers: make(map[string][]chan *JSONRPCNotification),
+		resources:     make(map[string]*registeredResource),
+		templates:     make(map[string]*registerResourceTemplate),
+		subscriptions: make(map[string]map[string]Session),
 	}
 }
 
@@ -69,6 +80,12 @@ func (m *resourceManager) withResourceListFilter(filter ResourceListFilter) *res
 	return m
 }
 
+// withPaginator sets the paginator used for resources/list and resources/templates/list.
+func (m *resourceManager) withPaginator(p *paginator) *resourceManager {
+	m.paginator = p
+	return m
+}
+
 // registerResource registers a resource
 func (m *resourceManager) registerResource(resource *Resource, handler resourceHandler) {
 	m.mu.Lock()
@@ -113,7 +130,86 @@ func (m *resourceManager) registerResources(resource *Resource, handler resource
 	}
 }
 
-// registerTemplate registers a resource template
+// unregisterResources removes multiple resources by URIs and returns the count of unregistered resources.
+func (m *resourceManager) unregisterResources(uris ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unregisteredCount := 0
+	for _, uri := range uris {
+		if _, exists := m.resources[uri]; !exists {
+			continue
+		}
+		delete(m.resources, uri)
+		unregisteredCount++
+
+		for i, resourceURI := range m.resourcesOrder {
+			if resourceURI == uri {
+				m.resourcesOrder = append(m.resourcesOrder[:i], m.resourcesOrder[i+1:]...)
+				break
+			}
+		}
+	}
+	return unregisteredCount
+}
+
+// registerSessionResources registers a resource with multiple contents
+// handler for a single session. It replaces the resource with the same URI
+// registered for all sessions.
+func (m *resourceManager) registerSessionResources(sessionID string, resource *Resource, handler resourcesHandler) {
+	if resource == nil || resource.URI == "" {
+		return
+	}
+	m.sessionResources.add(sessionID, resource.URI, &registeredResource{
+		Resource: resource,
+		Handler:  handler,
+	})
+}
+
+// unregisterSessionResources removes resources registered for a session and
+// returns the count of unregistered resources.
+func (m *resourceManager) unregisterSessionResources(sessionID string, uris ...string) int {
+	return m.sessionResources.remove(sessionID, uris...)
+}
+
+// findResource returns the resource a session reads by URI, looking up the
+// resources of the session first.
+func (m *resourceManager) findResource(session Session, uri string) (*registeredResource, bool) {
+	if registeredResource, ok := m.sessionResources.get(sessionIDOf(session), uri); ok {
+		return registeredResource, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	registeredResource, ok := m.resources[uri]
+	return registeredResource, ok
+}
+
+// getSessionResources retrieves the resources registered for all sessions
+// merged with the resources of the session.
+func (m *resourceManager) getSessionResources(session Session) []*Resource {
+	var sessionResources []*Resource
+	for _, registeredResource := range m.sessionResources.list(sessionIDOf(session)) {
+		sessionResources = append(sessionResources, registeredResource.Resource)
+	}
+	return mergeSessionItems(m.getResources(), sessionResources, resourceKey)
+}
+
+// resourceVisible reports whether the resource list filter keeps the
+// resource, so that a resource hidden from the list cannot be read either.
+func (m *resourceManager) resourceVisible(ctx context.Context, resource *Resource) bool {
+	if m.resourceListFilter == nil {
+		return true
+	}
+	for _, visible := range m.resourceListFilter(ctx, []*Resource{resource}) {
+		if visible != nil && visible.URI == resource.URI {
+			return true
+		}
+	}
+	return false
+}
+
+// registerTemplate registers a resource template, replacing the template with
+// the same name.
 func (m *resourceManager) registerTemplate(template *ResourceTemplate, handler resourceTemplateHandler) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -130,10 +226,6 @@ func (m *resourceManager) registerTemplate(template *ResourceTemplate, handler r
 		return fmt.Errorf("template URI cannot be empty")
 	}
 
-	if _, exists := m.templates[template.Name]; exists {
-		return fmt.Errorf("template %s already exists", template.Name)
-	}
-
 	m.templates[template.Name] = &registerResourceTemplate{
 		resourceTemplate: template,
 		Handler:          handler,
@@ -142,6 +234,47 @@ func (m *resourceManager) registerTemplate(template *ResourceTemplate, handler r
 	return nil
 }
 
+// unregisterTemplates removes resource templates by names and returns the
+// count of unregistered templates.
+func (m *resourceManager) unregisterTemplates(names ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unregisteredCount := 0
+	for _, name := range names {
+		if _, exists := m.templates[name]; exists {
+			delete(m.templates, name)
+			unregisteredCount++
+		}
+	}
+	return unregisteredCount
+}
+
+// findTemplate returns the template matching a resource URI, the one with
+// the longest URI template if several match, along with the values of its
+// variables.
+func (m *resourceManager) findTemplate(uri string) (*registerResourceTemplate, uritemplate.Values, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *registerResourceTemplate
+	var foundValues uritemplate.Values
+	for _, template := range m.templates {
+		values := template.resourceTemplate.URITemplate.Match(uri)
+		if values == nil {
+			continue
+		}
+		if found != nil {
+			raw, foundRaw := template.resourceTemplate.URITemplate.Raw(), found.resourceTemplate.URITemplate.Raw()
+			if len(raw) < len(foundRaw) || len(raw) == len(foundRaw) && raw > foundRaw {
+				continue
+			}
+		}
+		found, foundValues = template, values
+	}
+	return found, foundValues, found != nil
+}
+
 // getResource retrieves a resource
 func (m *resourceManager) getResource(uri string) (*Resource, bool) {
 	m.mu.RLock()
@@ -180,73 +313,143 @@ func (m *resourceManager) getTemplates() []*ResourceTemplate {
 	return templates
 }
 
-// subscribe subscribes to resource updates
-func (m *resourceManager) subscribe(uri string) chan *JSONRPCNotification {
+// hasTemplate reports whether a template with the given URI template is registered
+func (m *resourceManager) hasTemplate(uriTemplate string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, template := range m.templates {
+		if template.resourceTemplate.URITemplate.Raw() == uriTemplate {
+			return true
+		}
+	}
+	return false
+}
+
+// registerCompletionProvider registers a completion provider for a URI template variable
+func (m *resourceManager) registerCompletionProvider(uriTemplate, variable string, provider CompletionProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.completionProviders == nil {
+		m.completionProviders = make(map[string]map[string]CompletionProvider)
+	}
+	if m.completionProviders[uriTemplate] == nil {
+		m.completionProviders[uriTemplate] = make(map[string]CompletionProvider)
+	}
+	m.completionProviders[uriTemplate][variable] = provider
+}
+
+// getCompletionProvider retrieves the completion provider of a URI template variable
+func (m *resourceManager) getCompletionProvider(uriTemplate, variable string) CompletionProvider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.completionProviders[uriTemplate][variable]
+}
+
+// hasCompletionProviders reports whether any completion provider is registered
+func (m *resourceManager) hasCompletionProviders() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.completionProviders) > 0
+}
+
+// subscribe subscribes a session to updates of a resource
+func (m *resourceManager) subscribe(uri string, session Session) {
 	m.subMu.Lock()
 	defer m.subMu.Unlock()
 
-	ch := make(chan *JSONRPCNotification, 10)
-	m.subscribers[uri] = append(m.subscribers[uri], ch)
-	return ch
+	sessions, ok := m.subscriptions[uri]
+	if !ok {
+		sessions = make(map[string]Session)
+		m.subscriptions[uri] = sessions
+	}
+	sessions[session.GetID()] = session
 }
 
-// unsubscribe cancels a subscription
-func (m *resourceManager) unsubscribe(uri string, ch chan *JSONRPCNotification) {
+// unsubscribe cancels the subscription of a session to a resource
+func (m *resourceManager) unsubscribe(uri string, sessionID string) {
 	m.subMu.Lock()
 	defer m.subMu.Unlock()
 
-	subs := m.subscribers[uri]
-	for i, sub := range subs {
-		if sub == ch {
-			close(ch)
-			subs = append(subs[:i], subs[i+1:]...)
-			break
-		}
+	sessions := m.subscriptions[uri]
+	delete(sessions, sessionID)
+	if len(sessions) == 0 {
+		delete(m.subscriptions, uri)
 	}
-	if len(subs) == 0 {
-		delete(m.subscribers, uri)
-	} else {
-		m.subscribers[uri] = subs
+}
+
+// unsubscribeSession cancels all subscriptions of a session
+func (m *resourceManager) unsubscribeSession(sessionID string) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for uri, sessions := range m.subscriptions {
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(m.subscriptions, uri)
+		}
 	}
 }
 
-// notifyUpdate notifies about resource updates
-func (m *resourceManager) notifyUpdate(uri string) {
+// getSubscribers returns the sessions subscribed to a resource
+func (m *resourceManager) getSubscribers(uri string) []Session {
 	m.subMu.RLock()
-	subs := m.subscribers[uri]
-	m.subMu.RUnlock()
-
-	// Create jsonrpcNotification params with correct struct type
-	notification := Notification{
-		Method: "notifications/resources/updated",
-		Params: NotificationParams{
-			AdditionalFields: map[string]interface{}{
-				"uri": uri,
-			},
-		},
+	defer m.subMu.RUnlock()
+
+	sessions := make([]Session, 0, len(m.subscriptions[uri]))
+	for _, session := range m.subscriptions[uri] {
+		sessions = append(sessions, session)
 	}
+	return sessions
+}
 
-	jsonrpcNotification := newJSONRPCNotification(notification)
+// newResourceUpdatedNotification creates a notifications/resources/updated notification
+func newResourceUpdatedNotification(uri string) *JSONRPCNotification {
+	return NewJSONRPCNotificationFromMap(NotificationMethodResourcesUpdated, map[string]interface{}{
+		"uri": uri,
+	})
+}
 
-	for _, ch := range subs {
-		select {
-		case ch <- jsonrpcNotification:
-		default:
-			// Skip this subscriber if the channel is full
-		}
+// resourceKey returns the key resources are paginated by.
+func resourceKey(resource *Resource) string {
+	if resource == nil {
+		return ""
+	}
+	return resource.URI
+}
+
+// templateKey returns the key resource templates are paginated by.
+func templateKey(template *ResourceTemplate) string {
+	if template == nil {
+		return ""
 	}
+	return template.Name
 }
 
 // handleListResources handles listing resources requests
-func (m *resourceManager) handleListResources(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
-	// Get all resources
-	resourcePtrs := m.getResources()
+func (m *resourceManager) handleListResources(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	session Session,
+) (JSONRPCMessage, error) {
+	// Get all resources of the session
+	resourcePtrs := m.getSessionResources(session)
 
 	// Apply filter if available
 	if m.resourceListFilter != nil {
 		resourcePtrs = m.resourceListFilter(ctx, resourcePtrs)
 	}
 
+	// Select the requested page after filtering
+	resourcePtrs, nextCursor, err := paginate(m.paginator, cursorListResources, resourcePtrs, resourceKey,
+		cursorFromRequest(req))
+	if err != nil {
+		return invalidCursorResponse(req, err), nil
+	}
+
 	// Convert []*mcp.Resource to []mcp.Resource for the result
 	resultResources := make([]Resource, len(resourcePtrs))
 	for i, resource := range resourcePtrs {
@@ -259,13 +462,18 @@ func (m *resourceManager) handleListResources(ctx context.Context, req *JSONRPCR
 	result := ListResourcesResult{
 		Resources: resultResources,
 	}
+	result.NextCursor = nextCursor
 
 	// Return response
 	return result, nil
 }
 
 // handleReadResource handles reading resource requests
-func (m *resourceManager) handleReadResource(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
+func (m *resourceManager) handleReadResource(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	session Session,
+) (JSONRPCMessage, error) {
 	// Convert params to map for easier access
 	paramsMap, ok := req.Params.(map[string]interface{})
 	if !ok {
@@ -278,9 +486,19 @@ func (m *resourceManager) handleReadResource(ctx context.Context, req *JSONRPCRe
 		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil), nil
 	}
 
-	// Get resource
-	registeredResource, exists := m.resources[uri]
-	if !exists {
+	// Get resource, which must also be visible to the request, or else the
+	// template matching the URI
+	var handler resourcesHandler
+	var templateValues uritemplate.Values
+	if registeredResource, exists := m.findResource(session, uri); exists {
+		if m.resourceVisible(ctx, registeredResource.Resource) {
+			handler = registeredResource.Handler
+		}
+	} else if template, values, ok := m.findTemplate(uri); ok && template.Handler != nil {
+		handler = resourcesHandler(template.Handler)
+		templateValues = values
+	}
+	if handler == nil {
 		return newJSONRPCErrorResponse(
 			req.ID,
 			ErrCodeMethodNotFound,
@@ -299,15 +517,22 @@ func (m *resourceManager) handleReadResource(ctx context.Context, req *JSONRPCRe
 		},
 	}
 
-	// Extract and set arguments if present.
+	// Extract and set arguments if present, or else the values of the
+	// template variables.
 	if args, ok := paramsMap["arguments"]; ok && args != nil {
 		if argsMap, ok := args.(map[string]interface{}); ok {
 			readReq.Params.Arguments = argsMap
 		}
 	}
+	if readReq.Params.Arguments == nil && templateValues != nil {
+		readReq.Params.Arguments = make(map[string]interface{}, len(templateValues))
+		for name, value := range templateValues {
+			readReq.Params.Arguments[name] = value.String()
+		}
+	}
 
 	// Call resource handler
-	contents, err := registeredResource.Handler(ctx, readReq)
+	contents, err := handler(ctx, readReq)
 	if err != nil {
 		return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil), nil
 	}
@@ -322,7 +547,11 @@ func (m *resourceManager) handleReadResource(ctx context.Context, req *JSONRPCRe
 
 // handleListTemplates handles listing templates requests
 func (m *resourceManager) handleListTemplates(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
-	templates := m.getTemplates()
+	templates, nextCursor, err := paginate(m.paginator, cursorListResourceTemplates, m.getTemplates(), templateKey,
+		cursorFromRequest(req))
+	if err != nil {
+		return invalidCursorResponse(req, err), nil
+	}
 
 	// Convert []*mcp.ResourceTemplate to []mcp.ResourceTemplate for the result
 	resultTemplates := make([]ResourceTemplate, len(templates))
@@ -330,16 +559,16 @@ func (m *resourceManager) handleListTemplates(ctx context.Context, req *JSONRPCR
 		resultTemplates[i] = *template
 	}
 
-	// Use map structure since ListResourceTemplatesResult might not be defined
-	result := map[string]interface{}{
-		"resourceTemplates": resultTemplates,
+	result := ListResourceTemplatesResult{
+		ResourceTemplates: resultTemplates,
 	}
+	result.NextCursor = nextCursor
 
 	return result, nil
 }
 
 // handleSubscribe handles subscription requests
-func (m *resourceManager) handleSubscribe(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
+func (m *resourceManager) handleSubscribe(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
 	// Convert params to map for easier access
 	paramsMap, ok := req.Params.(map[string]interface{})
 	if !ok {
@@ -352,14 +581,19 @@ func (m *resourceManager) handleSubscribe(ctx context.Context, req *JSONRPCReque
 		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil), nil
 	}
 
+	// Updates are delivered to the session, so there must be one
+	if session == nil {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidRequest, errors.ErrSubscriptionRequiresSession.Error(), nil), nil
+	}
+
 	// Check if resource exists
-	_, exists := m.getResource(uri)
+	_, exists := m.findResource(session, uri)
 	if !exists {
 		return newJSONRPCErrorResponse(req.ID, ErrCodeMethodNotFound, fmt.Sprintf("resource %s not found", uri), nil), nil
 	}
 
 	// subscribe to resource updates
-	_ = m.subscribe(uri) // We're not using the channel directly in the response
+	m.subscribe(uri, session)
 
 	// Return success response
 	result := map[string]interface{}{
@@ -371,7 +605,7 @@ func (m *resourceManager) handleSubscribe(ctx context.Context, req *JSONRPCReque
 }
 
 // handleUnsubscribe handles unsubscription requests
-func (m *resourceManager) handleUnsubscribe(ctx context.Context, req *JSONRPCRequest) (JSONRPCMessage, error) {
+func (m *resourceManager) handleUnsubscribe(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
 	// Convert params to map for easier access
 	paramsMap, ok := req.Params.(map[string]interface{})
 	if !ok {
@@ -385,8 +619,9 @@ func (m *resourceManager) handleUnsubscribe(ctx context.Context, req *JSONRPCReq
 	}
 
 	// unsubscribe from resource updates
-	// Note: In real implementation, you need to locate the specific channel to unsubscribe
-	// This is just a simplified implementation
+	if session != nil {
+		m.unsubscribe(uri, session.GetID())
+	}
 
 	// Return success response
 	result := map[string]interface{}{
@@ -9,6 +9,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	stderrors "errors"
@@ -45,12 +46,28 @@ type toolManager struct {
 
 	// Method name modifier for external customization.
 	methodNameModifier MethodNameModifier
+
+	// Paginator for tools/list, nil if pagination is disabled.
+	paginator *paginator
+
+	// Tools registered for individual sessions.
+	sessionTools sessionItems[*registeredTool]
+
+	// Validation of tools/call arguments against the input schema.
+	inputValidation ToolInputValidation
+
+	// Validation of structured content against the output schema.
+	outputValidation ToolOutputValidation
+
+	// Logger reporting invalid structured content.
+	logger Logger
 }
 
 // newToolManager creates a tool manager
 func newToolManager() *toolManager {
 	return &toolManager{
-		tools: make(map[string]*registeredTool),
+		tools:  make(map[string]*registeredTool),
+		logger: GetDefaultLogger(),
 	}
 }
 
@@ -66,12 +83,38 @@ func (m *toolManager) withToolListFilter(filter ToolListFilter) *toolManager {
 	return m
 }
 
+// withInputValidation sets how tools/call arguments are validated.
+func (m *toolManager) withInputValidation(validation ToolInputValidation) *toolManager {
+	m.inputValidation = validation
+	return m
+}
+
+// withOutputValidation sets how the structured content of tool results is validated.
+func (m *toolManager) withOutputValidation(validation ToolOutputValidation) *toolManager {
+	m.outputValidation = validation
+	return m
+}
+
+// withLogger sets the logger.
+func (m *toolManager) withLogger(logger Logger) *toolManager {
+	if logger != nil {
+		m.logger = logger
+	}
+	return m
+}
+
 // withMethodNameModifier sets the method name modifier.
 func (m *toolManager) withMethodNameModifier(modifier MethodNameModifier) *toolManager {
 	m.methodNameModifier = modifier
 	return m
 }
 
+// withPaginator sets the paginator used for tools/list.
+func (m *toolManager) withPaginator(p *paginator) *toolManager {
+	m.paginator = p
+	return m
+}
+
 // registerTool registers a tool
 func (m *toolManager) registerTool(tool *Tool, handler toolHandler) {
 	m.mu.Lock()
@@ -92,6 +135,60 @@ func (m *toolManager) registerTool(tool *Tool, handler toolHandler) {
 	}
 }
 
+// registerSessionTool registers a tool for a single session. It replaces the
+// tool with the same name registered for all sessions.
+func (m *toolManager) registerSessionTool(sessionID string, tool *Tool, handler toolHandler) {
+	if tool == nil || tool.Name == "" {
+		return
+	}
+	m.sessionTools.add(sessionID, tool.Name, &registeredTool{
+		Tool:    tool,
+		Handler: handler,
+	})
+}
+
+// unregisterSessionTools removes tools registered for a session and returns
+// the count of unregistered tools.
+func (m *toolManager) unregisterSessionTools(sessionID string, names ...string) int {
+	return m.sessionTools.remove(sessionID, names...)
+}
+
+// findTool returns the tool a session calls by name, looking up the tools of
+// the session first.
+func (m *toolManager) findTool(session Session, name string) (*registeredTool, bool) {
+	if registeredTool, ok := m.sessionTools.get(sessionIDOf(session), name); ok {
+		return registeredTool, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	registeredTool, ok := m.tools[name]
+	return registeredTool, ok
+}
+
+// getSessionTools gets the tools registered for all sessions merged with the
+// tools of the session.
+func (m *toolManager) getSessionTools(session Session) []*Tool {
+	var sessionTools []*Tool
+	for _, registeredTool := range m.sessionTools.list(sessionIDOf(session)) {
+		sessionTools = append(sessionTools, registeredTool.Tool)
+	}
+	return mergeSessionItems(m.getTools(), sessionTools, toolKey)
+}
+
+// toolVisible reports whether the tool list filter keeps the tool, so that a
+// tool hidden from the list cannot be called either.
+func (m *toolManager) toolVisible(ctx context.Context, tool *Tool) bool {
+	if m.toolListFilter == nil {
+		return true
+	}
+	for _, visible := range m.toolListFilter(ctx, []*Tool{tool}) {
+		if visible != nil && visible.Name == tool.Name {
+			return true
+		}
+	}
+	return false
+}
+
 // getTool retrieves a tool by name
 func (m *toolManager) getTool(name string) (*Tool, bool) {
 	m.mu.RLock()
@@ -156,20 +253,34 @@ func (m *toolManager) getTools() []*Tool {
 	return tools
 }
 
+// toolKey returns the key tools are paginated by.
+func toolKey(tool *Tool) string {
+	if tool == nil {
+		return ""
+	}
+	return tool.Name
+}
+
 // handleListTools handles tools/list requests
 func (m *toolManager) handleListTools(
 	ctx context.Context,
 	req *JSONRPCRequest,
 	session Session,
 ) (JSONRPCMessage, error) {
-	// Get all tools
-	toolPtrs := m.getTools()
+	// Get the tools the request is granted the scopes of
+	toolPtrs := filterToolsByScopes(ctx, m.getSessionTools(session))
 
 	// Apply filter if available.
 	if m.toolListFilter != nil {
 		toolPtrs = m.toolListFilter(ctx, toolPtrs)
 	}
 
+	// Select the requested page after filtering.
+	toolPtrs, nextCursor, err := paginate(m.paginator, cursorListTools, toolPtrs, toolKey, cursorFromRequest(req))
+	if err != nil {
+		return invalidCursorResponse(req, err), nil
+	}
+
 	// Convert []*mcp.Tool to []mcp.Tool
 	tools := make([]Tool, len(toolPtrs))
 	for i, toolPtr := range toolPtrs {
@@ -182,10 +293,26 @@ func (m *toolManager) handleListTools(
 	result := ListToolsResult{
 		Tools: tools,
 	}
+	result.NextCursor = nextCursor
 
 	return result, nil
 }
 
+// deniedToolScopes returns the scopes required by the tool of a tools/call
+// request if the token of ctx does not grant them all, nil otherwise.
+func (m *toolManager) deniedToolScopes(ctx context.Context, req *JSONRPCRequest, session Session) []string {
+	paramsMap, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	toolName, _ := paramsMap["name"].(string)
+	registeredTool, ok := m.findTool(session, toolName)
+	if !ok || !m.toolVisible(ctx, registeredTool.Tool) || toolScopesAllowed(ctx, registeredTool.Tool) {
+		return nil
+	}
+	return registeredTool.Tool.RequiredScopes
+}
+
 // handleCallTool handles tools/call requests
 func (m *toolManager) handleCallTool(
 	ctx context.Context,
@@ -209,12 +336,9 @@ func (m *toolManager) handleCallTool(
 		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, "missing tool name", nil), nil
 	}
 
-	// Get tool with proper locking.
-	m.mu.RLock()
-	registeredTool, ok := m.tools[toolName]
-	m.mu.RUnlock()
-
-	if !ok {
+	// Get the tool, which must also be visible to the request.
+	registeredTool, ok := m.findTool(session, toolName)
+	if !ok || !m.toolVisible(ctx, registeredTool.Tool) {
 		return newJSONRPCErrorResponse(
 			req.ID,
 			ErrCodeMethodNotFound,
@@ -223,6 +347,17 @@ func (m *toolManager) handleCallTool(
 		), nil
 	}
 
+	// Check the scopes granted to the request
+	if !toolScopesAllowed(ctx, registeredTool.Tool) {
+		return newJSONRPCErrorResponse(
+			req.ID,
+			ErrCodeInvalidRequest,
+			fmt.Sprintf("%v: tool %s requires scopes %s", ErrInsufficientScope, toolName,
+				strings.Join(registeredTool.Tool.RequiredScopes, " ")),
+			map[string]interface{}{"requiredScopes": registeredTool.Tool.RequiredScopes},
+		), nil
+	}
+
 	// Create tool call request
 	toolReq := &CallToolRequest{}
 	toolReq.Method = MethodToolsCall // Set method manually
@@ -242,14 +377,28 @@ func (m *toolManager) handleCallTool(
 		params.Arguments = argsMap
 	}
 
+	// Validate the arguments against the input schema of the tool
+	if m.inputValidation != ToolInputValidationDisabled {
+		if violations := validateToolArguments(registeredTool, params.Arguments); len(violations) > 0 {
+			if m.inputValidation == ToolInputValidationError {
+				errMsg := fmt.Sprintf("%v: invalid arguments for tool %s", mcpErrors.ErrInvalidParams, toolName)
+				return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errMsg,
+					&SchemaValidationError{Violations: violations}), nil
+			}
+			return newToolArgumentsErrorResult(toolName, violations), nil
+		}
+	}
+
 	toolReq.Params = params
 
 	// Progress notification token (if any)
 	if meta, ok := paramsMap["_meta"].(map[string]interface{}); ok {
-		if progressToken, exists := meta["progressToken"]; exists {
-			// Note: The current version of CallToolRequest doesn't fully implement Meta field
-			// Future implementation should add toolReq.Meta = ... code
-			_ = progressToken // Ignore progress token for now
+		if progressToken, exists := meta["progressToken"]; exists && progressToken != nil {
+			params.Meta = &struct {
+				ProgressToken ProgressToken `json:"progressToken,omitempty"`
+			}{ProgressToken: progressToken}
+			toolReq.Params = params
+			ctx = withProgress(ctx, progressToken)
 		}
 	}
 
@@ -276,6 +425,19 @@ func (m *toolManager) handleCallTool(
 		return normalizeToolExecutionErrorResult(result, err), nil
 	}
 
+	// Validate the structured content against the output schema of the tool
+	if m.outputValidation != ToolOutputValidationDisabled {
+		if violations := validateToolResult(registeredTool, result); len(violations) > 0 {
+			validationErr := &SchemaValidationError{Violations: violations}
+			if m.outputValidation == ToolOutputValidationStrict {
+				errMsg := fmt.Sprintf("structured content of tool %s does not conform to its output schema", toolName)
+				return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, errMsg, validationErr), nil
+			}
+			m.logger.Warnf("Structured content of tool %s does not conform to its output schema: %v",
+				toolName, validationErr)
+		}
+	}
+
 	return result, nil
 }
 
@@ -0,0 +1,196 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/errors"
+)
+
+// Reference types of CompleteReference.Type.
+const (
+	// RefTypePrompt references a prompt by name.
+	RefTypePrompt = "ref/prompt"
+	// RefTypeResource references a resource template by its URI template.
+	RefTypeResource = "ref/resource"
+)
+
+// maxCompletionValues is the maximum number of values in a completion result.
+const maxCompletionValues = 100
+
+// CompleteReference identifies the prompt or resource template being completed.
+type CompleteReference struct {
+	// Type is RefTypePrompt or RefTypeResource.
+	Type string `json:"type"`
+
+	// Name is the prompt name, for RefTypePrompt.
+	Name string `json:"name,omitempty"`
+
+	// URI is the URI template of the resource template, for RefTypeResource.
+	URI string `json:"uri,omitempty"`
+}
+
+// CompleteArgument is the argument being completed.
+type CompleteArgument struct {
+	// Name is the prompt argument name or URI template variable name.
+	Name string `json:"name"`
+
+	// Value is the partial value entered so far.
+	Value string `json:"value"`
+}
+
+// CompleteContext carries additional context for a completion (since 2025-06-18).
+type CompleteContext struct {
+	// Arguments holds the values of arguments that were already resolved.
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// CompleteParams describes the params of a completion/complete request.
+type CompleteParams struct {
+	Ref      CompleteReference `json:"ref"`
+	Argument CompleteArgument  `json:"argument"`
+	Context  *CompleteContext  `json:"context,omitempty"`
+}
+
+// CompleteRequest is a request from the client to complete an argument value.
+type CompleteRequest struct {
+	Request
+	Params CompleteParams `json:"params"`
+}
+
+// ContextArguments returns the already-resolved arguments sent with the request.
+func (r *CompleteRequest) ContextArguments() map[string]string {
+	if r.Params.Context == nil {
+		return nil
+	}
+	return r.Params.Context.Arguments
+}
+
+// Completion holds completion values.
+type Completion struct {
+	// Values are the completion values, at most 100 are returned.
+	Values []string `json:"values"`
+
+	// Total is the total number of available values, which may exceed len(Values).
+	Total int `json:"total,omitempty"`
+
+	// HasMore indicates that more values are available than returned.
+	HasMore bool `json:"hasMore,omitempty"`
+}
+
+// CompleteResult is the server's response to a completion/complete request.
+type CompleteResult struct {
+	Result
+	Completion Completion `json:"completion"`
+}
+
+// CompletionProvider provides completion values for a prompt argument or a
+// resource template URI variable.
+type CompletionProvider interface {
+	// Complete returns the values matching the partial value of req.Params.Argument.
+	Complete(ctx context.Context, req *CompleteRequest) (*Completion, error)
+}
+
+// CompletionProviderFunc is a function adapter for CompletionProvider.
+type CompletionProviderFunc func(ctx context.Context, req *CompleteRequest) (*Completion, error)
+
+// Complete implements CompletionProvider.
+func (f CompletionProviderFunc) Complete(ctx context.Context, req *CompleteRequest) (*Completion, error) {
+	return f(ctx, req)
+}
+
+// handleCompletionComplete handles completion/complete requests by routing them
+// to the provider registered for the referenced prompt argument or resource
+// template variable. Arguments without a provider complete to no values.
+func handleCompletionComplete(
+	ctx context.Context,
+	req *JSONRPCRequest,
+	prompts *promptManager,
+	resources *resourceManager,
+) (JSONRPCMessage, error) {
+	completeReq, errResp := parseCompleteRequest(req)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	var provider CompletionProvider
+	ref := completeReq.Params.Ref
+	switch ref.Type {
+	case RefTypePrompt:
+		if _, exists := prompts.getPrompt(ref.Name); !exists {
+			return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams,
+				fmt.Sprintf("%v: %s", errors.ErrPromptNotFound, ref.Name), nil), nil
+		}
+		provider = prompts.getCompletionProvider(ref.Name, completeReq.Params.Argument.Name)
+	case RefTypeResource:
+		if !resources.hasTemplate(ref.URI) {
+			return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams,
+				fmt.Sprintf("%v: %s", errors.ErrResourceNotFound, ref.URI), nil), nil
+		}
+		provider = resources.getCompletionProvider(ref.URI, completeReq.Params.Argument.Name)
+	}
+
+	completion := &Completion{}
+	if provider != nil {
+		var err error
+		completion, err = provider.Complete(ctx, completeReq)
+		if err != nil {
+			return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil), nil
+		}
+	}
+	return &CompleteResult{Completion: limitCompletion(completion)}, nil
+}
+
+// parseCompleteRequest parses and validates the params of a completion/complete request.
+func parseCompleteRequest(req *JSONRPCRequest) (*CompleteRequest, JSONRPCMessage) {
+	if req.Params == nil {
+		return nil, newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil)
+	}
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrInvalidParams.Error(), nil)
+	}
+	completeReq := &CompleteRequest{Request: Request{Method: MethodCompletionComplete}}
+	if err := json.Unmarshal(data, &completeReq.Params); err != nil {
+		return nil, newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrInvalidParams.Error(), nil)
+	}
+
+	params := completeReq.Params
+	switch {
+	case params.Ref.Type == RefTypePrompt && params.Ref.Name == "",
+		params.Ref.Type == RefTypeResource && params.Ref.URI == "",
+		params.Argument.Name == "":
+		return nil, newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, errors.ErrMissingParams.Error(), nil)
+	case params.Ref.Type != RefTypePrompt && params.Ref.Type != RefTypeResource:
+		return nil, newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams,
+			fmt.Sprintf("%v: unknown reference type %q", errors.ErrInvalidParams, params.Ref.Type), nil)
+	}
+	return completeReq, nil
+}
+
+// limitCompletion caps the number of values to the maximum allowed by the
+// specification, reporting the remaining ones through Total and HasMore.
+func limitCompletion(completion *Completion) Completion {
+	if completion == nil {
+		return Completion{Values: []string{}}
+	}
+	result := *completion
+	if result.Values == nil {
+		result.Values = []string{}
+	}
+	if len(result.Values) > maxCompletionValues {
+		if result.Total < len(result.Values) {
+			result.Total = len(result.Values)
+		}
+		result.Values = result.Values[:maxCompletionValues]
+		result.HasMore = true
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCompletionTestClient starts a server with completion providers and returns an initialized client.
+func newCompletionTestClient(t *testing.T) (*Client, *InitializeResult) {
+	t.Helper()
+	server := NewServer("Test-Server", "1.0.0", WithGetSSEEnabled(false))
+	server.RegisterPrompt(&Prompt{
+		Name:      "code_review",
+		Arguments: []PromptArgument{{Name: "language"}, {Name: "framework"}},
+	}, func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+		return &GetPromptResult{}, nil
+	})
+	server.RegisterResourceTemplate(NewResourceTemplate("file:///{dir}/{name}", "files"),
+		func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+			return nil, nil
+		})
+
+	languages := []string{"go", "python", "javascript"}
+	server.RegisterPromptCompletion("code_review", "language", CompletionProviderFunc(
+		func(ctx context.Context, req *CompleteRequest) (*Completion, error) {
+			var values []string
+			for _, language := range languages {
+				if strings.HasPrefix(language, req.Params.Argument.Value) {
+					values = append(values, language)
+				}
+			}
+			return &Completion{Values: values, Total: len(values)}, nil
+		}))
+	server.RegisterResourceTemplateCompletion("file:///{dir}/{name}", "name", CompletionProviderFunc(
+		func(ctx context.Context, req *CompleteRequest) (*Completion, error) {
+			dir := req.ContextArguments()["dir"]
+			return &Completion{Values: []string{dir + "-a.txt", dir + "-b.txt"}}, nil
+		}))
+
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	result, err := client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	return client, result
+}
+
+func TestCompletion_PromptArgument(t *testing.T) {
+	client, initResult := newCompletionTestClient(t)
+	assert.NotNil(t, initResult.Capabilities.Completions)
+
+	req := &CompleteRequest{}
+	req.Params.Ref = CompleteReference{Type: RefTypePrompt, Name: "code_review"}
+	req.Params.Argument = CompleteArgument{Name: "language", Value: "py"}
+	result, err := client.Complete(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"python"}, result.Completion.Values)
+	assert.Equal(t, 1, result.Completion.Total)
+
+	// Arguments without a provider complete to no values.
+	req.Params.Argument = CompleteArgument{Name: "framework", Value: "d"}
+	result, err = client.Complete(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, result.Completion.Values)
+
+	req.Params.Ref.Name = "missing"
+	_, err = client.Complete(context.Background(), req)
+	assert.ErrorContains(t, err, "prompt not found")
+}
+
+func TestCompletion_ResourceTemplateVariable(t *testing.T) {
+	client, _ := newCompletionTestClient(t)
+
+	req := &CompleteRequest{}
+	req.Params.Ref = CompleteReference{Type: RefTypeResource, URI: "file:///{dir}/{name}"}
+	req.Params.Argument = CompleteArgument{Name: "name", Value: ""}
+	req.Params.Context = &CompleteContext{Arguments: map[string]string{"dir": "docs"}}
+	result, err := client.Complete(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs-a.txt", "docs-b.txt"}, result.Completion.Values)
+
+	req.Params.Ref.URI = "file:///unknown/{name}"
+	_, err = client.Complete(context.Background(), req)
+	assert.ErrorContains(t, err, "resource not found")
+}
+
+func TestCompletion_CapabilityOnlyWithProviders(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithGetSSEEnabled(false))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	result, err := client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, result.Capabilities.Completions)
+}
+
+func TestLimitCompletion(t *testing.T) {
+	values := make([]string, 150)
+	for i := range values {
+		values[i] = fmt.Sprintf("v%d", i)
+	}
+	completion := limitCompletion(&Completion{Values: values})
+	assert.Len(t, completion.Values, maxCompletionValues)
+	assert.Equal(t, 150, completion.Total)
+	assert.True(t, completion.HasMore)
+
+	assert.Equal(t, []string{}, limitCompletion(nil).Values)
+}
@@ -0,0 +1,301 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrElicitationNotSupported is returned when the client did not declare the elicitation capability.
+var ErrElicitationNotSupported = errors.New("client does not support elicitation")
+
+// Actions of ElicitResult.Action.
+const (
+	// ElicitActionAccept means the user submitted the requested data.
+	ElicitActionAccept = "accept"
+	// ElicitActionDecline means the user explicitly declined the request.
+	ElicitActionDecline = "decline"
+	// ElicitActionCancel means the user dismissed the request without choosing.
+	ElicitActionCancel = "cancel"
+)
+
+// PrimitiveSchemaDefinition describes a single requested field. Only primitive
+// types are allowed: string (optionally with enum or format), number, integer
+// and boolean.
+type PrimitiveSchemaDefinition struct {
+	Type        string `json:"type"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// String constraints.
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	EnumNames []string `json:"enumNames,omitempty"`
+
+	// Number and integer constraints.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Default value, allowed for booleans.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// ElicitationSchema is the restricted JSON schema of the requested data: a flat
+// object whose properties are primitive values.
+type ElicitationSchema struct {
+	Type       string                               `json:"type"`
+	Properties map[string]PrimitiveSchemaDefinition `json:"properties"`
+	Required   []string                             `json:"required,omitempty"`
+}
+
+// ElicitParams describes the params of an elicitation/create request.
+type ElicitParams struct {
+	// Message is presented to the user to explain what is requested.
+	Message string `json:"message"`
+
+	// RequestedSchema describes the requested fields.
+	RequestedSchema ElicitationSchema `json:"requestedSchema"`
+}
+
+// ElicitRequest is a request from the server to ask the user for input via the client.
+type ElicitRequest struct {
+	Request
+	Params ElicitParams `json:"params"`
+}
+
+// ElicitResult is the client's response to an elicitation/create request.
+type ElicitResult struct {
+	Result
+
+	// Action is one of ElicitActionAccept, ElicitActionDecline or ElicitActionCancel.
+	Action string `json:"action"`
+
+	// Content holds the submitted data. It is only present when Action is accept.
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// ElicitationHandler answers elicitation/create requests from the server,
+// typically by prompting the user.
+type ElicitationHandler interface {
+	// Elicit asks the user for the requested data.
+	Elicit(ctx context.Context, req *ElicitRequest) (*ElicitResult, error)
+}
+
+// ElicitationHandlerFunc adapts an ordinary function to an ElicitationHandler.
+type ElicitationHandlerFunc func(ctx context.Context, req *ElicitRequest) (*ElicitResult, error)
+
+// Elicit implements ElicitationHandler.
+func (f ElicitationHandlerFunc) Elicit(ctx context.Context, req *ElicitRequest) (*ElicitResult, error) {
+	return f(ctx, req)
+}
+
+// sessionRequestSender is implemented by servers that can send requests to a client session.
+type sessionRequestSender interface {
+	sendRequestToSession(ctx context.Context, session Session, request *JSONRPCRequest) (*json.RawMessage, error)
+}
+
+// Elicit asks the user of the calling session for input and blocks until the
+// user accepts, declines or cancels, or ctx is done. It must be called with the
+// context of a request being handled, e.g. from a tool handler, and the request
+// is sent on the stream answering that request. It fails with
+// ErrElicitationNotSupported if the session negotiated a protocol version older
+// than 2025-06-18 or the client did not declare the elicitation capability.
+//
+// Example:
+//
+//	result, err := mcp.Elicit(ctx, &mcp.ElicitRequest{Params: mcp.ElicitParams{
+//	    Message: "Delete all files?",
+//	    RequestedSchema: mcp.ElicitationSchema{
+//	        Type: "object",
+//	        Properties: map[string]mcp.PrimitiveSchemaDefinition{
+//	            "confirm": {Type: "boolean"},
+//	        },
+//	    },
+//	}})
+func Elicit(ctx context.Context, req *ElicitRequest) (*ElicitResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("elicit request is nil")
+	}
+	session := ClientSessionFromContext(ctx)
+	if session == nil {
+		return nil, ErrNoClientSession
+	}
+	sender, ok := GetServerFromContext(ctx).(sessionRequestSender)
+	if !ok {
+		return nil, fmt.Errorf("no server in context")
+	}
+	// Elicitation was introduced in 2025-06-18.
+	if version := protocolVersionFromSession(session); !protocolVersionAtLeast(version, ProtocolVersion_2025_06_18) {
+		return nil, fmt.Errorf("%w: protocol version %s", ErrElicitationNotSupported, version)
+	}
+	if capabilities, ok := clientCapabilitiesFromSession(session); !ok || capabilities.Elicitation == nil {
+		return nil, ErrElicitationNotSupported
+	}
+	if err := req.Params.RequestedSchema.validate(); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+
+	request := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		Request: Request{
+			Method: MethodElicitationCreate,
+		},
+		Params: req.Params,
+	}
+	response, err := sender.sendRequestToSession(ctx, session, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send elicitation/create request: %w", err)
+	}
+
+	if isErrorResponse(response) {
+		errResp, err := parseRawMessageToError(response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return nil, fmt.Errorf("elicitation error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+	}
+
+	var result ElicitResult
+	if err := json.Unmarshal(*response, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ElicitResult: %w", err)
+	}
+	if err := result.validate(req.Params.RequestedSchema); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// validate checks that the schema only uses the restricted subset of JSON schema
+// allowed for elicitation.
+func (s ElicitationSchema) validate() error {
+	if s.Type != "object" {
+		return fmt.Errorf("schema type must be object, got %q", s.Type)
+	}
+	for name, property := range s.Properties {
+		if err := property.validate(); err != nil {
+			return fmt.Errorf("property %q: %w", name, err)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("required property %q is not defined", name)
+		}
+	}
+	return nil
+}
+
+// validate checks that the definition describes a primitive value.
+func (d PrimitiveSchemaDefinition) validate() error {
+	switch d.Type {
+	case "string":
+		switch d.Format {
+		case "", "email", "uri", "date", "date-time":
+		default:
+			return fmt.Errorf("unsupported string format %q", d.Format)
+		}
+		if len(d.EnumNames) > 0 && len(d.EnumNames) != len(d.Enum) {
+			return fmt.Errorf("enumNames must match enum")
+		}
+	case "number", "integer", "boolean":
+		if len(d.Enum) > 0 {
+			return fmt.Errorf("enum is only allowed for strings")
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", d.Type)
+	}
+	return nil
+}
+
+// validate checks the action of the result and, on accept, that the content
+// matches the requested schema.
+func (r *ElicitResult) validate(schema ElicitationSchema) error {
+	switch r.Action {
+	case ElicitActionAccept:
+	case ElicitActionDecline, ElicitActionCancel:
+		return nil
+	default:
+		return fmt.Errorf("invalid elicitation action %q", r.Action)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := r.Content[name]; !ok {
+			return fmt.Errorf("elicitation content is missing required field %q", name)
+		}
+	}
+	for name, value := range r.Content {
+		property, ok := schema.Properties[name]
+		if !ok {
+			continue
+		}
+		if !property.matches(value) {
+			return fmt.Errorf("elicitation content field %q does not match schema type %s", name, property.Type)
+		}
+	}
+	return nil
+}
+
+// matches reports whether value is a valid instance of the definition.
+func (d PrimitiveSchemaDefinition) matches(value interface{}) bool {
+	switch d.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if len(d.Enum) == 0 {
+			return true
+		}
+		for _, e := range d.Enum {
+			if e == s {
+				return true
+			}
+		}
+		return false
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+// handleElicitRequest answers an elicitation/create request with handler and
+// returns the JSON-RPC response or error to send back.
+func handleElicitRequest(ctx context.Context, handler ElicitationHandler, request *JSONRPCRequest) JSONRPCMessage {
+	if handler == nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeMethodNotFound, "elicitation not supported", nil)
+	}
+
+	req := &ElicitRequest{}
+	req.Method = MethodElicitationCreate
+	paramsBytes, err := json.Marshal(request.Params)
+	if err == nil {
+		err = json.Unmarshal(paramsBytes, &req.Params)
+	}
+	if err != nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInvalidParams, err.Error(), nil)
+	}
+
+	result, err := handler.Elicit(ctx, req)
+	if err != nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInternal, err.Error(), nil)
+	}
+	if result == nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInternal, "elicitation handler returned no result", nil)
+	}
+	return newJSONRPCResponse(request.ID, result)
+}
@@ -0,0 +1,259 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// confirmTool asks the user for a name and a confirmation.
+func confirmTool(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+	result, err := Elicit(ctx, &ElicitRequest{Params: ElicitParams{
+		Message: "Who are you?",
+		RequestedSchema: ElicitationSchema{
+			Type: "object",
+			Properties: map[string]PrimitiveSchemaDefinition{
+				"name":    {Type: "string"},
+				"confirm": {Type: "boolean"},
+			},
+			Required: []string{"name"},
+		},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if result.Action != ElicitActionAccept {
+		return NewTextResult(result.Action), nil
+	}
+	return NewTextResult(fmt.Sprintf("%s:%v", result.Content["name"], result.Content["confirm"])), nil
+}
+
+// acceptingElicitationHandler accepts every request with fixed content.
+var acceptingElicitationHandler = ElicitationHandlerFunc(
+	func(ctx context.Context, req *ElicitRequest) (*ElicitResult, error) {
+		if req.Params.Message != "Who are you?" {
+			return nil, fmt.Errorf("unexpected message %q", req.Params.Message)
+		}
+		return &ElicitResult{
+			Action:  ElicitActionAccept,
+			Content: map[string]interface{}{"name": "alice", "confirm": true},
+		}, nil
+	})
+
+func callConfirmTool(t *testing.T, client Connector) *CallToolResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &CallToolRequest{}
+	req.Params.Name = "confirm"
+	result, err := client.CallTool(ctx, req)
+	require.NoError(t, err)
+	return result
+}
+
+func TestElicit_Server(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("confirm"), confirmTool)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	// The elicitation is sent on the stream answering the tool call, so no GET
+	// SSE stream is needed.
+	newInitializedClient := func(handler ElicitationHandler, options ...ClientOption) *Client {
+		options = append([]ClientOption{WithClientGetSSEEnabled(false)}, options...)
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"}, options...)
+		require.NoError(t, err)
+		if handler != nil {
+			client.SetElicitationHandler(handler)
+		}
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("accept", func(t *testing.T) {
+		client := newInitializedClient(acceptingElicitationHandler)
+		defer client.Close()
+
+		result := callConfirmTool(t, client)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "alice:true", result.Content[0].(TextContent).Text)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		client := newInitializedClient(ElicitationHandlerFunc(
+			func(ctx context.Context, req *ElicitRequest) (*ElicitResult, error) {
+				return &ElicitResult{Action: ElicitActionDecline}, nil
+			}))
+		defer client.Close()
+
+		result := callConfirmTool(t, client)
+		assert.Equal(t, ElicitActionDecline, result.Content[0].(TextContent).Text)
+	})
+
+	t.Run("without capability", func(t *testing.T) {
+		client := newInitializedClient(nil)
+		defer client.Close()
+
+		result := callConfirmTool(t, client)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(TextContent).Text, ErrElicitationNotSupported.Error())
+	})
+
+	t.Run("older protocol version", func(t *testing.T) {
+		client := newInitializedClient(acceptingElicitationHandler, WithProtocolVersion(ProtocolVersion_2025_03_26))
+		defer client.Close()
+
+		result := callConfirmTool(t, client)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(TextContent).Text, ErrElicitationNotSupported.Error())
+	})
+}
+
+func TestElicit_SSEServer(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("confirm"), confirmTool)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// Elicitation needs a protocol version newer than the default one of the
+	// SSE client.
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithProtocolVersion(ProtocolVersion_2025_06_18))
+	require.NoError(t, err)
+	defer client.Close()
+	client.SetElicitationHandler(acceptingElicitationHandler)
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	result := callConfirmTool(t, client)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "alice:true", result.Content[0].(TextContent).Text)
+}
+
+func TestElicit_StdioServer(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("confirm"), confirmTool)
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := newStdioTransport(server.internal)
+	go func() {
+		_ = transport.listen(ctx, stdinReader, stdoutWriter)
+	}()
+	go func() {
+		// The test plays the client: it initializes, sends the tool call and
+		// answers elicitation below.
+		_, _ = stdinWriter.Write([]byte(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{` +
+			`"protocolVersion":"2025-06-18","capabilities":{"elicitation":{}},` +
+			`"clientInfo":{"name":"Test-Client","version":"1.0.0"}}}` + "\n"))
+		_, _ = stdinWriter.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"confirm"}}` + "\n"))
+	}()
+
+	scanner := bufio.NewScanner(stdoutReader)
+	for scanner.Scan() {
+		var msg struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+			Result *json.RawMessage       `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+
+		if msg.Method == MethodElicitationCreate {
+			request := &JSONRPCRequest{ID: msg.ID, Params: msg.Params}
+			response, err := json.Marshal(handleElicitRequest(ctx, acceptingElicitationHandler, request))
+			require.NoError(t, err)
+			_, err = stdinWriter.Write(append(response, '\n'))
+			require.NoError(t, err)
+			continue
+		}
+
+		if msg.ID == float64(0) {
+			// The initialize response.
+			continue
+		}
+		require.NotNil(t, msg.Result)
+		result, err := parseCallToolResult(msg.Result)
+		require.NoError(t, err)
+		assert.Equal(t, "alice:true", result.Content[0].(TextContent).Text)
+		return
+	}
+	t.Fatal("no tool response received")
+}
+
+func TestElicitationSchema_Validate(t *testing.T) {
+	valid := ElicitationSchema{
+		Type: "object",
+		Properties: map[string]PrimitiveSchemaDefinition{
+			"email": {Type: "string", Format: "email"},
+			"color": {Type: "string", Enum: []string{"red", "green"}, EnumNames: []string{"Red", "Green"}},
+			"age":   {Type: "integer"},
+			"ok":    {Type: "boolean", Default: true},
+		},
+		Required: []string{"email"},
+	}
+	assert.NoError(t, valid.validate())
+
+	tests := map[string]ElicitationSchema{
+		"non-object":      {Type: "string"},
+		"nested object":   {Type: "object", Properties: map[string]PrimitiveSchemaDefinition{"a": {Type: "object"}}},
+		"array":           {Type: "object", Properties: map[string]PrimitiveSchemaDefinition{"a": {Type: "array"}}},
+		"bad format":      {Type: "object", Properties: map[string]PrimitiveSchemaDefinition{"a": {Type: "string", Format: "ipv4"}}},
+		"numeric enum":    {Type: "object", Properties: map[string]PrimitiveSchemaDefinition{"a": {Type: "number", Enum: []string{"1"}}}},
+		"undefined field": {Type: "object", Required: []string{"a"}},
+	}
+	for name, schema := range tests {
+		assert.Error(t, schema.validate(), name)
+	}
+}
+
+func TestElicitResult_Validate(t *testing.T) {
+	schema := ElicitationSchema{
+		Type: "object",
+		Properties: map[string]PrimitiveSchemaDefinition{
+			"color": {Type: "string", Enum: []string{"red", "green"}},
+			"count": {Type: "integer"},
+		},
+		Required: []string{"color"},
+	}
+
+	assert.NoError(t, (&ElicitResult{Action: ElicitActionCancel}).validate(schema))
+	assert.NoError(t, (&ElicitResult{
+		Action:  ElicitActionAccept,
+		Content: map[string]interface{}{"color": "red", "count": float64(2)},
+	}).validate(schema))
+
+	assert.Error(t, (&ElicitResult{Action: "maybe"}).validate(schema))
+	assert.Error(t, (&ElicitResult{Action: ElicitActionAccept}).validate(schema), "missing required field")
+	assert.Error(t, (&ElicitResult{
+		Action:  ElicitActionAccept,
+		Content: map[string]interface{}{"color": "blue"},
+	}).validate(schema), "value not in enum")
+	assert.Error(t, (&ElicitResult{
+		Action:  ElicitActionAccept,
+		Content: map[string]interface{}{"color": "red", "count": 1.5},
+	}).validate(schema), "non-integer number")
+}
+
+func TestElicit_NoSession(t *testing.T) {
+	_, err := Elicit(context.Background(), &ElicitRequest{})
+	assert.ErrorIs(t, err, ErrNoClientSession)
+}
@@ -0,0 +1,232 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	mcpErrors "trpc.group/trpc-go/trpc-mcp-go/internal/errors"
+)
+
+// LoggingLevel is the severity of a log message, following the syslog
+// severities of RFC 5424.
+type LoggingLevel string
+
+// Logging levels, from the least to the most severe.
+const (
+	LoggingLevelDebug     LoggingLevel = "debug"
+	LoggingLevelInfo      LoggingLevel = "info"
+	LoggingLevelNotice    LoggingLevel = "notice"
+	LoggingLevelWarning   LoggingLevel = "warning"
+	LoggingLevelError     LoggingLevel = "error"
+	LoggingLevelCritical  LoggingLevel = "critical"
+	LoggingLevelAlert     LoggingLevel = "alert"
+	LoggingLevelEmergency LoggingLevel = "emergency"
+)
+
+// logLevelKey is the session data key of the minimum level set by the client.
+const logLevelKey = "logLevel"
+
+// ErrNoNotificationSender is returned when a log message is sent outside of a request
+// whose transport can deliver notifications.
+var ErrNoNotificationSender = errors.New("no notification sender in context")
+
+// loggingLevelSeverity orders the logging levels.
+var loggingLevelSeverity = map[LoggingLevel]int{
+	LoggingLevelDebug:     0,
+	LoggingLevelInfo:      1,
+	LoggingLevelNotice:    2,
+	LoggingLevelWarning:   3,
+	LoggingLevelError:     4,
+	LoggingLevelCritical:  5,
+	LoggingLevelAlert:     6,
+	LoggingLevelEmergency: 7,
+}
+
+// IsValid reports whether the level is one of the defined logging levels.
+func (l LoggingLevel) IsValid() bool {
+	_, ok := loggingLevelSeverity[l]
+	return ok
+}
+
+// AtLeast reports whether the level is as severe as or more severe than minimum.
+func (l LoggingLevel) AtLeast(minimum LoggingLevel) bool {
+	return loggingLevelSeverity[l] >= loggingLevelSeverity[minimum]
+}
+
+// SetLevelRequest is a request from the client to set the minimum level of
+// log messages sent to it.
+type SetLevelRequest struct {
+	Request
+	Params struct {
+		Level LoggingLevel `json:"level"`
+	} `json:"params"`
+}
+
+// LoggingMessageParams describes the params of a notifications/message notification.
+type LoggingMessageParams struct {
+	// Level is the severity of the message.
+	Level LoggingLevel `json:"level"`
+
+	// Logger is the optional name of the logger that issued the message.
+	Logger string `json:"logger,omitempty"`
+
+	// Data is the message, any JSON serializable value.
+	Data interface{} `json:"data"`
+}
+
+// LogMessageHandler receives log messages sent by the server.
+type LogMessageHandler func(params *LoggingMessageParams)
+
+// handleSetLevel handles logging/setLevel requests by storing the level on the session.
+func handleSetLevel(ctx context.Context, req *JSONRPCRequest, session Session) (JSONRPCMessage, error) {
+	paramsMap, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, mcpErrors.ErrInvalidParams.Error(), nil), nil
+	}
+	levelValue, ok := paramsMap["level"].(string)
+	if !ok {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, mcpErrors.ErrMissingParams.Error(), nil), nil
+	}
+	level := LoggingLevel(levelValue)
+	if !level.IsValid() {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams,
+			fmt.Sprintf("%v: unknown logging level %q", mcpErrors.ErrInvalidParams, levelValue), nil), nil
+	}
+	if session != nil {
+		session.SetData(logLevelKey, level)
+	}
+	return map[string]interface{}{}, nil
+}
+
+// logLevelFromSession returns the minimum level set by the client of the session.
+func logLevelFromSession(session Session) (LoggingLevel, bool) {
+	if session == nil {
+		return "", false
+	}
+	value, ok := session.GetData(logLevelKey)
+	if !ok {
+		return "", false
+	}
+	level, ok := value.(LoggingLevel)
+	return level, ok
+}
+
+// ClientLogger sends log messages to the client of the request being handled
+// as notifications/message. Messages below the minimum level set by the client
+// with logging/setLevel are dropped; until the client sets a level, all
+// messages are sent.
+type ClientLogger struct {
+	ctx  context.Context
+	name string
+}
+
+// ClientLoggerFromContext returns a logger for the client of the request being
+// handled. It must be called with the context passed to a tool, resource or
+// prompt handler.
+//
+// Example:
+//
+//	logger := mcp.ClientLoggerFromContext(ctx).WithName("weather")
+//	logger.Logf(mcp.LoggingLevelInfo, "fetching forecast for %s", city)
+func ClientLoggerFromContext(ctx context.Context) *ClientLogger {
+	return &ClientLogger{ctx: ctx}
+}
+
+// WithName returns a copy of the logger that reports messages under the given logger name.
+func (l *ClientLogger) WithName(name string) *ClientLogger {
+	return &ClientLogger{ctx: l.ctx, name: name}
+}
+
+// Enabled reports whether messages of the given level are sent to the client.
+func (l *ClientLogger) Enabled(level LoggingLevel) bool {
+	minimum, ok := logLevelFromSession(l.session())
+	return !ok || level.AtLeast(minimum)
+}
+
+// Log sends data with the given level to the client. Data may be any JSON
+// serializable value. It returns nil without sending anything when the level
+// is below the client's threshold.
+func (l *ClientLogger) Log(level LoggingLevel, data interface{}) error {
+	if !level.IsValid() {
+		return fmt.Errorf("unknown logging level %q", level)
+	}
+	if !l.Enabled(level) {
+		return nil
+	}
+	sender, ok := GetNotificationSender(l.ctx)
+	if !ok {
+		return ErrNoNotificationSender
+	}
+	return sender.SendNotification(newLogMessageNotification(level, l.name, data))
+}
+
+// Logf formats a message and sends it with the given level to the client.
+func (l *ClientLogger) Logf(level LoggingLevel, format string, args ...interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	return l.Log(level, fmt.Sprintf(format, args...))
+}
+
+// session returns the session of the request the logger was created for.
+func (l *ClientLogger) session() Session {
+	if session := ClientSessionFromContext(l.ctx); session != nil {
+		return session
+	}
+	if session, ok := GetSessionFromContext(l.ctx); ok {
+		return session
+	}
+	return nil
+}
+
+// newLogMessageNotification builds a notifications/message notification.
+func newLogMessageNotification(level LoggingLevel, logger string, data interface{}) *Notification {
+	params := map[string]interface{}{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	return NewNotification(NotificationMethodMessage, params)
+}
+
+// requestLogHandlerKey is the context key of the handler of the log messages
+// the server sends while handling a request.
+type requestLogHandlerKey struct{}
+
+// withRequestLogHandler returns a context whose requests pass the log messages
+// the server sends while handling them to handler, rather than to the handler
+// registered for notifications/message. Only transports delivering these
+// messages along with the response, such as Streamable HTTP, can tell which
+// request they relate to.
+func withRequestLogHandler(ctx context.Context, handler NotificationHandler) context.Context {
+	return context.WithValue(ctx, requestLogHandlerKey{}, handler)
+}
+
+// requestLogHandlerFromContext returns the request log handler, if any.
+func requestLogHandlerFromContext(ctx context.Context) NotificationHandler {
+	handler, _ := ctx.Value(requestLogHandlerKey{}).(NotificationHandler)
+	return handler
+}
+
+// parseLoggingMessageParams extracts the params of a notifications/message notification.
+func parseLoggingMessageParams(notification *JSONRPCNotification) (*LoggingMessageParams, error) {
+	data, err := json.Marshal(notification.Params.AdditionalFields)
+	if err != nil {
+		return nil, err
+	}
+	var params LoggingMessageParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
@@ -0,0 +1,120 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingLevel_Ordering(t *testing.T) {
+	assert.True(t, LoggingLevelError.AtLeast(LoggingLevelWarning))
+	assert.True(t, LoggingLevelWarning.AtLeast(LoggingLevelWarning))
+	assert.False(t, LoggingLevelInfo.AtLeast(LoggingLevelWarning))
+	assert.True(t, LoggingLevelEmergency.AtLeast(LoggingLevelDebug))
+
+	assert.True(t, LoggingLevelNotice.IsValid())
+	assert.False(t, LoggingLevel("verbose").IsValid())
+}
+
+func TestHandleSetLevel(t *testing.T) {
+	session := newSession()
+	req := newJSONRPCRequest(1, MethodLoggingSetLevel, map[string]interface{}{"level": "error"})
+	result, err := handleSetLevel(context.Background(), req, session)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, result)
+	level, ok := logLevelFromSession(session)
+	require.True(t, ok)
+	assert.Equal(t, LoggingLevelError, level)
+
+	req = newJSONRPCRequest(2, MethodLoggingSetLevel, map[string]interface{}{"level": "verbose"})
+	result, err = handleSetLevel(context.Background(), req, session)
+	require.NoError(t, err)
+	errResp, ok := result.(*JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeInvalidParams, errResp.Error.Code)
+}
+
+func TestClientLogger_RespectsSessionLevel(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("log-tool"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		logger := ClientLoggerFromContext(ctx).WithName("log-tool")
+		if err := logger.Log(LoggingLevelDebug, "debug details"); err != nil {
+			return nil, err
+		}
+		if err := logger.Logf(LoggingLevelWarning, "disk at %d%%", 91); err != nil {
+			return nil, err
+		}
+		return NewTextResult("done"), nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+	initResult, err := client.Initialize(ctx, &InitializeRequest{})
+	require.NoError(t, err)
+	assert.NotNil(t, initResult.Capabilities.Logging)
+
+	var mu sync.Mutex
+	var messages []*LoggingMessageParams
+	client.SetLogMessageHandler(func(params *LoggingMessageParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, params)
+	})
+
+	callTool := func() {
+		req := &CallToolRequest{}
+		req.Params.Name = "log-tool"
+		_, err := client.CallTool(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// Without a level set, every message is sent.
+	callTool()
+	mu.Lock()
+	require.Len(t, messages, 2)
+	assert.Equal(t, LoggingLevelDebug, messages[0].Level)
+	assert.Equal(t, "log-tool", messages[0].Logger)
+	assert.Equal(t, "debug details", messages[0].Data)
+	messages = nil
+	mu.Unlock()
+
+	require.NoError(t, client.SetLogLevel(ctx, LoggingLevelWarning))
+	callTool()
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, messages, 1)
+	assert.Equal(t, LoggingLevelWarning, messages[0].Level)
+	assert.Equal(t, "disk at 91%", messages[0].Data)
+
+	assert.Error(t, client.SetLogLevel(ctx, LoggingLevel("verbose")))
+}
+
+func TestNotificationSender_SendLogMessageRespectsSessionLevel(t *testing.T) {
+	session := newSession()
+	var levels []interface{}
+	sender := newStreamNotificationSender(session, func(notification *JSONRPCNotification) error {
+		levels = append(levels, notification.Params.AdditionalFields["level"])
+		return nil
+	})
+
+	// Without a level set, every message is sent.
+	require.NoError(t, sender.SendLogMessage(string(LoggingLevelDebug), "debug details"))
+	session.SetData(logLevelKey, LoggingLevelWarning)
+	require.NoError(t, sender.SendLogMessage(string(LoggingLevelInfo), "dropped"))
+	require.NoError(t, sender.SendLogMessage(string(LoggingLevelError), "failed"))
+	assert.Equal(t, []interface{}{string(LoggingLevelDebug), string(LoggingLevelError)}, levels)
+}
@@ -11,6 +11,8 @@ package mcp
 type Implementation struct {
 	// Name of the implementation
 	Name string `json:"name"`
+	// Title is a human-readable name for display (since 2025-06-18)
+	Title string `json:"title,omitempty"`
 	// Version of the implementation
 	Version string `json:"version"`
 }
@@ -26,6 +28,10 @@ type ClientCapabilities struct {
 	// Corresponds to schema: "sampling": {"description": "Present if the client supports sampling from an LLM."}
 	Sampling *SamplingCapability `json:"sampling,omitempty"`
 
+	// Elicitation indicates whether the client supports elicitation requests from the server
+	// Corresponds to schema: "elicitation": {"description": "Present if the client supports elicitation from the server."}
+	Elicitation *ElicitationCapability `json:"elicitation,omitempty"`
+
 	// Experimental indicates non-standard experimental capabilities that the client supports
 	// Corresponds to schema: "experimental": {"description": "Experimental, non-standard capabilities
 	// that the client supports."}
@@ -78,6 +84,11 @@ type SamplingCapability struct {
 	// Corresponds to schema.json definition, currently has no specific fields
 }
 
+// ElicitationCapability describes client elicitation capabilities
+type ElicitationCapability struct {
+	// Corresponds to schema.json definition, currently has no specific fields
+}
+
 // PromptsCapability describes server prompt capabilities
 type PromptsCapability struct {
 	// ListChanged indicates whether the server supports notifications for changes to the prompt list
@@ -174,6 +185,7 @@ const (
 	// Base protocol
 	MethodInitialize               = "initialize"
 	MethodNotificationsInitialized = "notifications/initialized"
+	MethodNotificationsCancelled   = "notifications/cancelled"
 
 	// Tool related
 	MethodToolsList = "tools/list"
@@ -191,6 +203,12 @@ const (
 	MethodResourcesSubscribe     = "resources/subscribe"
 	MethodResourcesUnsubscribe   = "resources/unsubscribe"
 
+	// Sampling related
+	MethodSamplingCreateMessage = "sampling/createMessage"
+
+	// Elicitation related
+	MethodElicitationCreate = "elicitation/create"
+
 	// Roots related
 	MethodRootsList                     = "roots/list"
 	MethodNotificationsRootsListChanged = "notifications/roots/list_changed"
@@ -204,10 +222,12 @@ const (
 const (
 	ProtocolVersion_2024_11_05 = "2024-11-05"
 	ProtocolVersion_2025_03_26 = "2025-03-26"
+	ProtocolVersion_2025_06_18 = "2025-06-18"
 )
 
 // List of supported protocol versions, ordered by priority
 var SupportedProtocolVersions = []string{
+	ProtocolVersion_2025_06_18,
 	ProtocolVersion_2025_03_26,
 	ProtocolVersion_2024_11_05,
 }
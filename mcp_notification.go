@@ -17,6 +17,18 @@ const (
 
 	// NotificationMethodProgress for progress notification method
 	NotificationMethodProgress = "notifications/progress"
+
+	// NotificationMethodResourcesUpdated for resource update notification method
+	NotificationMethodResourcesUpdated = "notifications/resources/updated"
+
+	// NotificationMethodToolsListChanged for tool list change notification method
+	NotificationMethodToolsListChanged = "notifications/tools/list_changed"
+
+	// NotificationMethodPromptsListChanged for prompt list change notification method
+	NotificationMethodPromptsListChanged = "notifications/prompts/list_changed"
+
+	// NotificationMethodResourcesListChanged for resource list change notification method
+	NotificationMethodResourcesListChanged = "notifications/resources/list_changed"
 )
 
 // Context key type to avoid key collisions
@@ -64,6 +64,10 @@ type Prompt struct {
 	// Corresponds to schema: "name": {"description": "The name of the prompt or prompt template."}
 	Name string `json:"name"`
 
+	// Title is an optional human-readable title for display (since 2025-06-18)
+	// Corresponds to schema: "title": {"description": "Intended for UI and end-user contexts."}
+	Title string `json:"title,omitempty"`
+
 	// Description is an optional description of the prompt
 	// Corresponds to schema: "description": {"description": "An optional description of what this prompt provides"}
 	Description string `json:"description,omitempty"`
@@ -78,6 +82,9 @@ type PromptArgument struct {
 	// Parameter name
 	Name string `json:"name"`
 
+	// Human-readable title for display (optional, since 2025-06-18)
+	Title string `json:"title,omitempty"`
+
 	// Parameter description (optional)
 	Description string `json:"description,omitempty"`
 
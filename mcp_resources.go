@@ -44,6 +44,9 @@ type Resource struct {
 	// Resource name
 	Name string `json:"name"`
 
+	// Human-readable title for display (optional, since 2025-06-18)
+	Title string `json:"title,omitempty"`
+
 	// Resource URI
 	URI string `json:"uri"`
 
@@ -94,6 +97,17 @@ type ListResourcesResult struct {
 	Resources []Resource `json:"resources"`
 }
 
+// ListResourceTemplatesRequest describes a request to list resource templates.
+type ListResourceTemplatesRequest struct {
+	PaginatedRequest
+}
+
+// ListResourceTemplatesResult describes a result of listing resource templates.
+type ListResourceTemplatesResult struct {
+	PaginatedResult
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
 // ReadResourceRequest describes a request to read a resource.
 type ReadResourceRequest struct {
 	Request
@@ -117,6 +131,9 @@ type ResourceUpdatedNotification struct {
 	} `json:"params"`
 }
 
+// ResourceUpdatedHandler receives the updates of a subscribed resource.
+type ResourceUpdatedHandler func(notification *ResourceUpdatedNotification)
+
 // SubscribeRequest describes a request to subscribe to resource updates.
 type SubscribeRequest struct {
 	Request
@@ -138,6 +155,9 @@ type ResourceTemplate struct {
 	// Template name
 	Name string `json:"name"`
 
+	// Human-readable title for display (optional, since 2025-06-18)
+	Title string `json:"title,omitempty"`
+
 	// URI template
 	URITemplate *URITemplate `json:"uriTemplate"`
 
@@ -195,6 +215,13 @@ func WithTemplateDescription(description string) ResourceTemplateOption {
 	}
 }
 
+// WithTemplateTitle sets the human-readable title for the ResourceTemplate.
+func WithTemplateTitle(title string) ResourceTemplateOption {
+	return func(t *ResourceTemplate) {
+		t.Title = title
+	}
+}
+
 // WithTemplateMIMEType sets the MIME type for the ResourceTemplate.
 func WithTemplateMIMEType(mimeType string) ResourceTemplateOption {
 	return func(t *ResourceTemplate) {
@@ -0,0 +1,223 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSamplingNotSupported is returned when the client did not declare the sampling capability.
+var ErrSamplingNotSupported = errors.New("client does not support sampling")
+
+// Context inclusion values of CreateMessageParams.IncludeContext.
+const (
+	IncludeContextNone       = "none"
+	IncludeContextThisServer = "thisServer"
+	IncludeContextAllServers = "allServers"
+)
+
+// Stop reasons of CreateMessageResult.StopReason.
+const (
+	StopReasonEndTurn      = "endTurn"
+	StopReasonStopSequence = "stopSequence"
+	StopReasonMaxTokens    = "maxTokens"
+)
+
+// SamplingMessage describes a message issued to or received from an LLM API.
+// Content is one of TextContent, ImageContent or AudioContent.
+type SamplingMessage struct {
+	Role    Role    `json:"role"`
+	Content Content `json:"content"`
+}
+
+// UnmarshalJSON implements custom unmarshaling for SamplingMessage to handle polymorphic Content.
+func (m *SamplingMessage) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		Role    Role                   `json:"role"`
+		Content map[string]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return fmt.Errorf("failed to unmarshal sampling message: %w", err)
+	}
+
+	m.Role = temp.Role
+	m.Content = nil
+	if temp.Content != nil {
+		content, err := parseContent(temp.Content)
+		if err != nil {
+			return fmt.Errorf("failed to parse sampling message content: %w", err)
+		}
+		m.Content = content
+	}
+	return nil
+}
+
+// ModelHint is a hint to use for model selection. The client may map it to a
+// different model from another provider.
+type ModelHint struct {
+	// Name is a full or partial model name, e.g. "claude-3-5-sonnet".
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences expresses the server's priorities for model selection.
+// Priorities range from 0 to 1, where 1 means most important.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         float64     `json:"costPriority,omitempty"`
+	SpeedPriority        float64     `json:"speedPriority,omitempty"`
+	IntelligencePriority float64     `json:"intelligencePriority,omitempty"`
+}
+
+// CreateMessageParams describes the params of a sampling/createMessage request.
+type CreateMessageParams struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	ModelPreferences *ModelPreferences      `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	IncludeContext   string                 `json:"includeContext,omitempty"`
+	Temperature      *float64               `json:"temperature,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateMessageRequest is a request from the server to sample an LLM via the client.
+type CreateMessageRequest struct {
+	Request
+	Params CreateMessageParams `json:"params"`
+}
+
+// CreateMessageResult is the client's response to a sampling/createMessage request.
+type CreateMessageResult struct {
+	Result
+	SamplingMessage
+
+	// Model is the name of the model that generated the message.
+	Model string `json:"model"`
+
+	// StopReason is the reason why sampling stopped, if known.
+	StopReason string `json:"stopReason,omitempty"`
+}
+
+// UnmarshalJSON implements custom unmarshaling for CreateMessageResult to handle polymorphic Content.
+func (r *CreateMessageResult) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		Meta       map[string]interface{} `json:"_meta,omitempty"`
+		Model      string                 `json:"model"`
+		StopReason string                 `json:"stopReason,omitempty"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return fmt.Errorf("failed to unmarshal create message result: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.SamplingMessage); err != nil {
+		return err
+	}
+	r.Meta = temp.Meta
+	r.Model = temp.Model
+	r.StopReason = temp.StopReason
+	return nil
+}
+
+// SamplingHandler answers sampling/createMessage requests from the server,
+// typically by calling an LLM after the user approved the request.
+type SamplingHandler interface {
+	// CreateMessage samples the LLM and returns the generated message.
+	CreateMessage(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error)
+}
+
+// SamplingHandlerFunc adapts an ordinary function to a SamplingHandler.
+type SamplingHandlerFunc func(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error)
+
+// CreateMessage implements SamplingHandler.
+func (f SamplingHandlerFunc) CreateMessage(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error) {
+	return f(ctx, req)
+}
+
+// createMessage sends a sampling/createMessage request through send and parses the result.
+func createMessage(
+	ctx context.Context,
+	session Session,
+	req *CreateMessageRequest,
+	send func(ctx context.Context, request *JSONRPCRequest) (*json.RawMessage, error),
+) (*CreateMessageResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("create message request is nil")
+	}
+	if !clientSupportsSampling(session) {
+		return nil, ErrSamplingNotSupported
+	}
+
+	request := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		Request: Request{
+			Method: MethodSamplingCreateMessage,
+		},
+		Params: req.Params,
+	}
+
+	response, err := send(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send sampling/createMessage request: %w", err)
+	}
+
+	if isErrorResponse(response) {
+		errResp, err := parseRawMessageToError(response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return nil, fmt.Errorf("sampling error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+	}
+
+	var result CreateMessageResult
+	if err := json.Unmarshal(*response, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse CreateMessageResult: %w", err)
+	}
+	return &result, nil
+}
+
+// clientSupportsSampling reports whether the client of session declared the
+// sampling capability. Sessions without recorded capabilities are allowed.
+func clientSupportsSampling(session Session) bool {
+	capabilities, ok := clientCapabilitiesFromSession(session)
+	if !ok {
+		return true
+	}
+	return capabilities.Sampling != nil
+}
+
+// handleCreateMessageRequest answers a sampling/createMessage request with
+// handler and returns the JSON-RPC response or error to send back.
+func handleCreateMessageRequest(
+	ctx context.Context,
+	handler SamplingHandler,
+	request *JSONRPCRequest,
+) JSONRPCMessage {
+	if handler == nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeMethodNotFound, "sampling not supported", nil)
+	}
+
+	req := &CreateMessageRequest{}
+	req.Method = MethodSamplingCreateMessage
+	paramsBytes, err := json.Marshal(request.Params)
+	if err == nil {
+		err = json.Unmarshal(paramsBytes, &req.Params)
+	}
+	if err != nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInvalidParams, err.Error(), nil)
+	}
+
+	result, err := handler.CreateMessage(ctx, req)
+	if err != nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInternal, err.Error(), nil)
+	}
+	if result == nil {
+		return newJSONRPCErrorResponse(request.ID, ErrCodeInternal, "sampling handler returned no result", nil)
+	}
+	return newJSONRPCResponse(request.ID, result)
+}
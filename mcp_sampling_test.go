@@ -0,0 +1,227 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoSamplingHandler answers sampling requests by echoing the last message.
+var echoSamplingHandler = SamplingHandlerFunc(
+	func(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error) {
+		last := req.Params.Messages[len(req.Params.Messages)-1]
+		text := last.Content.(TextContent).Text
+		return &CreateMessageResult{
+			SamplingMessage: SamplingMessage{
+				Role:    RoleAssistant,
+				Content: NewTextContent("echo: " + text),
+			},
+			Model:      "echo-model",
+			StopReason: StopReasonEndTurn,
+		}, nil
+	})
+
+func newCreateMessageRequest(text string) *CreateMessageRequest {
+	req := &CreateMessageRequest{}
+	req.Params.Messages = []SamplingMessage{{Role: RoleUser, Content: NewTextContent(text)}}
+	req.Params.MaxTokens = 100
+	return req
+}
+
+// samplingResultText formats a sampling result as tool output.
+func samplingResultText(result *CreateMessageResult, err error) (*CallToolResult, error) {
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+	text := result.Content.(TextContent).Text
+	return NewTextResult(fmt.Sprintf("%s|%s|%s|%s", result.Role, text, result.Model, result.StopReason)), nil
+}
+
+func callSamplingTool(t *testing.T, client Connector) *CallToolResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &CallToolRequest{}
+	req.Params.Name = "sample"
+	result, err := client.CallTool(ctx, req)
+	require.NoError(t, err)
+	return result
+}
+
+func TestServer_CreateMessage(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("sample"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		session := ClientSessionFromContext(ctx)
+		return samplingResultText(server.CreateMessage(ctx, session.GetID(), newCreateMessageRequest("hi")))
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	newInitializedClient := func(handler SamplingHandler) *Client {
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+			WithClientGetSSEEnabled(true))
+		require.NoError(t, err)
+		if handler != nil {
+			client.SetSamplingHandler(handler)
+		}
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("with handler", func(t *testing.T) {
+		client := newInitializedClient(echoSamplingHandler)
+		defer client.Close()
+
+		result := callSamplingTool(t, client)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "assistant|echo: hi|echo-model|endTurn", result.Content[0].(TextContent).Text)
+	})
+
+	t.Run("without capability", func(t *testing.T) {
+		client := newInitializedClient(nil)
+		defer client.Close()
+
+		result := callSamplingTool(t, client)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(TextContent).Text, ErrSamplingNotSupported.Error())
+	})
+
+	t.Run("over the request stream", func(t *testing.T) {
+		// The request related to the tool call is sent on the stream answering
+		// it, so no GET SSE stream is needed.
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+			WithClientGetSSEEnabled(false))
+		require.NoError(t, err)
+		defer client.Close()
+		client.SetSamplingHandler(echoSamplingHandler)
+		_, err = client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+
+		result := callSamplingTool(t, client)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "assistant|echo: hi|echo-model|endTurn", result.Content[0].(TextContent).Text)
+	})
+}
+
+func TestSSEServer_CreateMessage(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("sample"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		session := ClientSessionFromContext(ctx)
+		return samplingResultText(server.CreateMessage(ctx, session.GetID(), newCreateMessageRequest("hello")))
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	client.SetSamplingHandler(echoSamplingHandler)
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	result := callSamplingTool(t, client)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "assistant|echo: hello|echo-model|endTurn", result.Content[0].(TextContent).Text)
+}
+
+func TestStdioServer_CreateMessage(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("sample"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return samplingResultText(server.CreateMessage(ctx, newCreateMessageRequest("ping")))
+	})
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := newStdioTransport(server.internal)
+	go func() {
+		_ = transport.listen(ctx, stdinReader, stdoutWriter)
+	}()
+	go func() {
+		// The test plays the client: it sends the tool call and answers sampling below.
+		_, _ = stdinWriter.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"sample"}}` + "\n"))
+	}()
+
+	scanner := bufio.NewScanner(stdoutReader)
+	for scanner.Scan() {
+		var msg struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+			Result *json.RawMessage       `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+
+		if msg.Method == MethodSamplingCreateMessage {
+			request := &JSONRPCRequest{ID: msg.ID, Params: msg.Params}
+			response, err := json.Marshal(handleCreateMessageRequest(ctx, echoSamplingHandler, request))
+			require.NoError(t, err)
+			_, err = stdinWriter.Write(append(response, '\n'))
+			require.NoError(t, err)
+			continue
+		}
+
+		assert.Equal(t, float64(1), msg.ID)
+		require.NotNil(t, msg.Result)
+		result, err := parseCallToolResult(msg.Result)
+		require.NoError(t, err)
+		assert.Equal(t, "assistant|echo: ping|echo-model|endTurn", result.Content[0].(TextContent).Text)
+		return
+	}
+	t.Fatal("no tool response received")
+}
+
+func TestCreateMessageResult_JSON(t *testing.T) {
+	data := `{"role":"assistant","content":{"type":"audio","data":"AAA=","mimeType":"audio/wav"},` +
+		`"model":"m","stopReason":"maxTokens","_meta":{"k":"v"}}`
+
+	var result CreateMessageResult
+	require.NoError(t, json.Unmarshal([]byte(data), &result))
+	assert.Equal(t, RoleAssistant, result.Role)
+	assert.Equal(t, NewAudioContent("AAA=", "audio/wav"), result.Content)
+	assert.Equal(t, "m", result.Model)
+	assert.Equal(t, StopReasonMaxTokens, result.StopReason)
+	assert.Equal(t, "v", result.Meta["k"])
+
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+}
+
+func TestHandleCreateMessageRequest_Errors(t *testing.T) {
+	request := newJSONRPCRequest(int64(1), MethodSamplingCreateMessage, map[string]interface{}{
+		"messages":  []interface{}{},
+		"maxTokens": 10,
+	})
+
+	response := handleCreateMessageRequest(context.Background(), nil, request)
+	require.IsType(t, &JSONRPCError{}, response)
+	assert.Equal(t, ErrCodeMethodNotFound, response.(*JSONRPCError).Error.Code)
+
+	failing := SamplingHandlerFunc(func(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error) {
+		return nil, fmt.Errorf("user rejected")
+	})
+	response = handleCreateMessageRequest(context.Background(), failing, request)
+	require.IsType(t, &JSONRPCError{}, response)
+	assert.Equal(t, "user rejected", response.(*JSONRPCError).Error.Message)
+
+	assert.Equal(t, map[string]interface{}{"sampling": map[string]interface{}{}},
+		declareClientCapabilities(map[string]interface{}{}, true, false))
+}
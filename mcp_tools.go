@@ -132,6 +132,9 @@ type Tool struct {
 	// Tool name
 	Name string `json:"name"`
 
+	// Human-readable title for display (since 2025-06-18)
+	Title string `json:"title,omitempty"`
+
 	// Tool description
 	Description string `json:"description,omitempty"`
 
@@ -148,6 +151,9 @@ type Tool struct {
 	RawInputSchema json.RawMessage `json:"-"`
 	// Raw output schema
 	RawOutputSchema json.RawMessage `json:"-"`
+
+	// OAuth scopes a request must be granted to list and call the tool
+	RequiredScopes []string `json:"-"`
 }
 
 // toolHandler defines the function type for handling tool execution
@@ -157,6 +163,10 @@ type toolHandler func(ctx context.Context, req *CallToolRequest) (*CallToolResul
 type registeredTool struct {
 	Tool    *Tool
 	Handler toolHandler
+
+	// Schemas of the tool compiled for validation
+	inputSchema  compiledSchema
+	outputSchema compiledSchema
 }
 
 // ToolOption represents a function that configures a Tool
@@ -207,6 +217,14 @@ func ensureInputSchemaProperties(t *Tool) {
 	}
 }
 
+// WithToolTitle sets the human-readable title of the tool.
+// Clients negotiating a protocol version older than 2025-06-18 do not receive it.
+func WithToolTitle(title string) ToolOption {
+	return func(t *Tool) {
+		t.Title = title
+	}
+}
+
 // WithDescription common option function
 func WithDescription(description string) ToolOption {
 	return func(t *Tool) {
@@ -309,6 +327,15 @@ func WithToolAnnotations(annotations *ToolAnnotations) ToolOption {
 	}
 }
 
+// WithToolRequiredScopes restricts the tool to requests whose access token grants
+// all the given scopes. Other requests do not see the tool in tools/list and
+// cannot call it. See WithAuthorization.
+func WithToolRequiredScopes(scopes ...string) ToolOption {
+	return func(t *Tool) {
+		t.RequiredScopes = append(t.RequiredScopes, scopes...)
+	}
+}
+
 // WithString adds a string parameter to the tool's input schema
 func WithString(name string, opts ...PropertyOption) ToolOption {
 	return func(t *Tool) {
@@ -622,8 +649,12 @@ func parseContent(contentMap map[string]any) (Content, error) {
 		return parseTextContent(contentMap)
 	case "image":
 		return parseImageContent(contentMap)
+	case "audio":
+		return parseAudioContent(contentMap)
 	case "resource":
 		return parseResourceContent(contentMap)
+	case ContentTypeResourceLink:
+		return parseResourceLink(contentMap)
 	default:
 		return nil, fmt.Errorf("unsupported content type: %s", contentType)
 	}
@@ -648,6 +679,16 @@ func parseImageContent(contentMap map[string]any) (Content, error) {
 	return NewImageContent(data, mimeType), nil
 }
 
+// parseAudioContent parses audio content
+func parseAudioContent(contentMap map[string]any) (Content, error) {
+	data := extractString(contentMap, "data")
+	mimeType := extractString(contentMap, "mimeType")
+	if data == "" || mimeType == "" {
+		return nil, fmt.Errorf("audio data or mimeType is missing")
+	}
+	return NewAudioContent(data, mimeType), nil
+}
+
 // parseResourceContent parses resource content
 func parseResourceContent(contentMap map[string]any) (Content, error) {
 	resourceMap := extractMap(contentMap, "resource")
@@ -661,6 +702,23 @@ func parseResourceContent(contentMap map[string]any) (Content, error) {
 	return NewEmbeddedResource(resourceContents), nil
 }
 
+// parseResourceLink parses resource link content
+func parseResourceLink(contentMap map[string]any) (Content, error) {
+	uri := extractString(contentMap, "uri")
+	name := extractString(contentMap, "name")
+	if uri == "" || name == "" {
+		return nil, fmt.Errorf("resource link uri or name is missing")
+	}
+	link := NewResourceLink(uri, name)
+	link.Title = extractString(contentMap, "title")
+	link.Description = extractString(contentMap, "description")
+	link.MimeType = extractString(contentMap, "mimeType")
+	if size, ok := contentMap["size"].(float64); ok {
+		link.Size = int64(size)
+	}
+	return link, nil
+}
+
 // extractString extracts a string value from a map by key
 func extractString(data map[string]any, key string) string {
 	if value, ok := data[key]; ok {
@@ -21,6 +21,8 @@ const (
 	ContentTypeAudio = "audio"
 	// ContentTypeEmbeddedResource represents embedded resource content type
 	ContentTypeEmbeddedResource = "embedded_resource"
+	// ContentTypeResourceLink represents resource link content type (since 2025-06-18)
+	ContentTypeResourceLink = "resource_link"
 )
 
 // MCP protcol Layer
@@ -212,6 +214,22 @@ type EmbeddedResource struct {
 
 func (EmbeddedResource) isContent() {}
 
+// ResourceLink represents a link to a resource that the server can read.
+// It is only understood by clients using protocol version 2025-06-18 or later;
+// older clients receive it as text content instead.
+type ResourceLink struct {
+	Type        string `json:"type"`
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Annotated
+}
+
+func (ResourceLink) isContent() {}
+
 // NewTextContent helpe functions for content creation
 func NewTextContent(text string) TextContent {
 	return TextContent{
@@ -246,6 +264,15 @@ func NewEmbeddedResource(resource ResourceContents) EmbeddedResource {
 	}
 }
 
+// NewResourceLink creates a new resource link
+func NewResourceLink(uri string, name string) ResourceLink {
+	return ResourceLink{
+		Type: ContentTypeResourceLink,
+		URI:  uri,
+		Name: name,
+	}
+}
+
 // RootsProvider defines the interface for root directory providers.
 type RootsProvider interface {
 	// GetRoots returns the list of currently available root directories.
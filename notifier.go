@@ -6,117 +6,56 @@
 
 package mcp
 
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-
-	"trpc.group/trpc-go/trpc-mcp-go/internal/sseutil"
-)
-
-// sseNotificationSender implements the SSE notification sender
-type sseNotificationSender struct {
-	// Response writer
-	writer http.ResponseWriter
-
-	// Flusher
-	flusher http.Flusher
-
-	// Session ID
-	sessionID string
-
-	// SSE utility writer
-	sseWriter *sseutil.Writer
-}
-
-// newSSENotificationSender creates an SSE notification sender
-func newSSENotificationSender(w http.ResponseWriter, f http.Flusher, sessionID string) *sseNotificationSender {
-	return &sseNotificationSender{
-		writer:    w,
-		flusher:   f,
-		sessionID: sessionID,
-		sseWriter: sseutil.NewWriter(),
+// logMessageParams builds the params of a log message notification.
+func logMessageParams(level string, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"level": level,
+		"data":  message,
 	}
 }
 
-// SendLogMessage sends a log message notification
-func (s *sseNotificationSender) SendLogMessage(level string, message string) error {
-	return s.SendCustomNotification(NotificationMethodMessage, map[string]interface{}{
-		"level": level,
-		"data": map[string]interface{}{
-			"type":    "log_message",
-			"message": message,
-		},
-	})
+// streamNotificationSender implements a notification sender on top of a
+// send function. It is used by every transport: POST and GET SSE streams,
+// the SSE server and stdio.
+type streamNotificationSender struct {
+	session Session
+	send    func(notification *JSONRPCNotification) error
 }
 
-// SendProgress sends a progress update notification
-func (s *sseNotificationSender) SendProgress(progress float64, message string) error {
-	return s.SendCustomNotification(NotificationMethodProgress, map[string]interface{}{
-		"progress": progress,
-		"message":  message,
-		"data": map[string]interface{}{
-			"type":     "process_progress",
-			"progress": progress,
-			"message":  message,
-		},
-	})
+// newStreamNotificationSender creates a notification sender that delivers
+// notifications to the client of session through send. The session may be
+// nil, e.g. in stateless mode.
+func newStreamNotificationSender(
+	session Session,
+	send func(notification *JSONRPCNotification) error,
+) *streamNotificationSender {
+	return &streamNotificationSender{session: session, send: send}
 }
 
-// SendCustomNotification sends a custom notification
-func (s *sseNotificationSender) SendCustomNotification(method string, params map[string]interface{}) error {
-	// Create NotificationParams
-	notificationParams := NotificationParams{
-		AdditionalFields: params,
-	}
-
-	// Handle _meta if present
-	if meta, ok := params["_meta"]; ok {
-		if metaMap, isMap := meta.(map[string]interface{}); isMap {
-			notificationParams.Meta = metaMap
-			delete(params, "_meta") // Remove from AdditionalFields to avoid duplication
-		}
+// SendLogMessage sends a log message notification, unless its level is below
+// the minimum level set by the client with logging/setLevel, as ClientLogger does.
+func (s *streamNotificationSender) SendLogMessage(level string, message string) error {
+	if minimum, ok := logLevelFromSession(s.session); ok && !LoggingLevel(level).AtLeast(minimum) {
+		return nil
 	}
-
-	notification := Notification{
-		Method: method,
-		Params: notificationParams,
-	}
-
-	// Create jsonNotification
-	jsonNotification := newJSONRPCNotification(notification)
-
-	// Serialize jsonNotification
-	data, err := json.Marshal(jsonNotification)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrNotificationSerialization, err)
-	}
-
-	// Send SSE event using sseutil.Writer instead of direct fmt.Fprintf
-	eventID := s.sseWriter.GenerateEventID()
-	return s.sseWriter.WriteEvent(s.writer, sseutil.Event{
-		ID:   eventID,
-		Data: data,
-	})
+	return s.SendCustomNotification(NotificationMethodMessage, logMessageParams(level, message))
 }
 
-// SendNotification sends a custom notification
-func (s *sseNotificationSender) SendNotification(notification *Notification) error {
-	// Create notification
-	jsonNotification := newJSONRPCNotification(*notification)
+// SendProgress is a no-op: progress notifications require the progress token
+// of the request, so they are only sent when the client supplied a
+// _meta.progressToken, through the sender wrapping this one for the request.
+func (s *streamNotificationSender) SendProgress(progress float64, message string) error {
+	return nil
+}
 
-	// Serialize notifications
-	data, err := json.Marshal(jsonNotification)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrNotificationSerialization, err)
-	}
+// SendCustomNotification sends a custom notification
+func (s *streamNotificationSender) SendCustomNotification(method string, params map[string]interface{}) error {
+	return s.SendNotification(NewNotification(method, params))
+}
 
-	// Send SSE event using sseutil.Writer instead of direct fmt.Fprintf
-	eventID := s.sseWriter.GenerateEventID()
-	return s.sseWriter.WriteEvent(s.writer, sseutil.Event{
-		ID:   eventID,
-		Data: data,
-	})
+// SendNotification sends a notification
+func (s *streamNotificationSender) SendNotification(notification *Notification) error {
+	return s.send(newJSONRPCNotification(*notification))
 }
 
 // noopNotificationSender implements a no-operation notification sender
@@ -0,0 +1,155 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/errors"
+)
+
+// List kinds bound into cursors, so that a cursor returned by one list
+// method cannot be used with another one.
+const (
+	cursorListTools             = "tools"
+	cursorListPrompts           = "prompts"
+	cursorListResources         = "resources"
+	cursorListResourceTemplates = "resourceTemplates"
+)
+
+// paginator splits list results into pages and issues cursors for them.
+//
+// Items are ordered by a stable key (tool name, prompt name, resource URI or
+// template name) and a cursor records the key of the last item returned. The
+// next page starts at the first item whose key sorts after it, so registering
+// or unregistering items between two requests neither repeats nor skips items
+// that are still registered.
+//
+// Cursors are signed with an HMAC key, so clients cannot forge or alter them.
+// Unless a key is configured, it is generated when the paginator is created and
+// cursors are only valid for the server that issued them.
+type paginator struct {
+	pageSize int
+	key      []byte
+}
+
+// cursorPayload is the signed content of a cursor.
+type cursorPayload struct {
+	List    string `json:"l"`
+	LastKey string `json:"k"`
+}
+
+// newPaginator creates a paginator returning at most pageSize items per page,
+// signing its cursors with key, or with a random key if key is empty. It
+// returns nil if pageSize is not positive, which disables pagination.
+func newPaginator(pageSize int, key []byte) *paginator {
+	if pageSize <= 0 {
+		return nil
+	}
+	if len(key) == 0 {
+		key = make([]byte, sha256.Size)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate pagination key: %v", err))
+		}
+	}
+	return &paginator{
+		pageSize: pageSize,
+		key:      key,
+	}
+}
+
+// encodeCursor creates a signed cursor pointing after lastKey.
+func (p *paginator) encodeCursor(list, lastKey string) Cursor {
+	payload, _ := json.Marshal(cursorPayload{List: list, LastKey: lastKey})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return Cursor(encoded + "." + base64.RawURLEncoding.EncodeToString(p.sign(encoded)))
+}
+
+// decodeCursor verifies a cursor and returns the key of the last item it points after.
+func (p *paginator) decodeCursor(list string, cursor Cursor) (string, error) {
+	encoded, signature, ok := strings.Cut(string(cursor), ".")
+	if !ok {
+		return "", errors.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, p.sign(encoded)) {
+		return "", errors.ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.List != list {
+		return "", errors.ErrInvalidCursor
+	}
+	return payload.LastKey, nil
+}
+
+// sign computes the HMAC of an encoded cursor payload.
+func (p *paginator) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// paginate returns the page of items selected by cursor and the cursor of the
+// next page, which is empty on the last page. Without a paginator all items
+// are returned unchanged.
+func paginate[T any](p *paginator, list string, items []T, key func(T) string, cursor Cursor) ([]T, Cursor, error) {
+	if p == nil {
+		return items, "", nil
+	}
+
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) < key(sorted[j])
+	})
+
+	start := 0
+	if cursor != "" {
+		lastKey, err := p.decodeCursor(list, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(sorted), func(i int) bool {
+			return key(sorted[i]) > lastKey
+		})
+	}
+
+	end := start + p.pageSize
+	if end >= len(sorted) {
+		return sorted[start:], "", nil
+	}
+	return sorted[start:end], p.encodeCursor(list, key(sorted[end-1])), nil
+}
+
+// cursorFromRequest extracts the pagination cursor from list request parameters.
+func cursorFromRequest(req *JSONRPCRequest) Cursor {
+	if req == nil {
+		return ""
+	}
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	cursor, _ := params["cursor"].(string)
+	return Cursor(cursor)
+}
+
+// invalidCursorResponse builds the error returned for a cursor that cannot be used.
+func invalidCursorResponse(req *JSONRPCRequest, err error) JSONRPCMessage {
+	return newJSONRPCErrorResponse(req.ID, ErrCodeInvalidParams, err.Error(), nil)
+}
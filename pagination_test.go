@@ -0,0 +1,173 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func identityKey(s string) string { return s }
+
+func TestPaginate_Pages(t *testing.T) {
+	p := newPaginator(2, nil)
+	items := []string{"e", "c", "a", "d", "b"}
+
+	page, next, err := paginate(p, cursorListTools, items, identityKey, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, page)
+	require.NotEmpty(t, next)
+
+	page, next, err = paginate(p, cursorListTools, items, identityKey, next)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, page)
+	require.NotEmpty(t, next)
+
+	page, next, err = paginate(p, cursorListTools, items, identityKey, next)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e"}, page)
+	assert.Empty(t, next)
+}
+
+func TestPaginate_Disabled(t *testing.T) {
+	assert.Nil(t, newPaginator(0, nil))
+
+	items := []string{"b", "a"}
+	page, next, err := paginate(nil, cursorListTools, items, identityKey, "ignored")
+	require.NoError(t, err)
+	assert.Equal(t, items, page)
+	assert.Empty(t, next)
+}
+
+func TestPaginate_StableUnderConcurrentChanges(t *testing.T) {
+	p := newPaginator(2, nil)
+
+	_, next, err := paginate(p, cursorListTools, []string{"a", "b", "c", "d"}, identityKey, "")
+	require.NoError(t, err)
+
+	// "b" is removed and "aa" is added before the next page is requested. Items
+	// after the cursor are neither repeated nor skipped.
+	page, _, err := paginate(p, cursorListTools, []string{"a", "aa", "c", "d", "e"}, identityKey, next)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, page)
+}
+
+func TestPaginate_InvalidCursor(t *testing.T) {
+	p := newPaginator(1, nil)
+	items := []string{"a", "b"}
+	_, next, err := paginate(p, cursorListTools, items, identityKey, "")
+	require.NoError(t, err)
+
+	tampered := Cursor(strings.Replace(string(next), string(next[0]), "x", 1))
+	for name, cursor := range map[string]Cursor{
+		"garbage":  "not-a-cursor",
+		"tampered": tampered,
+		"foreign":  newPaginator(1, nil).encodeCursor(cursorListTools, "a"),
+	} {
+		_, _, err := paginate(p, cursorListTools, items, identityKey, cursor)
+		assert.Error(t, err, name)
+	}
+
+	// A cursor is only valid for the list it was issued for.
+	_, _, err = paginate(p, cursorListPrompts, items, identityKey, next)
+	assert.Error(t, err)
+}
+
+func TestPaginate_SharedKey(t *testing.T) {
+	// Servers sharing the key accept the cursors of each other.
+	key := []byte("shared-pagination-key")
+	items := []string{"a", "b", "c"}
+	_, next, err := paginate(newPaginator(1, key), cursorListTools, items, identityKey, "")
+	require.NoError(t, err)
+
+	page, _, err := paginate(newPaginator(1, key), cursorListTools, items, identityKey, next)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, page)
+	_, _, err = paginate(newPaginator(1, []byte("other-key")), cursorListTools, items, identityKey, next)
+	assert.Error(t, err)
+}
+
+func TestPagination_ClientListsAllPages(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0",
+		WithGetSSEEnabled(false),
+		WithPageSize(3),
+		WithToolListFilter(func(ctx context.Context, tools []*Tool) []*Tool {
+			filtered := make([]*Tool, 0, len(tools))
+			for _, tool := range tools {
+				if !strings.HasPrefix(tool.Name, "hidden") {
+					filtered = append(filtered, tool)
+				}
+			}
+			return filtered
+		}),
+	)
+	handler := func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return NewTextResult("ok"), nil
+	}
+	for i := 0; i < 10; i++ {
+		server.RegisterTool(NewTool(fmt.Sprintf("tool-%02d", i)), handler)
+		server.RegisterTool(NewTool(fmt.Sprintf("hidden-%02d", i)), handler)
+		server.RegisterPrompt(&Prompt{Name: fmt.Sprintf("prompt-%02d", i)},
+			func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+				return &GetPromptResult{}, nil
+			})
+		server.RegisterResource(&Resource{URI: fmt.Sprintf("file:///%02d.txt", i), Name: fmt.Sprintf("r%02d", i)},
+			func(ctx context.Context, req *ReadResourceRequest) (ResourceContents, error) {
+				return TextResourceContents{URI: req.Params.URI, Text: "ok"}, nil
+			})
+		server.RegisterResourceTemplate(
+			NewResourceTemplate(fmt.Sprintf("file:///t%02d/{id}", i), fmt.Sprintf("t%02d", i)),
+			func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+				return nil, nil
+			})
+	}
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+	_, err = client.Initialize(ctx, &InitializeRequest{})
+	require.NoError(t, err)
+
+	page, err := client.ListTools(ctx, &ListToolsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, page.Tools, 3)
+	assert.NotEmpty(t, page.NextCursor)
+
+	tools, err := client.ListAllTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 10)
+	for i, tool := range tools.Tools {
+		assert.Equal(t, fmt.Sprintf("tool-%02d", i), tool.Name)
+	}
+
+	prompts, err := client.ListAllPrompts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, prompts.Prompts, 10)
+
+	resources, err := client.ListAllResources(ctx)
+	require.NoError(t, err)
+	assert.Len(t, resources.Resources, 10)
+
+	templates, err := client.ListAllResourceTemplates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, templates.ResourceTemplates, 10)
+
+	invalid := &ListToolsRequest{}
+	invalid.Params.Cursor = "bogus"
+	_, err = client.ListTools(ctx, invalid)
+	assert.ErrorContains(t, err, "invalid cursor")
+}
@@ -0,0 +1,270 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ProgressNotificationParams describes the params of a notifications/progress message.
+type ProgressNotificationParams struct {
+	// ProgressToken is the token the requester attached to the original request.
+	ProgressToken ProgressToken `json:"progressToken"`
+
+	// Progress is the progress so far. It increases with every notification.
+	Progress float64 `json:"progress"`
+
+	// Total is the total amount of work, if known.
+	Total float64 `json:"total,omitempty"`
+
+	// Message is an optional human-readable progress description.
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressHandler receives progress notifications for a single request.
+type ProgressHandler func(params *ProgressNotificationParams)
+
+// ProgressReporter reports progress of the request being handled back to the
+// client. It is only available when the client asked for progress by sending
+// a _meta.progressToken with its request.
+type ProgressReporter struct {
+	token  ProgressToken
+	sender notificationSender
+
+	mu   sync.Mutex
+	last float64
+	sent bool
+}
+
+// progressReporterKey is the context key of the progress reporter.
+type progressReporterKey struct{}
+
+// newProgressReporter creates a progress reporter bound to a progress token.
+func newProgressReporter(token ProgressToken, sender notificationSender) *ProgressReporter {
+	return &ProgressReporter{
+		token:  token,
+		sender: sender,
+	}
+}
+
+// withProgressReporter adds a progress reporter to the context.
+func withProgressReporter(ctx context.Context, reporter *ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// ProgressReporterFromContext returns the progress reporter of the request being
+// handled. It returns false if the client did not ask for progress updates.
+func ProgressReporterFromContext(ctx context.Context) (*ProgressReporter, bool) {
+	reporter, ok := ctx.Value(progressReporterKey{}).(*ProgressReporter)
+	return reporter, ok && reporter != nil
+}
+
+// Token returns the progress token supplied by the client.
+func (r *ProgressReporter) Token() ProgressToken {
+	if r == nil {
+		return nil
+	}
+	return r.token
+}
+
+// Report sends a notifications/progress message to the client. Total is omitted
+// when it is not positive and message is omitted when empty. Progress must
+// increase with every call, as required by the specification.
+// Calling Report on a nil reporter is a no-op.
+func (r *ProgressReporter) Report(progress, total float64, message string) error {
+	return r.report(progress, total, message, false)
+}
+
+// report sends a notifications/progress message. A progress that does not
+// increase fails, or is dropped if lenient.
+func (r *ProgressReporter) report(progress, total float64, message string, lenient bool) error {
+	if r == nil || r.sender == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sent && progress <= r.last {
+		if lenient {
+			return nil
+		}
+		return fmt.Errorf("progress must increase: got %v after %v", progress, r.last)
+	}
+	if err := r.sender.SendNotification(newProgressNotification(r.token, progress, total, message)); err != nil {
+		return err
+	}
+	r.last = progress
+	r.sent = true
+	return nil
+}
+
+// newProgressNotification builds a notifications/progress notification.
+// The token is omitted when nil.
+func newProgressNotification(token ProgressToken, progress, total float64, message string) *Notification {
+	params := map[string]interface{}{
+		"progress": progress,
+	}
+	if token != nil {
+		params["progressToken"] = token
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	return NewNotification(NotificationMethodProgress, params)
+}
+
+// progressNotificationSender wraps a notification sender so that the legacy
+// SendProgress method carries the progress token of the current request.
+type progressNotificationSender struct {
+	notificationSender
+	reporter *ProgressReporter
+}
+
+// SendProgress sends a progress notification bound to the request's token.
+// Unlike Report, a progress that does not increase is dropped silently, as
+// tools written before progress tokens may repeat or restart their progress.
+func (s *progressNotificationSender) SendProgress(progress float64, message string) error {
+	return s.reporter.report(progress, 0, message, true)
+}
+
+// progressHandlerKey is the context key of the client-side progress handler.
+type progressHandlerKey struct{}
+
+// WithProgressHandler returns a context that asks the server to report progress
+// for the request issued with it. Progress notifications for that request are
+// delivered to handler until the request completes.
+//
+// Example:
+//
+//	ctx := mcp.WithProgressHandler(ctx, func(p *mcp.ProgressNotificationParams) {
+//	    log.Printf("%v/%v %s", p.Progress, p.Total, p.Message)
+//	})
+//	result, err := client.CallTool(ctx, req)
+func WithProgressHandler(ctx context.Context, handler ProgressHandler) context.Context {
+	return context.WithValue(ctx, progressHandlerKey{}, handler)
+}
+
+// progressHandlerFromContext returns the client-side progress handler, if any.
+func progressHandlerFromContext(ctx context.Context) ProgressHandler {
+	handler, _ := ctx.Value(progressHandlerKey{}).(ProgressHandler)
+	return handler
+}
+
+// progressRouter dispatches incoming progress notifications to the handler
+// registered for their progress token. The zero value is ready to use.
+type progressRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]ProgressHandler
+	counter  atomic.Int64
+}
+
+// stringOrNumberKey normalizes a progress token or request ID for map lookups.
+// Numbers are decoded as float64, so integers and floats with the same value must match.
+func stringOrNumberKey(token interface{}) string {
+	switch v := token.(type) {
+	case string:
+		return "s:" + v
+	case float64:
+		return fmt.Sprintf("n:%v", v)
+	case float32:
+		return fmt.Sprintf("n:%v", float64(v))
+	default:
+		return fmt.Sprintf("n:%v", v)
+	}
+}
+
+// register registers a handler for a progress token and returns a function
+// that unregisters it.
+func (r *progressRouter) register(token ProgressToken, handler ProgressHandler) func() {
+	key := stringOrNumberKey(token)
+
+	r.mu.Lock()
+	if r.handlers == nil {
+		r.handlers = make(map[string]ProgressHandler)
+	}
+	r.handlers[key] = handler
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		delete(r.handlers, key)
+		r.mu.Unlock()
+	}
+}
+
+// prepareCallTool registers the progress handler carried by ctx, if any, and
+// returns a copy of req whose _meta carries the matching progress token.
+// The returned function must be called once the request completes.
+func (r *progressRouter) prepareCallTool(ctx context.Context, req *CallToolRequest) (*CallToolRequest, func()) {
+	handler := progressHandlerFromContext(ctx)
+	if handler == nil {
+		return req, func() {}
+	}
+
+	reqCopy := *req
+	var token ProgressToken
+	if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+		token = req.Params.Meta.ProgressToken
+	} else {
+		token = fmt.Sprintf("progress-%d", r.counter.Add(1))
+		reqCopy.Params.Meta = &struct {
+			ProgressToken ProgressToken `json:"progressToken,omitempty"`
+		}{ProgressToken: token}
+	}
+	return &reqCopy, r.register(token, handler)
+}
+
+// dispatch delivers a notifications/progress message to the matching handler.
+// It reports whether a handler was found.
+func (r *progressRouter) dispatch(notification *JSONRPCNotification) bool {
+	if notification == nil || notification.Method != NotificationMethodProgress {
+		return false
+	}
+
+	fields := notification.Params.AdditionalFields
+	token, ok := fields["progressToken"]
+	if !ok || token == nil {
+		return false
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[stringOrNumberKey(token)]
+	r.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	params := &ProgressNotificationParams{ProgressToken: token}
+	params.Progress, _ = fields["progress"].(float64)
+	params.Total, _ = fields["total"].(float64)
+	params.Message, _ = fields["message"].(string)
+	handler(params)
+	return true
+}
+
+// withProgress injects a progress reporter for token into the context. The
+// reporter sends through the request's notification sender, which is also
+// wrapped so that SendProgress carries the token.
+func withProgress(ctx context.Context, token ProgressToken) context.Context {
+	sender, ok := GetNotificationSender(ctx)
+	if !ok {
+		return ctx
+	}
+	reporter := newProgressReporter(token, sender)
+	ctx = withProgressReporter(ctx, reporter)
+	return withNotificationSender(ctx, &progressNotificationSender{
+		notificationSender: sender,
+		reporter:           reporter,
+	})
+}
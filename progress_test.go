@@ -0,0 +1,281 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressTool reports three progress steps before returning.
+func progressTool(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+	reporter, ok := ProgressReporterFromContext(ctx)
+	if !ok {
+		return NewTextResult("no progress"), nil
+	}
+	for i := 1; i <= 3; i++ {
+		if err := reporter.Report(float64(i), 3, "step"); err != nil {
+			return nil, err
+		}
+	}
+	return NewTextResult("done"), nil
+}
+
+// progressCollector collects progress notifications delivered to a handler.
+type progressCollector struct {
+	mu     sync.Mutex
+	events []*ProgressNotificationParams
+}
+
+func (c *progressCollector) handle(params *ProgressNotificationParams) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, params)
+}
+
+func (c *progressCollector) get() []*ProgressNotificationParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*ProgressNotificationParams(nil), c.events...)
+}
+
+func assertProgressEvents(t *testing.T, events []*ProgressNotificationParams) {
+	t.Helper()
+	require.Len(t, events, 3)
+	for i, event := range events {
+		assert.Equal(t, float64(i+1), event.Progress)
+		assert.Equal(t, float64(3), event.Total)
+		assert.Equal(t, "step", event.Message)
+		assert.NotNil(t, event.ProgressToken)
+	}
+}
+
+func callProgressTool(t *testing.T, client Connector, collector *progressCollector) *CallToolResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := &CallToolRequest{}
+	req.Params.Name = "progress-tool"
+	result, err := client.CallTool(WithProgressHandler(ctx, collector.handle), req)
+	require.NoError(t, err)
+	return result
+}
+
+func TestProgressReporter_StreamablePostSSE(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	collector := &progressCollector{}
+	result := callProgressTool(t, client, collector)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+
+	// Without a progress handler no token is sent and no reporter is available.
+	req := &CallToolRequest{}
+	req.Params.Name = "progress-tool"
+	result, err = client.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "no progress", result.Content[0].(TextContent).Text)
+}
+
+func TestProgressReporter_StreamableGetSSE(t *testing.T) {
+	// GET SSE events are not ordered with the JSON response, so the tool keeps
+	// the call open until the client has seen all progress notifications.
+	collector := &progressCollector{}
+	received := make(chan struct{})
+	handler := func(params *ProgressNotificationParams) {
+		collector.handle(params)
+		if len(collector.get()) == 3 {
+			close(received)
+		}
+	}
+	tool := func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		if _, err := progressTool(ctx, req); err != nil {
+			return nil, err
+		}
+		select {
+		case <-received:
+			return NewTextResult("done"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithPostSSEEnabled(false))
+	server.RegisterTool(NewTool("progress-tool"), tool)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(true))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	// Wait for the GET SSE stream to be established.
+	require.Eventually(t, func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		_, ok := server.httpHandler.getSSEConnections[client.GetSessionID()]
+		return ok
+	}, 2*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &CallToolRequest{}
+	req.Params.Name = "progress-tool"
+	result, err := client.CallTool(WithProgressHandler(ctx, handler), req)
+	require.NoError(t, err)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+}
+
+func TestProgressReporter_SSEServer(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	collector := &progressCollector{}
+	result := callProgressTool(t, client, collector)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+}
+
+func TestProgressReporter_StdioServer(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := newStdioTransport(server.internal)
+	go func() {
+		_ = transport.listen(ctx, stdinReader, stdoutWriter)
+	}()
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call",` +
+		`"params":{"name":"progress-tool","_meta":{"progressToken":"tok"}}}` + "\n"
+	_, err := stdinWriter.Write([]byte(request))
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(stdoutReader)
+	var progress []map[string]interface{}
+	for scanner.Scan() {
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		if msg["method"] == NotificationMethodProgress {
+			progress = append(progress, msg["params"].(map[string]interface{}))
+			continue
+		}
+		assert.Equal(t, float64(1), msg["id"])
+		break
+	}
+
+	require.Len(t, progress, 3)
+	for i, params := range progress {
+		assert.Equal(t, "tok", params["progressToken"])
+		assert.Equal(t, float64(i+1), params["progress"])
+		assert.Equal(t, float64(3), params["total"])
+	}
+}
+
+func TestProgressReporter_Report(t *testing.T) {
+	var sent []*Notification
+	sender := newStreamNotificationSender(nil, func(notification *JSONRPCNotification) error {
+		sent = append(sent, &notification.Notification)
+		return nil
+	})
+
+	ctx := withProgress(withNotificationSender(context.Background(), sender), 7)
+	reporter, ok := ProgressReporterFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, 7, reporter.Token())
+
+	require.NoError(t, reporter.Report(1, 0, ""))
+	assert.Error(t, reporter.Report(1, 0, ""), "progress must increase")
+
+	// The legacy sender API carries the request's progress token.
+	legacy, ok := GetNotificationSender(ctx)
+	require.True(t, ok)
+	require.NoError(t, legacy.SendProgress(2, "half"))
+	// It drops a progress that does not increase instead of failing.
+	require.NoError(t, legacy.SendProgress(2, "again"))
+	require.NoError(t, legacy.SendProgress(0, "restarted"))
+
+	require.Len(t, sent, 2)
+	data, err := json.Marshal(newJSONRPCNotification(*sent[1]))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"notifications/progress",`+
+		`"params":{"progressToken":7,"progress":2,"message":"half"}}`, string(data))
+
+	// Without a progress token, nothing is sent.
+	plain, ok := GetNotificationSender(withNotificationSender(context.Background(), sender))
+	require.True(t, ok)
+	require.NoError(t, plain.SendProgress(3, "ignored"))
+	assert.Len(t, sent, 2)
+
+	// A nil reporter is safe to use.
+	var nilReporter *ProgressReporter
+	assert.NoError(t, nilReporter.Report(1, 0, ""))
+	_, ok = ProgressReporterFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestProgressRouter_Dispatch(t *testing.T) {
+	var router progressRouter
+	collector := &progressCollector{}
+
+	req := &CallToolRequest{}
+	req.Params.Name = "tool"
+	prepared, done := router.prepareCallTool(WithProgressHandler(context.Background(), collector.handle), req)
+	require.NotNil(t, prepared.Params.Meta)
+	assert.Nil(t, req.Params.Meta, "the caller's request must not be modified")
+
+	notification := NewJSONRPCNotificationFromMap(NotificationMethodProgress, map[string]interface{}{
+		"progressToken": prepared.Params.Meta.ProgressToken,
+		"progress":      float64(1),
+	})
+	assert.True(t, router.dispatch(notification))
+	require.Len(t, collector.get(), 1)
+
+	done()
+	assert.False(t, router.dispatch(notification))
+
+	// Numeric tokens match regardless of their decoded Go type.
+	unregister := router.register(int64(5), collector.handle)
+	defer unregister()
+	notification.Params.AdditionalFields["progressToken"] = float64(5)
+	assert.True(t, router.dispatch(notification))
+}
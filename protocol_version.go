@@ -0,0 +1,161 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// protocolVersionKey is the session data key of the negotiated protocol version.
+const protocolVersionKey = "protocolVersion"
+
+// protocolVersionAtLeast reports whether version is the same as or newer than minimum.
+// Protocol versions are dates in YYYY-MM-DD form, so they compare lexically. An
+// empty version means the version is unknown and is treated as the latest one.
+func protocolVersionAtLeast(version, minimum string) bool {
+	return version == "" || version >= minimum
+}
+
+// protocolVersionFromSession returns the protocol version negotiated for the session,
+// or an empty string if it is not known.
+func protocolVersionFromSession(session Session) string {
+	if session == nil {
+		return ""
+	}
+	value, ok := session.GetData(protocolVersionKey)
+	if !ok {
+		return ""
+	}
+	version, _ := value.(string)
+	return version
+}
+
+// adaptResultToProtocolVersion removes fields and content types that the
+// negotiated protocol version does not know about, so that older clients keep
+// receiving results in the shape they expect. The result is not modified: an
+// adapted copy is returned, sharing what is left unchanged.
+func adaptResultToProtocolVersion(result JSONRPCMessage, version string) JSONRPCMessage {
+	switch r := result.(type) {
+	case *CallToolResult:
+		return adaptCallToolResult(r, version)
+	case CallToolResult:
+		return adaptCallToolResult(&r, version)
+	}
+
+	if protocolVersionAtLeast(version, ProtocolVersion_2025_06_18) {
+		return result
+	}
+
+	switch r := result.(type) {
+	case ListToolsResult:
+		tools := make([]Tool, len(r.Tools))
+		for i, tool := range r.Tools {
+			tool.Title = ""
+			tool.OutputSchema = nil
+			tools[i] = tool
+		}
+		r.Tools = tools
+		return r
+	case *ListPromptsResult:
+		adapted := *r
+		adapted.Prompts = make([]Prompt, len(r.Prompts))
+		for i, prompt := range r.Prompts {
+			adapted.Prompts[i] = downgradePrompt(prompt)
+		}
+		return &adapted
+	case ListResourcesResult:
+		resources := make([]Resource, len(r.Resources))
+		for i, resource := range r.Resources {
+			resource.Title = ""
+			resources[i] = resource
+		}
+		r.Resources = resources
+		return r
+	case ListResourceTemplatesResult:
+		templates := make([]ResourceTemplate, len(r.ResourceTemplates))
+		for i, template := range r.ResourceTemplates {
+			template.Title = ""
+			templates[i] = template
+		}
+		r.ResourceTemplates = templates
+		return r
+	case *GetPromptResult:
+		adapted := *r
+		adapted.Messages = make([]PromptMessage, len(r.Messages))
+		for i, message := range r.Messages {
+			message.Content = downgradeContent(message.Content)
+			adapted.Messages[i] = message
+		}
+		return &adapted
+	}
+	return result
+}
+
+// adaptCallToolResult returns a copy of a tool result carrying content that
+// the negotiated protocol version understands.
+func adaptCallToolResult(result *CallToolResult, version string) *CallToolResult {
+	if result == nil {
+		return nil
+	}
+	adapted := *result
+
+	// Structured results should also be available as serialized text, which is
+	// the only representation clients older than 2025-06-18 understand.
+	if adapted.StructuredContent != nil && len(adapted.Content) == 0 {
+		text, err := json.Marshal(adapted.StructuredContent)
+		if err != nil {
+			text = []byte(fmt.Sprintf("Error serializing structured content: %v", err))
+		}
+		adapted.Content = []Content{NewTextContent(string(text))}
+	}
+
+	if protocolVersionAtLeast(version, ProtocolVersion_2025_06_18) {
+		return &adapted
+	}
+
+	adapted.StructuredContent = nil
+	content := make([]Content, len(adapted.Content))
+	for i, c := range adapted.Content {
+		content[i] = downgradeContent(c)
+	}
+	adapted.Content = content
+	return &adapted
+}
+
+// downgradePrompt removes the fields added to prompts in 2025-06-18.
+func downgradePrompt(prompt Prompt) Prompt {
+	prompt.Title = ""
+	if len(prompt.Arguments) > 0 {
+		arguments := make([]PromptArgument, len(prompt.Arguments))
+		for i, argument := range prompt.Arguments {
+			argument.Title = ""
+			arguments[i] = argument
+		}
+		prompt.Arguments = arguments
+	}
+	return prompt
+}
+
+// downgradeContent replaces content types added in 2025-06-18 with text content.
+func downgradeContent(content Content) Content {
+	switch c := content.(type) {
+	case ResourceLink:
+		return resourceLinkAsText(c)
+	case *ResourceLink:
+		return resourceLinkAsText(*c)
+	}
+	return content
+}
+
+// resourceLinkAsText describes a resource link as text content.
+func resourceLinkAsText(link ResourceLink) TextContent {
+	if link.Name == "" {
+		return NewTextContent(link.URI)
+	}
+	return NewTextContent(fmt.Sprintf("%s (%s)", link.Name, link.URI))
+}
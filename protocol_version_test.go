@@ -0,0 +1,186 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+)
+
+type weatherOutput struct {
+	Temperature float64 `json:"temperature"`
+}
+
+// newProtocolVersionTestServer creates a server with a titled tool that returns
+// structured content and a resource link.
+func newProtocolVersionTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := NewServer("Test-Server", "1.0.0", WithGetSSEEnabled(false))
+	tool := NewTool("weather",
+		WithToolTitle("Weather"),
+		WithOutputStruct[weatherOutput](),
+	)
+	server.RegisterTool(tool, func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return &CallToolResult{
+			Content:           []Content{NewResourceLink("file:///weather.txt", "weather.txt")},
+			StructuredContent: weatherOutput{Temperature: 21},
+		}, nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+	return httpServer
+}
+
+// postJSON sends a JSON body to the server and returns the response with its body.
+func postJSON(t *testing.T, url string, body string, headers map[string]string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/mcp", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set(httputil.ContentTypeHeader, httputil.ContentTypeJSON)
+	req.Header.Set(httputil.AcceptHeader, httputil.ContentTypeJSON)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, data
+}
+
+// initializeSession initializes a session with the given protocol version and returns its ID.
+func initializeSession(t *testing.T, url string, version string) string {
+	t.Helper()
+	resp, body := postJSON(t, url, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+
+		version+`","clientInfo":{"name":"c","version":"1"},"capabilities":{}}}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	sessionID := resp.Header.Get(httputil.SessionIDHeader)
+	require.NotEmpty(t, sessionID)
+
+	resp, _ = postJSON(t, url, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, map[string]string{
+		httputil.SessionIDHeader: sessionID,
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	return sessionID
+}
+
+func TestProtocolVersion_LatestVersionKeepsNewFields(t *testing.T) {
+	httpServer := newProtocolVersionTestServer(t)
+	sessionID := initializeSession(t, httpServer.URL, ProtocolVersion_2025_06_18)
+	headers := map[string]string{
+		httputil.SessionIDHeader:       sessionID,
+		httputil.ProtocolVersionHeader: ProtocolVersion_2025_06_18,
+	}
+
+	_, body := postJSON(t, httpServer.URL, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, headers)
+	assert.Contains(t, string(body), `"title":"Weather"`)
+	assert.Contains(t, string(body), `"outputSchema"`)
+
+	_, body = postJSON(t, httpServer.URL,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"weather"}}`, headers)
+	assert.Contains(t, string(body), `"structuredContent":{"temperature":21}`)
+	assert.Contains(t, string(body), `"type":"resource_link"`)
+}
+
+func TestProtocolVersion_OlderVersionDropsNewFields(t *testing.T) {
+	httpServer := newProtocolVersionTestServer(t)
+	sessionID := initializeSession(t, httpServer.URL, ProtocolVersion_2025_03_26)
+	headers := map[string]string{httputil.SessionIDHeader: sessionID}
+
+	_, body := postJSON(t, httpServer.URL, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, headers)
+	assert.NotContains(t, string(body), `"title"`)
+	assert.NotContains(t, string(body), `"outputSchema"`)
+
+	_, body = postJSON(t, httpServer.URL,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"weather"}}`, headers)
+	assert.NotContains(t, string(body), `"structuredContent"`)
+	assert.NotContains(t, string(body), `"resource_link"`)
+	assert.Contains(t, string(body), `weather.txt (file:///weather.txt)`)
+}
+
+func TestProtocolVersion_AdaptationCopiesResult(t *testing.T) {
+	// Results shared between sessions, e.g. cached by a handler, must not
+	// lose their new fields for the sessions of newer versions.
+	result := &CallToolResult{
+		Content:           []Content{NewResourceLink("file:///weather.txt", "weather.txt")},
+		StructuredContent: weatherOutput{Temperature: 21},
+	}
+	adapted := adaptResultToProtocolVersion(result, ProtocolVersion_2025_03_26).(*CallToolResult)
+	assert.Nil(t, adapted.StructuredContent)
+	assert.IsType(t, TextContent{}, adapted.Content[0])
+	assert.NotNil(t, result.StructuredContent)
+	assert.IsType(t, ResourceLink{}, result.Content[0])
+
+	tools := ListToolsResult{Tools: []Tool{{Name: "weather", Title: "Weather"}}}
+	adaptResultToProtocolVersion(tools, ProtocolVersion_2025_03_26)
+	assert.Equal(t, "Weather", tools.Tools[0].Title)
+
+	prompt := &GetPromptResult{Messages: []PromptMessage{
+		{Role: RoleUser, Content: NewResourceLink("file:///weather.txt", "weather.txt")},
+	}}
+	adaptResultToProtocolVersion(prompt, ProtocolVersion_2025_03_26)
+	assert.IsType(t, ResourceLink{}, prompt.Messages[0].Content)
+}
+
+func TestProtocolVersion_HeaderValidation(t *testing.T) {
+	httpServer := newProtocolVersionTestServer(t)
+	sessionID := initializeSession(t, httpServer.URL, ProtocolVersion_2025_06_18)
+	request := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+
+	resp, _ := postJSON(t, httpServer.URL, request, map[string]string{
+		httputil.SessionIDHeader:       sessionID,
+		httputil.ProtocolVersionHeader: "1999-01-01",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = postJSON(t, httpServer.URL, request, map[string]string{
+		httputil.SessionIDHeader:       sessionID,
+		httputil.ProtocolVersionHeader: ProtocolVersion_2024_11_05,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = postJSON(t, httpServer.URL, request, map[string]string{
+		httputil.SessionIDHeader:       sessionID,
+		httputil.ProtocolVersionHeader: ProtocolVersion_2025_06_18,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestProtocolVersion_ClientSendsHeader(t *testing.T) {
+	var seen []string
+	server := NewServer("Test-Server", "1.0.0", WithGetSSEEnabled(false))
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get(httputil.ProtocolVersionHeader))
+		server.HTTPHandler().ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "c", Version: "1"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+
+	result, err := client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion_2025_06_18, result.ProtocolVersion)
+	_, err = client.ListTools(context.Background(), &ListToolsRequest{})
+	require.NoError(t, err)
+
+	require.Len(t, seen, 3)
+	assert.Empty(t, seen[0], "initialize must not carry the header")
+	assert.Equal(t, ProtocolVersion_2025_06_18, seen[1])
+	assert.Equal(t, ProtocolVersion_2025_06_18, seen[2])
+}
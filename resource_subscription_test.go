@@ -0,0 +1,162 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registerSubscriptionResources(register func(resource *Resource, handler resourceHandler)) {
+	for _, uri := range []string{"file:///a.txt", "file:///b.txt"} {
+		uri := uri
+		register(&Resource{URI: uri, Name: uri}, func(ctx context.Context, req *ReadResourceRequest) (ResourceContents, error) {
+			return TextResourceContents{URI: uri, Text: "content"}, nil
+		})
+	}
+}
+
+func TestResourceManager_Subscriptions(t *testing.T) {
+	m := newResourceManager()
+	registerSubscriptionResources(func(resource *Resource, handler resourceHandler) {
+		m.registerResource(resource, handler)
+	})
+	s1, s2 := newSession(), newSession()
+
+	req := newJSONRPCRequest(1, MethodResourcesSubscribe, map[string]interface{}{"uri": "file:///a.txt"})
+	_, err := m.handleSubscribe(context.Background(), req, s1)
+	require.NoError(t, err)
+	_, err = m.handleSubscribe(context.Background(), req, s2)
+	require.NoError(t, err)
+	assert.Len(t, m.getSubscribers("file:///a.txt"), 2)
+
+	// Subscriptions require a session and an existing resource.
+	result, err := m.handleSubscribe(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ErrCodeInvalidRequest, result.(*JSONRPCError).Error.Code)
+	req = newJSONRPCRequest(2, MethodResourcesSubscribe, map[string]interface{}{"uri": "file:///missing.txt"})
+	result, err = m.handleSubscribe(context.Background(), req, s1)
+	require.NoError(t, err)
+	assert.IsType(t, &JSONRPCError{}, result)
+
+	req = newJSONRPCRequest(3, MethodResourcesUnsubscribe, map[string]interface{}{"uri": "file:///a.txt"})
+	_, err = m.handleUnsubscribe(context.Background(), req, s1)
+	require.NoError(t, err)
+	subscribers := m.getSubscribers("file:///a.txt")
+	require.Len(t, subscribers, 1)
+	assert.Equal(t, s2.GetID(), subscribers[0].GetID())
+
+	m.unsubscribeSession(s2.GetID())
+	assert.Empty(t, m.getSubscribers("file:///a.txt"))
+	assert.Empty(t, m.subscriptions)
+}
+
+func TestServer_NotifyResourceUpdated(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"))
+	registerSubscriptionResources(server.RegisterResource)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	newInitializedClient := func() *Client {
+		client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+			WithClientGetSSEEnabled(true))
+		require.NoError(t, err)
+		initResult, err := client.Initialize(context.Background(), &InitializeRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, initResult.Capabilities.Resources)
+		assert.True(t, initResult.Capabilities.Resources.Subscribe)
+		require.Eventually(t, func() bool {
+			server.httpHandler.getSSEConnectionsLock.RLock()
+			defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+			_, ok := server.httpHandler.getSSEConnections[client.GetSessionID()]
+			return ok
+		}, 2*time.Second, 10*time.Millisecond)
+		return client
+	}
+	subscriber := newInitializedClient()
+	defer subscriber.Close()
+	other := newInitializedClient()
+	defer other.Close()
+
+	updates := make(chan string, 10)
+	require.NoError(t, subscriber.Subscribe(context.Background(), "file:///a.txt",
+		func(notification *ResourceUpdatedNotification) {
+			updates <- notification.Params.URI
+		}))
+	// A failed subscription leaves no handler behind.
+	assert.Error(t, subscriber.Subscribe(context.Background(), "file:///missing.txt",
+		func(notification *ResourceUpdatedNotification) {}))
+	subscriber.subscriptionsMu.Lock()
+	assert.NotContains(t, subscriber.resourceUpdatedHandlers, "file:///missing.txt")
+	subscriber.subscriptionsMu.Unlock()
+
+	otherUpdates := make(chan string, 10)
+	require.NoError(t, other.Subscribe(context.Background(), "file:///b.txt",
+		func(notification *ResourceUpdatedNotification) {
+			otherUpdates <- notification.Params.URI
+		}))
+
+	require.NoError(t, server.NotifyResourceUpdated("file:///a.txt"))
+	select {
+	case uri := <-updates:
+		assert.Equal(t, "file:///a.txt", uri)
+	case <-time.After(2 * time.Second):
+		t.Fatal("resource update not received")
+	}
+
+	// Only the subscribed session is notified.
+	require.NoError(t, server.NotifyResourceUpdated("file:///b.txt"))
+	select {
+	case uri := <-otherUpdates:
+		assert.Equal(t, "file:///b.txt", uri)
+	case <-time.After(2 * time.Second):
+		t.Fatal("resource update not received")
+	}
+	assert.Empty(t, updates)
+
+	require.NoError(t, subscriber.Unsubscribe(context.Background(), "file:///a.txt"))
+	assert.Empty(t, server.resourceManager.getSubscribers("file:///a.txt"))
+
+	// Terminating the session drops its subscriptions.
+	require.NoError(t, other.TerminateSession(context.Background()))
+	require.Eventually(t, func() bool {
+		return len(server.resourceManager.getSubscribers("file:///b.txt")) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestSSEServer_NotifyResourceUpdated(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0")
+	registerSubscriptionResources(server.RegisterResource)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	updates := make(chan string, 10)
+	require.NoError(t, client.Subscribe(context.Background(), "file:///a.txt",
+		func(notification *ResourceUpdatedNotification) {
+			updates <- notification.Params.URI
+		}))
+
+	require.NoError(t, server.NotifyResourceUpdated("file:///b.txt"))
+	require.NoError(t, server.NotifyResourceUpdated("file:///a.txt"))
+	select {
+	case uri := <-updates:
+		assert.Equal(t, "file:///a.txt", uri)
+	case <-time.After(2 * time.Second):
+		t.Fatal("resource update not received")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Common errors
@@ -66,6 +67,21 @@ type serverConfig struct {
 	EnableSession  bool
 	isStateless    bool
 
+	// Store of the sessions, nil keeps them in process memory
+	sessionStore SessionStore
+
+	// Pub/Sub routing messages between server nodes, nil for a single node
+	sessionPubSub SessionPubSub
+
+	// Time a session can be idle before it expires, zero or less for no limit
+	sessionIdleTimeout time.Duration
+
+	// Time a session can live regardless of activity, zero or less for no limit
+	sessionMaxLifetime time.Duration
+
+	// Hooks called as sessions are created, initialized and closed
+	sessionHooks *SessionHooks
+
 	// Response related
 	postSSEEnabled         bool
 	getSSEEnabled          bool
@@ -85,6 +101,37 @@ type serverConfig struct {
 
 	// Method name modifier for external customization.
 	methodNameModifier MethodNameModifier
+
+	// Maximum number of items per list page, 0 disables pagination
+	pageSize int
+
+	// HMAC key signing the pagination cursors, random if empty
+	paginationKey []byte
+
+	// Event store of resumable SSE streams, nil disables resumption
+	eventStore EventStore
+
+	// Verifier of bearer tokens, nil disables authorization
+	tokenVerifier TokenVerifier
+
+	// Protected resource metadata served to clients, nil if not served
+	resourceMetadata *ProtectedResourceMetadata
+
+	// Whether the X-Forwarded-Proto header of a reverse proxy is trusted
+	trustProxyHeaders bool
+
+	// List changed notification related
+	listChangedEnabled bool
+	listChangedDelay   time.Duration
+
+	// Validation of tools/call arguments against the input schema of the tool
+	toolInputValidation ToolInputValidation
+
+	// Validation of structured content against the output schema of the tool
+	toolOutputValidation ToolOutputValidation
+
+	// WebSocket transport configuration
+	webSocket webSocketConfig
 }
 
 // ServerNotificationHandler defines a function that handles notifications on the server side.
@@ -107,6 +154,10 @@ type Server struct {
 	notificationHandlers map[string]ServerNotificationHandler // Map of notification handlers by method name.
 	notificationMu       sync.RWMutex                         // Mutex for notification handlers map.
 	pendingMiddlewares   []Middleware                         // Middlewares to be applied after component initialization.
+	httpServer           *http.Server                         // HTTP server created by Start.
+	httpServerMu         sync.Mutex                           // Mutex for httpServer.
+	shutdownFuncs        []func() error                       // Functions releasing resources owned by the server on shutdown.
+	listChanged          *listChangedNotifier                 // Notifier of tool, prompt and resource list changes, nil if disabled.
 }
 
 // NewServer creates a new MCP server
@@ -120,6 +171,10 @@ func NewServer(name, version string, options ...ServerOption) *Server {
 		postSSEEnabled:         true,
 		getSSEEnabled:          true,
 		notificationBufferSize: defaultNotificationBufferSize,
+		sessionIdleTimeout:     defaultSessionExpirySeconds * time.Second,
+		listChangedEnabled:     true,
+		listChangedDelay:       defaultListChangedDelay,
+		webSocket:              newDefaultWebSocketConfig(),
 	}
 
 	// Create server with provided serverInfo
@@ -155,10 +210,26 @@ func (s *Server) initComponents() {
 	if s.config.isStateless {
 		lifecycleManager = lifecycleManager.withStatelessMode(true)
 	}
+	lifecycleManager.withSessionHooks(s.config.sessionHooks)
+
+	// Stateless servers cannot send notifications outside of a request.
+	listChanged := s.config.listChangedEnabled && !s.config.isStateless
+	lifecycleManager.withListChanged(listChanged)
+	if listChanged {
+		s.listChanged = newListChangedNotifier(s.config.listChangedDelay, s.sendListChanged)
+		s.onShutdown(s.listChanged.stop)
+	}
+
+	// Create a paginator shared by all list methods if a page size is set.
+	paginator := newPaginator(s.config.pageSize, s.config.paginationKey)
 
 	// Create tool manager.
 	toolManager := newToolManager()
+	toolManager.withPaginator(paginator)
 	toolManager.withServerProvider(s)
+	toolManager.withInputValidation(s.config.toolInputValidation)
+	toolManager.withOutputValidation(s.config.toolOutputValidation)
+	toolManager.withLogger(s.logger)
 	if s.config.methodNameModifier != nil {
 		toolManager.withMethodNameModifier(s.config.methodNameModifier)
 	}
@@ -170,6 +241,7 @@ func (s *Server) initComponents() {
 
 	// Create resource manager.
 	resourceManager := newResourceManager()
+	resourceManager.withPaginator(paginator)
 	// Only set resource list filter if not nil.
 	if s.config.resourceListFilter != nil {
 		resourceManager.withResourceListFilter(s.config.resourceListFilter)
@@ -178,6 +250,7 @@ func (s *Server) initComponents() {
 
 	// Create prompt manager.
 	promptManager := newPromptManager()
+	promptManager.withPaginator(paginator)
 	// Only set prompt list filter if not nil.
 	if s.config.promptListFilter != nil {
 		promptManager.withPromptListFilter(s.config.promptListFilter)
@@ -204,9 +277,18 @@ func (s *Server) initComponents() {
 	// Session configuration.
 	if !s.config.EnableSession {
 		httpOptions = append(httpOptions, withoutTransportSession())
+	} else if s.config.sessionStore != nil {
+		httpOptions = append(httpOptions, withTransportSessionManager(newStoreSessionManager(s.config.sessionStore, s.logger)))
 	} else if s.config.sessionManager != nil {
 		httpOptions = append(httpOptions, withTransportSessionManager(s.config.sessionManager))
 	}
+	if s.config.sessionPubSub != nil && !s.config.isStateless {
+		httpOptions = append(httpOptions, withTransportSessionPubSub(s.config.sessionPubSub))
+	}
+	httpOptions = append(httpOptions,
+		withTransportSessionExpiry(s.config.sessionIdleTimeout, s.config.sessionMaxLifetime),
+		withTransportSessionHooks(s.config.sessionHooks),
+	)
 
 	// State mode configuration.
 	if s.config.isStateless {
@@ -218,8 +300,21 @@ func (s *Server) initComponents() {
 		withServerPOSTSSEEnabled(s.config.postSSEEnabled),
 		withTransportGetSSEEnabled(s.config.getSSEEnabled),
 		withTransportNotificationBufferSize(s.config.notificationBufferSize),
+		withTransportWebSocket(s.config.webSocket),
 	)
 
+	// Stream resumption configuration.
+	if s.config.eventStore != nil {
+		httpOptions = append(httpOptions, withTransportEventStore(s.config.eventStore))
+	}
+
+	// Authorization configuration.
+	if s.config.tokenVerifier != nil {
+		authorizer := newAuthorizer(s.config.tokenVerifier, s.config.resourceMetadata, s.config.path)
+		authorizer.trustProxyHeaders = s.config.trustProxyHeaders
+		httpOptions = append(httpOptions, withTransportAuthorizer(authorizer))
+	}
+
 	// HTTP context functions configuration.
 	if len(s.config.httpContextFuncs) > 0 {
 		httpOptions = append(httpOptions, withTransportHTTPContextFuncs(s.config.httpContextFuncs))
@@ -275,6 +370,60 @@ func WithGetSSEEnabled(enabled bool) ServerOption {
 	}
 }
 
+// WithWebSocketEnabled sets whether the server path also accepts WebSocket
+// upgrade requests, served as by WebSocketHandler. Disabled by default.
+func WithWebSocketEnabled(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.config.webSocket.enabled = enabled
+	}
+}
+
+// WithServerWebSocketPingInterval sets the time between the pings the server
+// sends on WebSocket connections. A connection whose client answers nothing
+// for two intervals is closed. Zero disables pings. Defaults to 30 seconds.
+func WithServerWebSocketPingInterval(interval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.config.webSocket.pingInterval = interval
+	}
+}
+
+// WithServerWebSocketMaxMessageSize sets the maximum size of a message read
+// from a WebSocket connection, in bytes. A larger message closes the
+// connection. Zero means the maximum, 1 GiB. Defaults to 4 MiB.
+func WithServerWebSocketMaxMessageSize(size int64) ServerOption {
+	return func(s *Server) {
+		s.config.webSocket.maxMessageSize = size
+	}
+}
+
+// WithWebSocketCheckOrigin sets the function reporting whether the Origin of
+// a WebSocket upgrade request is allowed. By default, only requests without
+// an Origin header or from the host of the server are allowed.
+func WithWebSocketCheckOrigin(checkOrigin func(r *http.Request) bool) ServerOption {
+	return func(s *Server) {
+		s.config.webSocket.checkOrigin = checkOrigin
+	}
+}
+
+// WithListChangedEnabled enables or disables the list_changed notifications
+// sent to the sessions when tools, prompts or resources are registered or
+// unregistered. They are enabled by default, except in stateless mode, and the
+// listChanged capabilities are advertised accordingly.
+func WithListChangedEnabled(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.config.listChangedEnabled = enabled
+	}
+}
+
+// WithListChangedDelay sets the time changes are collected for before a
+// list_changed notification is sent, so that registering many tools, prompts
+// or resources at once sends one notification per list. Defaults to 100ms.
+func WithListChangedDelay(delay time.Duration) ServerOption {
+	return func(s *Server) {
+		s.config.listChangedDelay = delay
+	}
+}
+
 // WithNotificationBufferSize sets the notification buffer size
 func WithNotificationBufferSize(size int) ServerOption {
 	return func(s *Server) {
@@ -376,6 +525,195 @@ func WithResourceListFilter(filter ResourceListFilter) ServerOption {
 	}
 }
 
+// WithPageSize enables cursor-based pagination for tools/list, prompts/list,
+// resources/list and resources/templates/list, returning at most size items
+// per page. List filters are applied before pagination. A size of zero or
+// less, the default, returns all items in a single response.
+func WithPageSize(size int) ServerOption {
+	return func(s *Server) {
+		s.config.pageSize = size
+	}
+}
+
+// WithPaginationKey sets the HMAC key signing the pagination cursors. By
+// default a random key is generated, so a cursor is only accepted by the
+// server that issued it; servers behind a load balancer must share the key.
+func WithPaginationKey(key []byte) ServerOption {
+	return func(s *Server) {
+		s.config.paginationKey = key
+	}
+}
+
+// WithToolInputValidation validates the arguments of tools/call requests
+// against the input schema of the tool before its handler runs, including
+// the schemas generated by WithInputStruct. Arguments violating the schema are
+// reported with the JSON pointer of every violation, as an isError tool
+// result with ToolInputValidationResult, or as an invalid params error with
+// ToolInputValidationError. The schema of a tool is compiled once, on the
+// first call after the tool is registered. Validation is disabled by default.
+func WithToolInputValidation(validation ToolInputValidation) ServerOption {
+	return func(s *Server) {
+		s.config.toolInputValidation = validation
+	}
+}
+
+// WithToolOutputValidation validates the structured content returned by
+// tools against their output schema, including the schemas generated by
+// WithOutputStruct. A successful result of a tool with an output schema must
+// carry structured content that conforms to it. With ToolOutputValidationLog
+// violations are logged and the result is returned unchanged, while with
+// ToolOutputValidationStrict the result is replaced with an internal error
+// listing the violations. Validation is disabled by default.
+func WithToolOutputValidation(validation ToolOutputValidation) ServerOption {
+	return func(s *Server) {
+		s.config.toolOutputValidation = validation
+	}
+}
+
+// WithEventStore makes the SSE streams of stateful sessions resumable. Every
+// message sent on a POST response stream or a GET stream is recorded in the
+// store, and a client reconnecting with a Last-Event-ID header on a GET request,
+// or sending a request again with it, receives the messages that followed that
+// event on its stream. While a store is set, requests are not cancelled when
+// their connection drops. The events of a stream are deleted from the store
+// once the client received them all, or when its session ends.
+func WithEventStore(store EventStore) ServerOption {
+	return func(s *Server) {
+		s.config.eventStore = store
+	}
+}
+
+// WithSessionStore keeps the sessions of the server in the store instead of in
+// process memory, so that replicas of the server sharing the store can serve
+// the requests of any session. Session data set through Session.SetData is
+// saved in the store as JSON, and read back by later requests as generic JSON
+// values rather than the types that were set. Sessions expired in the store
+// are closed on each replica that served them, within a minute. To also
+// deliver notifications and server-to-client requests to the replica holding
+// a session's GET SSE stream, use WithServerSessionPubSub.
+//
+// Example:
+//
+//	server := mcp.NewServer("my-server", "1.0.0",
+//	    mcp.WithSessionStore(redisSessionStore),
+//	    mcp.WithServerSessionPubSub(redisPubSub),
+//	)
+func WithSessionStore(store SessionStore) ServerOption {
+	return func(s *Server) {
+		s.config.sessionStore = store
+	}
+}
+
+// WithServerSessionIdleTimeout sets the time a session can go without requests
+// before it expires, one hour by default. Zero or less disables idle expiry.
+// It does not apply to sessions kept in a SessionStore, which expires them itself.
+func WithServerSessionIdleTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.config.sessionIdleTimeout = timeout
+	}
+}
+
+// WithServerSessionMaxLifetime sets the time after which a session expires
+// regardless of its activity. Zero or less, the default, disables it. It does
+// not apply to sessions kept in a SessionStore.
+func WithServerSessionMaxLifetime(lifetime time.Duration) ServerOption {
+	return func(s *Server) {
+		s.config.sessionMaxLifetime = lifetime
+	}
+}
+
+// WithSessionHooks sets functions called as sessions are created, initialized
+// and closed. Sessions are closed when they expire or are deleted by the
+// client. It is ignored in stateless mode, which has no sessions.
+//
+// With WithSessionStore, OnSessionCreated is called by the replica handling
+// the initialize request, and OnSessionClosed by each replica that served the
+// session: with the reason of the closing on the replica closing it, and with
+// SessionCloseReasonExpired on the others, once they find it gone from the
+// store. Session data is saved in the store as JSON, so values such as
+// connections are better kept by the hooks, by session ID.
+//
+// Example:
+//
+//	var conns sync.Map // *sql.Conn by session ID.
+//	server := mcp.NewServer("my-server", "1.0.0", mcp.WithSessionHooks(&mcp.SessionHooks{
+//	    OnSessionCreated: func(ctx context.Context, session mcp.Session) {
+//	        conns.Store(session.GetID(), openConnection())
+//	    },
+//	    OnSessionClosed: func(ctx context.Context, session mcp.Session, reason mcp.SessionCloseReason) {
+//	        if conn, ok := conns.LoadAndDelete(session.GetID()); ok {
+//	            conn.(*sql.Conn).Close()
+//	        }
+//	    },
+//	}))
+func WithSessionHooks(hooks *SessionHooks) ServerOption {
+	return func(s *Server) {
+		s.config.sessionHooks = hooks
+	}
+}
+
+// WithServerSessionPubSub routes messages between replicas of the server. A
+// replica holding the GET SSE stream of a session subscribes to the session ID,
+// and other replicas publish the notifications and requests for that client to
+// it. Responses to server-to-client requests are routed back to the replica
+// waiting for them, whichever replica the client posts them to. It is ignored
+// in stateless mode.
+func WithServerSessionPubSub(pubSub SessionPubSub) ServerOption {
+	return func(s *Server) {
+		s.config.sessionPubSub = pubSub
+	}
+}
+
+// WithAuthorization makes the server an OAuth 2.1 resource server. Every
+// request must carry an "Authorization: Bearer" access token accepted by the
+// verifier, or it is rejected with 401 Unauthorized. The verified token is
+// available to handlers through TokenInfoFromContext, and tools registered
+// with WithToolRequiredScopes are restricted to tokens granting their scopes:
+// calling one without them is refused with 403 Forbidden, telling the client
+// the scopes to request.
+//
+// When metadata is not nil, the server serves it at
+// /.well-known/oauth-protected-resource and its 401 responses point clients
+// to it, so that they can discover the authorization servers. If the server's
+// handler is mounted on a mux, that path must be routed to it as well.
+//
+// A JWTVerifier checks that tokens were issued for the server: its audience
+// is set with WithJWTAudience, or else is the Resource of metadata. With
+// neither, every token is rejected.
+//
+// Example:
+//
+//	verifier, err := mcp.NewJWTVerifier(
+//	    mcp.WithJWKSFile("/etc/mcp/jwks.json"),
+//	    mcp.WithJWTIssuer("https://auth.example.com"),
+//	    mcp.WithJWTAudience("https://mcp.example.com/mcp"),
+//	)
+//	...
+//	server := mcp.NewServer("name", "1.0.0",
+//	    mcp.WithAuthorization(verifier, &mcp.ProtectedResourceMetadata{
+//	        Resource:             "https://mcp.example.com/mcp",
+//	        AuthorizationServers: []string{"https://auth.example.com"},
+//	    }),
+//	)
+func WithAuthorization(verifier TokenVerifier, metadata *ProtectedResourceMetadata) ServerOption {
+	return func(s *Server) {
+		s.config.tokenVerifier = verifier
+		s.config.resourceMetadata = metadata
+	}
+}
+
+// WithTrustedProxyHeaders sets whether the server runs behind a trusted
+// reverse proxy, whose X-Forwarded-Proto header gives the scheme of the URL
+// clients sent their requests to. It is used to derive the resource of the
+// server when the ProtectedResourceMetadata of WithAuthorization has none.
+// Only enable it if the proxy overwrites the header, as clients could
+// otherwise set it.
+func WithTrustedProxyHeaders(trusted bool) ServerOption {
+	return func(s *Server) {
+		s.config.trustProxyHeaders = trusted
+	}
+}
+
 // WithMiddleware registers one or more middlewares to the server.
 // Middlewares are executed in the order they are provided.
 // All middlewares must be configured at server creation time.
@@ -410,16 +748,72 @@ func WithServerAddress(addr string) ServerOption {
 
 // Start starts the server
 func (s *Server) Start() error {
-	if s.customServer != nil {
-		s.customServer.Handler = s.Handler()
-		return s.customServer.ListenAndServe()
+	s.httpServerMu.Lock()
+	if s.httpServer == nil {
+		if s.customServer != nil {
+			s.customServer.Handler = s.Handler()
+			s.httpServer = s.customServer
+		} else {
+			s.httpServer = &http.Server{Addr: s.config.addr, Handler: s.Handler()}
+		}
+	}
+	srv := s.httpServer
+	s.httpServerMu.Unlock()
+	return srv.ListenAndServe()
+}
+
+// Shutdown gracefully shuts down the server. New sessions are rejected, and the
+// requests being handled are given until ctx is done to complete, after which
+// the remaining ones are cancelled. The open SSE streams are then sent a close
+// event and ended, the sessions are closed with SessionCloseReasonShutdown, and
+// the HTTP server started by Start, if any, is shut down.
+//
+// Shutdown returns ctx.Err() if requests had to be cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.httpHandler.shuttingDown.Store(true)
+
+	var errs []error
+	drainErr := s.mcpHandler.inFlight.wait(ctx)
+	if drainErr != nil {
+		s.httpHandler.logger.Infof("Cancelling the requests still in flight on shutdown: %v", drainErr)
+		s.mcpHandler.inFlight.cancelAll()
+		errs = append(errs, drainErr)
+	}
+
+	s.httpHandler.shutdown(ctx)
+
+	for _, fn := range s.shutdownFuncs {
+		if err := fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s.httpServerMu.Lock()
+	if s.httpServer == nil {
+		// Start was not called, so it must not start serving from now on.
+		s.httpServer = s.customServer
+		if s.httpServer == nil {
+			s.httpServer = &http.Server{}
+		}
 	}
-	return http.ListenAndServe(s.config.addr, s.Handler())
+	srv := s.httpServer
+	s.httpServerMu.Unlock()
+	if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, drainErr) {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// onShutdown registers a function releasing a resource owned by the server,
+// called by Shutdown once the sessions are closed.
+func (s *Server) onShutdown(fn func() error) {
+	s.shutdownFuncs = append(s.shutdownFuncs, fn)
 }
 
 // RegisterTool registers a tool with its handler function
 func (s *Server) RegisterTool(tool *Tool, handler toolHandler) {
 	s.toolManager.registerTool(tool, handler)
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 }
 
 // GetTool retrieves a registered tool by name.
@@ -460,6 +854,7 @@ func (s *Server) UnregisterTools(names ...string) error {
 	if unregisteredCount == 0 {
 		return fmt.Errorf("none of the specified tools were found")
 	}
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 
 	return nil
 }
@@ -467,19 +862,50 @@ func (s *Server) UnregisterTools(names ...string) error {
 // RegisterResource registers a resource with its handler function
 func (s *Server) RegisterResource(resource *Resource, handler resourceHandler) {
 	s.resourceManager.registerResource(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 }
 
 // RegisterResources registers a resource with its handler function for multiple contents
 func (s *Server) RegisterResources(resource *Resource, handler resourcesHandler) {
 	s.resourceManager.registerResources(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
+}
+
+// UnregisterResources removes multiple resources by URIs and returns an error if no resources were unregistered
+func (s *Server) UnregisterResources(uris ...string) error {
+	if len(uris) == 0 {
+		return fmt.Errorf("no resource URIs provided")
+	}
+	if s.resourceManager.unregisterResources(uris...) == 0 {
+		return fmt.Errorf("none of the specified resources were found")
+	}
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
+	return nil
 }
 
-// RegisterResourceTemplate registers a resource template with its handler function.
+// RegisterResourceTemplate registers a resource template with its handler function,
+// replacing the template with the same name. Reads of URIs matching no resource are
+// handled by the template matching them, with the values of its variables as
+// arguments unless the request has some.
 func (s *Server) RegisterResourceTemplate(
 	template *ResourceTemplate,
 	handler resourceTemplateHandler,
 ) {
 	s.resourceManager.registerTemplate(template, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
+}
+
+// UnregisterResourceTemplates removes multiple resource templates by names and returns an error if no templates
+// were unregistered
+func (s *Server) UnregisterResourceTemplates(names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no resource template names provided")
+	}
+	if s.resourceManager.unregisterTemplates(names...) == 0 {
+		return fmt.Errorf("none of the specified resource templates were found")
+	}
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
+	return nil
 }
 
 // RegisterPrompt registers a prompt with its handler function
@@ -490,6 +916,140 @@ func (s *Server) RegisterResourceTemplate(
 // will return an empty list rather than an error.
 func (s *Server) RegisterPrompt(prompt *Prompt, handler promptHandler) {
 	s.promptManager.registerPrompt(prompt, handler)
+	s.listChanged.notify("", NotificationMethodPromptsListChanged)
+}
+
+// UnregisterPrompts removes multiple prompts by names and returns an error if no prompts were unregistered
+func (s *Server) UnregisterPrompts(names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no prompt names provided")
+	}
+	if s.promptManager.unregisterPrompts(names...) == 0 {
+		return fmt.Errorf("none of the specified prompts were found")
+	}
+	s.listChanged.notify("", NotificationMethodPromptsListChanged)
+	return nil
+}
+
+// RegisterSessionTool registers a tool for a single session, for example once
+// the user of the session authenticated. The session lists and calls it on top
+// of the tools registered for all sessions, and it replaces the tool with the
+// same name. The session is notified that its tool list changed.
+//
+// Session registrations are dropped when the session closes. They are kept in
+// the memory of this server, so with a SessionStore shared by several servers,
+// they are only seen by the requests this server handles.
+func (s *Server) RegisterSessionTool(sessionID string, tool *Tool, handler toolHandler) error {
+	if err := s.checkSession(sessionID); err != nil {
+		return err
+	}
+	s.toolManager.registerSessionTool(sessionID, tool, handler)
+	s.listChanged.notify(sessionID, NotificationMethodToolsListChanged)
+	return nil
+}
+
+// UnregisterSessionTools removes tools registered for a session and returns an
+// error if no tools were unregistered.
+func (s *Server) UnregisterSessionTools(sessionID string, names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no tool names provided")
+	}
+	if s.toolManager.unregisterSessionTools(sessionID, names...) == 0 {
+		return fmt.Errorf("none of the specified tools were found")
+	}
+	s.listChanged.notify(sessionID, NotificationMethodToolsListChanged)
+	return nil
+}
+
+// RegisterSessionPrompt registers a prompt for a single session, like
+// RegisterSessionTool registers tools.
+func (s *Server) RegisterSessionPrompt(sessionID string, prompt *Prompt, handler promptHandler) error {
+	if err := s.checkSession(sessionID); err != nil {
+		return err
+	}
+	s.promptManager.registerSessionPrompt(sessionID, prompt, handler)
+	s.listChanged.notify(sessionID, NotificationMethodPromptsListChanged)
+	return nil
+}
+
+// UnregisterSessionPrompts removes prompts registered for a session and
+// returns an error if no prompts were unregistered.
+func (s *Server) UnregisterSessionPrompts(sessionID string, names ...string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no prompt names provided")
+	}
+	if s.promptManager.unregisterSessionPrompts(sessionID, names...) == 0 {
+		return fmt.Errorf("none of the specified prompts were found")
+	}
+	s.listChanged.notify(sessionID, NotificationMethodPromptsListChanged)
+	return nil
+}
+
+// RegisterSessionResource registers a resource for a single session, like
+// RegisterSessionTool registers tools.
+func (s *Server) RegisterSessionResource(sessionID string, resource *Resource, handler resourceHandler) error {
+	return s.RegisterSessionResources(sessionID, resource,
+		func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+			content, err := handler(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return []ResourceContents{content}, nil
+		})
+}
+
+// RegisterSessionResources registers a resource with multiple contents for a
+// single session, like RegisterSessionTool registers tools.
+func (s *Server) RegisterSessionResources(sessionID string, resource *Resource, handler resourcesHandler) error {
+	if err := s.checkSession(sessionID); err != nil {
+		return err
+	}
+	s.resourceManager.registerSessionResources(sessionID, resource, handler)
+	s.listChanged.notify(sessionID, NotificationMethodResourcesListChanged)
+	return nil
+}
+
+// UnregisterSessionResources removes resources registered for a session and
+// returns an error if no resources were unregistered.
+func (s *Server) UnregisterSessionResources(sessionID string, uris ...string) error {
+	if len(uris) == 0 {
+		return fmt.Errorf("no resource URIs provided")
+	}
+	if s.resourceManager.unregisterSessionResources(sessionID, uris...) == 0 {
+		return fmt.Errorf("none of the specified resources were found")
+	}
+	s.listChanged.notify(sessionID, NotificationMethodResourcesListChanged)
+	return nil
+}
+
+// checkSession checks that a session exists to register items for.
+func (s *Server) checkSession(sessionID string) error {
+	if s.config.isStateless {
+		return ErrStatelessMode
+	}
+	if s.httpHandler.sessionManager == nil {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if _, ok := s.httpHandler.sessionManager.getSession(sessionID); !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return nil
+}
+
+// RegisterPromptCompletion registers a provider for completion/complete requests
+// on an argument of a prompt.
+//
+// The completions capability is advertised as soon as a provider is registered.
+func (s *Server) RegisterPromptCompletion(promptName, argumentName string, provider CompletionProvider) {
+	s.promptManager.registerCompletionProvider(promptName, argumentName, provider)
+}
+
+// RegisterResourceTemplateCompletion registers a provider for completion/complete
+// requests on a variable of a resource template, identified by its URI template.
+//
+// The completions capability is advertised as soon as a provider is registered.
+func (s *Server) RegisterResourceTemplateCompletion(uriTemplate, variable string, provider CompletionProvider) {
+	s.resourceManager.registerCompletionProvider(uriTemplate, variable, provider)
 }
 
 // SendNotification sends a notification to a specific session.
@@ -559,7 +1119,41 @@ func (s *Server) ListRoots(ctx context.Context) (*ListRootsResult, error) {
 	return &listRootsResult, nil
 }
 
-// SendRequest sends a JSON-RPC request to a client and waits for response.
+// CreateMessage asks the client of a session to sample an LLM, waiting for the
+// result until ctx is done, or for 30 seconds if ctx has no deadline. Called
+// while handling a request of the session, such as a tool call, the request is
+// delivered over the SSE stream answering it; otherwise over the session's GET
+// SSE stream, which the client must keep open.
+func (s *Server) CreateMessage(
+	ctx context.Context,
+	sessionID string,
+	req *CreateMessageRequest,
+) (*CreateMessageResult, error) {
+	if s.config.isStateless {
+		return nil, ErrStatelessMode
+	}
+
+	session, exists := s.httpHandler.sessionManager.getSession(sessionID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	return createMessage(ctx, session, req, func(ctx context.Context, request *JSONRPCRequest) (*json.RawMessage, error) {
+		return s.SendRequest(ctx, sessionID, request)
+	})
+}
+
+// sendRequestToSession implements sessionRequestSender.
+func (s *Server) sendRequestToSession(
+	ctx context.Context,
+	session Session,
+	request *JSONRPCRequest,
+) (*json.RawMessage, error) {
+	return s.SendRequest(ctx, session.GetID(), request)
+}
+
+// SendRequest sends a JSON-RPC request to a client and waits for the response
+// until ctx is done, or for 30 seconds if ctx has no deadline.
 func (s *Server) SendRequest(ctx context.Context, sessionID string, request *JSONRPCRequest) (*json.RawMessage, error) {
 	if s.config.isStateless {
 		return nil, ErrStatelessMode
@@ -607,6 +1201,20 @@ func (s *Server) BroadcastNotification(method string, params map[string]interfac
 	return successCount, nil
 }
 
+// sendListChanged sends a list_changed notification to a session, or all
+// sessions if sessionID is empty.
+func (s *Server) sendListChanged(sessionID, method string) {
+	var err error
+	if sessionID == "" {
+		_, err = s.BroadcastNotification(method, nil)
+	} else {
+		err = s.SendNotification(sessionID, method, nil)
+	}
+	if err != nil && s.logger != nil {
+		s.logger.Debugf("Failed to send %s: %v", method, err)
+	}
+}
+
 // Send notification to filtered sessions and count results
 func (s *Server) sendNotificationToFilteredSessions(sessions []string, notification *JSONRPCNotification, filter func(sessionID string) bool) (successCount, failedCount int, lastError error) {
 	for _, sessionID := range sessions {
@@ -648,6 +1256,27 @@ func (s *Server) SendFilteredNotification(
 	return successCount, failedCount, nil
 }
 
+// NotifyResourceUpdated sends a notifications/resources/updated notification to
+// the sessions subscribed to the resource. Sessions that did not subscribe are
+// not notified.
+func (s *Server) NotifyResourceUpdated(uri string) error {
+	if s.config.isStateless {
+		return ErrStatelessMode
+	}
+
+	subscribers := s.resourceManager.getSubscribers(uri)
+	sessionIDs := make([]string, 0, len(subscribers))
+	for _, session := range subscribers {
+		sessionIDs = append(sessionIDs, session.GetID())
+	}
+
+	_, failedCount, lastError := s.sendNotificationToSessions(sessionIDs, newResourceUpdatedNotification(uri))
+	if failedCount > 0 {
+		return fmt.Errorf("failed to notify %d of %d subscribed sessions: %w", failedCount, len(sessionIDs), lastError)
+	}
+	return nil
+}
+
 // getActiveSessions gets all active session IDs
 func (s *Server) getActiveSessions() ([]string, error) {
 	// Check if in stateless mode
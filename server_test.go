@@ -7,13 +7,17 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
 )
 
 // Create test server
@@ -356,3 +360,138 @@ func TestServer_UnregisterTools(t *testing.T) {
 	tools = server.toolManager.getTools()
 	assert.Len(t, tools, 0)
 }
+
+func TestServer_ShutdownDrainsRequests(t *testing.T) {
+	hooks, events := newRecordingSessionHooks()
+	hooks.OnSessionCreated, hooks.OnSessionInitialized = nil, nil
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithSessionHooks(hooks))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server.RegisterTool(NewTool("slow"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		close(started)
+		<-release
+		return NewTextResult("done"), nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(true))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	hasStream := func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		return len(server.httpHandler.getSSEConnections) > 0
+	}
+	require.Eventually(t, hasStream, 2*time.Second, 10*time.Millisecond)
+
+	type callResult struct {
+		result *CallToolResult
+		err    error
+	}
+	results := make(chan callResult, 1)
+	go func() {
+		req := &CallToolRequest{}
+		req.Params.Name = "slow"
+		result, err := client.CallTool(context.Background(), req)
+		results <- callResult{result, err}
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownDone <- server.Shutdown(ctx)
+	}()
+
+	// New sessions are rejected while the request completes.
+	require.Eventually(t, server.httpHandler.shuttingDown.Load, time.Second, 10*time.Millisecond)
+	other, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer other.Close()
+	_, err = other.Initialize(context.Background(), &InitializeRequest{})
+	assert.Error(t, err)
+
+	close(release)
+	call := <-results
+	require.NoError(t, call.err)
+	assert.Equal(t, "done", call.result.Content[0].(TextContent).Text)
+	require.NoError(t, <-shutdownDone)
+
+	closed := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "closed", sessionID: client.GetSessionID(), reason: SessionCloseReasonShutdown}, closed)
+	assert.False(t, hasStream())
+	assert.Empty(t, server.httpHandler.getActiveSessions())
+}
+
+func TestServer_ShutdownCancelsRequests(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithGetSSEEnabled(false))
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server.RegisterTool(NewTool("block"), blockingTool(started, cancelled))
+	stuckStarted := make(chan struct{})
+	release := make(chan struct{})
+	server.RegisterTool(NewTool("stuck"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		close(stuckStarted)
+		<-release
+		return NewTextResult("done"), nil
+	})
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+	defer close(release)
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(false))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	go func() {
+		req := &CallToolRequest{}
+		req.Params.Name = "block"
+		_, _ = client.CallTool(context.Background(), req)
+	}()
+	<-started
+
+	// A request whose handler ignores cancellation, on a POST SSE stream. The
+	// response starts with its first event.
+	body := strings.NewReader(`{"jsonrpc":"2.0","id":"stuck","method":"tools/call","params":{"name":"stuck"}}`)
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(httputil.SessionIDHeader, client.GetSessionID())
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		responses <- resp
+	}()
+	<-stuckStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The handler respecting its context is cancelled, and the stream of the
+	// other one ends with a close event.
+	<-cancelled
+	resp := <-responses
+	require.NotNil(t, resp)
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if scanner.Text() == "event: "+eventStreamCloseEvent {
+			break
+		}
+	}
+	assert.Equal(t, []string{"event: " + eventStreamCloseEvent}, lines)
+}
@@ -30,14 +30,17 @@ type Session interface {
 	// GetData gets session data
 	GetData(key string) (interface{}, bool)
 
-	// SetData sets session data
+	// SetData sets session data. With a SessionStore, the value is saved as
+	// JSON and read back by later requests as a generic JSON value
+	// (map[string]interface{}, []interface{}, float64, string or bool), so
+	// it must be JSON-encodable and decoded again by the caller.
 	SetData(key string, value interface{})
 }
 
 // sessionManager defines the session manager interface
 type sessionManager interface {
 	// CreateSession creates a new session
-	createSession() Session
+	createSession() (Session, error)
 
 	// GetSession gets a session
 	getSession(id string) (Session, bool)
@@ -60,8 +63,8 @@ type sessionManagerAdapter struct {
 }
 
 // CreateSession creates a new session
-func (a *sessionManagerAdapter) createSession() Session {
-	return a.manager.CreateSession()
+func (a *sessionManagerAdapter) createSession() (Session, error) {
+	return a.manager.CreateSession(), nil
 }
 
 // GetSession gets a session
@@ -80,9 +83,78 @@ func (a *sessionManagerAdapter) terminateSession(id string) bool {
 }
 
 // newSessionManager creates a session manager
-func newSessionManager(expirySeconds int) sessionManager {
+func newSessionManager(expirySeconds int, options ...session.Option) sessionManager {
 	return &sessionManagerAdapter{
-		manager: session.NewSessionManager(expirySeconds),
+		manager: session.NewSessionManager(expirySeconds, options...),
+	}
+}
+
+// SessionCloseReason tells why a session was closed.
+type SessionCloseReason string
+
+const (
+	// SessionCloseReasonExpired is the reason of sessions that reached their
+	// idle timeout or maximum lifetime.
+	SessionCloseReasonExpired SessionCloseReason = "expired"
+
+	// SessionCloseReasonDeleted is the reason of sessions the client terminated
+	// with a DELETE request.
+	SessionCloseReasonDeleted SessionCloseReason = "deleted"
+
+	// SessionCloseReasonDisconnected is the reason of sessions whose connection
+	// was closed by the client: the SSE stream of an SSEServer session, or the
+	// standard input of a StdioServer.
+	SessionCloseReasonDisconnected SessionCloseReason = "disconnected"
+
+	// SessionCloseReasonShutdown is the reason of sessions closed because the
+	// server shut down.
+	SessionCloseReasonShutdown SessionCloseReason = "shutdown"
+)
+
+// SessionHooks are functions called as sessions are created, initialized and
+// closed, so that per-session resources can be set up and released. Any of
+// them can be nil. They are called synchronously, so they should not block.
+type SessionHooks struct {
+	// OnSessionCreated is called when a session is created, before the
+	// response to the initialize request is sent.
+	OnSessionCreated func(ctx context.Context, session Session)
+
+	// OnSessionInitialized is called when the client sends
+	// notifications/initialized, with the client information and capabilities
+	// it sent in the initialize request.
+	OnSessionInitialized func(
+		ctx context.Context,
+		session Session,
+		clientInfo Implementation,
+		capabilities ClientCapabilities,
+	)
+
+	// OnSessionClosed is called once the session is closed, and no more
+	// requests are handled for it.
+	OnSessionClosed func(ctx context.Context, session Session, reason SessionCloseReason)
+}
+
+// sessionCreated calls OnSessionCreated if set.
+func (h *SessionHooks) sessionCreated(ctx context.Context, session Session) {
+	if h != nil && h.OnSessionCreated != nil {
+		h.OnSessionCreated(ctx, session)
+	}
+}
+
+// sessionInitialized calls OnSessionInitialized if set.
+func (h *SessionHooks) sessionInitialized(ctx context.Context, session Session) {
+	if h == nil || h.OnSessionInitialized == nil {
+		return
+	}
+	clientInfo, _ := clientInfoFromSession(session)
+	capabilities, _ := clientCapabilitiesFromSession(session)
+	h.OnSessionInitialized(ctx, session, clientInfo, capabilities)
+}
+
+// sessionClosed calls OnSessionClosed if set.
+func (h *SessionHooks) sessionClosed(ctx context.Context, session Session, reason SessionCloseReason) {
+	if h != nil && h.OnSessionClosed != nil {
+		h.OnSessionClosed(ctx, session, reason)
 	}
 }
 
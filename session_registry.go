@@ -0,0 +1,135 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import "sync"
+
+// sessionItems holds the tools, prompts or resources registered for
+// individual sessions, on top of the ones registered for all sessions. Items
+// are keyed by name, or URI for resources. The zero value is ready to use.
+type sessionItems[T any] struct {
+	mu       sync.RWMutex
+	sessions map[string]*sessionItemSet[T]
+}
+
+// sessionItemSet is the items of one session, in registration order.
+type sessionItemSet[T any] struct {
+	items map[string]T
+	order []string
+}
+
+// add registers an item for a session, replacing the item with the same key.
+func (r *sessionItems[T]) add(sessionID, key string, item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sessions == nil {
+		r.sessions = make(map[string]*sessionItemSet[T])
+	}
+	set, ok := r.sessions[sessionID]
+	if !ok {
+		set = &sessionItemSet[T]{items: make(map[string]T)}
+		r.sessions[sessionID] = set
+	}
+	if _, exists := set.items[key]; !exists {
+		set.order = append(set.order, key)
+	}
+	set.items[key] = item
+}
+
+// remove unregisters items of a session and returns the count of removed items.
+func (r *sessionItems[T]) remove(sessionID string, keys ...string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	set, ok := r.sessions[sessionID]
+	if !ok {
+		return 0
+	}
+	removed := 0
+	for _, key := range keys {
+		if _, exists := set.items[key]; !exists {
+			continue
+		}
+		delete(set.items, key)
+		removed++
+		for i, k := range set.order {
+			if k == key {
+				set.order = append(set.order[:i], set.order[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(set.items) == 0 {
+		delete(r.sessions, sessionID)
+	}
+	return removed
+}
+
+// get returns an item of a session.
+func (r *sessionItems[T]) get(sessionID, key string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var item T
+	set, ok := r.sessions[sessionID]
+	if !ok {
+		return item, false
+	}
+	item, ok = set.items[key]
+	return item, ok
+}
+
+// list returns the items of a session in registration order.
+func (r *sessionItems[T]) list(sessionID string) []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set, ok := r.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	items := make([]T, 0, len(set.order))
+	for _, key := range set.order {
+		items = append(items, set.items[key])
+	}
+	return items
+}
+
+// removeSession drops the items of a session.
+func (r *sessionItems[T]) removeSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, sessionID)
+}
+
+// mergeSessionItems returns the global items followed by the session items.
+// Session items replace the global items with the same key.
+func mergeSessionItems[T any](global, session []T, key func(T) string) []T {
+	if len(session) == 0 {
+		return global
+	}
+	overridden := make(map[string]bool, len(session))
+	for _, item := range session {
+		overridden[key(item)] = true
+	}
+	merged := make([]T, 0, len(global)+len(session))
+	for _, item := range global {
+		if !overridden[key(item)] {
+			merged = append(merged, item)
+		}
+	}
+	return append(merged, session...)
+}
+
+// sessionIDOf returns the ID of a session, or an empty string if there is no session.
+func sessionIDOf(session Session) string {
+	if session == nil {
+		return ""
+	}
+	return session.GetID()
+}
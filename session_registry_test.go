@@ -0,0 +1,173 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSessionRegistryServer starts a server with a global echo tool and returns
+// it with its URL.
+func newSessionRegistryServer(t *testing.T, options ...ServerOption) (*Server, string) {
+	t.Helper()
+	server := NewServer("Test-Server", "1.0.0", append([]ServerOption{WithServerPath("/mcp")}, options...)...)
+	server.RegisterTool(NewTool("echo"), textTool("global"))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+	return server, httpServer.URL + "/mcp"
+}
+
+func newInitializedClient(t *testing.T, url string) *Client {
+	t.Helper()
+	client, err := NewClient(url, Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	return client
+}
+
+func textTool(text string) toolHandler {
+	return func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		return NewTextResult(text), nil
+	}
+}
+
+func callTool(client *Client, name string) (string, error) {
+	req := &CallToolRequest{}
+	req.Params.Name = name
+	result, err := client.CallTool(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	return result.Content[0].(TextContent).Text, nil
+}
+
+func TestServer_SessionTools(t *testing.T) {
+	server, url := newSessionRegistryServer(t)
+	alice := newInitializedClient(t, url)
+	bob := newInitializedClient(t, url)
+
+	refetched := make(chan []Tool, 10)
+	alice.SetToolListChangedHandler(func(tools []Tool, err error) {
+		assert.NoError(t, err)
+		refetched <- tools
+	}, true)
+	require.Eventually(t, func() bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		_, ok := server.httpHandler.getSSEConnections[alice.GetSessionID()]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, server.RegisterSessionTool(alice.GetSessionID(), NewTool("unlocked"), textTool("unlocked")))
+	require.NoError(t, server.RegisterSessionTool(alice.GetSessionID(), NewTool("echo"), textTool("alice")))
+	select {
+	case tools := <-refetched:
+		assert.Len(t, tools, 2)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools list_changed not received")
+	}
+
+	assert.ElementsMatch(t, []string{"echo", "unlocked"}, gatewayToolNames(t, alice))
+	assert.Equal(t, []string{"echo"}, gatewayToolNames(t, bob))
+
+	text, err := callTool(alice, "unlocked")
+	require.NoError(t, err)
+	assert.Equal(t, "unlocked", text)
+	text, err = callTool(alice, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", text)
+	text, err = callTool(bob, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "global", text)
+	_, err = callTool(bob, "unlocked")
+	assert.Error(t, err)
+
+	require.NoError(t, server.UnregisterSessionTools(alice.GetSessionID(), "unlocked", "echo"))
+	assert.Error(t, server.UnregisterSessionTools(alice.GetSessionID(), "unlocked"))
+	_, err = callTool(alice, "unlocked")
+	assert.Error(t, err)
+	text, err = callTool(alice, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "global", text)
+}
+
+func TestServer_SessionPromptsAndResources(t *testing.T) {
+	server, url := newSessionRegistryServer(t)
+	alice := newInitializedClient(t, url)
+	bob := newInitializedClient(t, url)
+	ctx := context.Background()
+
+	require.NoError(t, server.RegisterSessionPrompt(alice.GetSessionID(), &Prompt{Name: "private"},
+		func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+			return &GetPromptResult{Description: "private"}, nil
+		}))
+	require.NoError(t, server.RegisterSessionResource(alice.GetSessionID(),
+		&Resource{URI: "file:///private", Name: "private"},
+		func(ctx context.Context, req *ReadResourceRequest) (ResourceContents, error) {
+			return TextResourceContents{URI: req.Params.URI, Text: "private"}, nil
+		}))
+
+	promptReq := &GetPromptRequest{}
+	promptReq.Params.Name = "private"
+	prompt, err := alice.GetPrompt(ctx, promptReq)
+	require.NoError(t, err)
+	assert.Equal(t, "private", prompt.Description)
+	_, err = bob.GetPrompt(ctx, promptReq)
+	assert.Error(t, err)
+
+	resources, err := alice.ListResources(ctx, &ListResourcesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resources.Resources, 1)
+	readReq := &ReadResourceRequest{}
+	readReq.Params.URI = "file:///private"
+	read, err := alice.ReadResource(ctx, readReq)
+	require.NoError(t, err)
+	assert.Equal(t, "private", read.Contents[0].(TextResourceContents).Text)
+	_, err = bob.ReadResource(ctx, readReq)
+	assert.Error(t, err)
+
+	// Closing the session drops its registrations.
+	sessionID := alice.GetSessionID()
+	require.NoError(t, alice.TerminateSession(ctx))
+	assert.Empty(t, server.promptManager.sessionPrompts.list(sessionID))
+	assert.Empty(t, server.resourceManager.sessionResources.list(sessionID))
+	assert.ErrorIs(t, server.RegisterSessionTool(sessionID, NewTool("late"), textTool("late")), ErrSessionNotFound)
+}
+
+func TestServer_SessionRegistrationRequiresSession(t *testing.T) {
+	server, _ := newSessionRegistryServer(t)
+	assert.ErrorIs(t, server.RegisterSessionTool("unknown", NewTool("tool"), textTool("tool")), ErrSessionNotFound)
+
+	stateless := NewServer("Test-Server", "1.0.0", WithStatelessMode(true))
+	assert.ErrorIs(t, stateless.RegisterSessionTool("unknown", NewTool("tool"), textTool("tool")), ErrStatelessMode)
+}
+
+func TestServer_ListFiltersAreEnforcedOnCall(t *testing.T) {
+	_, url := newSessionRegistryServer(t,
+		WithToolListFilter(func(ctx context.Context, tools []*Tool) []*Tool {
+			var visible []*Tool
+			for _, tool := range tools {
+				if tool.Name != "echo" {
+					visible = append(visible, tool)
+				}
+			}
+			return visible
+		}))
+	client := newInitializedClient(t, url)
+
+	assert.Empty(t, gatewayToolNames(t, client))
+	_, err := callTool(client, "echo")
+	assert.Error(t, err)
+}
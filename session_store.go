@@ -0,0 +1,559 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/session"
+)
+
+// ErrSessionExists is returned by a SessionStore when creating a session whose ID is taken.
+var ErrSessionExists = errors.New("session already exists")
+
+// SessionRecord is the state of a session kept in a SessionStore.
+type SessionRecord struct {
+	// ID is the session ID sent to the client in the Mcp-Session-Id header.
+	ID string `json:"id"`
+
+	// CreatedAt is the time the session was created.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// LastActivity is the time of the last request of the session.
+	LastActivity time.Time `json:"lastActivity"`
+
+	// ProtocolVersion is the protocol version negotiated for the session.
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+
+	// Initialized reports whether the client sent notifications/initialized.
+	Initialized bool `json:"initialized"`
+
+	// Data holds the session data, encoded as JSON.
+	Data map[string]json.RawMessage `json:"data,omitempty"`
+}
+
+// clone returns a deep copy of the record.
+func (r *SessionRecord) clone() *SessionRecord {
+	c := *r
+	if r.Data != nil {
+		c.Data = make(map[string]json.RawMessage, len(r.Data))
+		for key, value := range r.Data {
+			c.Data[key] = value
+		}
+	}
+	return &c
+}
+
+// SessionStore keeps the sessions of a streamable HTTP Server. With a store
+// shared by several replicas, such as one backed by Redis or a database, a
+// request can be served by any replica regardless of which one created its
+// session. Use it with WithSessionStore.
+//
+// Implementations must be safe for concurrent use, and must return
+// ErrSessionNotFound for sessions that do not exist or expired. A store
+// expiring idle sessions should tell the timeout with an IdleTimeout()
+// time.Duration method, like InMemorySessionStore, so that the server saves
+// the activity of sessions often enough without writing on every request.
+// The servers sharing the store look up the sessions they served periodically,
+// to release the state of those that expired.
+// The sessionstoretest package checks that an implementation behaves as the
+// server expects.
+type SessionStore interface {
+	// Create stores a new session. It returns ErrSessionExists if a session
+	// with the same ID exists.
+	Create(ctx context.Context, record *SessionRecord) error
+
+	// Get returns a copy of the session.
+	Get(ctx context.Context, id string) (*SessionRecord, error)
+
+	// Touch sets the last activity time of the session to now.
+	Touch(ctx context.Context, id string) error
+
+	// SetData sets a session data value.
+	SetData(ctx context.Context, id string, key string, value json.RawMessage) error
+
+	// SetProtocolVersion records the protocol version negotiated for the session.
+	SetProtocolVersion(ctx context.Context, id string, version string) error
+
+	// SetInitialized marks the session initialized. It returns false if the
+	// session already was, and must be atomic so that only one of concurrent
+	// callers gets true.
+	SetInitialized(ctx context.Context, id string) (bool, error)
+
+	// Terminate deletes the session, and reports whether it existed.
+	Terminate(ctx context.Context, id string) (bool, error)
+
+	// List returns the IDs of all sessions.
+	List(ctx context.Context) ([]string, error)
+}
+
+// InMemorySessionStore is a SessionStore that keeps sessions in process
+// memory. It is the reference implementation of SessionStore; sessions are
+// only shared between the servers of the same process.
+type InMemorySessionStore struct {
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*SessionRecord
+}
+
+// InMemorySessionStoreOption configures an InMemorySessionStore.
+type InMemorySessionStoreOption func(*InMemorySessionStore)
+
+// WithSessionIdleTimeout makes sessions expire once they have had no activity
+// for the timeout. A timeout of zero or less, the default, never expires them.
+func WithSessionIdleTimeout(timeout time.Duration) InMemorySessionStoreOption {
+	return func(s *InMemorySessionStore) {
+		s.idleTimeout = timeout
+	}
+}
+
+// NewInMemorySessionStore creates an in-memory session store.
+func NewInMemorySessionStore(options ...InMemorySessionStoreOption) *InMemorySessionStore {
+	s := &InMemorySessionStore{
+		sessions: make(map[string]*SessionRecord),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// IdleTimeout returns the time after which idle sessions expire, zero if they
+// never do.
+func (s *InMemorySessionStore) IdleTimeout() time.Duration {
+	return s.idleTimeout
+}
+
+// Create implements SessionStore.
+func (s *InMemorySessionStore) Create(ctx context.Context, record *SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupLocked(record.ID); ok {
+		return fmt.Errorf("%w: %s", ErrSessionExists, record.ID)
+	}
+	s.sessions[record.ID] = record.clone()
+	return nil
+}
+
+// Get implements SessionStore.
+func (s *InMemorySessionStore) Get(ctx context.Context, id string) (*SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.lookupLocked(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return record.clone(), nil
+}
+
+// Touch implements SessionStore.
+func (s *InMemorySessionStore) Touch(ctx context.Context, id string) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.LastActivity = time.Now()
+	})
+}
+
+// SetData implements SessionStore.
+func (s *InMemorySessionStore) SetData(ctx context.Context, id string, key string, value json.RawMessage) error {
+	return s.update(id, func(record *SessionRecord) {
+		if record.Data == nil {
+			record.Data = make(map[string]json.RawMessage)
+		}
+		record.Data[key] = append(json.RawMessage(nil), value...)
+	})
+}
+
+// SetProtocolVersion implements SessionStore.
+func (s *InMemorySessionStore) SetProtocolVersion(ctx context.Context, id string, version string) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.ProtocolVersion = version
+	})
+}
+
+// SetInitialized implements SessionStore.
+func (s *InMemorySessionStore) SetInitialized(ctx context.Context, id string) (bool, error) {
+	var first bool
+	err := s.update(id, func(record *SessionRecord) {
+		first = !record.Initialized
+		record.Initialized = true
+	})
+	return first, err
+}
+
+// Terminate implements SessionStore.
+func (s *InMemorySessionStore) Terminate(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupLocked(id); !ok {
+		return false, nil
+	}
+	delete(s.sessions, id)
+	return true, nil
+}
+
+// List implements SessionStore.
+func (s *InMemorySessionStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		if _, ok := s.lookupLocked(id); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// update applies fn to the stored session.
+func (s *InMemorySessionStore) update(id string, fn func(record *SessionRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.lookupLocked(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	fn(record)
+	return nil
+}
+
+// lookupLocked returns the stored session, deleting it if it expired.
+func (s *InMemorySessionStore) lookupLocked(id string) (*SessionRecord, bool) {
+	record, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	if s.idleTimeout > 0 && time.Since(record.LastActivity) > s.idleTimeout {
+		delete(s.sessions, id)
+		return nil, false
+	}
+	return record, true
+}
+
+// sessionDataTypes maps the session data keys set by the server to the type
+// of their values, so that values read back from a store keep their type.
+// Other values are decoded as generic JSON values.
+var sessionDataTypes = map[string]reflect.Type{
+	clientCapabilitiesKey: reflect.TypeOf(ClientCapabilities{}),
+	clientInfoKey:         reflect.TypeOf(Implementation{}),
+	logLevelKey:           reflect.TypeOf(LoggingLevel("")),
+}
+
+// storeSession is a Session kept in a SessionStore. Its data is written
+// through to the store, so it must be JSON-encodable.
+type storeSession struct {
+	store  SessionStore
+	logger Logger
+
+	mu     sync.RWMutex
+	record *SessionRecord
+	// values caches the decoded data values.
+	values map[string]interface{}
+}
+
+// newStoreSession wraps a record read from the store.
+func newStoreSession(store SessionStore, logger Logger, record *SessionRecord) *storeSession {
+	return &storeSession{
+		store:  store,
+		logger: logger,
+		record: record,
+		values: make(map[string]interface{}),
+	}
+}
+
+// GetID implements Session.
+func (s *storeSession) GetID() string {
+	return s.record.ID
+}
+
+// GetCreatedAt implements Session.
+func (s *storeSession) GetCreatedAt() time.Time {
+	return s.record.CreatedAt
+}
+
+// GetLastActivity implements Session.
+func (s *storeSession) GetLastActivity() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.record.LastActivity
+}
+
+// UpdateActivity implements Session.
+func (s *storeSession) UpdateActivity() {
+	if err := s.store.Touch(context.Background(), s.record.ID); err != nil {
+		s.logger.Errorf("Failed to update activity of session %s: %v", s.record.ID, err)
+		return
+	}
+	s.mu.Lock()
+	s.record.LastActivity = time.Now()
+	s.mu.Unlock()
+}
+
+// GetData implements Session.
+func (s *storeSession) GetData(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == protocolVersionKey {
+		return s.record.ProtocolVersion, s.record.ProtocolVersion != ""
+	}
+	if value, ok := s.values[key]; ok {
+		return value, true
+	}
+	raw, ok := s.record.Data[key]
+	if !ok {
+		return nil, false
+	}
+	value, err := decodeSessionValue(key, raw)
+	if err != nil {
+		s.logger.Errorf("Failed to decode data %s of session %s: %v", key, s.record.ID, err)
+		return nil, false
+	}
+	s.values[key] = value
+	return value, true
+}
+
+// SetData implements Session.
+func (s *storeSession) SetData(key string, value interface{}) {
+	ctx := context.Background()
+	if key == protocolVersionKey {
+		version, _ := value.(string)
+		if err := s.store.SetProtocolVersion(ctx, s.record.ID, version); err != nil {
+			s.logger.Errorf("Failed to save protocol version of session %s: %v", s.record.ID, err)
+			return
+		}
+		s.mu.Lock()
+		s.record.ProtocolVersion = version
+		s.mu.Unlock()
+		return
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Errorf("Failed to encode data %s of session %s: %v", key, s.record.ID, err)
+		return
+	}
+	if err := s.store.SetData(ctx, s.record.ID, key, raw); err != nil {
+		s.logger.Errorf("Failed to save data %s of session %s: %v", key, s.record.ID, err)
+		return
+	}
+	s.mu.Lock()
+	if s.record.Data == nil {
+		s.record.Data = make(map[string]json.RawMessage)
+	}
+	s.record.Data[key] = raw
+	s.values[key] = value
+	s.mu.Unlock()
+}
+
+// markInitialized implements initializationTracker.
+func (s *storeSession) markInitialized() (bool, error) {
+	return s.store.SetInitialized(context.Background(), s.record.ID)
+}
+
+// decodeSessionValue decodes a data value read from a store.
+func decodeSessionValue(key string, raw json.RawMessage) (interface{}, error) {
+	if typ, ok := sessionDataTypes[key]; ok {
+		value := reflect.New(typ)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return nil, err
+		}
+		return value.Elem().Interface(), nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Defaults of the store session manager.
+const (
+	// defaultSessionTouchInterval is how often the activity of a session is
+	// saved in a store that does not tell its idle timeout.
+	defaultSessionTouchInterval = 5 * time.Second
+	// sessionExpirySweepInterval is how often the sessions served by a server
+	// are looked up in the store to find the expired ones.
+	sessionExpirySweepInterval = time.Minute
+)
+
+// idleTimeoutStore is implemented by the session stores that expire idle
+// sessions and tell after how long.
+type idleTimeoutStore interface {
+	IdleTimeout() time.Duration
+}
+
+// storeSessionManager is a sessionManager keeping sessions in a SessionStore.
+type storeSessionManager struct {
+	store  SessionStore
+	logger Logger
+	// touchInterval is the minimum time between two saves of the activity of
+	// a session.
+	touchInterval time.Duration
+
+	mu sync.Mutex
+	// served holds the sessions this server created or served, whose state
+	// it releases once they expire in the store.
+	served map[string]Session
+	// onExpired is called with the served sessions found expired.
+	onExpired func(Session)
+
+	// done is closed to stop sweeping the expired sessions.
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newStoreSessionManager creates a session manager backed by the store.
+func newStoreSessionManager(store SessionStore, logger Logger) sessionManager {
+	if logger == nil {
+		logger = GetDefaultLogger()
+	}
+	m := &storeSessionManager{
+		store:         store,
+		logger:        logger,
+		touchInterval: defaultSessionTouchInterval,
+		served:        make(map[string]Session),
+		done:          make(chan struct{}),
+	}
+	// The activity is saved often enough for an active session not to expire.
+	if s, ok := store.(idleTimeoutStore); ok && s.IdleTimeout() > 0 && s.IdleTimeout()/10 < m.touchInterval {
+		m.touchInterval = s.IdleTimeout() / 10
+	}
+	go m.sweepExpiredSessions()
+	return m
+}
+
+// setExpiryHandler sets the function called with the sessions this server
+// served once they expired in the store.
+func (m *storeSessionManager) setExpiryHandler(handler func(Session)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExpired = handler
+}
+
+// createSession implements sessionManager. It fails if the session cannot be
+// stored, as no request of the session would find it.
+func (m *storeSessionManager) createSession() (Session, error) {
+	s := session.NewSession()
+	record := &SessionRecord{
+		ID:           s.ID,
+		CreatedAt:    s.CreatedAt,
+		LastActivity: s.LastActivity,
+	}
+	if err := m.store.Create(context.Background(), record); err != nil {
+		return nil, fmt.Errorf("failed to store session %s: %w", record.ID, err)
+	}
+	created := newStoreSession(m.store, m.logger, record)
+	m.mu.Lock()
+	m.served[record.ID] = created
+	m.mu.Unlock()
+	return created, nil
+}
+
+// getSession implements sessionManager. The activity of the session is only
+// saved once per touch interval, to spare the store a write per request.
+func (m *storeSessionManager) getSession(id string) (Session, bool) {
+	ctx := context.Background()
+	record, err := m.store.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrSessionNotFound) {
+			m.logger.Errorf("Failed to get session %s: %v", id, err)
+			return nil, false
+		}
+		m.expired(id)
+		return nil, false
+	}
+	s := newStoreSession(m.store, m.logger, record)
+	if time.Since(record.LastActivity) >= m.touchInterval {
+		s.UpdateActivity()
+	}
+	m.mu.Lock()
+	m.served[id] = s
+	m.mu.Unlock()
+	return s, true
+}
+
+// getActiveSessions implements sessionManager.
+func (m *storeSessionManager) getActiveSessions() []string {
+	ids, err := m.store.List(context.Background())
+	if err != nil {
+		m.logger.Errorf("Failed to list sessions: %v", err)
+		return []string{}
+	}
+	return ids
+}
+
+// terminateSession implements sessionManager.
+func (m *storeSessionManager) terminateSession(id string) bool {
+	m.mu.Lock()
+	delete(m.served, id)
+	m.mu.Unlock()
+	ok, err := m.store.Terminate(context.Background(), id)
+	if err != nil {
+		m.logger.Errorf("Failed to terminate session %s: %v", id, err)
+	}
+	return ok
+}
+
+// expired calls the expiry handler if the session was served by this server.
+func (m *storeSessionManager) expired(id string) {
+	m.mu.Lock()
+	s, served := m.served[id]
+	delete(m.served, id)
+	handler := m.onExpired
+	m.mu.Unlock()
+	if served && handler != nil {
+		handler(s)
+	}
+}
+
+// sweep looks up the served sessions in the store, and calls the expiry
+// handler for those that expired.
+func (m *storeSessionManager) sweep() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.served))
+	for id := range m.served {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	for _, id := range ids {
+		_, err := m.store.Get(context.Background(), id)
+		if errors.Is(err, ErrSessionNotFound) {
+			m.expired(id)
+		} else if err != nil {
+			m.logger.Errorf("Failed to get session %s: %v", id, err)
+		}
+	}
+}
+
+// sweepExpiredSessions sweeps the served sessions periodically until stop is
+// called.
+func (m *storeSessionManager) sweepExpiredSessions() {
+	ticker := time.NewTicker(sessionExpirySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.sweep()
+		}
+	}
+}
+
+// stop stops sweeping the expired sessions.
+func (m *storeSessionManager) stop() {
+	m.stopOnce.Do(func() { close(m.done) })
+}
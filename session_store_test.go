@@ -0,0 +1,342 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySessionPubSub is a SessionPubSub shared by the servers of a test.
+type memorySessionPubSub struct {
+	mu       sync.Mutex
+	handlers map[string]SessionMessageHandler
+}
+
+func newMemorySessionPubSub() *memorySessionPubSub {
+	return &memorySessionPubSub{handlers: make(map[string]SessionMessageHandler)}
+}
+
+func (p *memorySessionPubSub) Publish(ctx context.Context, sessionID string, payload []byte) error {
+	p.mu.Lock()
+	handler, ok := p.handlers[sessionID]
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return handler(ctx, sessionID, payload)
+}
+
+func (p *memorySessionPubSub) Subscribe(ctx context.Context, sessionID string, handler SessionMessageHandler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[sessionID] = handler
+	return nil
+}
+
+func (p *memorySessionPubSub) Unsubscribe(ctx context.Context, sessionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.handlers, sessionID)
+	return nil
+}
+
+func TestInMemorySessionStore_IdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemorySessionStore(WithSessionIdleTimeout(50 * time.Millisecond))
+	require.NoError(t, store.Create(ctx, &SessionRecord{ID: "idle", LastActivity: time.Now()}))
+	require.NoError(t, store.Create(ctx, &SessionRecord{ID: "active", LastActivity: time.Now()}))
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, store.Touch(ctx, "active"))
+	time.Sleep(30 * time.Millisecond)
+
+	_, err := store.Get(ctx, "idle")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	ids, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active"}, ids)
+}
+
+func TestStoreSession_DataTypes(t *testing.T) {
+	store := NewInMemorySessionStore()
+	manager := newStoreSessionManager(store, nil).(*storeSessionManager)
+	defer manager.stop()
+	created, err := manager.createSession()
+	require.NoError(t, err)
+	created.SetData(logLevelKey, LoggingLevelWarning)
+	created.SetData(clientCapabilitiesKey, ClientCapabilities{Roots: &RootsCapability{ListChanged: true}})
+	created.SetData(protocolVersionKey, ProtocolVersion_2025_06_18)
+	created.SetData("custom", map[string]interface{}{"a": 1})
+
+	// A session read back from the store, as on another server, keeps the
+	// types of the values set by the server.
+	session, ok := manager.getSession(created.GetID())
+	require.True(t, ok)
+	level, ok := logLevelFromSession(session)
+	require.True(t, ok)
+	assert.Equal(t, LoggingLevelWarning, level)
+	capabilities, ok := clientCapabilitiesFromSession(session)
+	require.True(t, ok)
+	assert.True(t, capabilities.Roots.ListChanged)
+	assert.Equal(t, ProtocolVersion_2025_06_18, protocolVersionFromSession(session))
+	custom, ok := session.GetData("custom")
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, custom)
+
+	record, err := store.Get(context.Background(), created.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion_2025_06_18, record.ProtocolVersion)
+}
+
+// roundRobinHandler sends each request to the next server, like a load
+// balancer without session affinity.
+type roundRobinHandler struct {
+	servers []http.Handler
+	next    atomic.Int64
+}
+
+func (h *roundRobinHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.servers[int(h.next.Add(1))%len(h.servers)].ServeHTTP(w, r)
+}
+
+func TestServer_SessionStoreReplicas(t *testing.T) {
+	store := NewInMemorySessionStore()
+	pubSub := newMemorySessionPubSub()
+	var servers []*Server
+	balancer := &roundRobinHandler{}
+	for i := 0; i < 2; i++ {
+		server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"),
+			WithSessionStore(store), WithServerSessionPubSub(pubSub))
+		server.RegisterTool(NewTool("list_roots"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			result, err := server.ListRoots(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return NewTextResult(result.Roots[0].URI), nil
+		})
+		servers = append(servers, server)
+		balancer.servers = append(balancer.servers, server.HTTPHandler())
+	}
+	httpServer := httptest.NewServer(balancer)
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(true))
+	require.NoError(t, err)
+	defer client.Close()
+	client.SetRootsProvider(NewDefaultRootsProvider(Root{URI: "file:///workspace", Name: "workspace"}))
+	_, err = client.Initialize(context.Background(), &InitializeRequest{
+		Params: InitializeParams{Capabilities: ClientCapabilities{Roots: &RootsCapability{}}},
+	})
+	require.NoError(t, err)
+
+	// Requests are served by either server.
+	for i := 0; i < 4; i++ {
+		_, err := client.ListTools(context.Background(), &ListToolsRequest{})
+		require.NoError(t, err)
+	}
+	record, err := store.Get(context.Background(), client.GetSessionID())
+	require.NoError(t, err)
+	assert.True(t, record.Initialized)
+
+	// Wait for the GET SSE stream, held by one of the servers.
+	hasStream := func(server *Server) bool {
+		server.httpHandler.getSSEConnectionsLock.RLock()
+		defer server.httpHandler.getSSEConnectionsLock.RUnlock()
+		_, ok := server.httpHandler.getSSEConnections[client.GetSessionID()]
+		return ok
+	}
+	require.Eventually(t, func() bool { return hasStream(servers[0]) || hasStream(servers[1]) },
+		2*time.Second, 10*time.Millisecond)
+	other := servers[0]
+	if hasStream(other) {
+		other = servers[1]
+	}
+
+	// Notifications sent by the other server are routed to the stream.
+	received := make(chan string, 1)
+	client.RegisterNotificationHandler("notifications/test", func(n *JSONRPCNotification) error {
+		received <- n.Method
+		return nil
+	})
+	require.NoError(t, other.SendNotification(client.GetSessionID(), "notifications/test", nil))
+	select {
+	case method := <-received:
+		assert.Equal(t, "notifications/test", method)
+	case <-time.After(2 * time.Second):
+		t.Fatal("notification not received")
+	}
+
+	// Server-to-client requests and their responses are routed between the servers.
+	for i := 0; i < 2; i++ {
+		req := &CallToolRequest{}
+		req.Params.Name = "list_roots"
+		result, err := client.CallTool(context.Background(), req)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Equal(t, "file:///workspace", result.Content[0].(TextContent).Text)
+	}
+
+	// Terminating the session on one server removes it for both.
+	require.NoError(t, client.TerminateSession(context.Background()))
+	_, err = store.Get(context.Background(), record.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+// countingSessionStore is a SessionStore counting the activity updates and
+// failing the creation of sessions with createErr.
+type countingSessionStore struct {
+	*InMemorySessionStore
+	createErr error
+	touches   atomic.Int64
+}
+
+func (s *countingSessionStore) Create(ctx context.Context, record *SessionRecord) error {
+	if s.createErr != nil {
+		return s.createErr
+	}
+	return s.InMemorySessionStore.Create(ctx, record)
+}
+
+func (s *countingSessionStore) Touch(ctx context.Context, id string) error {
+	s.touches.Add(1)
+	return s.InMemorySessionStore.Touch(ctx, id)
+}
+
+func TestServer_SessionStoreCreateFails(t *testing.T) {
+	store := &countingSessionStore{InMemorySessionStore: NewInMemorySessionStore(), createErr: errors.New("store down")}
+	httpServer := httptest.NewServer(NewServer("Test-Server", "1.0.0", WithSessionStore(store)).HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	assert.Error(t, err)
+	assert.Empty(t, client.GetSessionID())
+}
+
+func TestServer_SessionStoreExpiry(t *testing.T) {
+	store := NewInMemorySessionStore(WithSessionIdleTimeout(50 * time.Millisecond))
+	closed := make(chan SessionCloseReason, 1)
+	server := NewServer("Test-Server", "1.0.0", WithSessionStore(store), WithSessionHooks(&SessionHooks{
+		OnSessionClosed: func(ctx context.Context, session Session, reason SessionCloseReason) {
+			closed <- reason
+		},
+	}))
+	client := newInMemoryClient(t, server)
+	sessionID := client.GetSessionID()
+	require.NoError(t, server.RegisterSessionTool(sessionID, NewTool("unlocked"), textTool("unlocked")))
+
+	// The session expires in the store, the server releases its state.
+	time.Sleep(100 * time.Millisecond)
+	server.httpHandler.sessionManager.(*storeSessionManager).sweep()
+	select {
+	case reason := <-closed:
+		assert.Equal(t, SessionCloseReasonExpired, reason)
+	case <-time.After(time.Second):
+		t.Fatal("session not closed")
+	}
+	_, ok := server.mcpHandler.toolManager.sessionTools.get(sessionID, "unlocked")
+	assert.False(t, ok)
+}
+
+func TestServer_SessionStoreShutdown(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithSessionStore(NewInMemorySessionStore()))
+	manager := server.httpHandler.sessionManager.(*storeSessionManager)
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	// The expired sessions are no longer swept.
+	select {
+	case <-manager.done:
+	default:
+		t.Fatal("sweeping not stopped")
+	}
+}
+
+func TestStoreSessionManager_TouchThrottled(t *testing.T) {
+	store := &countingSessionStore{InMemorySessionStore: NewInMemorySessionStore(WithSessionIdleTimeout(time.Second))}
+	manager := newStoreSessionManager(store, nil).(*storeSessionManager)
+	defer manager.stop()
+	assert.Equal(t, 100*time.Millisecond, manager.touchInterval)
+	created, err := manager.createSession()
+	require.NoError(t, err)
+
+	// The activity of a session just used is not saved again.
+	for i := 0; i < 3; i++ {
+		_, ok := manager.getSession(created.GetID())
+		require.True(t, ok)
+	}
+	assert.Equal(t, int64(0), store.touches.Load())
+
+	time.Sleep(manager.touchInterval)
+	_, ok := manager.getSession(created.GetID())
+	require.True(t, ok)
+	_, ok = manager.getSession(created.GetID())
+	require.True(t, ok)
+	assert.Equal(t, int64(1), store.touches.Load())
+}
+
+func TestServer_SessionStoreHooks(t *testing.T) {
+	store := NewInMemorySessionStore()
+	var mu sync.Mutex
+	var created int
+	var reasons []SessionCloseReason
+	hooks := &SessionHooks{
+		OnSessionCreated: func(ctx context.Context, session Session) {
+			mu.Lock()
+			defer mu.Unlock()
+			created++
+		},
+		OnSessionClosed: func(ctx context.Context, session Session, reason SessionCloseReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			reasons = append(reasons, reason)
+		},
+	}
+	var servers []*Server
+	balancer := &roundRobinHandler{}
+	for i := 0; i < 2; i++ {
+		server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"),
+			WithSessionStore(store), WithSessionHooks(hooks))
+		servers = append(servers, server)
+		balancer.servers = append(balancer.servers, server.HTTPHandler())
+	}
+	httpServer := httptest.NewServer(balancer)
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := client.ListTools(context.Background(), &ListToolsRequest{})
+		require.NoError(t, err)
+	}
+
+	// The replica deleting the session closes it, the other one finds it
+	// expired.
+	require.NoError(t, client.TerminateSession(context.Background()))
+	for _, server := range servers {
+		server.httpHandler.sessionManager.(*storeSessionManager).sweep()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, created)
+	assert.ElementsMatch(t, []SessionCloseReason{SessionCloseReasonDeleted, SessionCloseReasonExpired}, reasons)
+}
@@ -7,11 +7,15 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSessionManager(t *testing.T) {
@@ -28,7 +32,8 @@ func TestSCreateSession(t *testing.T) {
 	manager := newSessionManager(3600)
 
 	// Create session
-	session := manager.createSession()
+	session, err := manager.createSession()
+	require.NoError(t, err)
 
 	// Verify session
 	assert.NotEmpty(t, session.GetID())
@@ -72,7 +77,8 @@ func TestGetSession(t *testing.T) {
 	}
 
 	// Create a session for testing
-	existingSession := manager.createSession()
+	existingSession, err := manager.createSession()
+	require.NoError(t, err)
 
 	// Record initial access time
 	initialTime := existingSession.GetLastActivity()
@@ -113,7 +119,8 @@ func TestTerminateSession(t *testing.T) {
 	manager := newSessionManager(3600)
 
 	// Create a session
-	session := manager.createSession()
+	session, err := manager.createSession()
+	require.NoError(t, err)
 
 	// Verify session exists
 	sessions := manager.getActiveSessions()
@@ -163,3 +170,140 @@ func TestSessionContext(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, session.GetID(), retrievedSession.GetID())
 }
+
+// sessionEvent is a call of a session hook.
+type sessionEvent struct {
+	hook       string
+	sessionID  string
+	clientName string
+	reason     SessionCloseReason
+}
+
+// newRecordingSessionHooks returns hooks sending their calls to the channel.
+func newRecordingSessionHooks() (*SessionHooks, chan sessionEvent) {
+	events := make(chan sessionEvent, 10)
+	hooks := &SessionHooks{
+		OnSessionCreated: func(ctx context.Context, session Session) {
+			events <- sessionEvent{hook: "created", sessionID: session.GetID()}
+		},
+		OnSessionInitialized: func(ctx context.Context, session Session, info Implementation, _ ClientCapabilities) {
+			events <- sessionEvent{hook: "initialized", sessionID: session.GetID(), clientName: info.Name}
+		},
+		OnSessionClosed: func(ctx context.Context, session Session, reason SessionCloseReason) {
+			events <- sessionEvent{hook: "closed", sessionID: session.GetID(), reason: reason}
+		},
+	}
+	return hooks, events
+}
+
+func nextSessionEvent(t *testing.T, events chan sessionEvent) sessionEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("session hook not called")
+		return sessionEvent{}
+	}
+}
+
+func TestServer_SessionHooks(t *testing.T) {
+	hooks, events := newRecordingSessionHooks()
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithSessionHooks(hooks))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	created := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "created", sessionID: client.GetSessionID()}, created)
+	initialized := nextSessionEvent(t, events)
+	assert.Equal(t, "initialized", initialized.hook)
+	assert.Equal(t, "Test-Client", initialized.clientName)
+
+	require.NoError(t, client.TerminateSession(context.Background()))
+	closed := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "closed", sessionID: created.sessionID, reason: SessionCloseReasonDeleted}, closed)
+}
+
+func TestServer_SessionExpiry(t *testing.T) {
+	hooks, events := newRecordingSessionHooks()
+	hooks.OnSessionCreated, hooks.OnSessionInitialized = nil, nil
+	server := NewServer("Test-Server", "1.0.0", WithServerPath("/mcp"), WithSessionHooks(hooks),
+		WithServerSessionIdleTimeout(100*time.Millisecond))
+	registerSubscriptionResources(server.RegisterResource)
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"},
+		WithClientGetSSEEnabled(true))
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	require.NoError(t, client.Subscribe(context.Background(), "file:///a.txt", func(*ResourceUpdatedNotification) {}))
+
+	// The GET SSE stream does not keep the session alive.
+	closed := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "closed", sessionID: client.GetSessionID(), reason: SessionCloseReasonExpired}, closed)
+
+	// The session's stream and subscriptions are released.
+	server.httpHandler.getSSEConnectionsLock.RLock()
+	assert.Empty(t, server.httpHandler.getSSEConnections)
+	server.httpHandler.getSSEConnectionsLock.RUnlock()
+	assert.Empty(t, server.resourceManager.getSubscribers("file:///a.txt"))
+	_, err = client.ListTools(context.Background(), &ListToolsRequest{})
+	assert.Error(t, err)
+}
+
+func TestSSEServer_SessionHooks(t *testing.T) {
+	hooks, events := newRecordingSessionHooks()
+	server := NewSSEServer("Test-Server", "1.0.0", WithSSESessionHooks(hooks))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	created := nextSessionEvent(t, events)
+	assert.Equal(t, "created", created.hook)
+	initialized := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "initialized", sessionID: created.sessionID, clientName: "Test-Client"}, initialized)
+
+	require.NoError(t, client.Close())
+	closed := nextSessionEvent(t, events)
+	assert.Equal(t, sessionEvent{hook: "closed", sessionID: created.sessionID, reason: SessionCloseReasonDisconnected}, closed)
+}
+
+func TestStdioServer_SessionHooks(t *testing.T) {
+	hooks, events := newRecordingSessionHooks()
+	server := NewStdioServer("Test-Server", "1.0.0", WithStdioSessionHooks(hooks))
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	transport := newStdioTransport(server.internal, server.transportOptions()...)
+	go func() {
+		_ = transport.listen(context.Background(), stdinReader, stdoutWriter)
+	}()
+
+	assert.Equal(t, sessionEvent{hook: "created", sessionID: "stdio"}, nextSessionEvent(t, events))
+	_, err := stdinWriter.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{` +
+		`"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"Test-Client","version":"1.0.0"}}}` + "\n"))
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(stdoutReader)
+	require.True(t, scanner.Scan())
+	_, err = stdinWriter.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, sessionEvent{hook: "initialized", sessionID: "stdio", clientName: "Test-Client"},
+		nextSessionEvent(t, events))
+
+	// Closing the standard input closes the session.
+	require.NoError(t, stdinWriter.Close())
+	assert.Equal(t, sessionEvent{hook: "closed", sessionID: "stdio", reason: SessionCloseReasonDisconnected},
+		nextSessionEvent(t, events))
+}
@@ -0,0 +1,203 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+// Package sessionstoretest checks that implementations of mcp.SessionStore
+// behave as the streamable HTTP server expects.
+package sessionstoretest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+)
+
+// TestSessionStore runs the conformance tests of mcp.SessionStore against the
+// stores returned by newStore. Each test gets a new, empty store.
+//
+// Example:
+//
+//	func TestRedisSessionStore(t *testing.T) {
+//	    sessionstoretest.TestSessionStore(t, func(t *testing.T) mcp.SessionStore {
+//	        return newRedisSessionStore(t)
+//	    })
+//	}
+func TestSessionStore(t *testing.T, newStore func(t *testing.T) mcp.SessionStore) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, newStore(t)) })
+	t.Run("CreateDuplicate", func(t *testing.T) { testCreateDuplicate(t, newStore(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newStore(t)) })
+	t.Run("Touch", func(t *testing.T) { testTouch(t, newStore(t)) })
+	t.Run("Data", func(t *testing.T) { testData(t, newStore(t)) })
+	t.Run("ProtocolVersion", func(t *testing.T) { testProtocolVersion(t, newStore(t)) })
+	t.Run("Initialized", func(t *testing.T) { testInitialized(t, newStore(t)) })
+	t.Run("Terminate", func(t *testing.T) { testTerminate(t, newStore(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, newStore(t)) })
+}
+
+var sessionCounter atomic.Int64
+
+// newRecord returns a record with a unique ID.
+func newRecord() *mcp.SessionRecord {
+	now := time.Now().Truncate(time.Millisecond)
+	return &mcp.SessionRecord{
+		ID:           fmt.Sprintf("session-%d-%d", now.UnixNano(), sessionCounter.Add(1)),
+		CreatedAt:    now,
+		LastActivity: now,
+	}
+}
+
+// createRecord stores a new record.
+func createRecord(t *testing.T, store mcp.SessionStore) *mcp.SessionRecord {
+	record := newRecord()
+	require.NoError(t, store.Create(context.Background(), record))
+	return record
+}
+
+func testCreateAndGet(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := createRecord(t, store)
+
+	got, err := store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, record.ID, got.ID)
+	assert.True(t, record.CreatedAt.Equal(got.CreatedAt))
+	assert.True(t, record.LastActivity.Equal(got.LastActivity))
+	assert.Empty(t, got.ProtocolVersion)
+	assert.False(t, got.Initialized)
+	assert.Empty(t, got.Data)
+
+	// The returned record is a copy.
+	got.ProtocolVersion = "modified"
+	got.Data = map[string]json.RawMessage{"key": json.RawMessage(`1`)}
+	got, err = store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.ProtocolVersion)
+	assert.Empty(t, got.Data)
+}
+
+func testCreateDuplicate(t *testing.T, store mcp.SessionStore) {
+	record := createRecord(t, store)
+	err := store.Create(context.Background(), record)
+	assert.ErrorIs(t, err, mcp.ErrSessionExists)
+}
+
+func testNotFound(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	const id = "missing-session"
+
+	_, err := store.Get(ctx, id)
+	assert.ErrorIs(t, err, mcp.ErrSessionNotFound)
+	assert.ErrorIs(t, store.Touch(ctx, id), mcp.ErrSessionNotFound)
+	assert.ErrorIs(t, store.SetData(ctx, id, "key", json.RawMessage(`1`)), mcp.ErrSessionNotFound)
+	assert.ErrorIs(t, store.SetProtocolVersion(ctx, id, mcp.ProtocolVersion_2025_06_18), mcp.ErrSessionNotFound)
+	_, err = store.SetInitialized(ctx, id)
+	assert.ErrorIs(t, err, mcp.ErrSessionNotFound)
+	ok, err := store.Terminate(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testTouch(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := newRecord()
+	record.LastActivity = record.LastActivity.Add(-time.Minute)
+	require.NoError(t, store.Create(ctx, record))
+
+	require.NoError(t, store.Touch(ctx, record.ID))
+	got, err := store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.True(t, got.LastActivity.After(record.LastActivity))
+	assert.True(t, record.CreatedAt.Equal(got.CreatedAt))
+}
+
+func testData(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := createRecord(t, store)
+
+	require.NoError(t, store.SetData(ctx, record.ID, "string", json.RawMessage(`"value"`)))
+	require.NoError(t, store.SetData(ctx, record.ID, "object", json.RawMessage(`{"a":1}`)))
+	require.NoError(t, store.SetData(ctx, record.ID, "string", json.RawMessage(`"updated"`)))
+
+	got, err := store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Data, 2)
+	assert.JSONEq(t, `"updated"`, string(got.Data["string"]))
+	assert.JSONEq(t, `{"a":1}`, string(got.Data["object"]))
+}
+
+func testProtocolVersion(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := createRecord(t, store)
+
+	require.NoError(t, store.SetProtocolVersion(ctx, record.ID, mcp.ProtocolVersion_2025_06_18))
+	got, err := store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, mcp.ProtocolVersion_2025_06_18, got.ProtocolVersion)
+}
+
+func testInitialized(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := createRecord(t, store)
+
+	// Only one of concurrent callers initializes the session.
+	var wg sync.WaitGroup
+	var firsts atomic.Int64
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first, err := store.SetInitialized(ctx, record.ID)
+			assert.NoError(t, err)
+			if first {
+				firsts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), firsts.Load())
+
+	got, err := store.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Initialized)
+}
+
+func testTerminate(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	record := createRecord(t, store)
+
+	ok, err := store.Terminate(ctx, record.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = store.Get(ctx, record.ID)
+	assert.ErrorIs(t, err, mcp.ErrSessionNotFound)
+
+	ok, err = store.Terminate(ctx, record.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testList(t *testing.T, store mcp.SessionStore) {
+	ctx := context.Background()
+	first := createRecord(t, store)
+	second := createRecord(t, store)
+
+	ids, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, ids)
+
+	_, err = store.Terminate(ctx, first.ID)
+	require.NoError(t, err)
+	ids, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.ID}, ids)
+}
@@ -0,0 +1,20 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package sessionstoretest
+
+import (
+	"testing"
+	"time"
+
+	mcp "trpc.group/trpc-go/trpc-mcp-go"
+)
+
+func TestInMemorySessionStore(t *testing.T) {
+	TestSessionStore(t, func(t *testing.T) mcp.SessionStore {
+		return mcp.NewInMemorySessionStore(mcp.WithSessionIdleTimeout(time.Hour))
+	})
+}
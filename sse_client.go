@@ -99,6 +99,7 @@ func NewSSEClient(serverURL string, clientInfo Implementation, options ...Client
 				sseTransport.httpReqHandler = NewHTTPReqHandler(
 					sseTransport.serviceName, sseTransport.httpReqHandlerOptions...)
 			}
+			sseTransport.httpReqHandler = newAuthHTTPReqHandler(sseTransport.httpReqHandler, config.tokenSource)
 
 			c.transport = sseTransport
 
@@ -382,6 +383,18 @@ func (t *sseClientTransport) handleNotification(data string) {
 		return
 	}
 
+	if t.client != nil {
+		t.client.dispatchCancelled(&notification)
+		if forwarder := t.client.forwarder; forwarder != nil {
+			forwarder.forwardNotification(&notification)
+			return
+		}
+		t.client.progress.dispatch(&notification)
+		t.client.dispatchLogMessage(&notification)
+		t.client.dispatchResourceUpdated(&notification)
+		t.client.dispatchListChanged(&notification)
+	}
+
 	t.notificationMu.RLock()
 	handler := t.onNotification
 	t.notificationMu.RUnlock()
@@ -401,18 +414,40 @@ func (t *sseClientTransport) handleIncomingRequest(data string) {
 		return
 	}
 
+	if t.client != nil && t.client.forwarder != nil {
+		// Forwarding may take long, so it must not block the SSE stream.
+		go t.serveRequest(&request, func(ctx context.Context) JSONRPCMessage {
+			return t.client.forwarder.forwardRequest(ctx, &request)
+		})
+		return
+	}
+
 	// Handle different types of requests.
 	switch request.Method {
 	case MethodPing:
 		t.handlePingRequest(&request)
 	case MethodRootsList:
 		t.handleRootsListRequest(&request)
+	case MethodSamplingCreateMessage:
+		// Sampling may take long, so it must not block the SSE stream.
+		go t.handleCreateMessageRequest(&request)
+	case MethodElicitationCreate:
+		// Elicitation waits for the user, so it must not block message processing.
+		go t.handleElicitRequest(&request)
 	default:
 		// Send method not found error.
 		t.sendErrorResponse(&request, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", request.Method))
 	}
 }
 
+// serveRequest sends the response of handle to a request of the server, unless
+// the server cancels the request first.
+func (t *sseClientTransport) serveRequest(request *JSONRPCRequest, handle func(ctx context.Context) JSONRPCMessage) {
+	if response := t.client.handleServerRequest(context.Background(), request, handle); response != nil {
+		t.sendResponseMessage(response)
+	}
+}
+
 // handlePingRequest handles ping requests from the server (MCP utilities/ping).
 // The receiver MUST respond promptly with an empty result object.
 func (t *sseClientTransport) handlePingRequest(request *JSONRPCRequest) {
@@ -456,6 +491,28 @@ func (t *sseClientTransport) handleRootsListRequest(request *JSONRPCRequest) {
 	t.sendResponseMessage(response)
 }
 
+// handleCreateMessageRequest handles sampling/createMessage requests from the server.
+func (t *sseClientTransport) handleCreateMessageRequest(request *JSONRPCRequest) {
+	var handler SamplingHandler
+	if t.client != nil {
+		handler = t.client.getSamplingHandler()
+	}
+	t.serveRequest(request, func(ctx context.Context) JSONRPCMessage {
+		return handleCreateMessageRequest(ctx, handler, request)
+	})
+}
+
+// handleElicitRequest handles elicitation/create requests from the server.
+func (t *sseClientTransport) handleElicitRequest(request *JSONRPCRequest) {
+	var handler ElicitationHandler
+	if t.client != nil {
+		handler = t.client.getElicitationHandler()
+	}
+	t.serveRequest(request, func(ctx context.Context) JSONRPCMessage {
+		return handleElicitRequest(ctx, handler, request)
+	})
+}
+
 // sendErrorResponse sends an error response to the server.
 func (t *sseClientTransport) sendErrorResponse(request *JSONRPCRequest, code int, message string) {
 	errorResp := newJSONRPCErrorResponse(request.ID, code, message, nil)
@@ -619,6 +676,9 @@ func (t *sseClientTransport) sendRequestInternal(ctx context.Context, req *JSONR
 	defer resp.Body.Close()
 
 	// Check response status.
+	if err := insufficientScopeError(resp); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("%w: status code %d, body: %s", ErrHTTPRequestFailed, resp.StatusCode, string(bodyBytes))
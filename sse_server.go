@@ -176,6 +176,15 @@ type SSEServer struct {
 	responsesMu          sync.RWMutex                                               // Mutex for responses map.
 	notificationHandlers map[string]ServerNotificationHandler                       // Map of notification handlers by method name.
 	notificationMu       sync.RWMutex                                               // Mutex for notification handlers map.
+	authorizer           *authorizer                                                // Bearer token authorization, nil if disabled.
+	trustProxyHeaders    bool                                                       // Whether the X-Forwarded-Proto header of a reverse proxy is trusted.
+	sessionHooks         *SessionHooks                                              // Hooks called as sessions are created, initialized and closed.
+	shuttingDown         atomic.Bool                                                // Whether Shutdown was called.
+	pageSize             int                                                        // Maximum number of items per list page, 0 disables pagination.
+	paginationKey        []byte                                                     // HMAC key signing the pagination cursors, random if empty.
+	listChangedEnabled   bool                                                       // Whether list_changed notifications are sent.
+	listChangedDelay     time.Duration                                              // Time list changes are collected for before notifying.
+	listChanged          *listChangedNotifier                                       // Notifier of tool, prompt and resource list changes, nil if disabled.
 }
 
 // SSEOption defines a function type for configuring the SSE server.
@@ -236,6 +245,8 @@ func NewSSEServer(name, version string, opts ...SSEOption) *SSEServer {
 		logger:               GetDefaultLogger(),
 		responses:            make(map[uint64]interface{}),
 		notificationHandlers: make(map[string]ServerNotificationHandler),
+		listChangedEnabled:   true,
+		listChangedDelay:     defaultListChangedDelay,
 	}
 
 	// Apply all options.
@@ -243,8 +254,25 @@ func NewSSEServer(name, version string, opts ...SSEOption) *SSEServer {
 		opt(s)
 	}
 
-	// Set logger for lifecycle manager.
+	// Create a paginator shared by all list methods if a page size is set.
+	paginator := newPaginator(s.pageSize, s.paginationKey)
+	toolManager.withPaginator(paginator)
+	promptManager.withPaginator(paginator)
+	resourceManager.withPaginator(paginator)
+
+	// Set logger and session hooks for lifecycle manager.
 	lifecycleManager.withLogger(s.logger)
+	lifecycleManager.withSessionHooks(s.sessionHooks)
+	lifecycleManager.withListChanged(s.listChangedEnabled)
+	if s.listChangedEnabled {
+		s.listChanged = newListChangedNotifier(s.listChangedDelay, s.sendListChanged)
+	}
+
+	// The resource clients are authorized for is the SSE endpoint.
+	if s.authorizer != nil {
+		s.authorizer.resourcePath = s.basePath + "/" + strings.TrimPrefix(s.sseEndpoint, "/")
+		s.authorizer.trustProxyHeaders = s.trustProxyHeaders
+	}
 
 	return s
 }
@@ -267,6 +295,32 @@ func WithSessionPubSub(pubSub SessionPubSub) SSEOption {
 	}
 }
 
+// WithSSESessionHooks sets functions called as sessions are created,
+// initialized and closed. A session is closed when its SSE connection closes,
+// or when the server shuts down. See WithSessionHooks.
+func WithSSESessionHooks(hooks *SessionHooks) SSEOption {
+	return func(s *SSEServer) {
+		s.sessionHooks = hooks
+	}
+}
+
+// WithSSEListChangedEnabled enables or disables the list_changed notifications
+// sent to the sessions when tools, prompts or resources are registered or
+// unregistered. See WithListChangedEnabled.
+func WithSSEListChangedEnabled(enabled bool) SSEOption {
+	return func(s *SSEServer) {
+		s.listChangedEnabled = enabled
+	}
+}
+
+// WithSSEListChangedDelay sets the time changes are collected for before a
+// list_changed notification is sent. See WithListChangedDelay.
+func WithSSEListChangedDelay(delay time.Duration) SSEOption {
+	return func(s *SSEServer) {
+		s.listChangedDelay = delay
+	}
+}
+
 // WithMessageEndpoint sets the message endpoint path.
 func WithMessageEndpoint(endpoint string) SSEOption {
 	return func(s *SSEServer) {
@@ -324,6 +378,22 @@ func WithSSEToolListFilter(filter ToolListFilter) SSEOption {
 	}
 }
 
+// WithSSEToolInputValidation sets how the SSE server validates the arguments
+// of tools/call requests. See WithToolInputValidation.
+func WithSSEToolInputValidation(validation ToolInputValidation) SSEOption {
+	return func(s *SSEServer) {
+		s.toolManager.withInputValidation(validation)
+	}
+}
+
+// WithSSEToolOutputValidation sets how the SSE server validates the
+// structured content returned by tools. See WithToolOutputValidation.
+func WithSSEToolOutputValidation(validation ToolOutputValidation) SSEOption {
+	return func(s *SSEServer) {
+		s.toolManager.withOutputValidation(validation)
+	}
+}
+
 // WithSSEPromptListFilter sets a prompt list filter for the SSE server.
 func WithSSEPromptListFilter(filter PromptListFilter) SSEOption {
 	return func(s *SSEServer) {
@@ -338,6 +408,22 @@ func WithSSEResourceListFilter(filter ResourceListFilter) SSEOption {
 	}
 }
 
+// WithSSEPageSize enables cursor-based pagination of list results for the SSE server.
+// A size of zero or less returns all items in a single response.
+func WithSSEPageSize(size int) SSEOption {
+	return func(s *SSEServer) {
+		s.pageSize = size
+	}
+}
+
+// WithSSEPaginationKey sets the HMAC key signing the pagination cursors of the
+// SSE server. See WithPaginationKey.
+func WithSSEPaginationKey(key []byte) SSEOption {
+	return func(s *SSEServer) {
+		s.paginationKey = key
+	}
+}
+
 // WithSSESessionIDGenerator sets a custom session ID generator for the SSE server.
 // This allows users to customize session ID generation, for example, to include
 // client IP and port information for load balancing node affinity.
@@ -365,13 +451,37 @@ func WithSSEMiddleware(middlewares ...Middleware) SSEOption {
 	}
 }
 
+// WithSSEAuthorization requires every request to the SSE server to carry a
+// bearer access token accepted by the verifier. See WithAuthorization.
+func WithSSEAuthorization(verifier TokenVerifier, metadata *ProtectedResourceMetadata) SSEOption {
+	return func(s *SSEServer) {
+		s.authorizer = newAuthorizer(verifier, metadata, "")
+	}
+}
+
+// WithSSETrustedProxyHeaders sets whether the SSE server runs behind a trusted
+// reverse proxy setting the X-Forwarded-Proto header. See WithTrustedProxyHeaders.
+func WithSSETrustedProxyHeaders(trusted bool) SSEOption {
+	return func(s *SSEServer) {
+		s.trustProxyHeaders = trusted
+	}
+}
+
 // Start starts the SSE server on the given address.
 func (s *SSEServer) Start(addr string) error {
 	return http.ListenAndServe(addr, s)
 }
 
-// Shutdown gracefully stops the SSE server.
+// Shutdown gracefully stops the SSE server. New connections are rejected, and
+// the requests being handled are given until ctx is done to complete, after
+// which the remaining ones are cancelled and the sessions closed.
 func (s *SSEServer) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	_ = s.listChanged.stop()
+	if err := s.mcpHandler.inFlight.wait(ctx); err != nil {
+		s.logger.Infof("Cancelling the requests still in flight on shutdown: %v", err)
+		s.mcpHandler.inFlight.cancelAll()
+	}
 	srv := s.httpServer
 	if srv != nil {
 		// Close all sessions.
@@ -449,6 +559,11 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		s.logger.Errorf("Streaming not supported by client")
@@ -487,6 +602,7 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	// Set session information to context.
 	ctx = setSessionToContext(ctx, session)
+	s.sessionHooks.sessionCreated(ctx, session)
 
 	// Subscribe to distributed SessionPubSub if configured.
 	if s.sessionPubSub != nil {
@@ -541,6 +657,12 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 	// Clean up resources.
 	closeSessionDone(s.logger, session)
 	s.sessions.Delete(sessionID)
+	s.mcpHandler.onSessionTerminated(sessionID)
+	reason := SessionCloseReasonDisconnected
+	if s.shuttingDown.Load() {
+		reason = SessionCloseReasonShutdown
+	}
+	s.sessionHooks.sessionClosed(icontext.WithoutCancel(ctx), session, reason)
 	s.logger.Debugf("Cleaned up session %s", sessionID)
 }
 
@@ -745,6 +867,18 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	// Create context with session.
 	ctx = s.createSessionContext(ctx, session)
 
+	// A tool call lacking scopes is refused before it is handled, telling the
+	// client the scopes to request.
+	if s.authorizer != nil && base.ID != nil && base.Method == MethodToolsCall {
+		var req JSONRPCRequest
+		if err := json.Unmarshal(rawMessage, &req); err == nil {
+			if scopes := s.mcpHandler.deniedToolScopes(ctx, &req, session); scopes != nil {
+				s.authorizer.writeInsufficientScope(w, r, scopes)
+				return
+			}
+		}
+	}
+
 	// Immediately return HTTP 202 Accepted status code, indicating request has been received.
 	w.WriteHeader(http.StatusAccepted)
 
@@ -786,6 +920,14 @@ func (s *SSEServer) handleNotificationMessage(ctx context.Context, rawMessage js
 		return
 	}
 
+	// The session can receive notifications once the client has initialized it.
+	if notification.Method == MethodNotificationsInitialized && session != nil {
+		session.Initialize()
+		if err := s.mcpHandler.lifecycleManager.handleInitialized(ctx, &notification, session); err != nil {
+			s.logger.Errorf("Error initializing session %s: %v", session.GetID(), err)
+		}
+	}
+
 	// Handle notification asynchronously.
 	go func() {
 		// Create a context that will not be canceled due to HTTP connection closure.
@@ -800,6 +942,11 @@ func (s *SSEServer) handleNotificationMessage(ctx context.Context, rawMessage js
 
 // handleNotification processes notifications (can be extended for different notification types).
 func (s *SSEServer) handleNotification(ctx context.Context, notification *JSONRPCNotification, session *sseSession) error {
+	// Abort the request named by a cancellation notification.
+	if notification.Method == MethodNotificationsCancelled && session != nil {
+		s.mcpHandler.inFlight.handleCancelledNotification(session.GetID(), notification)
+	}
+
 	// Check if there's a registered handler for this notification method.
 	s.notificationMu.RLock()
 	handler, exists := s.notificationHandlers[notification.Method]
@@ -812,7 +959,7 @@ func (s *SSEServer) handleNotification(ctx context.Context, notification *JSONRP
 				s.logger.Errorf("Error handling notification %s: %v", notification.Method, err)
 			}
 		}()
-	} else if s.logger != nil {
+	} else if s.logger != nil && notification.Method != MethodNotificationsCancelled {
 		s.logger.Warnf("Received notification with no handler registered: %s", notification.Method)
 	}
 
@@ -1117,8 +1264,19 @@ func (s *SSEServer) processRequestAsync(ctx context.Context, request *JSONRPCReq
 		return
 	}
 
+	// Notifications emitted while handling the request share the response's
+	// event queue so that they are delivered before the response.
+	detachedCtx = withNotificationSender(detachedCtx, newStreamNotificationSender(session,
+		func(notification *JSONRPCNotification) error {
+			return s.queueNotification(session, notification)
+		}))
+
 	// Process request.
 	result, err := s.mcpHandler.handleRequest(detachedCtx, request, session)
+	if errors.Is(err, errRequestCancelled) {
+		// The client cancelled the request, so no response is sent.
+		return
+	}
 
 	if err != nil {
 		s.handleRequestError(err, request.ID, session)
@@ -1146,6 +1304,23 @@ func (s *SSEServer) processRequestAsync(ctx context.Context, request *JSONRPCReq
 	s.sendSuccessResponse(request.ID, result, session)
 }
 
+// queueNotification queues a notification on the session's event queue.
+func (s *SSEServer) queueNotification(session *sseSession, notification *JSONRPCNotification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationSerialization, err)
+	}
+
+	select {
+	case session.eventQueue <- formatSSEEvent("message", data):
+		return nil
+	case <-session.done:
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, session.sessionID)
+	default:
+		return fmt.Errorf("event queue full for session %s", session.sessionID)
+	}
+}
+
 // isRootsListResponse checks if the request is actually a response to a roots/list request.
 func (s *SSEServer) isRootsListResponse(request *JSONRPCRequest) bool {
 	// Check if this looks like a response (has ID but no method).
@@ -1396,8 +1571,58 @@ func (s *SSEServer) sendNotificationToSession(sessionID string, notification *JS
 	}
 }
 
+// sendListChanged sends a list_changed notification to the initialized
+// sessions, for the listChangedNotifier.
+func (s *SSEServer) sendListChanged(sessionID, method string) {
+	notification := NewJSONRPCNotificationFromMap(method, nil)
+	if sessionID != "" {
+		if err := s.sendNotificationToSession(sessionID, notification); err != nil {
+			s.logger.Debugf("Failed to send %s to session %s: %v", method, sessionID, err)
+		}
+		return
+	}
+	s.sessions.Range(func(key, value interface{}) bool {
+		if session, ok := value.(*sseSession); ok && session.Initialized() {
+			if err := s.sendNotificationToSession(session.GetID(), notification); err != nil {
+				s.logger.Debugf("Failed to send %s to session %s: %v", method, session.GetID(), err)
+			}
+		}
+		return true
+	})
+}
+
+// NotifyResourceUpdated sends a notifications/resources/updated notification to
+// the sessions subscribed to the resource.
+func (s *SSEServer) NotifyResourceUpdated(uri string) error {
+	subscribers := s.resourceManager.getSubscribers(uri)
+	notification := newResourceUpdatedNotification(uri)
+
+	var failedCount int
+	var lastError error
+	for _, session := range subscribers {
+		if err := s.sendNotificationToSession(session.GetID(), notification); err != nil {
+			failedCount++
+			lastError = err
+		}
+	}
+	if failedCount > 0 {
+		return fmt.Errorf("failed to notify %d of %d subscribed sessions: %w", failedCount, len(subscribers), lastError)
+	}
+	return nil
+}
+
 // ServeHTTP implements the http.Handler interface.
 func (s *SSEServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.authorizer != nil {
+		if s.authorizer.serveMetadata(w, r) {
+			return
+		}
+		var ok bool
+		if r, ok = s.authorizer.authorize(w, r); !ok {
+			return
+		}
+	}
+
 	// Handle path matching.
 	path := r.URL.Path
 
@@ -1446,6 +1671,7 @@ func (s *SSEServer) RegisterTool(tool *Tool, handler toolHandler) {
 		return
 	}
 	s.toolManager.registerTool(tool, handler)
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 }
 
 // GetTool retrieves a registered tool by name.
@@ -1486,6 +1712,7 @@ func (s *SSEServer) UnregisterTools(names ...string) error {
 	if unregisteredCount == 0 {
 		return fmt.Errorf("none of the specified tools were found")
 	}
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 
 	return nil
 }
@@ -1497,6 +1724,7 @@ func (s *SSEServer) RegisterResource(resource *Resource, handler resourceHandler
 		return
 	}
 	s.resourceManager.registerResource(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 }
 
 // RegisterResources registers a resource with its handler for multiple contents.
@@ -1506,6 +1734,7 @@ func (s *SSEServer) RegisterResources(resource *Resource, handler resourcesHandl
 		return
 	}
 	s.resourceManager.registerResources(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 }
 
 // RegisterResourceTemplate registers a resource template with its handler.
@@ -1515,6 +1744,7 @@ func (s *SSEServer) RegisterResourceTemplate(template *ResourceTemplate, handler
 		return
 	}
 	s.resourceManager.registerTemplate(template, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 }
 
 // RegisterPrompt registers a prompt with its handler.
@@ -1524,6 +1754,25 @@ func (s *SSEServer) RegisterPrompt(prompt *Prompt, handler promptHandler) {
 		return
 	}
 	s.promptManager.registerPrompt(prompt, handler)
+	s.listChanged.notify("", NotificationMethodPromptsListChanged)
+}
+
+// RegisterPromptCompletion registers a completion provider for a prompt argument.
+func (s *SSEServer) RegisterPromptCompletion(promptName, argumentName string, provider CompletionProvider) {
+	if provider == nil {
+		s.logger.Errorf("RegisterPromptCompletion: provider cannot be nil")
+		return
+	}
+	s.promptManager.registerCompletionProvider(promptName, argumentName, provider)
+}
+
+// RegisterResourceTemplateCompletion registers a completion provider for a resource template variable.
+func (s *SSEServer) RegisterResourceTemplateCompletion(uriTemplate, variable string, provider CompletionProvider) {
+	if provider == nil {
+		s.logger.Errorf("RegisterResourceTemplateCompletion: provider cannot be nil")
+		return
+	}
+	s.resourceManager.registerCompletionProvider(uriTemplate, variable, provider)
 }
 
 // GetServerInfo returns the server information.
@@ -1575,8 +1824,41 @@ func (s *SSEServer) ListRoots(ctx context.Context) (*ListRootsResult, error) {
 	return &listRootsResult, nil
 }
 
-// SendRequest sends a JSON-RPC request to a client and waits for response.
+// CreateMessage asks the client of a session to sample an LLM.
+func (s *SSEServer) CreateMessage(
+	ctx context.Context,
+	sessionID string,
+	req *CreateMessageRequest,
+) (*CreateMessageResult, error) {
+	sessionValue, ok := s.sessions.Load(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	session, ok := sessionValue.(*sseSession)
+	if !ok {
+		return nil, fmt.Errorf("invalid session type")
+	}
+
+	return createMessage(ctx, session, req, func(ctx context.Context, request *JSONRPCRequest) (*json.RawMessage, error) {
+		return s.SendRequest(ctx, sessionID, request)
+	})
+}
+
+// sendRequestToSession implements sessionRequestSender.
+func (s *SSEServer) sendRequestToSession(
+	ctx context.Context,
+	session Session,
+	request *JSONRPCRequest,
+) (*json.RawMessage, error) {
+	return s.SendRequest(ctx, session.GetID(), request)
+}
+
+// SendRequest sends a JSON-RPC request to a client and waits for the response
+// until ctx is done, or for 30 seconds if ctx has no deadline.
 func (s *SSEServer) SendRequest(ctx context.Context, sessionID string, request *JSONRPCRequest) (*json.RawMessage, error) {
+	ctx, cancel := withServerRequestTimeout(ctx)
+	defer cancel()
+
 	// Get session
 	sessionValue, ok := s.sessions.Load(sessionID)
 	if !ok {
@@ -1630,14 +1912,16 @@ func (s *SSEServer) SendRequest(ctx context.Context, sessionID string, request *
 
 	s.logger.Debugf("Sent request with ID: %v", request.ID)
 
-	// Wait for the response or timeout.
+	// Wait for the response until ctx is done.
 	select {
 	case <-ctx.Done():
+		// The client stops handling the abandoned request.
+		sendCancelledNotification(ctx, request.ID, func(ctx context.Context, notification *JSONRPCNotification) error {
+			return s.sendNotificationToSession(sessionID, notification)
+		})
 		return nil, ctx.Err()
 	case response := <-resultChan:
 		return response, nil
-	case <-time.After(30 * time.Second):
-		return nil, fmt.Errorf("request timeout")
 	}
 }
 
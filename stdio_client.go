@@ -8,6 +8,7 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -40,15 +41,37 @@ type StdioClient struct {
 	initialized     atomic.Bool
 	requestID       atomic.Int64
 	capabilities    map[string]interface{}
-	state           atomic.Value // stores State
+	state           stateTracker
 	logger          Logger
 
 	// Roots support.
 	rootsProvider RootsProvider // Provider for roots information.
 	rootsMu       sync.RWMutex  // Mutex for protecting the rootsProvider.
 
+	// Sampling support.
+	samplingHandler SamplingHandler // Handler for sampling/createMessage requests.
+	samplingMu      sync.RWMutex    // Mutex for protecting the samplingHandler.
+
+	// Elicitation support.
+	elicitationHandler ElicitationHandler // Handler for elicitation/create requests.
+	elicitationMu      sync.RWMutex       // Mutex for protecting the elicitationHandler.
+
 	// Whether to include "arguments": {} for tool calls with no arguments.
 	sendEmptyToolArguments bool
+
+	// Routes progress notifications to per-call handlers.
+	progress progressRouter
+
+	// Requests of the server being handled, cancelled by its notifications/cancelled.
+	serverRequests inFlightRegistry
+
+	// Called when the server process exits on its own.
+	processExitHandler func(err error)
+
+	// Pings the server periodically after initialization, nil if disabled.
+	healthCheck *healthChecker
+	// Request of the last Initialize, sent again when the process is restarted.
+	initRequest atomic.Pointer[InitializeRequest]
 }
 
 // StdioClientOption defines configuration options for StdioClient.
@@ -64,13 +87,13 @@ func NewStdioClient(config StdioTransportConfig, clientInfo Implementation, opti
 	// Create client.
 	client := &StdioClient{
 		clientInfo:      clientInfo,
-		protocolVersion: ProtocolVersion_2025_03_26,
+		protocolVersion: ProtocolVersion_2025_06_18,
 		capabilities:    make(map[string]interface{}),
 		logger:          GetDefaultLogger(),
 	}
 
 	// Set initial state.
-	client.state.Store(StateDisconnected)
+	client.state.set(StateDisconnected)
 
 	// Apply options.
 	for _, option := range options {
@@ -91,6 +114,7 @@ func NewStdioClient(config StdioTransportConfig, clientInfo Implementation, opti
 
 	// Set client reference in transport for roots handling.
 	client.transport.client = client
+	client.transport.exitHandler = client.handleProcessExit
 
 	return client, nil
 }
@@ -126,18 +150,49 @@ func WithStdioSendEmptyToolArguments(enabled bool) StdioClientOption {
 	}
 }
 
+// WithStdioProcessExitHandler sets a handler called when the server process
+// exits on its own, rather than by Close or RestartProcess. Requests fail
+// until the process is restarted with RestartProcess and the client is
+// initialized again.
+func WithStdioProcessExitHandler(handler func(err error)) StdioClientOption {
+	return func(c *StdioClient) {
+		c.processExitHandler = handler
+	}
+}
+
+// WithStdioHealthCheck enables a health check pinging the server every
+// interval once the client is initialized, until it is closed. When the server
+// process exited, it is restarted and the client initialized again with the
+// request of the last Initialize. A server that does not answer sets the
+// state to StateDisconnected until a ping succeeds.
+func WithStdioHealthCheck(interval time.Duration) StdioClientOption {
+	return func(c *StdioClient) {
+		c.healthCheck = newHealthChecker(interval, c.checkHealth)
+	}
+}
+
+// WithStdioStateChangeHandler sets a handler called when the state of the
+// client changes.
+func WithStdioStateChangeHandler(handler StateChangeHandler) StdioClientOption {
+	return func(c *StdioClient) {
+		c.state.setHandler(handler)
+	}
+}
+
 // Initialize initializes the client connection
 func (c *StdioClient) Initialize(ctx context.Context, req *InitializeRequest) (*InitializeResult, error) {
 	if c.initialized.Load() {
 		return nil, fmt.Errorf("client already initialized")
 	}
+	c.initRequest.Store(req)
 
 	// Create initialization request
 	requestID := c.requestID.Add(1)
 	jsonReq := newJSONRPCRequest(requestID, MethodInitialize, map[string]interface{}{
 		"protocolVersion": c.protocolVersion,
 		"clientInfo":      c.clientInfo,
-		"capabilities":    c.capabilities,
+		"capabilities": declareClientCapabilities(c.capabilities,
+			c.getSamplingHandler() != nil, c.getElicitationHandler() != nil),
 	})
 
 	// Override with provided params if any.
@@ -184,6 +239,7 @@ func (c *StdioClient) Initialize(ctx context.Context, req *InitializeRequest) (*
 	c.initialized.Store(true)
 	c.setState(StateInitialized)
 
+	c.healthCheck.start(ctx)
 	return initResult, nil
 }
 
@@ -195,6 +251,7 @@ func (c *StdioClient) sendInitialized(ctx context.Context) error {
 
 // Close closes the client and terminates the process.
 func (c *StdioClient) Close() error {
+	c.healthCheck.stop()
 	if c.transport != nil {
 		err := c.transport.close()
 		c.setState(StateDisconnected)
@@ -206,15 +263,22 @@ func (c *StdioClient) Close() error {
 
 // GetState returns the current client state.
 func (c *StdioClient) GetState() State {
-	if state := c.state.Load(); state != nil {
-		return state.(State)
-	}
-	return StateDisconnected
+	return c.state.get()
 }
 
 // setState sets the client state thread-safely.
 func (c *StdioClient) setState(state State) {
-	c.state.Store(state)
+	c.state.set(state)
+}
+
+// sendRequest sends a request and waits for its response. If ctx is done before
+// the response arrives, the server is told to stop processing the request.
+func (c *StdioClient) sendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	rawResp, err := c.transport.sendRequest(ctx, req)
+	if err != nil {
+		sendCancelledNotification(ctx, req.ID, c.transport.sendNotification)
+	}
+	return rawResp, err
 }
 
 // ListTools lists available tools.
@@ -233,7 +297,7 @@ func (c *StdioClient) ListTools(ctx context.Context, req *ListToolsRequest) (*Li
 		Params: req.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("list tools request failed: %w", err)
 	}
@@ -256,6 +320,10 @@ func (c *StdioClient) CallTool(ctx context.Context, req *CallToolRequest) (*Call
 		return nil, fmt.Errorf("client not initialized")
 	}
 
+	// Ask for progress updates if the context carries a progress handler.
+	req, done := c.progress.prepareCallTool(ctx, req)
+	defer done()
+
 	requestID := c.requestID.Add(1)
 	params := map[string]interface{}{
 		"name":      req.Params.Name,
@@ -264,9 +332,12 @@ func (c *StdioClient) CallTool(ctx context.Context, req *CallToolRequest) (*Call
 	if c.sendEmptyToolArguments && len(req.Params.Arguments) == 0 {
 		params["arguments"] = map[string]interface{}{}
 	}
+	if req.Params.Meta != nil {
+		params["_meta"] = req.Params.Meta
+	}
 	jsonReq := newJSONRPCRequest(requestID, MethodToolsCall, params)
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("call tool request failed: %w", err)
 	}
@@ -299,7 +370,7 @@ func (c *StdioClient) ListPrompts(ctx context.Context, req *ListPromptsRequest)
 		Params: req.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("list prompts request failed: %w", err)
 	}
@@ -328,7 +399,7 @@ func (c *StdioClient) GetPrompt(ctx context.Context, req *GetPromptRequest) (*Ge
 		"arguments": req.Params.Arguments,
 	})
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("get prompt request failed: %w", err)
 	}
@@ -345,6 +416,39 @@ func (c *StdioClient) GetPrompt(ctx context.Context, req *GetPromptRequest) (*Ge
 	return parseGetPromptResultFromJSON(rawResp)
 }
 
+// Complete requests completion values for a prompt argument or a resource template variable.
+func (c *StdioClient) Complete(ctx context.Context, req *CompleteRequest) (*CompleteResult, error) {
+	if !c.initialized.Load() {
+		return nil, fmt.Errorf("client not initialized")
+	}
+
+	requestID := c.requestID.Add(1)
+	jsonReq := &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		ID:      requestID,
+		Request: Request{
+			Method: MethodCompletionComplete,
+		},
+		Params: req.Params,
+	}
+
+	rawResp, err := c.sendRequest(ctx, jsonReq)
+	if err != nil {
+		return nil, fmt.Errorf("complete request failed: %w", err)
+	}
+
+	if isErrorResponse(rawResp) {
+		errResp, err := parseRawMessageToError(rawResp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse error response: %w", err)
+		}
+		return nil, fmt.Errorf("complete error: %s (code: %d)",
+			errResp.Error.Message, errResp.Error.Code)
+	}
+
+	return parseCompleteResultFromJSON(rawResp)
+}
+
 // ListResources lists available resources.
 func (c *StdioClient) ListResources(ctx context.Context, req *ListResourcesRequest) (*ListResourcesResult, error) {
 	if !c.initialized.Load() {
@@ -361,7 +465,7 @@ func (c *StdioClient) ListResources(ctx context.Context, req *ListResourcesReque
 		Params: req.Params,
 	}
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("list resources request failed: %w", err)
 	}
@@ -390,7 +494,7 @@ func (c *StdioClient) ReadResource(ctx context.Context, req *ReadResourceRequest
 		"arguments": req.Params.Arguments,
 	})
 
-	rawResp, err := c.transport.sendRequest(ctx, jsonReq)
+	rawResp, err := c.sendRequest(ctx, jsonReq)
 	if err != nil {
 		return nil, fmt.Errorf("read resource request failed: %w", err)
 	}
@@ -432,25 +536,30 @@ func (c *StdioClient) IsProcessRunning() bool {
 	return c.transport.isProcessRunning()
 }
 
-// RestartProcess restarts the server process.
+// RestartProcess restarts the server process. The client must be initialized
+// again before sending requests.
 func (c *StdioClient) RestartProcess(ctx context.Context) error {
-	// Close current process
-	if err := c.transport.close(); err != nil {
-		c.logger.Warnf("Error closing current process: %v", err)
-	}
-
 	// Reset state.
 	c.initialized.Store(false)
 	c.setState(StateDisconnected)
 
-	// Start new process
-	if err := c.transport.startProcess(); err != nil {
+	// Stop the current process and start a new one.
+	if err := c.transport.restartProcess(); err != nil {
 		return fmt.Errorf("failed to restart process: %w", err)
 	}
 
 	return nil
 }
 
+// handleProcessExit resets the client state when the server process exits on its own.
+func (c *StdioClient) handleProcessExit(err error) {
+	c.initialized.Store(false)
+	c.setState(StateDisconnected)
+	if c.processExitHandler != nil {
+		c.processExitHandler(err)
+	}
+}
+
 // GetTransportInfo returns information about the transport.
 func (c *StdioClient) GetTransportInfo() TransportInfo {
 	capabilities := make(map[string]interface{})
@@ -476,6 +585,36 @@ func (c *StdioClient) SetRootsProvider(provider RootsProvider) {
 	c.rootsProvider = provider
 }
 
+// SetSamplingHandler sets the handler for responding to server's sampling/createMessage
+// requests. The sampling capability is declared when the handler is set before Initialize.
+func (c *StdioClient) SetSamplingHandler(handler SamplingHandler) {
+	c.samplingMu.Lock()
+	defer c.samplingMu.Unlock()
+	c.samplingHandler = handler
+}
+
+// getSamplingHandler returns the sampling handler, if any.
+func (c *StdioClient) getSamplingHandler() SamplingHandler {
+	c.samplingMu.RLock()
+	defer c.samplingMu.RUnlock()
+	return c.samplingHandler
+}
+
+// SetElicitationHandler sets the handler for responding to server's elicitation/create
+// requests. The elicitation capability is declared when the handler is set before Initialize.
+func (c *StdioClient) SetElicitationHandler(handler ElicitationHandler) {
+	c.elicitationMu.Lock()
+	defer c.elicitationMu.Unlock()
+	c.elicitationHandler = handler
+}
+
+// getElicitationHandler returns the elicitation handler, if any.
+func (c *StdioClient) getElicitationHandler() ElicitationHandler {
+	c.elicitationMu.RLock()
+	defer c.elicitationMu.RUnlock()
+	return c.elicitationHandler
+}
+
 // SendRootsListChangedNotification notifies server that roots changed.
 func (c *StdioClient) SendRootsListChangedNotification(ctx context.Context) error {
 	// Create roots list changed notification.
@@ -9,10 +9,22 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const defaultStdioProxyDiscoveryTimeout = 30 * time.Second
+const (
+	defaultStdioProxyDiscoveryTimeout = 30 * time.Second
+
+	// defaultStdioProxyRestartMinDelay is the default delay before restarting
+	// a crashed stdio server process.
+	defaultStdioProxyRestartMinDelay = time.Second
+
+	// defaultStdioProxyRestartMaxDelay caps the default delay between failed
+	// restart attempts.
+	defaultStdioProxyRestartMaxDelay = 30 * time.Second
+)
 
 // StreamableStdioProxyConfig configures a Streamable HTTP server backed by an
 // external stdio MCP server process.
@@ -32,6 +44,18 @@ type StreamableStdioProxyConfig struct {
 	// DiscoveryTimeout bounds initialization and capability discovery. Defaults
 	// to 30 seconds when unset.
 	DiscoveryTimeout time.Duration
+	// DisableRestart disables restarting the stdio server process when it
+	// exits unexpectedly.
+	DisableRestart bool
+	// RestartMinDelay is the delay before restarting a crashed stdio server
+	// process. It doubles after each failed attempt, and after each restarted
+	// process that crashes again within RestartMaxDelay. Defaults to 1 second
+	// when unset.
+	RestartMinDelay time.Duration
+	// RestartMaxDelay caps the delay between restart attempts, and is how long
+	// a restarted process must stay up for the delay to be reset. Defaults to
+	// 30 seconds when unset.
+	RestartMaxDelay time.Duration
 	// ServerOptions are passed to NewServer for the public Streamable HTTP server.
 	ServerOptions []ServerOption
 	// ClientOptions are passed to NewStdioClient for the stdio side.
@@ -39,10 +63,44 @@ type StreamableStdioProxyConfig struct {
 }
 
 // StreamableStdioProxy owns the stdio client process used by a Streamable HTTP
-// proxy server.
+// proxy server. Shutting down the server closes the proxy.
+//
+// The tools, resources and prompts of the server are kept in sync with the
+// stdio server: they are refreshed when the stdio server sends a list_changed
+// notification, or is restarted, and the server's sessions are notified in
+// turn. A crashed stdio server process is restarted with exponential backoff
+// unless DisableRestart is set.
 type StreamableStdioProxy struct {
-	client           *StdioClient
+	client            *StdioClient
+	server            *Server
+	logger            Logger
+	initializeRequest *InitializeRequest
+	timeout           time.Duration
+	disableRestart    bool
+	restartMinDelay   time.Duration
+	restartMaxDelay   time.Duration
+
+	// initializeResult is replaced when the process is restarted.
+	mu               sync.RWMutex
 	initializeResult *InitializeResult
+
+	// Names of the registered tools and prompts and URIs of the registered
+	// resources, replaced by refreshes, which refreshMu serializes.
+	refreshMu sync.Mutex
+	tools     []string
+	prompts   []string
+	resources []string
+
+	// restarting is set while a restart after a crash is in progress.
+	restarting atomic.Bool
+	// restartDelay is the delay before the next restart if the process last
+	// restarted crashes before restartMaxDelay, at restartedAt. They are only
+	// used by the restart loop.
+	restartDelay time.Duration
+	restartedAt  time.Time
+	// closed is closed by Close to stop restarting.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // Close closes the underlying stdio client and terminates the external process.
@@ -50,10 +108,14 @@ func (p *StreamableStdioProxy) Close() error {
 	if p == nil || p.client == nil {
 		return nil
 	}
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
 	return p.client.Close()
 }
 
-// Client returns the underlying stdio client.
+// Client returns the underlying stdio client. Use Restart rather than the
+// RestartProcess method of the client to restart the process.
 func (p *StreamableStdioProxy) Client() *StdioClient {
 	if p == nil {
 		return nil
@@ -62,14 +124,40 @@ func (p *StreamableStdioProxy) Client() *StdioClient {
 }
 
 // InitializeResult returns the stdio server initialize result discovered during
-// proxy startup.
+// proxy startup, or the last restart.
 func (p *StreamableStdioProxy) InitializeResult() *InitializeResult {
 	if p == nil {
 		return nil
 	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.initializeResult
 }
 
+// Refresh re-syncs the server with the tools, resources and prompts of the
+// stdio server.
+func (p *StreamableStdioProxy) Refresh(ctx context.Context) error {
+	return p.refresh(ctx, p.InitializeResult().Capabilities)
+}
+
+// Restart restarts the stdio server process, initializes it, and re-syncs
+// the server with its tools, resources and prompts. The server's sessions are
+// notified that the lists changed.
+func (p *StreamableStdioProxy) Restart(ctx context.Context) error {
+	if err := p.client.RestartProcess(ctx); err != nil {
+		return err
+	}
+	initResult, err := p.client.Initialize(ctx, p.initializeRequest)
+	if err != nil {
+		return fmt.Errorf("initialize stdio server: %w", err)
+	}
+	p.mu.Lock()
+	p.initializeResult = initResult
+	p.mu.Unlock()
+
+	return p.refresh(ctx, initResult.Capabilities)
+}
+
 // NewStreamableServerWithStdio creates a Streamable HTTP MCP server that
 // exposes an external stdio MCP server by discovering its tools, resources, and
 // prompts and registering local forwarding handlers.
@@ -98,40 +186,67 @@ func NewStreamableServerWithStdio(
 		config.Stdio.Timeout = defaultStdioProxyDiscoveryTimeout
 	}
 
-	stdioClient, err := NewStdioClient(config.Stdio, clientInfo, config.ClientOptions...)
-	if err != nil {
-		return nil, nil, fmt.Errorf("create stdio client: %w", err)
-	}
-
 	timeout := config.DiscoveryTimeout
 	if timeout <= 0 {
 		timeout = defaultStdioProxyDiscoveryTimeout
 	}
-	discoveryCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
 	initReq := config.InitializeRequest
 	if initReq == nil {
 		initReq = &InitializeRequest{}
 	}
-	initResult, err := stdioClient.Initialize(discoveryCtx, initReq)
+	proxy := &StreamableStdioProxy{
+		server:            NewServer(config.ServerName, config.ServerVersion, config.ServerOptions...),
+		initializeRequest: initReq,
+		timeout:           timeout,
+		disableRestart:    config.DisableRestart,
+		restartMinDelay:   config.RestartMinDelay,
+		restartMaxDelay:   config.RestartMaxDelay,
+		closed:            make(chan struct{}),
+	}
+	if proxy.restartMinDelay <= 0 {
+		proxy.restartMinDelay = defaultStdioProxyRestartMinDelay
+	}
+	if proxy.restartMaxDelay <= 0 {
+		proxy.restartMaxDelay = defaultStdioProxyRestartMaxDelay
+	}
+
+	clientOptions := append([]StdioClientOption{}, config.ClientOptions...)
+	clientOptions = append(clientOptions, WithStdioProcessExitHandler(proxy.handleProcessExit))
+	stdioClient, err := NewStdioClient(config.Stdio, clientInfo, clientOptions...)
 	if err != nil {
-		_ = stdioClient.Close()
-		return nil, nil, fmt.Errorf("initialize stdio server: %w", err)
+		return nil, nil, fmt.Errorf("create stdio client: %w", err)
+	}
+	proxy.client = stdioClient
+	proxy.logger = stdioClient.logger
+	if proxy.logger == nil {
+		proxy.logger = GetDefaultLogger()
 	}
+	stdioClient.RegisterNotificationHandler(NotificationMethodToolsListChanged,
+		proxy.handleListChanged(proxy.refreshTools))
+	stdioClient.RegisterNotificationHandler(NotificationMethodResourcesListChanged,
+		proxy.handleListChanged(proxy.refreshResources))
+	stdioClient.RegisterNotificationHandler(NotificationMethodPromptsListChanged,
+		proxy.handleListChanged(proxy.refreshPrompts))
 
-	server := NewServer(config.ServerName, config.ServerVersion, config.ServerOptions...)
-	proxy := &StreamableStdioProxy{
-		client:           stdioClient,
-		initializeResult: initResult,
+	discoveryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	initResult, err := stdioClient.Initialize(discoveryCtx, initReq)
+	if err != nil {
+		_ = proxy.Close()
+		return nil, nil, fmt.Errorf("initialize stdio server: %w", err)
 	}
+	proxy.mu.Lock()
+	proxy.initializeResult = initResult
+	proxy.mu.Unlock()
 
-	if err := registerStdioProxyCapabilities(discoveryCtx, server, proxy, initResult.Capabilities); err != nil {
+	if err := proxy.refresh(discoveryCtx, initResult.Capabilities); err != nil {
 		_ = proxy.Close()
 		return nil, nil, err
 	}
+	proxy.server.onShutdown(proxy.Close)
 
-	return server, proxy, nil
+	return proxy.server, proxy, nil
 }
 
 // NewStreamableServerWithStdioParams is a convenience wrapper around
@@ -151,77 +266,219 @@ func NewStreamableServerWithStdioParams(
 	})
 }
 
-func registerStdioProxyCapabilities(
-	ctx context.Context,
-	server *Server,
-	proxy *StreamableStdioProxy,
-	capabilities ServerCapabilities,
-) error {
+// refresh re-syncs all kinds of items the stdio server supports, and
+// unregisters the kinds it no longer supports.
+func (p *StreamableStdioProxy) refresh(ctx context.Context, capabilities ServerCapabilities) error {
 	if capabilities.Tools != nil {
-		if err := registerStdioProxyTools(ctx, server, proxy); err != nil {
+		if err := p.refreshTools(ctx); err != nil {
 			return fmt.Errorf("register stdio proxy tools: %w", err)
 		}
+	} else {
+		p.syncTools(nil)
 	}
 	if capabilities.Resources != nil {
-		if err := registerStdioProxyResources(ctx, server, proxy); err != nil {
+		if err := p.refreshResources(ctx); err != nil {
 			return fmt.Errorf("register stdio proxy resources: %w", err)
 		}
+	} else {
+		p.syncResources(nil)
 	}
 	if capabilities.Prompts != nil {
-		if err := registerStdioProxyPrompts(ctx, server, proxy); err != nil {
+		if err := p.refreshPrompts(ctx); err != nil {
 			return fmt.Errorf("register stdio proxy prompts: %w", err)
 		}
+	} else {
+		p.syncPrompts(nil)
 	}
 	return nil
 }
 
-func registerStdioProxyTools(ctx context.Context, server *Server, proxy *StreamableStdioProxy) error {
-	result, err := listAllStdioProxyTools(ctx, proxy.client)
+// handleListChanged returns a notification handler running refresh in the
+// background. The server notifies its sessions of the changes.
+func (p *StreamableStdioProxy) handleListChanged(refresh func(ctx context.Context) error) NotificationHandler {
+	return func(notification *JSONRPCNotification) error {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+			defer cancel()
+			if err := refresh(ctx); err != nil {
+				p.logger.Errorf("Stdio proxy: failed to handle %s: %v", notification.Method, err)
+			}
+		}()
+		return nil
+	}
+}
+
+func (p *StreamableStdioProxy) refreshTools(ctx context.Context) error {
+	result, err := listAllUpstreamTools(ctx, p.client)
 	if err != nil {
 		return err
 	}
-	for _, tool := range result.Tools {
+	p.syncTools(result.Tools)
+	return nil
+}
+
+// syncTools registers tools and unregisters the previously registered tools
+// missing from them.
+func (p *StreamableStdioProxy) syncTools(tools []Tool) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
 		toolCopy := tool
-		server.RegisterTool(&toolCopy, func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
-			return proxy.client.CallTool(ctx, req)
+		p.server.RegisterTool(&toolCopy, func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			return p.client.CallTool(ctx, req)
 		})
+		names = append(names, tool.Name)
 	}
-	return nil
+	if removed := missingStdioProxyItems(p.tools, names); len(removed) > 0 {
+		_ = p.server.UnregisterTools(removed...)
+	}
+	p.tools = names
 }
 
-func registerStdioProxyResources(ctx context.Context, server *Server, proxy *StreamableStdioProxy) error {
-	result, err := listAllStdioProxyResources(ctx, proxy.client)
+func (p *StreamableStdioProxy) refreshResources(ctx context.Context) error {
+	result, err := listAllUpstreamResources(ctx, p.client)
 	if err != nil {
 		return err
 	}
-	for _, resource := range result.Resources {
+	p.syncResources(result.Resources)
+	return nil
+}
+
+// syncResources registers resources and unregisters the previously registered
+// resources missing from them.
+func (p *StreamableStdioProxy) syncResources(resources []Resource) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	uris := make([]string, 0, len(resources))
+	for _, resource := range resources {
 		resourceCopy := resource
-		server.RegisterResources(&resourceCopy, func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
-			readResult, err := proxy.client.ReadResource(ctx, req)
+		p.server.RegisterResources(&resourceCopy, func(ctx context.Context, req *ReadResourceRequest) ([]ResourceContents, error) {
+			readResult, err := p.client.ReadResource(ctx, req)
 			if err != nil {
 				return nil, err
 			}
 			return readResult.Contents, nil
 		})
+		uris = append(uris, resource.URI)
 	}
-	return nil
+	if removed := missingStdioProxyItems(p.resources, uris); len(removed) > 0 {
+		_ = p.server.UnregisterResources(removed...)
+	}
+	p.resources = uris
 }
 
-func registerStdioProxyPrompts(ctx context.Context, server *Server, proxy *StreamableStdioProxy) error {
-	result, err := listAllStdioProxyPrompts(ctx, proxy.client)
+func (p *StreamableStdioProxy) refreshPrompts(ctx context.Context) error {
+	result, err := listAllUpstreamPrompts(ctx, p.client)
 	if err != nil {
 		return err
 	}
-	for _, prompt := range result.Prompts {
+	p.syncPrompts(result.Prompts)
+	return nil
+}
+
+// syncPrompts registers prompts and unregisters the previously registered
+// prompts missing from them.
+func (p *StreamableStdioProxy) syncPrompts(prompts []Prompt) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	names := make([]string, 0, len(prompts))
+	for _, prompt := range prompts {
 		promptCopy := prompt
-		server.RegisterPrompt(&promptCopy, func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
-			return proxy.client.GetPrompt(ctx, req)
+		p.server.RegisterPrompt(&promptCopy, func(ctx context.Context, req *GetPromptRequest) (*GetPromptResult, error) {
+			return p.client.GetPrompt(ctx, req)
 		})
+		names = append(names, prompt.Name)
+	}
+	if removed := missingStdioProxyItems(p.prompts, names); len(removed) > 0 {
+		_ = p.server.UnregisterPrompts(removed...)
+	}
+	p.prompts = names
+}
+
+// missingStdioProxyItems returns the names of previous missing from current.
+func missingStdioProxyItems(previous, current []string) []string {
+	kept := make(map[string]bool, len(current))
+	for _, name := range current {
+		kept[name] = true
+	}
+	var missing []string
+	for _, name := range previous {
+		if !kept[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// handleProcessExit restarts the stdio server process after it crashed.
+func (p *StreamableStdioProxy) handleProcessExit(err error) {
+	if p.InitializeResult() == nil {
+		// A crash during startup fails NewStreamableServerWithStdio instead.
+		return
+	}
+	if p.disableRestart {
+		p.logger.Errorf("Stdio proxy: stdio server process exited: %v", err)
+		return
+	}
+	if !p.restarting.CompareAndSwap(false, true) {
+		return
+	}
+	p.logger.Warnf("Stdio proxy: stdio server process exited (%v), restarting", err)
+	go p.restartLoop()
+}
+
+// restartLoop restarts the stdio server process with exponential backoff
+// until it succeeds or the proxy is closed. The backoff is only reset once a
+// restarted process stayed up for restartMaxDelay, so that a process crashing
+// shortly after each start is not restarted in a tight loop.
+func (p *StreamableStdioProxy) restartLoop() {
+	delay := p.restartMinDelay
+	if !p.restartedAt.IsZero() && time.Since(p.restartedAt) < p.restartMaxDelay {
+		delay = p.restartDelay
+	}
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-p.closed:
+			timer.Stop()
+			p.restarting.Store(false)
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		err := p.Restart(ctx)
+		cancel()
+		if err == nil {
+			p.logger.Infof("Stdio proxy: stdio server process restarted")
+			p.restartedAt = time.Now()
+			if p.restartDelay = delay * 2; p.restartDelay > p.restartMaxDelay {
+				p.restartDelay = p.restartMaxDelay
+			}
+			p.restarting.Store(false)
+			// The new process may have exited before restarting was reset.
+			if p.client.GetState() == StateInitialized || !p.restarting.CompareAndSwap(false, true) {
+				return
+			}
+			delay = p.restartDelay
+			continue
+		}
+
+		select {
+		case <-p.closed:
+			p.restarting.Store(false)
+			return
+		default:
+		}
+		if delay *= 2; delay > p.restartMaxDelay {
+			delay = p.restartMaxDelay
+		}
+		p.logger.Errorf("Stdio proxy: failed to restart stdio server process, retrying in %v: %v", delay, err)
 	}
-	return nil
 }
 
-func listAllStdioProxyTools(ctx context.Context, client *StdioClient) (*ListToolsResult, error) {
+func listAllUpstreamTools(ctx context.Context, client Connector) (*ListToolsResult, error) {
 	result := &ListToolsResult{}
 	req := &ListToolsRequest{}
 	for {
@@ -237,7 +494,7 @@ func listAllStdioProxyTools(ctx context.Context, client *StdioClient) (*ListTool
 	}
 }
 
-func listAllStdioProxyResources(ctx context.Context, client *StdioClient) (*ListResourcesResult, error) {
+func listAllUpstreamResources(ctx context.Context, client Connector) (*ListResourcesResult, error) {
 	result := &ListResourcesResult{}
 	req := &ListResourcesRequest{}
 	for {
@@ -253,7 +510,7 @@ func listAllStdioProxyResources(ctx context.Context, client *StdioClient) (*List
 	}
 }
 
-func listAllStdioProxyPrompts(ctx context.Context, client *StdioClient) (*ListPromptsResult, error) {
+func listAllUpstreamPrompts(ctx context.Context, client Connector) (*ListPromptsResult, error) {
 	result := &ListPromptsResult{}
 	req := &ListPromptsRequest{}
 	for {
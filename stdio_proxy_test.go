@@ -0,0 +1,54 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamableStdioProxy_RestartBackoff(t *testing.T) {
+	const minDelay = 200 * time.Millisecond
+	_, proxy, err := NewStreamableServerWithStdio(context.Background(), StreamableStdioProxyConfig{
+		ServerName:    "Test-Proxy",
+		ServerVersion: "1.0.0",
+		Stdio: StdioTransportConfig{
+			ServerParams: StdioServerParameters{
+				Command: os.Args[0],
+				Args:    []string{"-test.run=^TestStdioHealthCheckServerHelper$"},
+				Env:     map[string]string{"TRPC_MCP_HEALTH_CHECK_SERVER": "1"},
+			},
+			Timeout: 10 * time.Second,
+		},
+		RestartMinDelay: minDelay,
+		RestartMaxDelay: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	// crash makes the process exit, and returns how long it took to restart.
+	crash := func() time.Duration {
+		pid := proxy.Client().GetProcessID()
+		start := time.Now()
+		_, _ = proxy.Client().CallTool(context.Background(), &CallToolRequest{Params: CallToolParams{Name: "exit"}})
+		require.Eventually(t, func() bool {
+			return proxy.Client().GetProcessID() != pid && proxy.Client().GetState() == StateInitialized
+		}, 10*time.Second, 10*time.Millisecond)
+		return time.Since(start)
+	}
+
+	// A process crashing again soon after its restart is restarted after a
+	// longer delay.
+	assert.GreaterOrEqual(t, crash(), minDelay)
+	assert.GreaterOrEqual(t, crash(), 2*minDelay)
+	assert.GreaterOrEqual(t, crash(), 4*minDelay)
+}
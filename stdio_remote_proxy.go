@@ -0,0 +1,297 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// StdioRemoteProxyConfig configures a stdio MCP server backed by a remote
+// Streamable HTTP or SSE MCP server.
+type StdioRemoteProxyConfig struct {
+	// ServerName is the stdio MCP server name.
+	ServerName string
+	// ServerVersion is the stdio MCP server version.
+	ServerVersion string
+	// Client connects to the remote server. It must be created by NewClient or
+	// NewSSEClient and not be initialized: the proxy initializes it with the
+	// initialize request of the stdio client, and closes it when the stdio
+	// server stops.
+	Client *Client
+	// ServerOptions are passed to NewStdioServer.
+	ServerOptions []StdioServerOption
+}
+
+// StdioRemoteProxy forwards the messages of a stdio MCP server to a remote
+// MCP server and back.
+//
+// Requests and notifications of the stdio client are forwarded to the remote
+// server, and the requests (roots, sampling, ping...) and notifications of the
+// remote server are forwarded to the stdio client. When the remote server no
+// longer knows the session of the proxy, for example because it expired, the
+// proxy initializes a new session with the initialize request of the stdio
+// client and retries the request once. Only Streamable HTTP remote servers
+// report expired sessions.
+type StdioRemoteProxy struct {
+	server *StdioServer
+	client *Client
+	logger Logger
+
+	// mu serializes the initialization of the remote session.
+	mu                sync.RWMutex
+	initializeRequest *InitializeRequest
+	initializeResult  *InitializeResult
+
+	// session is the session of the stdio client, set by its first message.
+	session atomic.Pointer[stdioSession]
+}
+
+// NewStdioServerWithRemote creates a stdio MCP server that exposes a remote
+// MCP server by forwarding every message to it.
+func NewStdioServerWithRemote(config StdioRemoteProxyConfig) (*StdioServer, *StdioRemoteProxy, error) {
+	if config.ServerName == "" {
+		return nil, nil, fmt.Errorf("server name cannot be empty")
+	}
+	if config.ServerVersion == "" {
+		return nil, nil, fmt.Errorf("server version cannot be empty")
+	}
+	if config.Client == nil {
+		return nil, nil, fmt.Errorf("remote client cannot be nil")
+	}
+
+	server := NewStdioServer(config.ServerName, config.ServerVersion, config.ServerOptions...)
+	proxy := &StdioRemoteProxy{
+		server: server,
+		client: config.Client,
+		logger: server.logger,
+	}
+	server.internal = &stdioRemoteHandler{proxy: proxy, local: server.internal}
+	config.Client.forwarder = proxy
+	server.onShutdown(proxy.Close)
+	return server, proxy, nil
+}
+
+// Client returns the client connected to the remote server.
+func (p *StdioRemoteProxy) Client() *Client {
+	return p.client
+}
+
+// InitializeResult returns the result of the last initialization of the
+// remote server, nil before the stdio client initialized.
+func (p *StdioRemoteProxy) InitializeResult() *InitializeResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.initializeResult
+}
+
+// Close closes the client connected to the remote server.
+func (p *StdioRemoteProxy) Close() error {
+	return p.client.Close()
+}
+
+// initialize initializes the remote session with the initialize request of
+// the stdio client. A client that initializes again gets a new session.
+func (p *StdioRemoteProxy) initialize(ctx context.Context, request *JSONRPCRequest) (interface{}, error) {
+	initReq := &InitializeRequest{}
+	params, err := json.Marshal(request.Params)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &initReq.Params); err != nil {
+		return nil, fmt.Errorf("invalid initialize params: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.initializeRequest != nil {
+		p.client.resetSession()
+	}
+	result, err := p.client.Initialize(ctx, initReq)
+	if err != nil {
+		return nil, err
+	}
+	p.initializeRequest = initReq
+	p.initializeResult = result
+	return result, nil
+}
+
+// reinitialize creates a new remote session after expiredSessionID expired,
+// unless a concurrent request already did.
+func (p *StdioRemoteProxy) reinitialize(ctx context.Context, expiredSessionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.initializeRequest == nil || p.client.GetSessionID() != expiredSessionID {
+		return nil
+	}
+	p.logger.Infof("Remote session %s expired, initializing a new session", expiredSessionID)
+	p.client.resetSession()
+	result, err := p.client.Initialize(ctx, p.initializeRequest)
+	if err != nil {
+		return fmt.Errorf("reinitialize remote session: %w", err)
+	}
+	p.initializeResult = result
+	return nil
+}
+
+// forward sends a request of the stdio client to the remote server, and
+// returns its result as the raw message returned by Client.sendRequest.
+func (p *StdioRemoteProxy) forward(ctx context.Context, request *JSONRPCRequest) (*json.RawMessage, error) {
+	p.mu.RLock()
+	sessionID := p.client.GetSessionID()
+	p.mu.RUnlock()
+
+	send := func() (*json.RawMessage, error) {
+		return p.client.sendRequest(ctx, &JSONRPCRequest{
+			JSONRPC: JSONRPCVersion,
+			ID:      p.client.requestID.Add(1),
+			Params:  request.Params,
+			Request: request.Request,
+		})
+	}
+	result, err := send()
+	if !errors.Is(err, ErrSessionNotFound) {
+		return result, err
+	}
+	if err := p.reinitialize(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// forwardRequest implements clientForwarder by sending a request of the
+// remote server to the stdio client.
+func (p *StdioRemoteProxy) forwardRequest(ctx context.Context, request *JSONRPCRequest) interface{} {
+	session := p.session.Load()
+	if session == nil {
+		return newJSONRPCErrorResponse(request.ID, -32603, "Internal error", "no stdio client connected")
+	}
+	result, err := p.server.SendRequest(setSessionToContext(ctx, session), &JSONRPCRequest{
+		JSONRPC: JSONRPCVersion,
+		Params:  request.Params,
+		Request: request.Request,
+	})
+	return remoteResponse(request.ID, result, err)
+}
+
+// forwardNotification implements clientForwarder by sending a notification of
+// the remote server to the stdio client. Cancellations name requests of the
+// remote server by their remote ID, unknown to the stdio client, so they are
+// dropped: they cancel the context of the forwarded request instead, which
+// sends a cancellation with the ID known to the stdio client.
+func (p *StdioRemoteProxy) forwardNotification(notification *JSONRPCNotification) {
+	session := p.session.Load()
+	if session == nil || notification.Method == MethodNotificationsCancelled {
+		return
+	}
+	select {
+	case session.NotificationChannel() <- *notification:
+	default:
+		p.logger.Warnf("Dropping notification %s: notification channel full", notification.Method)
+	}
+}
+
+// remoteResponse builds the response to a forwarded request with the given
+// ID from the outcome of sending it to the peer. Errors returned by the peer
+// are passed through as is.
+func remoteResponse(id RequestId, result *json.RawMessage, err error) interface{} {
+	if err != nil {
+		return newJSONRPCErrorResponse(id, -32603, "Internal error", err.Error())
+	}
+	if isErrorResponse(result) {
+		errResp, err := parseRawMessageToError(result)
+		if err != nil {
+			return newJSONRPCErrorResponse(id, -32603, "Internal error", err.Error())
+		}
+		errResp.JSONRPC = JSONRPCVersion
+		errResp.ID = id
+		return errResp
+	}
+	return &JSONRPCResponse{JSONRPC: JSONRPCVersion, ID: id, Result: *result}
+}
+
+// stdioRemoteHandler implements messageHandler for a stdio server backed by a
+// remote server. Responses to the requests the proxy forwards to the stdio
+// client are handled by the local handler of the stdio server.
+type stdioRemoteHandler struct {
+	proxy *StdioRemoteProxy
+	local messageHandler
+}
+
+// HandleRequest implements messageHandler.HandleRequest.
+func (h *stdioRemoteHandler) HandleRequest(
+	ctx context.Context,
+	rawMessage json.RawMessage,
+) (response interface{}, err error) {
+	var request JSONRPCRequest
+	if err := json.Unmarshal(rawMessage, &request); err != nil {
+		return newJSONRPCErrorResponse(nil, -32700, "Parse error", nil), nil
+	}
+
+	session := sessionFromContext(ctx)
+	if session != nil {
+		h.proxy.session.Store(session)
+	}
+
+	if request.Method == MethodInitialize {
+		result, err := h.proxy.initialize(ctx, &request)
+		if err != nil {
+			return newJSONRPCErrorResponse(request.ID, -32603, "Internal error", err.Error()), nil
+		}
+		return newJSONRPCResponse(request.ID, result), nil
+	}
+
+	// Track the request so that notifications/cancelled can abort it, which
+	// also cancels the remote request.
+	if session != nil {
+		var done func() bool
+		ctx, done = h.proxy.server.inFlight.begin(ctx, session.GetID(), request.ID)
+		defer func() {
+			if done() {
+				// The client cancelled the request, so no response is sent.
+				response, err = nil, nil
+			}
+		}()
+	}
+
+	result, err := h.proxy.forward(ctx, &request)
+	return remoteResponse(request.ID, result, err), nil
+}
+
+// HandleNotification implements messageHandler.HandleNotification.
+func (h *stdioRemoteHandler) HandleNotification(ctx context.Context, rawMessage json.RawMessage) error {
+	var notification JSONRPCNotification
+	if err := json.Unmarshal(rawMessage, &notification); err != nil {
+		return err
+	}
+
+	session := sessionFromContext(ctx)
+	switch notification.Method {
+	case MethodNotificationsCancelled:
+		// The cancelled request notifies the remote server itself.
+		if session != nil {
+			h.proxy.server.inFlight.handleCancelledNotification(session.GetID(), &notification)
+		}
+		return nil
+	case MethodNotificationsInitialized:
+		// The client sent it to the remote server when initializing.
+		if session != nil {
+			session.Initialize()
+		}
+		return nil
+	}
+	return h.proxy.client.transport.sendNotification(ctx, &notification)
+}
+
+// HandleResponse implements messageHandler.HandleResponse.
+func (h *stdioRemoteHandler) HandleResponse(ctx context.Context, rawMessage json.RawMessage) error {
+	return h.local.HandleResponse(ctx, rawMessage)
+}
@@ -0,0 +1,129 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRemoteProxy starts a remote server with an echo tool and a tool listing
+// the roots of the client, and exposes it over stdio through a proxy.
+func newRemoteProxy(
+	t *testing.T,
+	serverOptions []ServerOption,
+	clientOptions ...ClientOption,
+) (*Server, func(string), func() map[string]interface{}) {
+	t.Helper()
+	remote := NewServer("Remote-Server", "1.0.0", append([]ServerOption{WithServerPath("/mcp")}, serverOptions...)...)
+	remote.RegisterTool(NewTool("echo", WithString("text")),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			text, _ := req.Params.Arguments["text"].(string)
+			return NewTextResult("remote:" + text), nil
+		})
+	remote.RegisterTool(NewTool("roots"),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			roots, err := remote.ListRoots(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return NewTextResult(roots.Roots[0].URI), nil
+		})
+	httpServer := httptest.NewServer(remote.HTTPHandler())
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Proxy", Version: "1.0.0"}, clientOptions...)
+	require.NoError(t, err)
+	server, proxy, err := NewStdioServerWithRemote(StdioRemoteProxyConfig{
+		ServerName:    "Proxy",
+		ServerVersion: "1.0.0",
+		Client:        client,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = proxy.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	write, read, _ := listenStdio(t, ctx, server)
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26",` +
+		`"capabilities":{"roots":{}},"clientInfo":{"name":"Host","version":"1.0.0"}}}`)
+	msg := read()
+	result := msg["result"].(map[string]interface{})
+	assert.Equal(t, "Remote-Server", result["serverInfo"].(map[string]interface{})["name"])
+	write(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return remote, write, read
+}
+
+func TestNewStdioServerWithRemote_InvalidConfig(t *testing.T) {
+	client, err := NewClient("http://localhost/mcp", Implementation{Name: "Proxy", Version: "1.0.0"})
+	require.NoError(t, err)
+	for _, config := range []StdioRemoteProxyConfig{
+		{ServerVersion: "1.0.0", Client: client},
+		{ServerName: "Proxy", Client: client},
+		{ServerName: "Proxy", ServerVersion: "1.0.0"},
+	} {
+		_, _, err := NewStdioServerWithRemote(config)
+		assert.Error(t, err)
+	}
+}
+
+func TestStdioRemoteProxy_ForwardsRequests(t *testing.T) {
+	_, write, read := newRemoteProxy(t, nil)
+
+	write(`{"jsonrpc":"2.0","id":"list","method":"tools/list"}`)
+	msg := read()
+	assert.Equal(t, "list", msg["id"])
+	tools := msg["result"].(map[string]interface{})["tools"].([]interface{})
+	assert.Len(t, tools, 2)
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	msg = read()
+	assert.Equal(t, float64(2), msg["id"])
+	content := msg["result"].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, "remote:hi", content[0].(map[string]interface{})["text"])
+
+	write(`{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"missing"}}`)
+	msg = read()
+	assert.Equal(t, float64(3), msg["id"])
+	assert.NotNil(t, msg["error"])
+}
+
+func TestStdioRemoteProxy_ForwardsServerRequests(t *testing.T) {
+	_, write, read := newRemoteProxy(t, nil)
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"roots"}}`)
+	msg := read()
+	require.Equal(t, MethodRootsList, msg["method"])
+	write(fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"roots":[{"uri":"file:///project"}]}}`, msg["id"]))
+
+	msg = read()
+	assert.Equal(t, float64(2), msg["id"])
+	content := msg["result"].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, "file:///project", content[0].(map[string]interface{})["text"])
+}
+
+func TestStdioRemoteProxy_ReinitializesExpiredSession(t *testing.T) {
+	_, write, read := newRemoteProxy(t,
+		[]ServerOption{WithServerSessionIdleTimeout(100 * time.Millisecond)},
+		WithClientGetSSEEnabled(false))
+
+	time.Sleep(300 * time.Millisecond)
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"again"}}}`)
+	msg := read()
+	assert.Equal(t, float64(2), msg["id"])
+	require.NotNil(t, msg["result"], msg["error"])
+	content := msg["result"].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, "remote:again", content[0].(map[string]interface{})["text"])
+}
@@ -17,6 +17,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	icontext "trpc.group/trpc-go/trpc-mcp-go/internal/context"
 )
 
 // StdioServer provides API for STDIO MCP servers.
@@ -37,6 +39,17 @@ type StdioServer struct {
 
 	// STDIO transport components for sending requests to client.
 	outputMu sync.Mutex // Mutex for protecting stdout writer.
+
+	inFlight inFlightRegistry // In-flight requests that can be cancelled by the client.
+
+	sessionHooks *SessionHooks // Hooks called as the session is created, initialized and closed.
+
+	shutdownTimeout time.Duration // Time given to in-flight requests to complete on shutdown.
+
+	shutdownFuncs []func() error // Functions called once the server stopped.
+
+	listChanged *listChangedNotifier         // Notifier of tool, prompt and resource list changes, nil if disabled.
+	session     atomic.Pointer[stdioSession] // Session of the running server, nil until it starts.
 }
 
 // messageHandler defines the core interface for handling JSON-RPC messages (internal use).
@@ -53,10 +66,21 @@ type messageHandler interface {
 
 // stdioServerConfig contains configuration for the STDIO server.
 type stdioServerConfig struct {
-	logger      Logger
-	contextFunc StdioContextFunc
+	logger           Logger
+	contextFunc      StdioContextFunc
+	pageSize         int
+	sessionHooks     *SessionHooks
+	shutdownTimeout  time.Duration
+	inputValidation  ToolInputValidation
+	outputValidation ToolOutputValidation
+	listChanged      bool
+	listChangedDelay time.Duration
 }
 
+// defaultStdioShutdownTimeout is the time given to in-flight requests to
+// complete once the context passed to StartWithContext is cancelled.
+const defaultStdioShutdownTimeout = 5 * time.Second
+
 // StdioServerOption defines an option function for configuring StdioServer.
 type StdioServerOption func(*stdioServerConfig)
 
@@ -74,14 +98,79 @@ func WithStdioContext(fn StdioContextFunc) StdioServerOption {
 	}
 }
 
+// WithStdioPageSize enables cursor-based pagination of list results for the STDIO server.
+// A size of zero or less returns all items in a single response.
+func WithStdioPageSize(size int) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.pageSize = size
+	}
+}
+
+// WithStdioToolInputValidation sets how the STDIO server validates the
+// arguments of tools/call requests. See WithToolInputValidation.
+func WithStdioToolInputValidation(validation ToolInputValidation) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.inputValidation = validation
+	}
+}
+
+// WithStdioToolOutputValidation sets how the STDIO server validates the
+// structured content returned by tools. See WithToolOutputValidation.
+func WithStdioToolOutputValidation(validation ToolOutputValidation) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.outputValidation = validation
+	}
+}
+
+// WithStdioSessionHooks sets functions called as the session of the STDIO
+// server is created, initialized and closed. The session is created when the
+// server starts, and closed when its standard input is closed or the context
+// passed to StartWithContext is cancelled. See WithSessionHooks.
+func WithStdioSessionHooks(hooks *SessionHooks) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.sessionHooks = hooks
+	}
+}
+
+// WithStdioShutdownTimeout sets the time the requests being handled are given
+// to complete once the context passed to StartWithContext is cancelled, after
+// which they are cancelled. Messages are still read while they complete, so
+// that responses to requests sent to the client are received. Defaults to
+// 5 seconds.
+func WithStdioShutdownTimeout(timeout time.Duration) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.shutdownTimeout = timeout
+	}
+}
+
+// WithStdioListChangedEnabled enables or disables the list_changed
+// notifications sent to the client when tools, prompts or resources are
+// registered or unregistered. See WithListChangedEnabled.
+func WithStdioListChangedEnabled(enabled bool) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.listChanged = enabled
+	}
+}
+
+// WithStdioListChangedDelay sets the time changes are collected for before a
+// list_changed notification is sent. See WithListChangedDelay.
+func WithStdioListChangedDelay(delay time.Duration) StdioServerOption {
+	return func(config *stdioServerConfig) {
+		config.listChangedDelay = delay
+	}
+}
+
 // StdioContextFunc defines a function that can modify the context for stdio requests.
 type StdioContextFunc func(ctx context.Context) context.Context
 
 // NewStdioServer creates a new high-level STDIO server that reuses existing managers.
 func NewStdioServer(name, version string, options ...StdioServerOption) *StdioServer {
 	config := &stdioServerConfig{
-		logger:      GetDefaultLogger(),
-		contextFunc: nil,
+		logger:           GetDefaultLogger(),
+		contextFunc:      nil,
+		shutdownTimeout:  defaultStdioShutdownTimeout,
+		listChanged:      true,
+		listChangedDelay: defaultListChangedDelay,
 	}
 
 	for _, option := range options {
@@ -92,6 +181,13 @@ func NewStdioServer(name, version string, options ...StdioServerOption) *StdioSe
 	toolManager := newToolManager()
 	resourceManager := newResourceManager()
 	promptManager := newPromptManager()
+	paginator := newPaginator(config.pageSize, nil)
+	toolManager.withPaginator(paginator)
+	toolManager.withInputValidation(config.inputValidation)
+	toolManager.withOutputValidation(config.outputValidation)
+	toolManager.withLogger(config.logger)
+	resourceManager.withPaginator(paginator)
+	promptManager.withPaginator(paginator)
 	lifecycleManager := newLifecycleManager(Implementation{
 		Name:    name,
 		Version: version,
@@ -102,6 +198,8 @@ func NewStdioServer(name, version string, options ...StdioServerOption) *StdioSe
 	lifecycleManager.withResourceManager(resourceManager)
 	lifecycleManager.withPromptManager(promptManager)
 	lifecycleManager.withLogger(config.logger)
+	lifecycleManager.withSessionHooks(config.sessionHooks)
+	lifecycleManager.withListChanged(config.listChanged)
 
 	server := &StdioServer{
 		serverInfo: Implementation{
@@ -116,10 +214,16 @@ func NewStdioServer(name, version string, options ...StdioServerOption) *StdioSe
 		lifecycleManager:     lifecycleManager,
 		responses:            make(map[uint64]interface{}),
 		notificationHandlers: make(map[string]ServerNotificationHandler),
+		sessionHooks:         config.sessionHooks,
+		shutdownTimeout:      config.shutdownTimeout,
 	}
 
 	// Set server as server provider for toolManager (to inject server context in tool calls).
 	toolManager.withServerProvider(server)
+	if config.listChanged {
+		server.listChanged = newListChangedNotifier(config.listChangedDelay, server.sendListChanged)
+		server.onShutdown(server.listChanged.stop)
+	}
 
 	server.internal = &stdioServerInternal{
 		parent: server,
@@ -135,6 +239,7 @@ func (s *StdioServer) RegisterTool(tool *Tool, handler toolHandler) {
 		return
 	}
 	s.toolManager.registerTool(tool, handler)
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 	s.logger.Debugf("Registered tool: %s", tool.Name)
 }
 
@@ -178,6 +283,7 @@ func (s *StdioServer) UnregisterTools(names ...string) error {
 		err := fmt.Errorf("none of the specified tools were found")
 		return err
 	}
+	s.listChanged.notify("", NotificationMethodToolsListChanged)
 
 	return nil
 }
@@ -189,6 +295,7 @@ func (s *StdioServer) RegisterPrompt(prompt *Prompt, handler promptHandler) {
 		return
 	}
 	s.promptManager.registerPrompt(prompt, handler)
+	s.listChanged.notify("", NotificationMethodPromptsListChanged)
 	s.logger.Debugf("Registered prompt: %s", prompt.Name)
 }
 
@@ -199,6 +306,7 @@ func (s *StdioServer) RegisterResource(resource *Resource, handler resourceHandl
 		return
 	}
 	s.resourceManager.registerResource(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 	s.logger.Debugf("Registered resource: %s", resource.URI)
 }
 
@@ -209,6 +317,7 @@ func (s *StdioServer) RegisterResources(resource *Resource, handler resourcesHan
 		return
 	}
 	s.resourceManager.registerResources(resource, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 	s.logger.Debugf("Registered resources: %s", resource.URI)
 }
 
@@ -219,17 +328,66 @@ func (s *StdioServer) RegisterResourceTemplate(template *ResourceTemplate, handl
 		return
 	}
 	s.resourceManager.registerTemplate(template, handler)
+	s.listChanged.notify("", NotificationMethodResourcesListChanged)
 	s.logger.Debugf("Registered resource template: %s", template.Name)
 }
 
+// RegisterPromptCompletion registers a completion provider for a prompt argument.
+func (s *StdioServer) RegisterPromptCompletion(promptName, argumentName string, provider CompletionProvider) {
+	if provider == nil {
+		s.logger.Errorf("RegisterPromptCompletion: provider cannot be nil")
+		return
+	}
+	s.promptManager.registerCompletionProvider(promptName, argumentName, provider)
+	s.logger.Debugf("Registered completion provider for prompt argument: %s/%s", promptName, argumentName)
+}
+
+// RegisterResourceTemplateCompletion registers a completion provider for a resource template variable.
+func (s *StdioServer) RegisterResourceTemplateCompletion(uriTemplate, variable string, provider CompletionProvider) {
+	if provider == nil {
+		s.logger.Errorf("RegisterResourceTemplateCompletion: provider cannot be nil")
+		return
+	}
+	s.resourceManager.registerCompletionProvider(uriTemplate, variable, provider)
+	s.logger.Debugf("Registered completion provider for template variable: %s/%s", uriTemplate, variable)
+}
+
 // Start starts the STDIO server.
 func (s *StdioServer) Start() error {
-	return serveStdio(s.internal, withStdioErrorLogger(s.logger), withStdioContextFunc(s.contextFunc))
+	defer s.stopped()
+	return serveStdio(s.internal, s.transportOptions()...)
 }
 
 // StartWithContext starts the STDIO server with context.
 func (s *StdioServer) StartWithContext(ctx context.Context) error {
-	return serveStdioWithContext(ctx, s.internal, withStdioErrorLogger(s.logger), withStdioContextFunc(s.contextFunc))
+	defer s.stopped()
+	return serveStdioWithContext(ctx, s.internal, s.transportOptions()...)
+}
+
+// onShutdown registers a function called once the server stopped, to release
+// resources owned by the server.
+func (s *StdioServer) onShutdown(fn func() error) {
+	s.shutdownFuncs = append(s.shutdownFuncs, fn)
+}
+
+// stopped calls the functions registered with onShutdown.
+func (s *StdioServer) stopped() {
+	for _, fn := range s.shutdownFuncs {
+		if err := fn(); err != nil {
+			s.logger.Errorf("Error releasing server resources: %v", err)
+		}
+	}
+}
+
+// transportOptions returns the options of the server's stdio transport.
+func (s *StdioServer) transportOptions() []stdioServerTransportOption {
+	return []stdioServerTransportOption{
+		withStdioErrorLogger(s.logger),
+		withStdioContextFunc(s.contextFunc),
+		withStdioSessionHooks(s.sessionHooks),
+		withStdioShutdownTimeout(s.shutdownTimeout),
+		withStdioSessionTracker(&s.session),
+	}
 }
 
 // GetServerInfo returns the server information.
@@ -249,6 +407,19 @@ type stdioTransport struct {
 	logger      Logger
 	contextFunc StdioContextFunc
 	session     *stdioSession
+	hooks       *SessionHooks
+	tracker     *atomic.Pointer[stdioSession] // Holds the session while listening, if set.
+
+	// Time given to in-flight messages to be handled on shutdown.
+	shutdownTimeout time.Duration
+	// Messages being handled.
+	handling pendingCounter
+	// drainMu guards draining, set once the transport shuts down.
+	drainMu  sync.Mutex
+	draining bool
+
+	// Serializes writes to the output stream.
+	writeMu sync.Mutex
 }
 
 // stdioServerTransportOption configures a stdioTransport.
@@ -268,6 +439,29 @@ func withStdioContextFunc(fn StdioContextFunc) stdioServerTransportOption {
 	}
 }
 
+// withStdioShutdownTimeout sets the time given to in-flight messages to be
+// handled on shutdown.
+func withStdioShutdownTimeout(timeout time.Duration) stdioServerTransportOption {
+	return func(t *stdioTransport) {
+		t.shutdownTimeout = timeout
+	}
+}
+
+// withStdioSessionHooks sets the hooks called as the session is created and closed.
+func withStdioSessionHooks(hooks *SessionHooks) stdioServerTransportOption {
+	return func(s *stdioTransport) {
+		s.hooks = hooks
+	}
+}
+
+// withStdioSessionTracker sets the pointer holding the session while the
+// transport listens.
+func withStdioSessionTracker(session *atomic.Pointer[stdioSession]) stdioServerTransportOption {
+	return func(s *stdioTransport) {
+		s.tracker = session
+	}
+}
+
 // stdioSession represents a stdio session implementing the Session interface.
 type stdioSession struct {
 	id            string
@@ -367,15 +561,57 @@ func newStdioTransport(server messageHandler, options ...stdioServerTransportOpt
 }
 
 // listen starts listening for JSON-RPC messages on stdin and writes responses to stdout.
+// Once ctx is cancelled, the messages being handled are given the shutdown
+// timeout to complete before their context is cancelled.
 func (s *stdioTransport) listen(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
 	if s.contextFunc != nil {
 		ctx = s.contextFunc(ctx)
 	}
 
+	// Messages are handled with a context that outlives ctx, so that they can
+	// complete while the transport shuts down.
+	handlerCtx, cancelHandlers := context.WithCancel(icontext.WithoutCancel(ctx))
+	defer cancelHandlers()
+
 	reader := bufio.NewReader(stdin)
-	go s.handleOutgoingMessages(ctx, stdout)
+	go s.handleOutgoingMessages(handlerCtx, stdout)
+
+	if s.tracker != nil {
+		s.tracker.Store(s.session)
+		defer s.tracker.CompareAndSwap(s.session, nil)
+	}
+	s.hooks.sessionCreated(setSessionToContext(ctx, s.session), s.session)
+	inputDone := make(chan error, 1)
+	go func() {
+		inputDone <- s.processInputStream(handlerCtx, reader, stdout)
+	}()
 
-	return s.processInputStream(ctx, reader, stdout)
+	var err error
+	reason := SessionCloseReasonDisconnected
+	select {
+	case err = <-inputDone:
+	case <-ctx.Done():
+		reason = SessionCloseReasonShutdown
+		s.drain()
+		err = ctx.Err()
+	}
+	cancelHandlers()
+	s.hooks.sessionClosed(icontext.WithoutCancel(ctx), s.session, reason)
+	return err
+}
+
+// drain waits for the messages being handled, up to the shutdown timeout.
+// The requests received meanwhile are rejected.
+func (s *stdioTransport) drain() {
+	s.drainMu.Lock()
+	s.draining = true
+	s.drainMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.handling.wait(ctx); err != nil {
+		s.logger.Infof("Cancelling the requests still in flight after %v", s.shutdownTimeout)
+	}
 }
 
 // handleOutgoingMessages processes all outgoing messages (notifications and other JSON-RPC messages)
@@ -414,8 +650,19 @@ func (s *stdioTransport) processInputStream(ctx context.Context, reader *bufio.R
 			return err
 		}
 
+		// Once the transport drains, requests are rejected, but the responses
+		// and notifications of the client are still handled: the requests
+		// being drained may wait for them.
+		tracked := s.beginHandling()
+		if !tracked && s.rejectRequest(line, stdout) {
+			continue
+		}
+
 		// Process message asynchronously to avoid blocking input reading.
 		go func(line string) {
+			if tracked {
+				defer s.handling.done()
+			}
 			if err := s.processMessage(ctx, line, stdout); err != nil {
 				if err == io.EOF {
 					return
@@ -426,6 +673,33 @@ func (s *stdioTransport) processInputStream(ctx context.Context, reader *bufio.R
 	}
 }
 
+// beginHandling records that a message is being handled, unless the
+// transport drains, and reports whether it did.
+func (s *stdioTransport) beginHandling() bool {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.handling.add()
+	return true
+}
+
+// rejectRequest answers the message of line with an error if it is a
+// request, and reports whether it was one.
+func (s *stdioTransport) rejectRequest(line string, writer io.Writer) bool {
+	var base baseMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &base); err != nil ||
+		base.ID == nil || base.Method == "" {
+		return false
+	}
+	response := newJSONRPCErrorResponse(base.ID, ErrCodeInternal, "Server is shutting down", nil)
+	if err := s.writeResponse(response, writer); err != nil {
+		s.logger.Errorf("Error rejecting request: %v", err)
+	}
+	return true
+}
+
 // readNextLine reads a single line from the input reader.
 func (s *stdioTransport) readNextLine(ctx context.Context, reader *bufio.Reader) (string, error) {
 	readChan := make(chan string, 1)
@@ -489,7 +763,13 @@ func (s *stdioTransport) processMessage(ctx context.Context, line string, writer
 
 	switch msgType {
 	case JSONRPCMessageTypeRequest:
-		response, err := s.server.HandleRequest(sessionCtx, rawMessage)
+		// Notifications emitted while handling the request are written in
+		// order with the response.
+		reqCtx := withNotificationSender(sessionCtx, newStreamNotificationSender(s.session,
+			func(notification *JSONRPCNotification) error {
+				return s.writeResponse(notification, writer)
+			}))
+		response, err := s.server.HandleRequest(reqCtx, rawMessage)
 		if err != nil {
 			s.logger.Errorf("Error handling request: %v", err)
 			return nil
@@ -521,6 +801,9 @@ func (s *stdioTransport) writeResponse(response interface{}, writer io.Writer) e
 		return fmt.Errorf("error marshaling response: %w", err)
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	if _, err := writer.Write(data); err != nil {
 		return fmt.Errorf("error writing response: %w", err)
 	}
@@ -579,7 +862,10 @@ type stdioServerInternal struct {
 }
 
 // HandleRequest implements messageHandler.HandleRequest by delegating to existing managers.
-func (s *stdioServerInternal) HandleRequest(ctx context.Context, rawMessage json.RawMessage) (interface{}, error) {
+func (s *stdioServerInternal) HandleRequest(
+	ctx context.Context,
+	rawMessage json.RawMessage,
+) (response interface{}, err error) {
 	var request JSONRPCRequest
 	if err := json.Unmarshal(rawMessage, &request); err != nil {
 		return newJSONRPCErrorResponse(nil, -32700, "Parse error", nil), nil
@@ -590,24 +876,46 @@ func (s *stdioServerInternal) HandleRequest(ctx context.Context, rawMessage json
 	// Get session from context for managers that need it.
 	session := sessionFromContext(ctx)
 
+	// Track the request so that notifications/cancelled can abort it. The
+	// initialize request must not be cancelled.
+	if session != nil && request.Method != MethodInitialize {
+		var done func() bool
+		ctx, done = s.parent.inFlight.begin(ctx, session.GetID(), request.ID)
+		defer func() {
+			if done() {
+				// The client cancelled the request, so no response is sent.
+				response, err = nil, nil
+			}
+		}()
+	}
+
 	var result interface{}
-	var err error
 
 	switch request.Method {
 	case MethodInitialize:
 		result, err = s.parent.lifecycleManager.handleInitialize(ctx, &request, session)
 	case MethodToolsList:
-		result, err = s.parent.toolManager.handleListTools(ctx, &request, session)
+		result, err = s.parent.toolManager.handleListTools(ctx, &request, sessionOrNil(session))
 	case MethodToolsCall:
-		result, err = s.parent.toolManager.handleCallTool(ctx, &request, session)
+		result, err = s.parent.toolManager.handleCallTool(ctx, &request, sessionOrNil(session))
 	case MethodPromptsList:
-		result, err = s.parent.promptManager.handleListPrompts(ctx, &request)
+		result, err = s.parent.promptManager.handleListPrompts(ctx, &request, sessionOrNil(session))
 	case MethodPromptsGet:
-		result, err = s.parent.promptManager.handleGetPrompt(ctx, &request)
+		result, err = s.parent.promptManager.handleGetPrompt(ctx, &request, sessionOrNil(session))
 	case MethodResourcesList:
-		result, err = s.parent.resourceManager.handleListResources(ctx, &request)
+		result, err = s.parent.resourceManager.handleListResources(ctx, &request, sessionOrNil(session))
 	case MethodResourcesRead:
-		result, err = s.parent.resourceManager.handleReadResource(ctx, &request)
+		result, err = s.parent.resourceManager.handleReadResource(ctx, &request, sessionOrNil(session))
+	case MethodResourcesTemplatesList:
+		result, err = s.parent.resourceManager.handleListTemplates(ctx, &request)
+	case MethodResourcesSubscribe:
+		result, err = s.parent.resourceManager.handleSubscribe(ctx, &request, sessionOrNil(session))
+	case MethodResourcesUnsubscribe:
+		result, err = s.parent.resourceManager.handleUnsubscribe(ctx, &request, sessionOrNil(session))
+	case MethodCompletionComplete:
+		result, err = handleCompletionComplete(ctx, &request, s.parent.promptManager, s.parent.resourceManager)
+	case MethodLoggingSetLevel:
+		result, err = handleSetLevel(ctx, &request, session)
 	case MethodPing:
 		return s.handlePing(ctx, request)
 	default:
@@ -618,6 +926,11 @@ func (s *stdioServerInternal) HandleRequest(ctx context.Context, rawMessage json
 		return newJSONRPCErrorResponse(request.ID, -32603, "Internal error", err.Error()), nil
 	}
 
+	// Shape the result for the protocol version negotiated with the client.
+	if session != nil && request.Method != MethodInitialize {
+		result = adaptResultToProtocolVersion(result, protocolVersionFromSession(session))
+	}
+
 	// Check if result is already a JSON-RPC response or error (has jsonrpc field).
 	switch result.(type) {
 	case *JSONRPCResponse, *JSONRPCError, JSONRPCResponse, JSONRPCError:
@@ -628,6 +941,15 @@ func (s *stdioServerInternal) HandleRequest(ctx context.Context, rawMessage json
 	return newJSONRPCResponse(request.ID, result), nil
 }
 
+// sessionOrNil converts a stdio session to a Session, keeping it nil rather
+// than a nil pointer of type *stdioSession.
+func sessionOrNil(session *stdioSession) Session {
+	if session == nil {
+		return nil
+	}
+	return session
+}
+
 // HandleNotification implements messageHandler.HandleNotification.
 func (s *stdioServerInternal) HandleNotification(ctx context.Context, rawMessage json.RawMessage) error {
 	var notification JSONRPCNotification
@@ -637,6 +959,18 @@ func (s *stdioServerInternal) HandleNotification(ctx context.Context, rawMessage
 
 	s.parent.logger.Debugf("Received notification: %s", notification.Method)
 
+	if session := sessionFromContext(ctx); session != nil {
+		// Abort the request named by a cancellation notification.
+		s.parent.inFlight.handleCancelledNotification(session.GetID(), &notification)
+
+		if notification.Method == MethodNotificationsInitialized {
+			session.Initialize()
+			if err := s.parent.lifecycleManager.handleInitialized(ctx, &notification, session); err != nil {
+				s.parent.logger.Errorf("Error initializing session: %v", err)
+			}
+		}
+	}
+
 	// Check if there's a registered handler for this notification method.
 	s.parent.notificationMu.RLock()
 	handler, exists := s.parent.notificationHandlers[notification.Method]
@@ -649,7 +983,7 @@ func (s *stdioServerInternal) HandleNotification(ctx context.Context, rawMessage
 				s.parent.logger.Errorf("Error handling notification %s: %v", notification.Method, err)
 			}
 		}()
-	} else {
+	} else if notification.Method != MethodNotificationsCancelled && notification.Method != MethodNotificationsInitialized {
 		s.parent.logger.Warnf("Received notification with no handler registered: %s", notification.Method)
 	}
 
@@ -748,6 +1082,38 @@ func (s *stdioServerInternal) handlePing(ctx context.Context, request JSONRPCReq
 	return newJSONRPCResponse(request.ID, struct{}{}), nil
 }
 
+// sendListChanged sends a list_changed notification to the client once it
+// initialized the session, for the listChangedNotifier.
+func (s *StdioServer) sendListChanged(sessionID, method string) {
+	session := s.session.Load()
+	if session == nil || !session.Initialized() {
+		return
+	}
+	select {
+	case session.NotificationChannel() <- *NewJSONRPCNotificationFromMap(method, nil):
+	default:
+		s.logger.Debugf("Failed to send %s: notification channel full", method)
+	}
+}
+
+// NotifyResourceUpdated sends a notifications/resources/updated notification to
+// the client if it subscribed to the resource.
+func (s *StdioServer) NotifyResourceUpdated(uri string) error {
+	notification := newResourceUpdatedNotification(uri)
+	for _, subscriber := range s.resourceManager.getSubscribers(uri) {
+		session, ok := subscriber.(*stdioSession)
+		if !ok {
+			continue
+		}
+		select {
+		case session.NotificationChannel() <- *notification:
+		default:
+			return fmt.Errorf("notification channel full")
+		}
+	}
+	return nil
+}
+
 // ListRoots sends a request to the client asking for its list of roots.
 func (s *StdioServer) ListRoots(ctx context.Context) (*ListRootsResult, error) {
 	// Get the session from context.
@@ -786,8 +1152,32 @@ func (s *StdioServer) ListRoots(ctx context.Context) (*ListRootsResult, error) {
 	return &listRootsResult, nil
 }
 
-// SendRequest sends a JSON-RPC request to the client and waits for response.
+// CreateMessage asks the client to sample an LLM. Like SendRequest, it must be
+// called with the context of a request being handled.
+func (s *StdioServer) CreateMessage(ctx context.Context, req *CreateMessageRequest) (*CreateMessageResult, error) {
+	session := sessionFromContext(ctx)
+	if session == nil {
+		return nil, ErrNoClientSession
+	}
+	return createMessage(ctx, session, req, s.SendRequest)
+}
+
+// sendRequestToSession implements sessionRequestSender. The stdio server has a
+// single session, which SendRequest takes from ctx.
+func (s *StdioServer) sendRequestToSession(
+	ctx context.Context,
+	session Session,
+	request *JSONRPCRequest,
+) (*json.RawMessage, error) {
+	return s.SendRequest(ctx, request)
+}
+
+// SendRequest sends a JSON-RPC request to the client and waits for the
+// response until ctx is done, or for 30 seconds if ctx has no deadline.
 func (s *StdioServer) SendRequest(ctx context.Context, request *JSONRPCRequest) (*json.RawMessage, error) {
+	ctx, cancel := withServerRequestTimeout(ctx)
+	defer cancel()
+
 	// Generate unique request ID if not provided.
 	if request.ID == nil {
 		request.ID = s.requestID.Add(1)
@@ -819,14 +1209,21 @@ func (s *StdioServer) SendRequest(ctx context.Context, request *JSONRPCRequest)
 
 	select {
 	case session.MessageChannel() <- request:
-		// Wait for the response or timeout.
+		// Wait for the response until ctx is done.
 		select {
 		case <-ctx.Done():
+			// The client stops handling the abandoned request.
+			sendCancelledNotification(ctx, request.ID, func(ctx context.Context, notification *JSONRPCNotification) error {
+				select {
+				case session.NotificationChannel() <- *notification:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
 			return nil, ctx.Err()
 		case response := <-resultChan:
 			return response, nil
-		case <-time.After(30 * time.Second):
-			return nil, fmt.Errorf("SendRequest: Request timeout for ID %d after 30s", requestIDUint)
 		}
 	default:
 		return nil, fmt.Errorf("failed to send request: MessageChannel full")
@@ -7,10 +7,15 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStdioServer_UnregisterTools(t *testing.T) {
@@ -102,3 +107,94 @@ func TestStdioServer_RegisterAndUnregisterTool(t *testing.T) {
 	tools = server.toolManager.getTools()
 	assert.Len(t, tools, 0)
 }
+
+// listenStdio runs a transport of the server on pipes until ctx is cancelled.
+// It returns functions writing a message and reading the next one, and a
+// channel receiving the error listen returns.
+func listenStdio(
+	t *testing.T,
+	ctx context.Context,
+	server *StdioServer,
+) (func(string), func() map[string]interface{}, chan error) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() {
+		stdinWriter.Close()
+		stdoutReader.Close()
+	})
+	transport := newStdioTransport(server.internal, server.transportOptions()...)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- transport.listen(ctx, stdinReader, stdoutWriter)
+	}()
+
+	write := func(msg string) {
+		_, err := stdinWriter.Write([]byte(msg + "\n"))
+		require.NoError(t, err)
+	}
+	scanner := bufio.NewScanner(stdoutReader)
+	read := func() map[string]interface{} {
+		require.True(t, scanner.Scan())
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		return msg
+	}
+	return write, read, errs
+}
+
+func TestStdioServer_ShutdownDrainsRequests(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server.RegisterTool(NewTool("slow"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		close(started)
+		<-release
+		return NewTextResult("done"), nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	write, read, errs := listenStdio(t, ctx, server)
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	<-started
+	cancel()
+
+	// The request completes after the context is cancelled.
+	select {
+	case err := <-errs:
+		t.Fatalf("listen returned before the request completed: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// New requests are rejected meanwhile.
+	write(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	msg := read()
+	assert.Equal(t, float64(2), msg["id"])
+	assert.NotNil(t, msg["error"])
+
+	close(release)
+	msg = read()
+	assert.Equal(t, float64(1), msg["id"])
+	assert.NotNil(t, msg["result"])
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+func TestStdioServer_ShutdownCancelsRequests(t *testing.T) {
+	server := NewStdioServer("Test-Server", "1.0.0", WithStdioShutdownTimeout(50*time.Millisecond))
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server.RegisterTool(NewTool("block"), blockingTool(started, cancelled))
+	ctx, cancel := context.WithCancel(context.Background())
+	write, _, errs := listenStdio(t, ctx, server)
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block"}}`)
+	<-started
+	cancel()
+
+	// The request is cancelled once the shutdown timeout elapses.
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("request not cancelled")
+	}
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
@@ -11,6 +11,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,6 +25,21 @@ import (
 	"trpc.group/trpc-go/trpc-mcp-go/internal/retry"
 )
 
+const (
+	// getSSEReconnectMinDelay is the delay before reconnecting a dropped GET SSE connection.
+	getSSEReconnectMinDelay = 100 * time.Millisecond
+
+	// getSSEReconnectMaxDelay caps the delay between failed reconnection attempts.
+	getSSEReconnectMaxDelay = 5 * time.Second
+
+	// maxStreamResumeAttempts is the number of attempts to resume a dropped response stream.
+	maxStreamResumeAttempts = 3
+)
+
+// errGetSSERejected is returned when the server rejects a GET SSE connection,
+// in which case it is not reconnected.
+var errGetSSERejected = errors.New("GET SSE connection rejected")
+
 // streamableHTTPClientTransport implements an HTTP-based MCP transport
 type streamableHTTPClientTransport struct {
 	// Server URL
@@ -43,10 +59,14 @@ type streamableHTTPClientTransport struct {
 	// Session ID
 	sessionID string
 
+	// Protocol version negotiated during initialization, sent in the
+	// MCP-Protocol-Version header of every subsequent request.
+	protocolVersion string
+
 	// Notification handlers
 	notificationHandlers map[string]NotificationHandler
 
-	// Last event ID (for connection recovery)
+	// Last event ID received on the GET SSE stream (for connection recovery)
 	lastEventID string
 
 	// Whether GET SSE is enabled
@@ -126,6 +146,7 @@ func newStreamableHTTPClientTransport(config *transportConfig, options ...transp
 		transport.httpReqHandler = NewHTTPReqHandler(
 			transport.serviceName, transport.httpReqHandlerOptions...)
 	}
+	transport.httpReqHandler = newAuthHTTPReqHandler(transport.httpReqHandler, config.tokenSource)
 
 	return transport
 }
@@ -257,12 +278,11 @@ func (t *streamableHTTPClientTransport) send(
 	if t.sessionID != "" && !t.isStateless {
 		httpReq.Header.Set(httputil.SessionIDHeader, t.sessionID)
 	}
+	t.setProtocolVersionHeader(httpReq)
 
 	// If lastEventID is provided, attach it to the request
 	if options != nil && options.lastEventID != "" {
 		httpReq.Header.Set(httputil.LastEventIDHeader, options.lastEventID)
-	} else if t.lastEventID != "" {
-		httpReq.Header.Set(httputil.LastEventIDHeader, t.lastEventID)
 	}
 
 	// Add custom headers
@@ -306,6 +326,12 @@ func (t *streamableHTTPClientTransport) send(
 	defer httpResp.Body.Close()
 
 	// Check status code
+	if httpResp.StatusCode == http.StatusNotFound && httpReq.Header.Get(httputil.SessionIDHeader) != "" {
+		return nil, fmt.Errorf("%w: %w: status code %d", ErrHTTPRequestFailed, ErrSessionNotFound, httpResp.StatusCode)
+	}
+	if err := insufficientScopeError(httpResp); err != nil {
+		return nil, err
+	}
 	if httpResp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("%w: status code %d", ErrHTTPRequestFailed, httpResp.StatusCode)
 	}
@@ -357,6 +383,15 @@ func (t *streamableHTTPClientTransport) processEventData(
 	// First, check if it's a response to our request by looking at the ID
 	var jsonResp map[string]interface{}
 	if err := json.Unmarshal(rawMessage, &jsonResp); err == nil {
+		if _, hasMethod := jsonResp["method"]; hasMethod && jsonResp["id"] != nil {
+			// A request of the server related to ours, such as sampling.
+			var request JSONRPCRequest
+			if err := json.Unmarshal(rawMessage, &request); err != nil {
+				return nil, err
+			}
+			t.handleIncomingRequest(&request)
+			return nil, nil
+		}
 		// Check if it has an ID that matches our request ID
 		if id, hasID := jsonResp["id"]; hasID && fmt.Sprintf("%v", id) == fmt.Sprintf("%v", reqID) {
 			return t.handleResponseMessage(jsonResp, &rawMessage)
@@ -403,6 +438,18 @@ func (t *streamableHTTPClientTransport) handleNotificationMessage(
 		return nil, err
 	}
 
+	if t.client != nil {
+		t.client.dispatchCancelled(&notification)
+		if forwarder := t.client.forwarder; forwarder != nil {
+			forwarder.forwardNotification(&notification)
+			return nil, nil
+		}
+		t.client.progress.dispatch(&notification)
+		t.client.dispatchLogMessage(&notification)
+		t.client.dispatchResourceUpdated(&notification)
+		t.client.dispatchListChanged(&notification)
+	}
+
 	if handler, ok := handlers[notification.Method]; ok {
 		if err := handler(&notification); err != nil {
 			t.logger.Debugf("Failed to handle notification: %s, error: %v",
@@ -412,16 +459,23 @@ func (t *streamableHTTPClientTransport) handleNotificationMessage(
 	return nil, nil
 }
 
-// Handle SSE response
+// Handle SSE response. If the stream drops before the response is received,
+// it is resumed after the last event received.
 func (t *streamableHTTPClientTransport) handleSSEResponse(
 	ctx context.Context,
 	httpResp *http.Response,
 	reqID interface{},
 	options *streamOptions,
 ) (*json.RawMessage, error) {
-	reader := bufio.NewReader(httpResp.Body)
+	body := httpResp.Body
+	defer func() {
+		body.Close()
+	}()
+	reader := bufio.NewReader(body)
 	var rawResult *json.RawMessage
 	var resultReceived bool
+	var lastEventID string
+	var resumeAttempts int
 
 	// Merge notification handlers
 	handlers := make(map[string]NotificationHandler)
@@ -439,6 +493,9 @@ func (t *streamableHTTPClientTransport) handleSSEResponse(
 			handlers[method] = handler
 		}
 	}
+	if handler := requestLogHandlerFromContext(ctx); handler != nil {
+		handlers[NotificationMethodMessage] = handler
+	}
 
 	for {
 		select {
@@ -448,10 +505,22 @@ func (t *streamableHTTPClientTransport) handleSSEResponse(
 			// Read SSE event
 			line, err := reader.ReadString('\n')
 			if err != nil {
-				if err == io.EOF {
-					if resultReceived {
-						return rawResult, nil
+				if err == io.EOF && resultReceived {
+					return rawResult, nil
+				}
+				if lastEventID != "" && resumeAttempts < maxStreamResumeAttempts && ctx.Err() == nil {
+					resumeAttempts++
+					t.logger.Infof("SSE response stream dropped (%v), resuming after event %s", err, lastEventID)
+					resumed, resumeErr := t.resumeStream(ctx, lastEventID)
+					if resumeErr == nil {
+						body.Close()
+						body = resumed.Body
+						reader = bufio.NewReader(body)
+						continue
 					}
+					t.logger.Infof("Failed to resume SSE response stream: %v", resumeErr)
+				}
+				if err == io.EOF {
 					return nil, fmt.Errorf("connection closed but no final response received")
 				}
 				return nil, fmt.Errorf("failed to read SSE event: %w", err)
@@ -464,7 +533,7 @@ func (t *streamableHTTPClientTransport) handleSSEResponse(
 
 			// Process event ID
 			if strings.HasPrefix(line, "id:") {
-				t.lastEventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+				lastEventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
 				continue
 			}
 
@@ -487,6 +556,25 @@ func (t *streamableHTTPClientTransport) handleSSEResponse(
 	}
 }
 
+// resumeStream resumes an SSE stream after lastEventID with a GET request, as
+// required by the specification for POST and GET streams alike.
+func (t *streamableHTTPClientTransport) resumeStream(ctx context.Context, lastEventID string) (*http.Response, error) {
+	req, err := t.newGetSSERequest(ctx, lastEventID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.httpReqHandler.Handle(ctx, t.httpClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHTTPRequestFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK ||
+		!strings.Contains(resp.Header.Get(httputil.ContentTypeHeader), httputil.ContentTypeSSE) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: status code %d", ErrHTTPRequestFailed, resp.StatusCode)
+	}
+	return resp, nil
+}
+
 // registerNotificationHandler registers a notification handler
 func (t *streamableHTTPClientTransport) registerNotificationHandler(method string, handler NotificationHandler) {
 	t.handlersMutex.Lock()
@@ -526,6 +614,7 @@ func (t *streamableHTTPClientTransport) sendNotification(ctx context.Context, no
 	if t.sessionID != "" {
 		httpReq.Header.Set(httputil.SessionIDHeader, t.sessionID)
 	}
+	t.setProtocolVersionHeader(httpReq)
 
 	// Add custom headers
 	for key, values := range t.httpHeaders {
@@ -602,6 +691,19 @@ func (t *streamableHTTPClientTransport) getSessionID() string {
 	return t.sessionID
 }
 
+// setProtocolVersion sets the protocol version negotiated during initialization.
+func (t *streamableHTTPClientTransport) setProtocolVersion(version string) {
+	t.protocolVersion = version
+}
+
+// setProtocolVersionHeader adds the MCP-Protocol-Version header to requests sent
+// after initialization.
+func (t *streamableHTTPClientTransport) setProtocolVersionHeader(req *http.Request) {
+	if t.protocolVersion != "" {
+		req.Header.Set(httputil.ProtocolVersionHeader, t.protocolVersion)
+	}
+}
+
 // SetSessionID sets the session ID
 func (t *streamableHTTPClientTransport) setSessionID(sessionID string) {
 	t.sessionID = sessionID
@@ -635,24 +737,48 @@ func (t *streamableHTTPClientTransport) establishGetSSE(parentCtx context.Contex
 			t.getSSEConn.mutex.Unlock()
 		}()
 
-		// Try to establish GET SSE connection
-		if err := t.connectGetSSE(ctx); err != nil {
-			t.logger.Infof("GET SSE connection failed: %v", err)
-		}
+		t.runGetSSE(ctx)
 	}()
 }
 
-// Connect to GET SSE endpoint
-func (t *streamableHTTPClientTransport) connectGetSSE(ctx context.Context) error {
-	// Check if there's a session ID
-	if t.sessionID == "" {
-		return fmt.Errorf("cannot establish GET SSE connection: session ID is empty")
+// runGetSSE keeps the GET SSE connection open. When the connection drops, it
+// reconnects with the last event ID received so that the server can replay
+// the missed messages, until ctx is cancelled or the server rejects the
+// connection.
+func (t *streamableHTTPClientTransport) runGetSSE(ctx context.Context) {
+	delay := getSSEReconnectMinDelay
+	for {
+		connected, err := t.connectGetSSE(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errGetSSERejected) {
+			t.logger.Infof("GET SSE connection failed: %v", err)
+			return
+		}
+		if connected {
+			delay = getSSEReconnectMinDelay
+		}
+		t.logger.Infof("GET SSE connection dropped (%v), reconnecting in %v", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if delay *= 2; delay > getSSEReconnectMaxDelay {
+			delay = getSSEReconnectMaxDelay
+		}
 	}
+}
 
-	// Build GET request
+// newGetSSERequest builds a GET request opening or resuming an SSE stream.
+func (t *streamableHTTPClientTransport) newGetSSERequest(ctx context.Context, lastEventID string) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.serverURL.String(), nil)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrHTTPRequestCreation, err)
+		return nil, fmt.Errorf("%w: %v", ErrHTTPRequestCreation, err)
 	}
 	if len(t.path) != 0 {
 		req.URL.Path = t.path
@@ -661,8 +787,9 @@ func (t *streamableHTTPClientTransport) connectGetSSE(ctx context.Context) error
 	// Set necessary headers
 	req.Header.Set(httputil.AcceptHeader, httputil.ContentTypeSSE)
 	req.Header.Set(httputil.SessionIDHeader, t.sessionID)
-	if t.lastEventID != "" {
-		req.Header.Set(httputil.LastEventIDHeader, t.lastEventID)
+	t.setProtocolVersionHeader(req)
+	if lastEventID != "" {
+		req.Header.Set(httputil.LastEventIDHeader, lastEventID)
 	}
 
 	// Add custom headers
@@ -675,16 +802,31 @@ func (t *streamableHTTPClientTransport) connectGetSSE(ctx context.Context) error
 	// Apply HTTP before-request functions.
 	if t.client != nil {
 		if err := t.client.applyHTTPBeforeRequest(ctx, req); err != nil {
-			return fmt.Errorf("HTTP before-request failed: %w", err)
+			return nil, fmt.Errorf("HTTP before-request failed: %w", err)
 		}
 	}
+	return req, nil
+}
+
+// Connect to GET SSE endpoint. It reports whether the connection was established.
+func (t *streamableHTTPClientTransport) connectGetSSE(ctx context.Context) (bool, error) {
+	// Check if there's a session ID
+	if t.sessionID == "" {
+		return false, fmt.Errorf("%w: session ID is empty", errGetSSERejected)
+	}
+
+	// Build GET request
+	req, err := t.newGetSSERequest(ctx, t.lastEventID)
+	if err != nil {
+		return false, err
+	}
 
 	t.logger.Debugf("Attempting to establish GET SSE connection, session ID: %s", t.sessionID)
 
 	// Send request
 	resp, err := t.httpReqHandler.Handle(ctx, t.httpClient, req)
 	if err != nil {
-		return fmt.Errorf("GET SSE connection request failed: %w", err)
+		return false, fmt.Errorf("GET SSE connection request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -693,16 +835,19 @@ func (t *streamableHTTPClientTransport) connectGetSSE(ctx context.Context) error
 		// If server doesn't support GET SSE, this is acceptable
 		if resp.StatusCode == http.StatusMethodNotAllowed {
 			t.logger.Infof("Server does not support GET SSE, status code: %d", resp.StatusCode)
-			return fmt.Errorf("server does not support GET SSE: %s", resp.Status)
+			return false, fmt.Errorf("%w: server does not support GET SSE: %s", errGetSSERejected, resp.Status)
 		}
-		return fmt.Errorf("GET SSE connection failed, status code: %d", resp.StatusCode)
+		if resp.StatusCode < http.StatusInternalServerError {
+			return false, fmt.Errorf("%w: status code %d", errGetSSERejected, resp.StatusCode)
+		}
+		return false, fmt.Errorf("GET SSE connection failed, status code: %d", resp.StatusCode)
 	}
 
 	// Handle response
 	t.logger.Debugf("GET SSE connection established, session ID: %s", t.sessionID)
 
 	// Handle SSE event stream
-	return t.handleGetSSEEvents(ctx, resp.Body)
+	return true, t.handleGetSSEEvents(ctx, resp.Body)
 }
 
 // Handle GET SSE event stream
@@ -782,6 +927,18 @@ func (t *streamableHTTPClientTransport) processSSEEvent(eventID, eventData strin
 			return
 		}
 
+		if t.client != nil {
+			t.client.dispatchCancelled(&notification)
+			if forwarder := t.client.forwarder; forwarder != nil {
+				forwarder.forwardNotification(&notification)
+				return
+			}
+			t.client.progress.dispatch(&notification)
+			t.client.dispatchLogMessage(&notification)
+			t.client.dispatchResourceUpdated(&notification)
+			t.client.dispatchListChanged(&notification)
+		}
+
 		// Get notification handlers.
 		t.handlersMutex.RLock()
 		handlers := make(map[string]NotificationHandler, len(t.notificationHandlers))
@@ -815,16 +972,40 @@ func (t *streamableHTTPClientTransport) processSSEEvent(eventID, eventData strin
 
 // handleIncomingRequest handles JSON-RPC requests from the server.
 func (t *streamableHTTPClientTransport) handleIncomingRequest(request *JSONRPCRequest) {
+	if t.client != nil && t.client.forwarder != nil {
+		// Forwarding may take long, so it must not block the SSE stream.
+		go t.serveRequest(request, func(ctx context.Context) JSONRPCMessage {
+			return t.client.forwarder.forwardRequest(ctx, request)
+		})
+		return
+	}
+
 	// Handle different types of requests.
 	switch request.Method {
+	case MethodPing:
+		t.sendResponseToServer(newJSONRPCResponse(request.ID, struct{}{}))
 	case MethodRootsList:
 		t.handleRootsListRequest(request)
+	case MethodSamplingCreateMessage:
+		// Sampling may take long, so it must not block the SSE stream.
+		go t.handleCreateMessageRequest(request)
+	case MethodElicitationCreate:
+		// Elicitation waits for the user, so it must not block message processing.
+		go t.handleElicitRequest(request)
 	default:
 		// Send method not found error.
 		t.sendErrorResponse(request, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", request.Method))
 	}
 }
 
+// serveRequest sends the response of handle to a request of the server, unless
+// the server cancels the request first.
+func (t *streamableHTTPClientTransport) serveRequest(request *JSONRPCRequest, handle func(ctx context.Context) JSONRPCMessage) {
+	if response := t.client.handleServerRequest(context.Background(), request, handle); response != nil {
+		t.sendResponseToServer(response)
+	}
+}
+
 // handleRootsListRequest handles roots/list requests from the server.
 func (t *streamableHTTPClientTransport) handleRootsListRequest(request *JSONRPCRequest) {
 	// Get roots from the client if it has a reference.
@@ -857,6 +1038,28 @@ func (t *streamableHTTPClientTransport) handleRootsListRequest(request *JSONRPCR
 	t.sendResponseToServer(response)
 }
 
+// handleCreateMessageRequest handles sampling/createMessage requests from the server.
+func (t *streamableHTTPClientTransport) handleCreateMessageRequest(request *JSONRPCRequest) {
+	var handler SamplingHandler
+	if t.client != nil {
+		handler = t.client.getSamplingHandler()
+	}
+	t.serveRequest(request, func(ctx context.Context) JSONRPCMessage {
+		return handleCreateMessageRequest(ctx, handler, request)
+	})
+}
+
+// handleElicitRequest handles elicitation/create requests from the server.
+func (t *streamableHTTPClientTransport) handleElicitRequest(request *JSONRPCRequest) {
+	var handler ElicitationHandler
+	if t.client != nil {
+		handler = t.client.getElicitationHandler()
+	}
+	t.serveRequest(request, func(ctx context.Context) JSONRPCMessage {
+		return handleElicitRequest(ctx, handler, request)
+	})
+}
+
 // sendErrorResponse sends an error response to the server.
 func (t *streamableHTTPClientTransport) sendErrorResponse(request *JSONRPCRequest, code int, message string) {
 	errorResp := newJSONRPCErrorResponse(request.ID, code, message, nil)
@@ -893,6 +1096,7 @@ func (t *streamableHTTPClientTransport) sendResponseToServer(response interface{
 	if t.sessionID != "" {
 		httpReq.Header.Set(httputil.SessionIDHeader, t.sessionID) // Use correct MCP protocol header: Mcp-Session-Id.
 	}
+	t.setProtocolVersionHeader(httpReq)
 
 	var resp *http.Response
 	resp, err = t.httpReqHandler.Handle(ctx, t.httpClient, httpReq) // Always use httpReqHandler as there's always a default value.
@@ -931,6 +1135,7 @@ func (t *streamableHTTPClientTransport) terminateSession(ctx context.Context) er
 	} else {
 		return fmt.Errorf("no active session")
 	}
+	t.setProtocolVersionHeader(httpReq)
 
 	// Add custom headers
 	for key, values := range t.httpHeaders {
@@ -939,8 +1144,8 @@ func (t *streamableHTTPClientTransport) terminateSession(ctx context.Context) er
 		}
 	}
 
-	// Send request
-	httpResp, err := t.httpClient.Do(httpReq)
+	// Send request, authorized like the other requests of the session
+	httpResp, err := t.httpReqHandler.Handle(ctx, t.httpClient, httpReq)
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -959,6 +1164,21 @@ func (t *streamableHTTPClientTransport) terminateSession(ctx context.Context) er
 	return nil
 }
 
+// resetSession stops the GET SSE connection and forgets the session, so that
+// a new one is created by the next initialize request.
+func (t *streamableHTTPClientTransport) resetSession() {
+	t.getSSEConn.mutex.Lock()
+	if t.getSSEConn.active && t.getSSEConn.cancel != nil {
+		t.getSSEConn.cancel()
+		t.getSSEConn.active = false
+	}
+	t.getSSEConn.mutex.Unlock()
+
+	t.sessionID = ""
+	t.lastEventID = ""
+	t.isStateless = false
+}
+
 // isStatelessMode returns whether the client is in stateless mode
 //
 // The client automatically detects if the server is in stateless mode: when no session ID
@@ -9,14 +9,19 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	icontext "trpc.group/trpc-go/trpc-mcp-go/internal/context"
 	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/session"
 	"trpc.group/trpc-go/trpc-mcp-go/internal/sseutil"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/websocket"
 )
 
 const (
@@ -73,21 +78,59 @@ type httpServerHandler struct {
 
 	// Response manager for server-to-client requests.
 	responseManager *responseManager
+
+	// Event store of resumable SSE streams, nil disables resumption
+	eventStore EventStore
+
+	// SSE streams that can still be resumed, by stream ID
+	eventStreams     map[string]*eventStream
+	eventStreamsLock sync.RWMutex
+
+	// IDs of the streams opened by this server whose events are in the event
+	// store, guarded by eventStreamsLock
+	storedStreams map[string]struct{}
+
+	// SSE streams that have not ended, closed on shutdown
+	openStreams     map[*eventStream]struct{}
+	openStreamsLock sync.Mutex
+
+	// Counter used to build unique stream IDs
+	eventStreamCounter atomic.Int64
+
+	// Bearer token authorization, nil if requests are not authorized
+	authorizer *authorizer
+
+	// Pub/Sub routing messages between server nodes, nil for a single node
+	sessionPubSub SessionPubSub
+
+	// Time a session can be idle before it expires, zero or less for no limit
+	sessionIdleTimeout time.Duration
+
+	// Time a session can live regardless of activity, zero or less for no limit
+	sessionMaxLifetime time.Duration
+
+	// Hooks called as sessions are created and closed, nil if none
+	sessionHooks *SessionHooks
+
+	// Whether the server is shutting down and rejects new sessions and streams
+	shuttingDown atomic.Bool
+
+	// WebSocket transport configuration
+	webSocket webSocketConfig
 }
 
+// routedRequestIDPrefix starts the IDs of server-to-client requests sent while
+// a SessionPubSub is set. The ID is also the Pub/Sub key the node sending the
+// request listens on, so that the node receiving the response can route it back.
+const routedRequestIDPrefix = "mcp_routed_req_"
+
 // getSSEConnection represents a GET SSE connection
 type getSSEConnection struct {
-	writer      http.ResponseWriter
-	flusher     http.Flusher
-	ctx         context.Context
-	cancelFunc  context.CancelFunc
-	lastEventID string
-
-	// Prevent concurrent write conflicts
-	writeLock sync.Mutex
+	ctx        context.Context
+	cancelFunc context.CancelFunc
 
-	// Event ID generator, reuses existing sseResponder
-	sseResponder *sseResponder
+	// Stream the messages to the client are sent on
+	stream *eventStream
 }
 
 // newHTTPServerHandler creates an HTTP server handler
@@ -103,6 +146,10 @@ func newHTTPServerHandler(handler requestHandler, serverPath string, options ...
 		getSSEConnections:      make(map[string]*getSSEConnection),
 		serverPath:             serverPath,
 		responseManager:        newResponseManager(),
+		eventStreams:           make(map[string]*eventStream),
+		storedStreams:          make(map[string]struct{}),
+		openStreams:            make(map[*eventStream]struct{}),
+		sessionIdleTimeout:     defaultSessionExpirySeconds * time.Second,
 	}
 
 	// Apply options
@@ -123,7 +170,18 @@ func newHTTPServerHandler(handler requestHandler, serverPath string, options ...
 
 	// If sessions are enabled but no session manager is set, create a default one
 	if h.enableSession && h.sessionManager == nil {
-		h.sessionManager = newSessionManager(defaultSessionExpirySeconds)
+		h.sessionManager = newSessionManager(defaultSessionExpirySeconds,
+			session.WithIdleTimeout(h.sessionIdleTimeout),
+			session.WithMaxLifetime(h.sessionMaxLifetime),
+			session.WithExpiryHandler(func(s *session.Session) {
+				h.closeSession(context.Background(), s, SessionCloseReasonExpired)
+			}),
+		)
+	}
+	if m, ok := h.sessionManager.(*storeSessionManager); ok {
+		m.setExpiryHandler(func(s Session) {
+			h.closeSession(context.Background(), s, SessionCloseReasonExpired)
+		})
 	}
 
 	return h
@@ -193,8 +251,61 @@ func withTransportHTTPContextFuncs(funcs []HTTPContextFunc) func(*httpServerHand
 	}
 }
 
+// withTransportEventStore sets the event store that makes SSE streams resumable
+func withTransportEventStore(store EventStore) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.eventStore = store
+	}
+}
+
+// withTransportAuthorizer sets the authorizer verifying the bearer tokens of requests
+func withTransportAuthorizer(a *authorizer) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.authorizer = a
+	}
+}
+
+// withTransportSessionExpiry sets the idle timeout and maximum lifetime of sessions
+func withTransportSessionExpiry(idleTimeout, maxLifetime time.Duration) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.sessionIdleTimeout = idleTimeout
+		h.sessionMaxLifetime = maxLifetime
+	}
+}
+
+// withTransportSessionHooks sets the hooks called as sessions are created and closed
+func withTransportSessionHooks(hooks *SessionHooks) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.sessionHooks = hooks
+	}
+}
+
+// withTransportWebSocket sets the configuration of the WebSocket transport
+func withTransportWebSocket(config webSocketConfig) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.webSocket = config
+	}
+}
+
+// withTransportSessionPubSub sets the Pub/Sub routing messages between server nodes
+func withTransportSessionPubSub(pubSub SessionPubSub) func(*httpServerHandler) {
+	return func(h *httpServerHandler) {
+		h.sessionPubSub = pubSub
+	}
+}
+
 // ServeHTTP implements the http.Handler interface
 func (h *httpServerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authorizer != nil {
+		if h.authorizer.serveMetadata(w, r) {
+			return
+		}
+		var ok bool
+		if r, ok = h.authorizer.authorize(w, r); !ok {
+			return
+		}
+	}
+
 	if !h.isValidPath(r.URL.Path) {
 		if h.serverPath == "" {
 			http.Error(w, fmt.Sprintf("Path not found: %s (expected: %s)", r.URL.Path, h.serverPath), http.StatusNotFound)
@@ -202,6 +313,11 @@ func (h *httpServerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.webSocket.enabled && websocket.IsUpgradeRequest(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodPost:
 		h.handlePost(r.Context(), w, r)
@@ -258,6 +374,12 @@ func (h *httpServerHandler) handlePost(ctx context.Context, w http.ResponseWrite
 		isInitialize = true
 	}
 
+	// No session is created once the server is shutting down
+	if isInitialize && h.shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Get session
 	var session Session
 	if h.isStateless {
@@ -275,8 +397,14 @@ func (h *httpServerHandler) handlePost(ctx context.Context, w http.ResponseWrite
 			}
 		} else if isInitialize {
 			// If it's an initialize request and no session ID header, create a new session
-			session = h.sessionManager.createSession()
+			var err error
+			if session, err = h.sessionManager.createSession(); err != nil {
+				h.logger.Errorf("Failed to create session: %v", err)
+				http.Error(w, "Failed to create session", http.StatusInternalServerError)
+				return
+			}
 			h.logger.Infof("Created new session ID: %s for initialize request", session.GetID())
+			h.sessionHooks.sessionCreated(enrichedCtx, session)
 		} else {
 			// Not an initialize request and no session ID header was provided.
 			// According to MCP spec, server SHOULD respond with 400 Bad Request.
@@ -285,6 +413,10 @@ func (h *httpServerHandler) handlePost(ctx context.Context, w http.ResponseWrite
 		}
 	}
 
+	if !isInitialize && !h.checkProtocolVersionHeader(w, r, session) {
+		return
+	}
+
 	// Branch: request or notification
 	if base.ID != nil && base.Method != "" {
 		h.handlePostRequest(enrichedCtx, w, r, rawMessage, base, session)
@@ -304,6 +436,37 @@ func (h *httpServerHandler) handlePost(ctx context.Context, w http.ResponseWrite
 	http.Error(w, "Invalid JSON-RPC message", http.StatusBadRequest)
 }
 
+// checkProtocolVersionHeader validates the MCP-Protocol-Version header of a
+// request sent after initialization. It responds with 400 Bad Request and
+// returns false if the header names an unsupported version, or one that differs
+// from the version negotiated for the session.
+func (h *httpServerHandler) checkProtocolVersionHeader(w http.ResponseWriter, r *http.Request, session Session) bool {
+	version := r.Header.Get(httputil.ProtocolVersionHeader)
+	if version == "" {
+		// Clients older than 2025-06-18 do not send the header. Without a
+		// negotiated version the specification says to assume 2025-03-26.
+		if h.isStateless && session != nil {
+			session.SetData(protocolVersionKey, ProtocolVersion_2025_03_26)
+		}
+		return true
+	}
+	if !IsProtocolVersionSupported(version) {
+		http.Error(w, "Unsupported MCP-Protocol-Version: "+version, http.StatusBadRequest)
+		return false
+	}
+	if negotiated := protocolVersionFromSession(session); negotiated != "" && negotiated != version {
+		http.Error(w, fmt.Sprintf("MCP-Protocol-Version %s does not match negotiated version %s",
+			version, negotiated), http.StatusBadRequest)
+		return false
+	}
+	if h.isStateless && session != nil {
+		// Temporary sessions are never initialized, so the header is the only
+		// source of the protocol version.
+		session.SetData(protocolVersionKey, version)
+	}
+	return true
+}
+
 // handlePostRequest handles JSON-RPC requests
 func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, rawMessage json.RawMessage, base baseMessage, session Session) {
 	respCtx, cancel := context.WithCancel(ctx)
@@ -315,13 +478,31 @@ func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.Respon
 		return
 	}
 
+	// A tool call lacking scopes is refused before it is handled, telling the
+	// client the scopes to request.
+	if checker, ok := h.requestHandler.(toolScopeChecker); ok && h.authorizer != nil {
+		if scopes := checker.deniedToolScopes(ctx, &req, session); scopes != nil {
+			h.authorizer.writeInsufficientScope(w, r, scopes)
+			return
+		}
+	}
+
+	// A client that lost the stream answering a request may send the request
+	// again with the Last-Event-ID header, which resumes the stream instead of
+	// handling the request twice.
+	if lastEventID := r.Header.Get(httputil.LastEventIDHeader); lastEventID != "" && h.eventStore != nil &&
+		!h.isStateless && session != nil {
+		if h.resumePostStream(ctx, w, session, lastEventID) {
+			return
+		}
+	}
+
 	responder := h.responderFactory.createResponder(r, rawMessage)
 
 	// Check response processor type
-	if sseResponder, ok := responder.(*sseResponder); ok {
+	if _, ok := responder.(*sseResponder); ok {
 		// Use SSE response mode
-		flusher, ok := w.(http.Flusher)
-		if !ok {
+		if _, ok := w.(http.Flusher); !ok {
 			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 			return
 		}
@@ -329,19 +510,37 @@ func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.Respon
 		if !h.isStateless && session != nil {
 			w.Header().Set(httputil.SessionIDHeader, session.GetID())
 		}
-		var sessionID string
-		if session != nil {
-			sessionID = session.GetID()
+		stream := h.openEventStream(session, eventStreamKindPost)
+		detach := stream.attach(w)
+		defer func() {
+			detach()
+			if r.Context().Err() != nil {
+				stream.lose()
+			}
+			h.closeEventStream(stream)
+		}()
+		if stream.store != nil {
+			// The client may drop the connection and resume the stream, so the
+			// request is not cancelled with the connection.
+			ctx = icontext.WithoutCancel(ctx)
 		}
-		notificationSender := newSSENotificationSender(w, flusher, sessionID)
-		reqCtx := withNotificationSender(ctx, notificationSender)
+		reqCtx := withNotificationSender(ctx, newStreamNotificationSender(session,
+			func(notification *JSONRPCNotification) error {
+				_, err := stream.sendMessage(ctx, notification)
+				return err
+			}))
 		if session != nil {
 			reqCtx = setSessionToContext(reqCtx, session)
+			reqCtx = withRelatedStream(reqCtx, session.GetID(), stream)
 		}
 		resp, err := h.requestHandler.handleRequest(reqCtx, &req, session)
+		if errors.Is(err, errRequestCancelled) {
+			// The client cancelled the request, so the stream ends without a response.
+			return
+		}
 		if err != nil {
 			errorResp := newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil)
-			if err := sseResponder.respond(ctx, w, r, errorResp, session); err != nil {
+			if _, err := stream.sendMessage(ctx, errorResp); err != nil {
 				h.logger.Errorf("Failed to send SSE system error response: %v", err)
 			}
 			return
@@ -349,7 +548,7 @@ func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.Respon
 
 		// Check if result is already a JSON-RPC error.
 		if errorResp, ok := resp.(*JSONRPCError); ok {
-			if err := sseResponder.respond(ctx, w, r, errorResp, session); err != nil {
+			if _, err := stream.sendMessage(ctx, errorResp); err != nil {
 				h.logger.Errorf("Failed to send SSE business error response: %v", err)
 			}
 			return
@@ -361,18 +560,23 @@ func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.Respon
 			ID:      req.ID,
 			Result:  resp,
 		}
-		if err := sseResponder.respond(ctx, w, r, jsonrpcResponse, session); err != nil {
+		if _, err := stream.sendMessage(ctx, jsonrpcResponse); err != nil {
 			h.logger.Errorf("Failed to send SSE success response: %v", err)
 		}
 		return
 	}
-	// Use normal JSON response mode
-	noopSender := &noopNotificationSender{}
-	reqCtx := withNotificationSender(ctx, noopSender)
+	// Use normal JSON response mode. Notifications emitted while handling the
+	// request fall back to the session's GET SSE stream, if any.
+	reqCtx := withNotificationSender(ctx, h.getSSENotificationSender(session))
 	if session != nil {
 		reqCtx = setSessionToContext(reqCtx, session)
 	}
 	resp, err := h.requestHandler.handleRequest(reqCtx, &req, session)
+	if errors.Is(err, errRequestCancelled) {
+		// The client cancelled the request, so no response is sent.
+		h.sendNotificationResponse(w, session)
+		return
+	}
 	if err != nil {
 		errorResp := newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil)
 		if err := responder.respond(respCtx, w, r, errorResp, session); err != nil {
@@ -400,6 +604,67 @@ func (h *httpServerHandler) handlePostRequest(ctx context.Context, w http.Respon
 	}
 }
 
+// resumePostStream resumes the stream of a POST request of the session after
+// lastEventID, until the stream ends or the client disconnects. It reports
+// whether the stream was resumed; nothing is written to w otherwise.
+func (h *httpServerHandler) resumePostStream(ctx context.Context, w http.ResponseWriter, session Session, lastEventID string) bool {
+	if _, ok := w.(http.Flusher); !ok {
+		return false
+	}
+	w.Header().Set(httputil.SessionIDHeader, session.GetID())
+	streamID, resumed, detach, err := h.resumeEventStream(ctx, w, session.GetID(), lastEventID, true)
+	if err != nil {
+		h.logger.Infof("Session [%s]: cannot resume stream after event %s, handling the request: %v",
+			session.GetID(), lastEventID, err)
+		return false
+	}
+	h.logger.Infof("Session [%s]: resumed stream %s after event %s", session.GetID(), streamID, lastEventID)
+	if resumed != nil {
+		select {
+		case <-resumed.done:
+		case <-ctx.Done():
+		}
+	}
+	detach()
+	return true
+}
+
+// getSSENotificationSender returns a sender that delivers notifications over the
+// session's GET SSE stream, or a no-op sender if there is no such stream.
+func (h *httpServerHandler) getSSENotificationSender(session Session) notificationSender {
+	if !h.enableGetSSE || h.isStateless || session == nil {
+		return &noopNotificationSender{}
+	}
+	sessionID := session.GetID()
+	return newStreamNotificationSender(session, func(notification *JSONRPCNotification) error {
+		err := h.sendNotification(sessionID, notification)
+		if errors.Is(err, ErrSessionNotFound) {
+			// No GET SSE stream is open, so there is nowhere to deliver it.
+			return nil
+		}
+		return err
+	})
+}
+
+// sendJSONRPCError writes a JSON-RPC error response with the given HTTP status code.
+func (h *httpServerHandler) sendJSONRPCError(
+	w http.ResponseWriter,
+	statusCode int,
+	id interface{},
+	code int,
+	message string,
+	session Session,
+) {
+	w.Header().Set(httputil.ContentTypeHeader, httputil.ContentTypeJSON)
+	if !h.isStateless && session != nil {
+		w.Header().Set(httputil.SessionIDHeader, session.GetID())
+	}
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(newJSONRPCErrorResponse(id, code, message, nil)); err != nil {
+		h.logger.Errorf("Failed to send JSON-RPC error response: %v", err)
+	}
+}
+
 // handlePostNotification handles JSON-RPC notifications
 func (h *httpServerHandler) handlePostNotification(ctx context.Context, w http.ResponseWriter, r *http.Request, rawMessage json.RawMessage, base baseMessage, session Session) {
 	var notification JSONRPCNotification
@@ -452,12 +717,70 @@ func (h *httpServerHandler) handlePostResponse(ctx context.Context, w http.Respo
 		return
 	}
 
-	sessionID := session.GetID()
-	h.logger.Debugf("Received JSON-RPC response for session %s, ID: %v", sessionID, response.ID)
+	h.deliverClientResponse(session.GetID(), rawMessage)
+
+	// Send 202 Accepted response.
+	h.sendNotificationResponse(w, session)
+}
+
+// deliverClientResponse hands a JSON-RPC response sent by the client to the
+// server-to-client request that is waiting for it. If the request was sent by
+// another node, the response is routed to that node.
+func (h *httpServerHandler) deliverClientResponse(sessionID string, rawMessage json.RawMessage) {
+	requestIDStr, responseMessage, err := parseClientResponse(rawMessage)
+	if err != nil {
+		h.logger.Errorf("Invalid JSON-RPC response: %v", err)
+		return
+	}
+
+	h.logger.Debugf("Received JSON-RPC response for session %s, ID: %s", sessionID, requestIDStr)
+
+	// Deliver response using responseManager.
+	if h.responseManager.DeliverResponse(requestIDStr, responseMessage) {
+		h.logger.Debugf("Successfully delivered response for request ID: %s", requestIDStr)
+		return
+	}
+	if h.sessionPubSub != nil && strings.HasPrefix(requestIDStr, routedRequestIDPrefix) {
+		if err := h.sessionPubSub.Publish(context.Background(), requestIDStr, rawMessage); err != nil {
+			h.logger.Errorf("Failed to route response for request ID %s: %v", requestIDStr, err)
+		}
+		return
+	}
+	h.logger.Debugf("Received response for unknown request ID: %s", requestIDStr)
+}
+
+// handleRoutedResponse is the SessionPubSub callback of the node waiting for
+// the response to a server-to-client request, receiving the response from the
+// node the client sent it to.
+func (h *httpServerHandler) handleRoutedResponse(ctx context.Context, requestID string, payload []byte) error {
+	requestIDStr, responseMessage, err := parseClientResponse(payload)
+	if err != nil {
+		return err
+	}
+	if !h.responseManager.DeliverResponse(requestIDStr, responseMessage) {
+		h.logger.Debugf("Received routed response for unknown request ID: %s", requestIDStr)
+	}
+	return nil
+}
+
+// parseClientResponse parses a JSON-RPC response sent by the client, and
+// returns its request ID along with the message handed to the waiting request:
+// the result, or an object holding the error.
+func parseClientResponse(rawMessage json.RawMessage) (string, *json.RawMessage, error) {
+	var response struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      interface{} `json:"id"`
+		Result  interface{} `json:"result,omitempty"`
+		Error   interface{} `json:"error,omitempty"`
+	}
+
+	if err := json.Unmarshal(rawMessage, &response); err != nil {
+		return "", nil, fmt.Errorf("invalid JSON-RPC response format: %w", err)
+	}
 
 	// Prepare response data
 	requestIDStr := fmt.Sprintf("%v", response.ID)
-	var responseMessage *json.RawMessage
+	var responseMessage json.RawMessage
 
 	// Handle error response.
 	if response.Error != nil {
@@ -465,34 +788,19 @@ func (h *httpServerHandler) handlePostResponse(ctx context.Context, w http.Respo
 		errorBytes, _ := json.Marshal(map[string]interface{}{
 			"error": response.Error,
 		})
-		errorMsg := json.RawMessage(errorBytes)
-		responseMessage = &errorMsg
+		responseMessage = errorBytes
 	} else if response.Result != nil {
 		// Handle success response.
 		resultBytes, err := json.Marshal(response.Result)
 		if err != nil {
-			h.logger.Errorf("Failed to marshal response result: %v", err)
-			h.sendNotificationResponse(w, session)
-			return
+			return "", nil, fmt.Errorf("failed to marshal response result: %w", err)
 		}
-		resultMsg := json.RawMessage(resultBytes)
-		responseMessage = &resultMsg
+		responseMessage = resultBytes
 	} else {
 		// Invalid response - neither error nor result.
-		h.logger.Errorf("Invalid JSON-RPC response: missing both result and error for ID: %v", response.ID)
-		h.sendNotificationResponse(w, session)
-		return
+		return "", nil, fmt.Errorf("missing both result and error for ID: %v", response.ID)
 	}
-
-	// Deliver response using responseManager.
-	if h.responseManager.DeliverResponse(requestIDStr, responseMessage) {
-		h.logger.Debugf("Successfully delivered response for request ID: %v", response.ID)
-	} else {
-		h.logger.Debugf("Received response for unknown request ID: %v", response.ID)
-	}
-
-	// Send 202 Accepted response.
-	h.sendNotificationResponse(w, session)
+	return requestIDStr, &responseMessage, nil
 }
 
 // handleDelete handles DELETE requests
@@ -506,10 +814,14 @@ func (h *httpServerHandler) handleDelete(ctx context.Context, w http.ResponseWri
 
 	// Get session
 	if h.enableSession {
+		session, ok := h.sessionManager.getSession(sessionID)
+		if ok && !h.checkProtocolVersionHeader(w, r, session) {
+			return
+		}
+
 		// Terminate session
-		if h.sessionManager.terminateSession(sessionID) {
-			// Clean up GET SSE connections
-			h.cleanupSession(sessionID)
+		if ok && h.sessionManager.terminateSession(sessionID) {
+			h.closeSession(ctx, session, SessionCloseReasonDeleted)
 
 			// Return success response
 			h.sendEmptyResponse(w, http.StatusOK, nil)
@@ -562,6 +874,12 @@ func (h *httpServerHandler) handleGet(ctx context.Context, w http.ResponseWriter
 		return
 	}
 
+	// No stream is opened once the server is shutting down
+	if h.shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Check if there's a session ID
 	sessionID := r.Header.Get(httputil.SessionIDHeader)
 	if sessionID == "" {
@@ -576,6 +894,10 @@ func (h *httpServerHandler) handleGet(ctx context.Context, w http.ResponseWriter
 		return
 	}
 
+	if !h.checkProtocolVersionHeader(w, r, session) {
+		return
+	}
+
 	// Check if streaming is supported
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -593,23 +915,54 @@ func (h *httpServerHandler) handleGet(ctx context.Context, w http.ResponseWriter
 	connCtx, cancelConn := context.WithCancel(ctx)
 	localCancelFunc = cancelConn // Assign to the variable captured by defer
 
-	// Check if there's already a GET SSE connection
+	// If there's Last-Event-ID, try to resume the stream it belongs to
+	var stream *eventStream
+	var detach func()
+	if lastEventID := r.Header.Get(httputil.LastEventIDHeader); lastEventID != "" && h.eventStore != nil {
+		streamID, resumed, resumedDetach, err := h.resumeEventStream(connCtx, w, session.GetID(), lastEventID, false)
+		switch {
+		case err != nil:
+			h.logger.Infof("Session [%s]: cannot resume stream after event %s, opening a new stream: %v",
+				session.GetID(), lastEventID, err)
+		case isPostEventStream(streamID, session.GetID()):
+			// The stream of a POST request ends with its response.
+			h.logger.Infof("Session [%s]: resumed stream %s after event %s", session.GetID(), streamID, lastEventID)
+			if resumed != nil {
+				select {
+				case <-resumed.done:
+				case <-connCtx.Done():
+				}
+			}
+			resumedDetach()
+			return
+		case resumed != nil:
+			h.logger.Infof("Session [%s]: resumed GET SSE stream after event %s", session.GetID(), lastEventID)
+			stream, detach = resumed, resumedDetach
+		}
+	}
+	if stream == nil {
+		stream = h.openEventStream(session, eventStreamKindGet)
+		detach = stream.attach(w)
+	}
+
+	// Replace the existing GET SSE connection, if any
 	h.getSSEConnectionsLock.Lock()
 	existingConn, exists := h.getSSEConnections[session.GetID()]
 	if exists {
-		// Cancel existing connection
 		existingConn.cancelFunc()
+		if existingConn.stream != stream {
+			h.closeEventStream(existingConn.stream)
+		}
+	} else if h.sessionPubSub != nil {
+		// Let other nodes route the messages of the session to this node
+		if err := h.sessionPubSub.Subscribe(context.Background(), session.GetID(), h.handleRoutedMessage); err != nil {
+			h.logger.Errorf("Failed to subscribe to session %s: %v", session.GetID(), err)
+		}
 	}
-
-	// Create new GET SSE connection
-	lastEventID := r.Header.Get(httputil.LastEventIDHeader)
 	conn := &getSSEConnection{
-		writer:       w,
-		flusher:      flusher,
-		ctx:          connCtx,
-		cancelFunc:   cancelConn,
-		lastEventID:  lastEventID,
-		sseResponder: newSSEResponder(),
+		ctx:        connCtx,
+		cancelFunc: cancelConn,
+		stream:     stream,
 	}
 	h.getSSEConnections[session.GetID()] = conn
 	h.getSSEConnectionsLock.Unlock()
@@ -617,17 +970,21 @@ func (h *httpServerHandler) handleGet(ctx context.Context, w http.ResponseWriter
 	// Record connection information
 	h.logger.Infof("Established GET SSE connection, session ID: %s", session.GetID())
 
-	// If there's Last-Event-ID, try to resume stream
-	if lastEventID != "" {
-		h.handleStreamResumption(connCtx, conn, session.GetID())
-	}
-
 	// Wait for connection to close
 	<-connCtx.Done()
+	detach()
+	if r.Context().Err() != nil {
+		stream.lose()
+	}
 
-	// Clean up connection
+	// Clean up connection. A resumable stream keeps collecting messages until
+	// the client resumes it or the session ends.
 	h.getSSEConnectionsLock.Lock()
-	delete(h.getSSEConnections, session.GetID())
+	if h.getSSEConnections[session.GetID()] == conn && stream.store == nil {
+		delete(h.getSSEConnections, session.GetID())
+		h.closeEventStream(stream)
+		h.unsubscribeSession(session.GetID())
+	}
 	h.getSSEConnectionsLock.Unlock()
 	h.logger.Infof("GET SSE connection closed, session ID: %s", session.GetID())
 }
@@ -638,67 +995,142 @@ func (h *httpServerHandler) sendNotificationToGetSSE(sessionID string, notificat
 	conn, ok := h.getSSEConnections[sessionID]
 	h.getSSEConnectionsLock.RUnlock()
 
-	if !ok {
+	if !ok && h.sessionPubSub == nil {
 		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 	}
 
-	conn.writeLock.Lock()
-	defer conn.writeLock.Unlock()
-
-	// Use SSE responder to send notification
-	eventID, err := conn.sseResponder.sendNotification(conn.writer, notification)
-	if err != nil {
+	// Ensure jsonrpc field is set correctly
+	if notification.JSONRPC == "" {
+		notification.JSONRPC = JSONRPCVersion
+	}
+	if !ok {
+		// The stream may be held by another node.
+		return h.routeMessage(context.Background(), sessionID, notification)
+	}
+	if _, err := conn.stream.sendMessage(context.Background(), notification); err != nil {
 		return fmt.Errorf("failed to send notification via SSE: %w", err)
 	}
-
-	// Update last event ID
-	conn.lastEventID = eventID
 	return nil
 }
 
-// Handle SSE stream resumption
-func (h *httpServerHandler) handleStreamResumption(ctx context.Context, conn *getSSEConnection, sessionID string) {
-	// Get session
-	session, ok := h.sessionManager.getSession(sessionID)
-	if !ok {
-		h.logger.Infof("Stream resumption failed: session %s not found", sessionID)
-		return
-	}
+// openEventStream creates a stream of the session. Streams of stateful sessions
+// are resumable when an event store is set.
+func (h *httpServerHandler) openEventStream(session Session, kind string) *eventStream {
+	var stream *eventStream
+	if h.eventStore == nil || h.isStateless || session == nil {
+		stream = newEventStream("", nil)
+	} else {
+		stream = newEventStream(newEventStreamID(session.GetID(), kind, h.eventStreamCounter.Add(1)), h.eventStore)
+		h.eventStreamsLock.Lock()
+		h.eventStreams[stream.id] = stream
+		h.storedStreams[stream.id] = struct{}{}
+		h.eventStreamsLock.Unlock()
+	}
+	h.openStreamsLock.Lock()
+	h.openStreams[stream] = struct{}{}
+	h.openStreamsLock.Unlock()
+	return stream
+}
 
-	// Add session to context (this is useful for future code that might use session through context)
-	// Note: Currently not used in this context, but we keep this code to ensure context has session information
-	_ = setSessionToContext(ctx, session)
+// closeEventStream ends the stream, after which it can only be replayed. A
+// stream fully delivered to the client is removed from the event store.
+func (h *httpServerHandler) closeEventStream(stream *eventStream) {
+	delivered := stream.close()
+	h.forgetEventStream(stream)
+	if delivered && stream.store != nil {
+		h.deleteStoredStream(stream.id)
+	}
+}
 
-	// Implement resumption logic, re-sending messages based on lastEventID
-	// This needs to be handled according to the server's storage/cache mechanism
-	h.logger.Infof("Resuming session %s GET SSE stream, event ID: %s", sessionID, conn.lastEventID)
+// deleteStoredStream removes the events of a stream from the event store.
+func (h *httpServerHandler) deleteStoredStream(streamID string) {
+	h.eventStreamsLock.Lock()
+	delete(h.storedStreams, streamID)
+	h.eventStreamsLock.Unlock()
+	if err := h.eventStore.DeleteStream(context.Background(), streamID); err != nil {
+		h.logger.Errorf("Failed to delete stream %s from the event store: %v", streamID, err)
+	}
+}
 
-	// Create params for the notification
-	params := map[string]interface{}{
-		"resumedFrom": conn.lastEventID,
+// deleteSessionStreams removes the events of the streams of a session opened
+// by this server from the event store.
+func (h *httpServerHandler) deleteSessionStreams(sessionID string) {
+	if h.eventStore == nil {
+		return
 	}
+	h.eventStreamsLock.RLock()
+	var streamIDs []string
+	for streamID := range h.storedStreams {
+		if isSessionEventStream(streamID, sessionID) {
+			streamIDs = append(streamIDs, streamID)
+		}
+	}
+	h.eventStreamsLock.RUnlock()
+	for _, streamID := range streamIDs {
+		h.deleteStoredStream(streamID)
+	}
+}
 
-	// Create NotificationParams struct
-	notification := Notification{
-		Method: "stream/resumed",
-		Params: NotificationParams{
-			AdditionalFields: params,
-		},
+// forgetEventStream removes an ended stream from the open streams.
+func (h *httpServerHandler) forgetEventStream(stream *eventStream) {
+	h.eventStreamsLock.Lock()
+	if h.eventStreams[stream.id] == stream {
+		delete(h.eventStreams, stream.id)
 	}
+	h.eventStreamsLock.Unlock()
+	h.openStreamsLock.Lock()
+	delete(h.openStreams, stream)
+	h.openStreamsLock.Unlock()
+}
 
-	// Create strictly conforming JSON-RPC 2.0 notification object
-	// Use core.NewNotification to ensure jsonrpc field is set to "2.0"
-	jsonNotification := newJSONRPCNotification(notification)
+// resumeEventStream replays to w the events that followed lastEventID on its
+// stream, which must be the stream of a POST request if postOnly is set. If the
+// stream has not ended, w is attached to it to receive the following messages,
+// and the stream and the function detaching w are returned along with the
+// stream ID. Nothing is written to w if the stream cannot be resumed. A stream
+// that ended is removed from the event store once replayed.
+func (h *httpServerHandler) resumeEventStream(
+	ctx context.Context,
+	w http.ResponseWriter,
+	sessionID string,
+	lastEventID string,
+	postOnly bool,
+) (string, *eventStream, func(), error) {
+	// The events are collected before writing anything, as the stream may
+	// belong to another session.
+	var events []sseutil.Event
+	streamID, err := h.eventStore.ReplayEventsAfter(ctx, lastEventID, func(eventID string, message []byte) error {
+		events = append(events, sseutil.Event{ID: eventID, Data: message})
+		return nil
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if !isSessionEventStream(streamID, sessionID) || (postOnly && !isPostEventStream(streamID, sessionID)) {
+		return "", nil, nil, fmt.Errorf("%w: %s", ErrEventNotFound, lastEventID)
+	}
 
-	// Validate notification object format before sending
-	notifBytes, _ := json.Marshal(notification)
-	h.logger.Infof("Preparing to send stream resumption notification: %s", string(notifBytes))
+	sseutil.SetStandardHeaders(w)
+	h.eventStreamsLock.RLock()
+	stream, live := h.eventStreams[streamID]
+	h.eventStreamsLock.RUnlock()
+	if live {
+		// Replay again while holding the stream so that no message is lost.
+		detach, err := stream.resume(ctx, w, lastEventID)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return streamID, stream, detach, nil
+	}
 
-	// Send notification
-	err := h.sendNotificationToGetSSE(sessionID, jsonNotification)
-	if err != nil {
-		h.logger.Infof("Failed to send stream resumption notification: %v", err)
+	sseWriter := sseutil.NewWriter()
+	for _, event := range events {
+		if err := sseWriter.WriteEvent(w, event); err != nil {
+			return "", nil, nil, err
+		}
 	}
+	h.deleteStoredStream(streamID)
+	return streamID, nil, func() {}, nil
 }
 
 // sendEmptyResponse sends an empty response with the specified status code
@@ -729,19 +1161,63 @@ func (h *httpServerHandler) sendNotification(sessionID string, notification *JSO
 	return h.sendNotificationToGetSSE(sessionID, notification)
 }
 
-// SendRequest sends a JSON-RPC request to a client and waits for response.
+// relatedStreamKey is the context key of the stream of the request being handled.
+type relatedStreamKey struct{}
+
+// relatedStream is the SSE stream answering a request of a session.
+type relatedStream struct {
+	sessionID string
+	stream    *eventStream
+}
+
+// withRelatedStream returns a context carrying the SSE stream answering the
+// request of the session being handled.
+func withRelatedStream(ctx context.Context, sessionID string, stream *eventStream) context.Context {
+	return context.WithValue(ctx, relatedStreamKey{}, &relatedStream{sessionID: sessionID, stream: stream})
+}
+
+// relatedStreamFromContext returns the SSE stream answering the request of the
+// session being handled with ctx, or nil if there is none.
+func relatedStreamFromContext(ctx context.Context, sessionID string) *eventStream {
+	related, ok := ctx.Value(relatedStreamKey{}).(*relatedStream)
+	if !ok || related.sessionID != sessionID {
+		return nil
+	}
+	return related.stream
+}
+
+// SendRequest sends a JSON-RPC request to a client and waits for the response
+// until ctx is done, or for 30 seconds if ctx has no deadline. A request sent
+// while handling a request of the session is sent on the SSE stream answering
+// that request, other requests on the session's GET SSE stream.
 func (h *httpServerHandler) SendRequest(ctx context.Context, sessionID string, request *JSONRPCRequest) (*json.RawMessage, error) {
-	// Check if there's a GET SSE connection for this session.
-	h.getSSEConnectionsLock.RLock()
-	conn, ok := h.getSSEConnections[sessionID]
-	h.getSSEConnectionsLock.RUnlock()
+	ctx, cancel := withServerRequestTimeout(ctx)
+	defer cancel()
 
-	if !ok {
-		return nil, fmt.Errorf("no GET SSE connection found for session: %s", sessionID)
+	stream := relatedStreamFromContext(ctx, sessionID)
+	if stream == nil {
+		// Check if there's a GET SSE connection for this session.
+		h.getSSEConnectionsLock.RLock()
+		if conn, ok := h.getSSEConnections[sessionID]; ok {
+			stream = conn.stream
+		}
+		h.getSSEConnectionsLock.RUnlock()
+	}
+
+	if stream == nil && h.sessionPubSub == nil {
+		return nil, fmt.Errorf("no SSE stream found for session: %s", sessionID)
 	}
 
-	// Generate unique request ID if not provided.
-	if request.ID == nil {
+	if h.sessionPubSub != nil {
+		// The client may send the response to any node, which routes it
+		// back to this one through the request ID.
+		id, err := randomURLString(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate request ID: %w", err)
+		}
+		request.ID = routedRequestIDPrefix + id
+	} else if request.ID == nil {
+		// Generate unique request ID if not provided.
 		request.ID = h.responseManager.GenerateRequestID()
 	}
 
@@ -750,24 +1226,43 @@ func (h *httpServerHandler) SendRequest(ctx context.Context, sessionID string, r
 	responseChan := h.responseManager.RegisterRequest(requestIDStr)
 	defer h.responseManager.UnregisterRequest(requestIDStr)
 
-	// Send the request through GET SSE using the proper sendRequest method.
-	conn.writeLock.Lock()
-	eventID, err := conn.sseResponder.sendRequest(conn.writer, request)
-	if err != nil {
-		conn.writeLock.Unlock()
-		return nil, fmt.Errorf("failed to send request via SSE: %w", err)
+	if h.sessionPubSub != nil {
+		if err := h.sessionPubSub.Subscribe(ctx, requestIDStr, h.handleRoutedResponse); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to the response: %w", err)
+		}
+		defer func() {
+			if err := h.sessionPubSub.Unsubscribe(context.Background(), requestIDStr); err != nil {
+				h.logger.Errorf("Failed to unsubscribe from request %s: %v", requestIDStr, err)
+			}
+		}()
+	}
+
+	send := func(ctx context.Context, message interface{}) error {
+		if stream == nil {
+			// The stream may be held by another node.
+			return h.routeMessage(ctx, sessionID, message)
+		}
+		if _, err := stream.sendMessage(ctx, message); err != nil {
+			return fmt.Errorf("failed to send message via SSE: %w", err)
+		}
+		return nil
+	}
+	if request.JSONRPC == "" {
+		request.JSONRPC = JSONRPCVersion
+	}
+	if err := send(ctx, request); err != nil {
+		return nil, err
 	}
-	conn.lastEventID = eventID
-	conn.writeLock.Unlock()
 
-	// Wait for response or timeout.
 	select {
 	case <-ctx.Done():
+		// The client stops handling the abandoned request.
+		sendCancelledNotification(ctx, request.ID, func(ctx context.Context, notification *JSONRPCNotification) error {
+			return send(ctx, notification)
+		})
 		return nil, ctx.Err()
 	case response := <-responseChan:
 		return response, nil
-	case <-time.After(30 * time.Second):
-		return nil, fmt.Errorf("request timeout")
 	}
 }
 
@@ -779,6 +1274,70 @@ func (h *httpServerHandler) getActiveSessions() []string {
 	return h.sessionManager.getActiveSessions()
 }
 
+// closeSession releases the resources of a session removed from the session
+// manager, and calls the OnSessionClosed hook.
+func (h *httpServerHandler) closeSession(ctx context.Context, session Session, reason SessionCloseReason) {
+	sessionID := session.GetID()
+	h.logger.Infof("Closing session %s: %s", sessionID, reason)
+
+	// Clean up GET SSE connections
+	h.cleanupSession(sessionID)
+	h.deleteSessionStreams(sessionID)
+
+	// Let the request handler release the session's state
+	if notifier, ok := h.requestHandler.(sessionEventNotifier); ok {
+		notifier.onSessionTerminated(sessionID)
+	}
+
+	h.sessionHooks.sessionClosed(ctx, session, reason)
+}
+
+// shutdown sends a close event on the open SSE streams and ends them, then
+// closes the sessions with SessionCloseReasonShutdown. The sessions of a
+// session store outlive this server, so only the GET SSE connections this
+// server holds are released, and the expired sessions are no longer swept.
+func (h *httpServerHandler) shutdown(ctx context.Context) {
+	h.shuttingDown.Store(true)
+
+	h.openStreamsLock.Lock()
+	streams := make([]*eventStream, 0, len(h.openStreams))
+	for stream := range h.openStreams {
+		streams = append(streams, stream)
+	}
+	h.openStreamsLock.Unlock()
+	for _, stream := range streams {
+		stream.closeWithEvent()
+		h.forgetEventStream(stream)
+	}
+
+	store, shared := h.sessionManager.(*storeSessionManager)
+	if shared {
+		store.stop()
+	}
+
+	if !h.enableSession || h.isStateless {
+		return
+	}
+	if shared {
+		h.getSSEConnectionsLock.RLock()
+		sessionIDs := make([]string, 0, len(h.getSSEConnections))
+		for sessionID := range h.getSSEConnections {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+		h.getSSEConnectionsLock.RUnlock()
+		for _, sessionID := range sessionIDs {
+			h.cleanupSession(sessionID)
+		}
+		return
+	}
+	for _, sessionID := range h.sessionManager.getActiveSessions() {
+		session, ok := h.sessionManager.getSession(sessionID)
+		if ok && h.sessionManager.terminateSession(sessionID) {
+			h.closeSession(icontext.WithoutCancel(ctx), session, SessionCloseReasonShutdown)
+		}
+	}
+}
+
 // Clean up resources when session terminates
 func (h *httpServerHandler) cleanupSession(sessionID string) {
 	// close GET SSE connection
@@ -786,10 +1345,51 @@ func (h *httpServerHandler) cleanupSession(sessionID string) {
 	if conn, exists := h.getSSEConnections[sessionID]; exists {
 		conn.cancelFunc()
 		delete(h.getSSEConnections, sessionID)
+		h.closeEventStream(conn.stream)
+		h.unsubscribeSession(sessionID)
 	}
 	h.getSSEConnectionsLock.Unlock()
 }
 
+// routeMessage publishes a message for the client of a session whose GET SSE
+// stream is not held by this node, to the node holding it.
+func (h *httpServerHandler) routeMessage(ctx context.Context, sessionID string, message interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	if err := h.sessionPubSub.Publish(ctx, sessionID, payload); err != nil {
+		return fmt.Errorf("failed to route message to session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// handleRoutedMessage is the SessionPubSub callback of the node holding the GET
+// SSE stream of a session, writing the messages other nodes route to it.
+func (h *httpServerHandler) handleRoutedMessage(ctx context.Context, sessionID string, payload []byte) error {
+	h.getSSEConnectionsLock.RLock()
+	conn, ok := h.getSSEConnections[sessionID]
+	h.getSSEConnectionsLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if _, err := conn.stream.send(ctx, payload); err != nil {
+		return fmt.Errorf("failed to send routed message via SSE: %w", err)
+	}
+	return nil
+}
+
+// unsubscribeSession stops receiving the messages other nodes route to the
+// session, once this node no longer holds its GET SSE stream.
+func (h *httpServerHandler) unsubscribeSession(sessionID string) {
+	if h.sessionPubSub == nil {
+		return
+	}
+	if err := h.sessionPubSub.Unsubscribe(context.Background(), sessionID); err != nil {
+		h.logger.Errorf("Failed to unsubscribe from session %s: %v", sessionID, err)
+	}
+}
+
 // isValidPath validates if the request path matches the configured server path.
 func (h *httpServerHandler) isValidPath(requestPath string) bool {
 	if h.serverPath == "" {
@@ -0,0 +1,233 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/sseutil"
+)
+
+// errEventStreamDisconnected is returned when a message is sent on a stream
+// whose client has disconnected and which cannot be resumed.
+var errEventStreamDisconnected = errors.New("SSE stream disconnected")
+
+// Kinds of streams, part of the stream IDs.
+const (
+	eventStreamKindGet  = "get"
+	eventStreamKindPost = "post"
+)
+
+// eventStreamCloseEvent names the event sent on the open streams when the
+// server shuts down. It has no ID and no data, so clients resuming or parsing
+// the stream ignore it.
+const eventStreamCloseEvent = "close"
+
+// eventStream is an SSE stream of the streamable HTTP transport: the stream of a
+// POST request's response or the session's GET stream. With an event store,
+// every message is stored before it is written, and the stream outlives its
+// connection so that the client can resume it.
+type eventStream struct {
+	id string
+
+	// store records the messages of the stream, nil if it cannot be resumed.
+	store EventStore
+
+	// sseWriter writes events and generates their IDs when there is no store.
+	sseWriter *sseutil.Writer
+
+	// deliver hands the messages to an in-process client instead of writing
+	// them to a connection, nil for SSE streams.
+	deliver func(data []byte) error
+
+	mu sync.Mutex
+	// writer is the connection the stream is written to, nil while detached.
+	writer http.ResponseWriter
+	// attachment identifies the current connection.
+	attachment uint64
+	// done is closed when the stream ends.
+	done   chan struct{}
+	closed bool
+	// missed is whether a stored message was not written to a connection
+	// since the stream was last resumed.
+	missed bool
+}
+
+// newEventStream creates a stream without a connection.
+func newEventStream(id string, store EventStore) *eventStream {
+	return &eventStream{
+		id:        id,
+		store:     store,
+		sseWriter: sseutil.NewWriter(),
+		done:      make(chan struct{}),
+	}
+}
+
+// newEventStreamID builds the ID of a stream of the session. The session ID is
+// part of the stream ID so that a stream can only be resumed by its session.
+func newEventStreamID(sessionID, kind string, n int64) string {
+	return fmt.Sprintf("%s-%s-%d", sessionID, kind, n)
+}
+
+// isSessionEventStream reports whether the stream belongs to the session.
+func isSessionEventStream(streamID, sessionID string) bool {
+	return strings.HasPrefix(streamID, sessionID+"-")
+}
+
+// isPostEventStream reports whether the stream is the response stream of a POST request.
+func isPostEventStream(streamID, sessionID string) bool {
+	return strings.HasPrefix(streamID, sessionID+"-"+eventStreamKindPost+"-")
+}
+
+// sendMessage serializes a JSON-RPC message and sends it on the stream.
+func (s *eventStream) sendMessage(ctx context.Context, message interface{}) (string, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+	}
+	return s.send(ctx, data)
+}
+
+// send stores the message, if the stream can be resumed, and writes it to the
+// current connection. A message sent while no connection is attached, or whose
+// write fails, is delivered when the client resumes the stream.
+func (s *eventStream) send(ctx context.Context, data []byte) (string, error) {
+	if s.deliver != nil {
+		return "", s.deliverMessage(data)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var eventID string
+	if s.store != nil {
+		var err error
+		if eventID, err = s.store.StoreEvent(ctx, s.id, data); err != nil {
+			return "", fmt.Errorf("failed to store SSE event: %w", err)
+		}
+	} else {
+		eventID = s.sseWriter.GenerateEventID()
+	}
+
+	if s.writer == nil {
+		if s.store != nil {
+			s.missed = true
+			return eventID, nil
+		}
+		return "", errEventStreamDisconnected
+	}
+	if err := s.sseWriter.WriteEvent(s.writer, sseutil.Event{ID: eventID, Data: data}); err != nil {
+		if s.store != nil {
+			s.writer = nil
+			s.missed = true
+			return eventID, nil
+		}
+		return "", err
+	}
+	return eventID, nil
+}
+
+// deliverMessage hands a message to the in-process client of the stream. The
+// lock is not held while the client handles it, so that the client can call
+// back into the server.
+func (s *eventStream) deliverMessage(data []byte) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return errEventStreamDisconnected
+	}
+	return s.deliver(data)
+}
+
+// attach writes the stream to w from now on. The returned function detaches w,
+// unless another connection was attached in the meantime; it must be called
+// before the HTTP handler owning w returns.
+func (s *eventStream) attach(w http.ResponseWriter) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attachLocked(w)
+}
+
+// attachLocked attaches w, s.mu must be held.
+func (s *eventStream) attachLocked(w http.ResponseWriter) func() {
+	if s.closed {
+		return func() {}
+	}
+	s.attachment++
+	s.writer = w
+	attachment := s.attachment
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.attachment == attachment {
+			s.writer = nil
+		}
+	}
+}
+
+// resume replays the events following lastEventID to w and attaches w, so that
+// no message sent in between is lost.
+func (s *eventStream) resume(ctx context.Context, w http.ResponseWriter, lastEventID string) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.store.ReplayEventsAfter(ctx, lastEventID, func(eventID string, message []byte) error {
+		return s.sseWriter.WriteEvent(w, sseutil.Event{ID: eventID, Data: message})
+	}); err != nil {
+		return nil, err
+	}
+	s.missed = false
+	return s.attachLocked(w), nil
+}
+
+// lose records that the connection of the stream was lost, so that the last
+// messages written to it may not have reached the client.
+func (s *eventStream) lose() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.missed = true
+}
+
+// close ends the stream and detaches its connection. It reports whether every
+// message of the stream was written to a connection.
+func (s *eventStream) close() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	return !s.missed
+}
+
+// closeWithEvent sends a close event to the current connection, if any, and
+// ends the stream.
+func (s *eventStream) closeWithEvent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if s.writer != nil {
+		_ = s.sseWriter.WriteEvent(s.writer, sseutil.Event{Event: eventStreamCloseEvent})
+	}
+	s.closeLocked()
+}
+
+// closeLocked ends the stream, s.mu must be held.
+func (s *eventStream) closeLocked() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.writer = nil
+	close(s.done)
+}
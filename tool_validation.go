@@ -0,0 +1,334 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ToolInputValidation selects whether and how the server validates the
+// arguments of tools/call requests against the input schema of the tool,
+// before the tool handler runs.
+type ToolInputValidation int
+
+const (
+	// ToolInputValidationDisabled passes the arguments to the tool handler
+	// without validating them. This is the default.
+	ToolInputValidationDisabled ToolInputValidation = iota
+
+	// ToolInputValidationResult reports invalid arguments as a tool result
+	// with isError set, so that the model calling the tool can correct them.
+	ToolInputValidationResult
+
+	// ToolInputValidationError reports invalid arguments as a JSON-RPC error
+	// with code -32602 (invalid params).
+	ToolInputValidationError
+)
+
+// ToolOutputValidation selects whether and how the server validates the
+// structured content returned by a tool against the output schema of the tool.
+type ToolOutputValidation int
+
+const (
+	// ToolOutputValidationDisabled returns the results of tools without
+	// validating them. This is the default.
+	ToolOutputValidationDisabled ToolOutputValidation = iota
+
+	// ToolOutputValidationLog logs the results that do not conform to the
+	// output schema, and returns them unchanged. It suits production.
+	ToolOutputValidationLog
+
+	// ToolOutputValidationStrict replaces the results that do not conform to
+	// the output schema with an internal error, to fail fast in development.
+	ToolOutputValidationStrict
+)
+
+// SchemaViolation describes a value that does not conform to a schema.
+type SchemaViolation struct {
+	// JSON pointer to the value, relative to the validated document. It is
+	// empty when the document itself is invalid.
+	Pointer string `json:"pointer"`
+
+	// Reason the value is invalid
+	Message string `json:"message"`
+}
+
+// SchemaValidationError reports the violations found validating tool
+// arguments or structured content against a schema. It is sent in the
+// structured content of an isError result, or in the data of a JSON-RPC
+// error.
+type SchemaValidationError struct {
+	Violations []SchemaViolation `json:"violations"`
+}
+
+// Error implements the error interface.
+func (e *SchemaValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.String())
+	}
+	return "schema validation failed: " + strings.Join(violations, "; ")
+}
+
+// String formats the violation as its pointer followed by its message.
+func (v SchemaViolation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// compiledSchema is a schema prepared for validation, compiled on first use.
+// The zero value is ready to use.
+type compiledSchema struct {
+	once   sync.Once
+	schema *openapi3.Schema
+}
+
+// get returns the compiled form of schema, which must be the same schema on
+// every call. It returns nil if schema is nil.
+func (c *compiledSchema) get(schema *openapi3.Schema) *openapi3.Schema {
+	c.once.Do(func() {
+		c.schema = compileSchema(schema)
+	})
+	return c.schema
+}
+
+// validateToolArguments validates the arguments of a tool call against the
+// input schema of the tool, and returns the violations found.
+func validateToolArguments(tool *registeredTool, arguments map[string]interface{}) []SchemaViolation {
+	schema := tool.inputSchema.get(tool.Tool.InputSchema)
+	if schema == nil {
+		return nil
+	}
+	// Missing arguments are validated as an empty object, so that required
+	// arguments are reported.
+	var value interface{} = arguments
+	if arguments == nil {
+		value = map[string]interface{}{}
+	}
+	return schemaViolations(schema.VisitJSON(value, openapi3.MultiErrors()))
+}
+
+// validateToolResult validates the structured content of a successful tool
+// result against the output schema of the tool, and returns the violations
+// found.
+func validateToolResult(tool *registeredTool, result *CallToolResult) []SchemaViolation {
+	schema := tool.outputSchema.get(tool.Tool.OutputSchema)
+	if schema == nil || result == nil || result.IsError {
+		return nil
+	}
+	return validateStructuredContent(schema, result.StructuredContent)
+}
+
+// validateStructuredContent validates structured content against a compiled
+// output schema. The content is validated in its JSON form, as it is sent.
+func validateStructuredContent(schema *openapi3.Schema, content interface{}) []SchemaViolation {
+	if content == nil {
+		return []SchemaViolation{{Message: "structured content is missing"}}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return []SchemaViolation{{Message: fmt.Sprintf("structured content cannot be encoded: %v", err)}}
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []SchemaViolation{{Message: fmt.Sprintf("structured content cannot be decoded: %v", err)}}
+	}
+	return schemaViolations(schema.VisitJSON(value, openapi3.MultiErrors()))
+}
+
+// schemaViolations flattens a validation error of kin-openapi into the
+// violations it reports.
+func schemaViolations(err error) []SchemaViolation {
+	if err == nil {
+		return nil
+	}
+	var multiErr openapi3.MultiError
+	if errors.As(err, &multiErr) {
+		var violations []SchemaViolation
+		for _, e := range multiErr {
+			violations = append(violations, schemaViolations(e)...)
+		}
+		return violations
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		return []SchemaViolation{{
+			Pointer: jsonPointer(schemaErr.JSONPointer()),
+			Message: schemaErr.Reason,
+		}}
+	}
+	return []SchemaViolation{{Message: err.Error()}}
+}
+
+// jsonPointer formats the tokens of a JSON pointer as defined by RFC 6901.
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// newToolArgumentsErrorResult creates the isError result reporting the
+// invalid arguments of a tool call.
+func newToolArgumentsErrorResult(toolName string, violations []SchemaViolation) *CallToolResult {
+	lines := make([]string, 0, len(violations)+1)
+	lines = append(lines, fmt.Sprintf("invalid arguments for tool %s:", toolName))
+	for _, v := range violations {
+		lines = append(lines, v.String())
+	}
+	return &CallToolResult{
+		IsError:           true,
+		Content:           []Content{NewTextContent(strings.Join(lines, "\n"))},
+		StructuredContent: &SchemaValidationError{Violations: violations},
+	}
+}
+
+// compileSchema prepares a schema for validation. kin-openapi only follows
+// references it resolved while loading a document, so the $ref of schemas
+// generated by WithInputStruct, or decoded from JSON, are resolved here
+// against the schema itself. A reference that cannot be resolved accepts
+// any value. The schema passed in is not modified.
+func compileSchema(schema *openapi3.Schema) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return &openapi3.Schema{}
+	}
+	c := &schemaCompiler{refs: make(map[string]*openapi3.Schema), linked: make(map[*openapi3.Schema]bool)}
+	if err := json.Unmarshal(data, &c.doc); err != nil {
+		return &openapi3.Schema{}
+	}
+	root := &openapi3.Schema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return &openapi3.Schema{}
+	}
+	if ref, ok := root.Extensions["$ref"].(string); ok {
+		return c.resolve(ref)
+	}
+	c.link(root)
+	return root
+}
+
+// schemaCompiler resolves the references of a schema decoded from JSON.
+type schemaCompiler struct {
+	doc    interface{}                 // Decoded JSON of the root schema, that references point into.
+	refs   map[string]*openapi3.Schema // Schemas resolved by reference.
+	linked map[*openapi3.Schema]bool   // Schemas whose references are resolved.
+}
+
+// resolve returns the schema a reference points to.
+func (c *schemaCompiler) resolve(ref string) *openapi3.Schema {
+	if schema, ok := c.refs[ref]; ok {
+		return schema
+	}
+	schema := &openapi3.Schema{}
+	c.refs[ref] = schema
+	node, ok := lookupJSONPointer(c.doc, ref)
+	if !ok {
+		return schema
+	}
+	data, err := json.Marshal(node)
+	if err != nil || json.Unmarshal(data, schema) != nil {
+		*schema = openapi3.Schema{}
+		return schema
+	}
+	if next, ok := schema.Extensions["$ref"].(string); ok {
+		*schema = openapi3.Schema{}
+		if target := c.resolve(next); target != schema {
+			c.refs[ref] = target
+			return target
+		}
+		return schema
+	}
+	c.link(schema)
+	return schema
+}
+
+// link resolves the references of the subschemas of a schema.
+func (c *schemaCompiler) link(schema *openapi3.Schema) {
+	if schema == nil || c.linked[schema] {
+		return
+	}
+	c.linked[schema] = true
+	for _, ref := range schema.Properties {
+		c.linkRef(ref)
+	}
+	c.linkRef(schema.Items)
+	c.linkRef(schema.AdditionalProperties.Schema)
+	c.linkRef(schema.Not)
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for _, ref := range refs {
+			c.linkRef(ref)
+		}
+	}
+}
+
+// linkRef resolves a subschema that is a reference, or the references of
+// its own subschemas.
+func (c *schemaCompiler) linkRef(ref *openapi3.SchemaRef) {
+	if ref == nil {
+		return
+	}
+	if ref.Ref != "" {
+		ref.Value = c.resolve(ref.Ref)
+		return
+	}
+	if ref.Value == nil {
+		ref.Value = &openapi3.Schema{}
+		return
+	}
+	c.link(ref.Value)
+}
+
+// lookupJSONPointer returns the value a local reference such as
+// "#/$defs/Item" points to in a decoded JSON document.
+func lookupJSONPointer(doc interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	node := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, false
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
@@ -0,0 +1,278 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validationAddress struct {
+	City string `json:"city" jsonschema:"required,minLength=2"`
+}
+
+type validationPerson struct {
+	Name    string             `json:"name" jsonschema:"required,minLength=2"`
+	Age     int                `json:"age,omitempty" jsonschema:"minimum=0,maximum=150"`
+	Role    string             `json:"role,omitempty" jsonschema:"enum=admin,enum=user"`
+	Home    validationAddress  `json:"home"`
+	Work    *validationAddress `json:"work,omitempty"`
+	Friends []validationPerson `json:"friends,omitempty"`
+}
+
+func newValidatedTool(t *testing.T, validation ToolInputValidation) *Client {
+	t.Helper()
+	server, url := newSessionRegistryServer(t, WithToolInputValidation(validation))
+	server.RegisterTool(NewTool("book",
+		WithString("name", Required(), MinLength(3), Pattern("^[a-z]+$")),
+		WithString("unit", Enum("celsius", "fahrenheit")),
+		WithNumber("nights", Min(1), Max(30)),
+		WithArray("guests", Items(openapi3.NewStringSchema()), MinItems(1)),
+	), textTool("booked"))
+	return newInitializedClient(t, url)
+}
+
+func callToolWithArguments(client *Client, name string, arguments map[string]interface{}) (*CallToolResult, error) {
+	req := &CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = arguments
+	return client.CallTool(context.Background(), req)
+}
+
+func violationPointers(t *testing.T, violations []SchemaViolation) []string {
+	t.Helper()
+	pointers := make([]string, 0, len(violations))
+	for _, v := range violations {
+		assert.NotEmpty(t, v.Message)
+		pointers = append(pointers, v.Pointer)
+	}
+	return pointers
+}
+
+func TestServer_ToolInputValidationResult(t *testing.T) {
+	client := newValidatedTool(t, ToolInputValidationResult)
+
+	result, err := callToolWithArguments(client, "book", map[string]interface{}{
+		"name":   "Al",
+		"unit":   "kelvin",
+		"nights": 31,
+		"guests": []interface{}{},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(TextContent).Text, "/unit")
+
+	data, err := json.Marshal(result.StructuredContent)
+	require.NoError(t, err)
+	var reported SchemaValidationError
+	require.NoError(t, json.Unmarshal(data, &reported))
+	assert.ElementsMatch(t, []string{"/name", "/name", "/unit", "/nights", "/guests"},
+		violationPointers(t, reported.Violations))
+
+	result, err = callToolWithArguments(client, "book", nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(TextContent).Text, "name")
+
+	result, err = callToolWithArguments(client, "book", map[string]interface{}{"name": "alice", "nights": 2})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "booked", result.Content[0].(TextContent).Text)
+}
+
+func TestServer_ToolInputValidationError(t *testing.T) {
+	client := newValidatedTool(t, ToolInputValidationError)
+
+	_, err := callToolWithArguments(client, "book", map[string]interface{}{"name": "Al"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arguments for tool book")
+
+	result, err := callToolWithArguments(client, "book", map[string]interface{}{"name": "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "booked", result.Content[0].(TextContent).Text)
+}
+
+func TestServer_ToolInputValidationDisabled(t *testing.T) {
+	client := newValidatedTool(t, ToolInputValidationDisabled)
+
+	result, err := callToolWithArguments(client, "book", map[string]interface{}{"name": 1})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "booked", result.Content[0].(TextContent).Text)
+}
+
+func TestSSEServer_ToolInputValidation(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0", WithSSEToolInputValidation(ToolInputValidationError))
+	server.RegisterTool(NewTool("book", WithString("name", Required(), MinLength(3))), textTool("booked"))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	_, err = callToolWithArguments(client, "book", map[string]interface{}{"name": "Al"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arguments for tool book")
+
+	result, err := callToolWithArguments(client, "book", map[string]interface{}{"name": "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "booked", result.Content[0].(TextContent).Text)
+}
+
+func TestValidateToolArguments_InputStruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []SchemaOption
+	}{
+		{"nested ref style", nil},
+		{"ref style", []SchemaOption{WithRefStyle()}},
+		{"inline style", []SchemaOption{WithInlineStyle()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &registeredTool{Tool: NewTool("person", WithInputStruct[validationPerson](tt.options...))}
+
+			valid := map[string]interface{}{
+				"name": "alice",
+				"home": map[string]interface{}{"city": "Paris"},
+				"friends": []interface{}{
+					map[string]interface{}{"name": "bob", "home": map[string]interface{}{"city": "Rome"}},
+				},
+			}
+			assert.Empty(t, validateToolArguments(tool, valid))
+
+			invalid := map[string]interface{}{
+				"age":  200.0,
+				"role": "guest",
+				"home": map[string]interface{}{"city": "P"},
+				"friends": []interface{}{
+					map[string]interface{}{"name": "b", "home": map[string]interface{}{"city": "Rome"}},
+				},
+			}
+			pointers := violationPointers(t, validateToolArguments(tool, invalid))
+			assert.Subset(t, pointers, []string{"/name", "/age", "/role", "/home/city", "/friends/0/name"})
+		})
+	}
+}
+
+func TestCompileSchema_ResolvesReferencesFromJSON(t *testing.T) {
+	var schema openapi3.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {"item": {"$ref": "#/$defs/Item"}, "missing": {"$ref": "#/$defs/Missing"}},
+		"$defs": {"Item": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}}
+	}`), &schema))
+
+	tool := &registeredTool{Tool: NewTool("json", WithInputSchema(&schema))}
+	violations := validateToolArguments(tool, map[string]interface{}{
+		"item":    map[string]interface{}{"id": "one"},
+		"missing": "anything",
+	})
+	assert.Equal(t, []string{"/item/id"}, violationPointers(t, violations))
+	assert.NotNil(t, schema.Properties["item"])
+	assert.Nil(t, schema.Properties["item"].Value, "the schema of the tool is not modified")
+}
+
+// registerAddressTool registers a tool with an output schema, returning the
+// structured content passed in its "content" argument.
+func registerAddressTool(server interface {
+	RegisterTool(tool *Tool, handler toolHandler)
+}, name string) {
+	server.RegisterTool(NewTool(name, WithOutputStruct[validationAddress]()),
+		func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+			content, ok := req.Params.Arguments["content"]
+			if !ok {
+				return NewTextResult("no structured content"), nil
+			}
+			if content == "error" {
+				return NewErrorResult("failed"), nil
+			}
+			return &CallToolResult{Content: []Content{NewTextContent("address")}, StructuredContent: content}, nil
+		})
+}
+
+func TestServer_ToolOutputValidation(t *testing.T) {
+	valid := map[string]interface{}{"content": map[string]interface{}{"city": "Paris"}}
+	invalid := map[string]interface{}{"content": map[string]interface{}{"city": 1}}
+
+	t.Run("strict", func(t *testing.T) {
+		server, url := newSessionRegistryServer(t, WithToolOutputValidation(ToolOutputValidationStrict))
+		registerAddressTool(server, "address")
+		client := newInitializedClient(t, url)
+
+		result, err := callToolWithArguments(client, "address", valid)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+
+		_, err = callToolWithArguments(client, "address", invalid)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not conform to its output schema")
+
+		_, err = callToolWithArguments(client, "address", nil)
+		assert.Error(t, err)
+
+		result, err = callToolWithArguments(client, "address", map[string]interface{}{"content": "error"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		server, url := newSessionRegistryServer(t, WithToolOutputValidation(ToolOutputValidationLog))
+		registerAddressTool(server, "address")
+		client := newInitializedClient(t, url)
+
+		result, err := callToolWithArguments(client, "address", invalid)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"city": float64(1)}, result.StructuredContent)
+	})
+}
+
+func TestSSEServer_ToolOutputValidation(t *testing.T) {
+	server := NewSSEServer("Test-Server", "1.0.0", WithSSEToolOutputValidation(ToolOutputValidationStrict))
+	registerAddressTool(server, "address")
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := NewSSEClient(httpServer.URL+"/sse", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+
+	result, err := callToolWithArguments(client, "address",
+		map[string]interface{}{"content": map[string]interface{}{"city": "Paris"}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	_, err = callToolWithArguments(client, "address",
+		map[string]interface{}{"content": map[string]interface{}{"city": 1}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not conform to its output schema")
+}
+
+func TestValidateToolResult(t *testing.T) {
+	tool := &registeredTool{Tool: NewTool("person", WithOutputStruct[validationPerson]())}
+
+	violations := validateToolResult(tool, &CallToolResult{
+		StructuredContent: validationPerson{Name: "a", Home: validationAddress{City: "Paris"}},
+	})
+	assert.Equal(t, []string{"/name"}, violationPointers(t, violations))
+	assert.Empty(t, validateToolResult(tool, &CallToolResult{
+		StructuredContent: validationPerson{Name: "alice", Home: validationAddress{City: "Paris"}},
+	}))
+	assert.Empty(t, validateToolResult(tool, NewErrorResult("failed")))
+	assert.Empty(t, validateToolResult(&registeredTool{Tool: NewTool("text")}, NewTextResult("text")))
+}
@@ -61,7 +61,9 @@ var (
 	// ErrInvalidContentType is returned when the content type is not supported
 	ErrInvalidContentType = errors.New("invalid content type")
 
-	// ErrSessionNotFound is returned when a requested session cannot be found
+	// ErrSessionNotFound is returned when a requested session cannot be found.
+	// Clients return it when the server no longer knows their session, for
+	// example because it expired, in which case they must be initialized again.
 	ErrSessionNotFound = errors.New("session not found")
 
 	// ErrInvalidSessionID is returned when a session ID is invalid
@@ -0,0 +1,423 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errInMemorySessionNotInitialized is returned when a message other than the
+// initialize request is sent before the session is initialized.
+var errInMemorySessionNotInitialized = errors.New("session not initialized")
+
+// errInMemoryTransportClosed is returned when a message is sent on a closed
+// transport.
+var errInMemoryTransportClosed = errors.New("transport is closed")
+
+// InMemoryTransport is a ClientTransport connecting a Client directly to a
+// Server in the same process, without HTTP or stdio. The server handles the
+// messages as it handles those of the streamable HTTP transport, with the
+// same sessions, session hooks and server-to-client requests, so it suits
+// unit tests and agents embedding a server. HTTP context functions and bearer
+// token authorization do not apply, as there is no HTTP request.
+//
+// A transport connects one client. Closing it terminates its session.
+//
+// Example usage:
+//
+//	server := mcp.NewServer("Embedded-Server", "1.0.0")
+//	client, err := mcp.NewClientWithTransport(mcp.NewInMemoryTransport(server),
+//	    mcp.Implementation{Name: "Embedded-Client", Version: "1.0.0"})
+type InMemoryTransport struct {
+	handler *httpServerHandler
+
+	mu            sync.Mutex
+	clientHandler ClientTransportHandler
+	// session is the session of the client, nil before initialization and
+	// in stateless mode.
+	session Session
+	// pendingSession is a session created before initialization, that the
+	// initialize request uses instead of creating one.
+	pendingSession Session
+	// onSessionClosed is called when the server closes the session of the
+	// client, as it expires or the server shuts down, nil if not needed.
+	onSessionClosed func()
+	// protocolVersion is the version negotiated on initialization, set on the
+	// temporary sessions of stateless mode.
+	protocolVersion string
+	closed          bool
+}
+
+// NewInMemoryTransport creates a transport connecting a client to server.
+func NewInMemoryTransport(server *Server) *InMemoryTransport {
+	return newInMemoryTransport(server.httpHandler)
+}
+
+// newInMemoryTransport creates a transport connecting a client to the
+// sessions of h.
+func newInMemoryTransport(h *httpServerHandler) *InMemoryTransport {
+	return &InMemoryTransport{handler: h}
+}
+
+// Start implements ClientTransport.
+func (t *InMemoryTransport) Start(ctx context.Context, handler ClientTransportHandler) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return errInMemoryTransportClosed
+	}
+	t.clientHandler = handler
+	return nil
+}
+
+// SendRequest implements ClientTransport.
+func (t *InMemoryTransport) SendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	var request JSONRPCRequest
+	if err := roundTripJSON(req, &request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRequestSerialization, err)
+	}
+
+	h := t.handler
+	isInitialize := request.Method == MethodInitialize
+	var session, created Session
+	if isInitialize && !h.isStateless && h.enableSession {
+		if t.isClosed() {
+			return nil, errInMemoryTransportClosed
+		}
+		if h.shuttingDown.Load() {
+			return nil, errors.New("server is shutting down")
+		}
+		if session = t.takePendingSession(); session == nil {
+			var err error
+			if session, err = h.sessionManager.createSession(); err != nil {
+				return nil, fmt.Errorf("failed to create session: %w", err)
+			}
+			h.logger.Infof("Created new session ID: %s for in-memory initialize request", session.GetID())
+			h.sessionHooks.sessionCreated(ctx, session)
+		}
+		created = session
+	} else {
+		var err error
+		if session, err = t.currentSession(); err != nil {
+			return nil, err
+		}
+	}
+
+	clientHandler := t.getClientHandler()
+	reqCtx := withNotificationSender(ctx, newStreamNotificationSender(session,
+		func(notification *JSONRPCNotification) error {
+			if clientHandler != nil {
+				clientHandler.HandleNotification(ctx, notification)
+			}
+			return nil
+		}))
+	if session != nil {
+		reqCtx = setSessionToContext(reqCtx, session)
+	}
+	resp, err := h.requestHandler.handleRequest(reqCtx, &request, session)
+	if errors.Is(err, errRequestCancelled) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	var message JSONRPCMessage
+	if err != nil {
+		message = newJSONRPCErrorResponse(request.ID, ErrCodeInternal, err.Error(), nil)
+	} else if errorResp, ok := resp.(*JSONRPCError); ok {
+		message = errorResp
+	} else {
+		message = JSONRPCResponse{JSONRPC: JSONRPCVersion, ID: request.ID, Result: resp}
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+	}
+	rawMessage := json.RawMessage(data)
+	if isErrorResponse(&rawMessage) {
+		return &rawMessage, nil
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+	}
+	if isInitialize {
+		t.initialized(created, response.Result)
+	}
+	return &response.Result, nil
+}
+
+// SendNotification implements ClientTransport.
+func (t *InMemoryTransport) SendNotification(ctx context.Context, notification *JSONRPCNotification) error {
+	var n JSONRPCNotification
+	if err := roundTripJSON(notification, &n); err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestSerialization, err)
+	}
+
+	h := t.handler
+	if n.Method == MethodNotificationsInitialized && h.isStateless {
+		// Temporary sessions are never initialized.
+		return nil
+	}
+	session, err := t.currentSession()
+	if err != nil {
+		return err
+	}
+	notificationCtx := ctx
+	if session != nil {
+		notificationCtx = setSessionToContext(ctx, session)
+		notificationCtx = withClientSession(notificationCtx, session)
+	}
+	return h.requestHandler.handleNotification(notificationCtx, &n, session)
+}
+
+// SendResponse implements ClientTransport.
+func (t *InMemoryTransport) SendResponse(ctx context.Context, resp *JSONRPCResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestSerialization, err)
+	}
+	session, err := t.currentSession()
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errInMemorySessionNotInitialized
+	}
+	t.handler.deliverClientResponse(session.GetID(), data)
+	return nil
+}
+
+// SessionID implements ClientSessionTransport.
+func (t *InMemoryTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.session == nil {
+		return ""
+	}
+	return t.session.GetID()
+}
+
+// TerminateSession implements ClientSessionTransport.
+func (t *InMemoryTransport) TerminateSession(ctx context.Context) error {
+	t.mu.Lock()
+	session := t.session
+	t.session = nil
+	t.mu.Unlock()
+	if session == nil {
+		return errors.New("no active session")
+	}
+	if !t.handler.sessionManager.terminateSession(session.GetID()) {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, session.GetID())
+	}
+	t.handler.closeSession(ctx, session, SessionCloseReasonDeleted)
+	return nil
+}
+
+// Close implements ClientTransport. It terminates the session of the client,
+// as the client cannot use it any more.
+func (t *InMemoryTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	hasSession := t.session != nil
+	pending := t.pendingSession
+	t.pendingSession = nil
+	t.mu.Unlock()
+
+	if pending != nil && t.handler.sessionManager.terminateSession(pending.GetID()) {
+		t.handler.closeSession(context.Background(), pending, SessionCloseReasonDeleted)
+	}
+	if hasSession {
+		if err := t.TerminateSession(context.Background()); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentSession returns the session messages are handled in: the session of
+// the client, a temporary session in stateless mode, or nil if sessions are
+// disabled.
+func (t *InMemoryTransport) currentSession() (Session, error) {
+	h := t.handler
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errInMemoryTransportClosed
+	}
+	if h.isStateless {
+		session := newSession()
+		if t.protocolVersion != "" {
+			session.SetData(protocolVersionKey, t.protocolVersion)
+		}
+		return session, nil
+	}
+	if !h.enableSession {
+		return nil, nil
+	}
+	if t.session == nil {
+		return nil, errInMemorySessionNotInitialized
+	}
+	session, ok := h.sessionManager.getSession(t.session.GetID())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, t.session.GetID())
+	}
+	return session, nil
+}
+
+// isClosed reports whether the transport is closed.
+func (t *InMemoryTransport) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// takePendingSession returns the session created before initialization, if
+// any, and forgets it.
+func (t *InMemoryTransport) takePendingSession() Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	session := t.pendingSession
+	t.pendingSession = nil
+	return session
+}
+
+// getClientHandler returns the handler of the messages sent to the client.
+func (t *InMemoryTransport) getClientHandler() ClientTransportHandler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.clientHandler
+}
+
+// initialized records the outcome of a successful initialize request. In
+// stateful mode, the session created for it becomes the session of the
+// client, and the messages the server sends on its own are delivered to the
+// client as over the GET SSE stream of the streamable HTTP transport, whether
+// or not GET SSE is enabled.
+func (t *InMemoryTransport) initialized(session Session, result json.RawMessage) {
+	var initResult InitializeResult
+	if err := json.Unmarshal(result, &initResult); err == nil {
+		t.mu.Lock()
+		t.protocolVersion = initResult.ProtocolVersion
+		t.mu.Unlock()
+	}
+	if session == nil {
+		return
+	}
+
+	t.mu.Lock()
+	previous := t.session
+	t.session = session
+	t.mu.Unlock()
+	if previous != nil && t.handler.sessionManager.terminateSession(previous.GetID()) {
+		t.handler.closeSession(context.Background(), previous, SessionCloseReasonDeleted)
+	}
+
+	t.openSessionStream(session)
+}
+
+// openSessionStream registers the stream delivering the messages the server
+// sends on its own in the session to the client.
+func (t *InMemoryTransport) openSessionStream(session Session) {
+	h := t.handler
+	stream := newEventStream("", nil)
+	stream.deliver = func(data []byte) error {
+		return t.receive(session.GetID(), data)
+	}
+	h.openStreamsLock.Lock()
+	h.openStreams[stream] = struct{}{}
+	h.openStreamsLock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.getSSEConnectionsLock.Lock()
+	if existingConn, exists := h.getSSEConnections[session.GetID()]; exists {
+		existingConn.cancelFunc()
+		h.closeEventStream(existingConn.stream)
+	}
+	h.getSSEConnections[session.GetID()] = &getSSEConnection{
+		ctx:        ctx,
+		cancelFunc: cancel,
+		stream:     stream,
+	}
+	h.getSSEConnectionsLock.Unlock()
+
+	if t.onSessionClosed != nil {
+		go func() {
+			<-ctx.Done()
+			// The session is no longer the session of the client when the
+			// client terminated it or initialized again.
+			if t.SessionID() == session.GetID() {
+				t.onSessionClosed()
+			}
+		}()
+	}
+}
+
+// receive hands a message the server sent on its own to the client. Requests
+// are handled in their own goroutine, and their response is delivered back to
+// the server.
+func (t *InMemoryTransport) receive(sessionID string, data []byte) error {
+	clientHandler := t.getClientHandler()
+	if clientHandler == nil {
+		return errors.New("transport not started")
+	}
+	messageType, err := parseJSONRPCMessageType(data)
+	if err != nil {
+		return err
+	}
+	switch messageType {
+	case JSONRPCMessageTypeNotification:
+		var notification JSONRPCNotification
+		if err := json.Unmarshal(data, &notification); err != nil {
+			return fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+		}
+		clientHandler.HandleNotification(context.Background(), &notification)
+		return nil
+	case JSONRPCMessageTypeRequest:
+		var req JSONRPCRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+		}
+		go func() {
+			response := clientHandler.HandleRequest(context.Background(), &req)
+			if response == nil {
+				return
+			}
+			resp, err := json.Marshal(response)
+			if err != nil {
+				t.handler.logger.Errorf("Failed to marshal response to request %v: %v", req.ID, err)
+				return
+			}
+			t.handler.deliverClientResponse(sessionID, resp)
+		}()
+		return nil
+	default:
+		return fmt.Errorf("unexpected message type: %s", messageType)
+	}
+}
+
+// roundTripJSON copies src into dst through its JSON encoding, so that the
+// server receives a message decoded as if it came over the wire.
+func roundTripJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,134 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRoots []Root
+
+func (r memoryRoots) GetRoots() []Root { return r }
+
+// newInMemoryClient creates a client connected to server in memory and
+// initializes it.
+func newInMemoryClient(t *testing.T, server *Server, options ...ClientOption) *Client {
+	t.Helper()
+	client, err := NewClientWithTransport(NewInMemoryTransport(server),
+		Implementation{Name: "Test-Client", Version: "1.0.0"}, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	return client
+}
+
+func TestInMemoryTransport_Tools(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+	client := newInMemoryClient(t, server)
+	assert.NotEmpty(t, client.GetSessionID())
+
+	tools, err := client.ListTools(context.Background(), &ListToolsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, tools.Tools, 2)
+
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+	_, err = callTool(client, "unknown")
+	assert.Error(t, err)
+
+	collector := &progressCollector{}
+	result := callProgressTool(t, client, collector)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+}
+
+func TestInMemoryTransport_ServerRequestsAndNotifications(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("roots"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		roots, err := server.ListRoots(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewTextResult(roots.Roots[0].URI), nil
+	})
+	client := newInMemoryClient(t, server)
+	client.SetRootsProvider(memoryRoots{{URI: "file:///workspace", Name: "workspace"}})
+
+	text, err := callTool(client, "roots")
+	require.NoError(t, err)
+	assert.Equal(t, "file:///workspace", text)
+
+	// The registration of the roots tool is notified too, so the tools may be
+	// listed before the session tool is registered. The results are checked
+	// here, as a refetch may still be running when the client is closed.
+	type refetch struct {
+		tools []Tool
+		err   error
+	}
+	refetched := make(chan refetch, 10)
+	client.SetToolListChangedHandler(func(tools []Tool, err error) {
+		refetched <- refetch{tools: tools, err: err}
+	}, true)
+	require.NoError(t, server.RegisterSessionTool(client.GetSessionID(), NewTool("unlocked"), textTool("unlocked")))
+	for tools := 0; tools < 2; {
+		select {
+		case result := <-refetched:
+			require.NoError(t, result.err)
+			tools = len(result.tools)
+		case <-time.After(5 * time.Second):
+			t.Fatal("tools list_changed not received")
+		}
+	}
+	require.NoError(t, client.Close())
+}
+
+func TestInMemoryTransport_SessionLifecycle(t *testing.T) {
+	closed := make(chan SessionCloseReason, 2)
+	server := NewServer("Test-Server", "1.0.0", WithSessionHooks(&SessionHooks{
+		OnSessionClosed: func(ctx context.Context, session Session, reason SessionCloseReason) {
+			closed <- reason
+		},
+	}))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	ctx := context.Background()
+
+	client := newInMemoryClient(t, server)
+	require.NoError(t, client.TerminateSession(ctx))
+	assert.Equal(t, SessionCloseReasonDeleted, <-closed)
+	assert.Empty(t, client.GetSessionID())
+	_, err := callTool(client, "echo")
+	assert.Error(t, err)
+
+	client = newInMemoryClient(t, server)
+	sessionID := client.GetSessionID()
+	require.NoError(t, client.Close())
+	assert.Equal(t, SessionCloseReasonDeleted, <-closed)
+	_, ok := server.httpHandler.sessionManager.getSession(sessionID)
+	assert.False(t, ok)
+	_, err = callTool(client, "echo")
+	assert.Error(t, err)
+}
+
+func TestInMemoryTransport_Stateless(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithStatelessMode(true))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	client := newInMemoryClient(t, server)
+	assert.Empty(t, client.GetSessionID())
+
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
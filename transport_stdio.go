@@ -10,6 +10,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +23,10 @@ import (
 	"trpc.group/trpc-go/trpc-mcp-go/internal/retry"
 )
 
+// errStdioProcessExited is returned for requests to a stdio MCP server process
+// that has exited, until the process is restarted.
+var errStdioProcessExited = errors.New("stdio server process exited")
+
 // StdioServerParameters defines parameters for launching a stdio MCP server.
 // This matches the industry standard used by MCP Python SDK, Cursor, and other clients.
 type StdioServerParameters struct {
@@ -49,6 +54,13 @@ type stdioClientTransport struct {
 	stderr  io.ReadCloser
 	done    chan error
 	pgid    int
+	// exited is closed when the current process exits.
+	exited chan struct{}
+	// processMu serializes starting and stopping the process.
+	processMu sync.Mutex
+	// exitHandler is called when the process exits on its own while the
+	// transport is open.
+	exitHandler func(err error)
 
 	encoder   *json.Encoder
 	decoder   *json.Decoder
@@ -120,6 +132,45 @@ func (t *stdioClientTransport) start(ctx context.Context) error {
 
 // startProcess starts the MCP server process.
 func (t *stdioClientTransport) startProcess() error {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+	return t.startProcessLocked()
+}
+
+// ensureProcess starts the MCP server process unless it is already started,
+// and returns the channel closed when the process exits. It fails if the
+// process has exited, until it is restarted.
+func (t *stdioClientTransport) ensureProcess() (<-chan struct{}, error) {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+	if t.process == nil {
+		if err := t.startProcessLocked(); err != nil {
+			return nil, err
+		}
+	}
+	select {
+	case <-t.exited:
+		return nil, errStdioProcessExited
+	default:
+		return t.exited, nil
+	}
+}
+
+// restartProcess stops the MCP server process, if any, and starts a new one.
+func (t *stdioClientTransport) restartProcess() error {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
+	if t.closed.Load() {
+		return fmt.Errorf("transport is closed")
+	}
+	if errs := t.stopProcessLocked(nil); len(errs) > 0 {
+		t.logger.Warnf("Error stopping current process: %v", errs)
+	}
+	return t.startProcessLocked()
+}
+
+// startProcessLocked starts the MCP server process. processMu must be held.
+func (t *stdioClientTransport) startProcessLocked() error {
 	if t.closed.Load() {
 		return fmt.Errorf("transport is closed")
 	}
@@ -174,16 +225,19 @@ func (t *stdioClientTransport) startProcess() error {
 	t.stdout = stdout
 	t.stderr = stderr
 	t.done = make(chan error, 1)
+	t.exited = make(chan struct{})
 	t.pgid = stdioProcessGroupID(cmd)
 
 	// Create JSON encoder/decoder.
+	t.requestMutex.Lock()
 	t.encoder = json.NewEncoder(stdin)
+	t.requestMutex.Unlock()
 	t.decoder = json.NewDecoder(stdout)
 
 	// Start background goroutines.
-	go t.readLoop()
-	go t.stderrLoop()
-	go t.processWatcher()
+	go t.readLoop(t.decoder)
+	go t.stderrLoop(stderr)
+	go t.processWatcher(cmd, t.done, t.exited)
 
 	t.logger.Infof("Started stdio process: %s %v (PID: %d)",
 		t.serverParams.Command, t.serverParams.Args, cmd.Process.Pid)
@@ -203,10 +257,9 @@ func (t *stdioClientTransport) sendRequest(ctx context.Context, req *JSONRPCRequ
 	}
 
 	// Start process if isn't started.
-	if t.process == nil {
-		if err := t.startProcess(); err != nil {
-			return nil, fmt.Errorf("failed to start process: %w", err)
-		}
+	exited, err := t.ensureProcess()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start process: %w", err)
 	}
 
 	// Generate request ID if not set.
@@ -223,17 +276,17 @@ func (t *stdioClientTransport) sendRequest(ctx context.Context, req *JSONRPCRequ
 	t.pendingRequests[reqID] = respChan
 	t.pendingMutex.Unlock()
 
-	// Clean up on exit.
+	// Clean up on exit. The buffered channel is not closed, so a response that
+	// races with an abandoned request cannot panic the read loop.
 	defer func() {
 		t.pendingMutex.Lock()
 		delete(t.pendingRequests, reqID)
 		t.pendingMutex.Unlock()
-		close(respChan)
 	}()
 
 	// Send request.
 	t.requestMutex.Lock()
-	err := t.encoder.Encode(req)
+	err = t.encoder.Encode(req)
 	t.requestMutex.Unlock()
 
 	if err != nil {
@@ -250,6 +303,8 @@ func (t *stdioClientTransport) sendRequest(ctx context.Context, req *JSONRPCRequ
 		return nil, fmt.Errorf("request timeout after %v", t.timeout)
 	case <-t.ctx.Done():
 		return nil, fmt.Errorf("transport closed")
+	case <-exited:
+		return nil, errStdioProcessExited
 	}
 }
 
@@ -260,10 +315,8 @@ func (t *stdioClientTransport) sendNotification(ctx context.Context, notificatio
 	}
 
 	// Start process if not started.
-	if t.process == nil {
-		if err := t.startProcess(); err != nil {
-			return fmt.Errorf("failed to start process: %w", err)
-		}
+	if _, err := t.ensureProcess(); err != nil {
+		return fmt.Errorf("failed to start process: %w", err)
 	}
 
 	t.requestMutex.Lock()
@@ -311,8 +364,8 @@ func (t *stdioClientTransport) sendResponse(ctx context.Context, resp *JSONRPCRe
 	return err
 }
 
-// readLoop continuously reads messages from stdout.
-func (t *stdioClientTransport) readLoop() {
+// readLoop continuously reads messages from the stdout of a process.
+func (t *stdioClientTransport) readLoop(decoder *json.Decoder) {
 	defer func() {
 		if r := recover(); r != nil {
 			t.logger.Errorf("readLoop panic: %v", r)
@@ -321,12 +374,12 @@ func (t *stdioClientTransport) readLoop() {
 
 	for !t.closed.Load() {
 		var rawMessage json.RawMessage
-		if err := t.decoder.Decode(&rawMessage); err != nil {
-			if err == io.EOF || t.closed.Load() {
-				break
+		if err := decoder.Decode(&rawMessage); err != nil {
+			// Decoding errors are sticky, so reading cannot go on.
+			if err != io.EOF && !errors.Is(err, os.ErrClosed) && !t.closed.Load() {
+				t.logger.Errorf("Error reading message: %v", err)
 			}
-			t.logger.Errorf("Error reading message: %v", err)
-			continue
+			break
 		}
 
 		// Parse message type.
@@ -456,6 +509,12 @@ func (t *stdioClientTransport) handleNotification(rawMessage json.RawMessage) {
 		return
 	}
 
+	// Progress is delivered synchronously so that it precedes the response.
+	if t.client != nil {
+		t.client.progress.dispatch(&notification)
+		t.client.dispatchCancelled(&notification)
+	}
+
 	t.handlersMutex.RLock()
 	handler, exists := t.notificationHandlers[notification.Method]
 	t.handlersMutex.RUnlock()
@@ -485,6 +544,12 @@ func (t *stdioClientTransport) handleIncomingRequest(rawMessage json.RawMessage)
 	switch request.Method {
 	case MethodRootsList:
 		t.handleRootsListRequest(&request)
+	case MethodSamplingCreateMessage:
+		// Sampling may take long, so it must not block the read loop.
+		go t.handleCreateMessageRequest(&request)
+	case MethodElicitationCreate:
+		// Elicitation waits for the user, so it must not block message processing.
+		go t.handleElicitRequest(&request)
 	default:
 		t.logger.Warnf("Client handleIncomingRequest: Unknown method: %s", request.Method)
 		// Send method not found error
@@ -530,29 +595,60 @@ func (t *stdioClientTransport) handleRootsListRequest(request *JSONRPCRequest) {
 	}
 }
 
-// sendErrorResponse sends an error response to the server.
-func (t *stdioClientTransport) sendErrorResponse(request *JSONRPCRequest, code int, message string) {
-	errorResp := newJSONRPCErrorResponse(request.ID, code, message, nil)
+// handleCreateMessageRequest handles sampling/createMessage requests from the server.
+func (t *stdioClientTransport) handleCreateMessageRequest(request *JSONRPCRequest) {
+	var handler SamplingHandler
+	if t.client != nil {
+		handler = t.client.getSamplingHandler()
+	}
 
-	// Convert JSONRPCError to bytes and send.
-	errorBytes, err := json.Marshal(errorResp)
-	if err != nil {
-		t.logger.Errorf("Failed to marshal error response: %v", err)
+	response := t.client.handleServerRequest(t.ctx, request, func(ctx context.Context) JSONRPCMessage {
+		return handleCreateMessageRequest(ctx, handler, request)
+	})
+	if response == nil {
 		return
 	}
-
-	if err := t.encoder.Encode(json.RawMessage(errorBytes)); err != nil {
-		t.logger.Errorf("Failed to send error response: %v", err)
+	if err := t.sendMessage(response); err != nil {
+		t.logger.Errorf("Client handleCreateMessageRequest: Failed to send sampling response: %v", err)
 	}
 }
 
-// stderrLoop reads and logs stderr output.
-func (t *stdioClientTransport) stderrLoop() {
-	if t.stderr == nil {
+// handleElicitRequest handles elicitation/create requests from the server.
+func (t *stdioClientTransport) handleElicitRequest(request *JSONRPCRequest) {
+	var handler ElicitationHandler
+	if t.client != nil {
+		handler = t.client.getElicitationHandler()
+	}
+
+	response := t.client.handleServerRequest(t.ctx, request, func(ctx context.Context) JSONRPCMessage {
+		return handleElicitRequest(ctx, handler, request)
+	})
+	if response == nil {
 		return
 	}
+	if err := t.sendMessage(response); err != nil {
+		t.logger.Errorf("Client handleElicitRequest: Failed to send elicitation response: %v", err)
+	}
+}
 
-	scanner := bufio.NewScanner(t.stderr)
+// sendErrorResponse sends an error response to the server.
+func (t *stdioClientTransport) sendErrorResponse(request *JSONRPCRequest, code int, message string) {
+	errorResp := newJSONRPCErrorResponse(request.ID, code, message, nil)
+	if err := t.sendMessage(errorResp); err != nil {
+		t.logger.Errorf("Failed to send error response: %v", err)
+	}
+}
+
+// sendMessage writes a JSON-RPC message to the server.
+func (t *stdioClientTransport) sendMessage(message interface{}) error {
+	t.requestMutex.Lock()
+	defer t.requestMutex.Unlock()
+	return t.encoder.Encode(message)
+}
+
+// stderrLoop reads and logs the stderr output of a process.
+func (t *stdioClientTransport) stderrLoop(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
 	for scanner.Scan() && !t.closed.Load() {
 		line := scanner.Text()
 		if line != "" {
@@ -561,25 +657,28 @@ func (t *stdioClientTransport) stderrLoop() {
 	}
 }
 
-// processWatcher monitors the process and handles unexpected exits.
-func (t *stdioClientTransport) processWatcher() {
-	if t.process == nil {
+// processWatcher monitors a process and handles unexpected exits.
+func (t *stdioClientTransport) processWatcher(cmd *exec.Cmd, done chan error, exited chan struct{}) {
+	err := cmd.Wait()
+	done <- err
+	close(done)
+	close(exited)
+
+	// A process stopped by close or restartProcess is no longer current once
+	// processMu is released.
+	t.processMu.Lock()
+	unexpected := t.process == cmd && !t.closed.Load()
+	t.processMu.Unlock()
+	if !unexpected {
 		return
 	}
-
-	err := t.process.Wait()
-	if t.done != nil {
-		t.done <- err
-		close(t.done)
+	if err != nil {
+		t.logger.Debugf("Process exited with error: %v", err)
+	} else {
+		t.logger.Debugf("Process exited normally")
 	}
-	if !t.closed.Load() {
-		if err != nil {
-			t.logger.Debugf("Process exited with error: %v", err)
-		} else {
-			t.logger.Debugf("Process exited normally")
-		}
-		// Cancel context to signal shutdown.
-		t.cancel()
+	if t.exitHandler != nil {
+		t.exitHandler(err)
 	}
 }
 
@@ -603,6 +702,29 @@ func (t *stdioClientTransport) close() error {
 		return nil // Already closed
 	}
 
+	t.processMu.Lock()
+	errs := t.stopProcessLocked(t.cancel)
+	t.processMu.Unlock()
+
+	// Close all pending request channels.
+	t.pendingMutex.Lock()
+	for reqID, ch := range t.pendingRequests {
+		close(ch)
+		delete(t.pendingRequests, reqID)
+	}
+	t.pendingMutex.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("close errors: %v", errs)
+	}
+
+	return nil
+}
+
+// stopProcessLocked terminates the MCP server process, if any. cancel, if
+// not nil, is called once the descendants of the process are known.
+// processMu must be held.
+func (t *stdioClientTransport) stopProcessLocked(cancel func()) []error {
 	var errs []error
 
 	var childPGIDs []int
@@ -611,23 +733,25 @@ func (t *stdioClientTransport) close() error {
 	}
 
 	// Cancel context first.
-	t.cancel()
+	if cancel != nil {
+		cancel()
+	}
 
-	// Close pipes
+	// Close pipes. Wait closes them once the process exited.
 	if t.stdin != nil {
-		if err := t.stdin.Close(); err != nil {
+		if err := t.stdin.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 			errs = append(errs, fmt.Errorf("failed to close stdin: %w", err))
 		}
 	}
 
 	if t.stdout != nil {
-		if err := t.stdout.Close(); err != nil {
+		if err := t.stdout.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 			errs = append(errs, fmt.Errorf("failed to close stdout: %w", err))
 		}
 	}
 
 	if t.stderr != nil {
-		if err := t.stderr.Close(); err != nil {
+		if err := t.stderr.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 			errs = append(errs, fmt.Errorf("failed to close stderr: %w", err))
 		}
 	}
@@ -665,20 +789,9 @@ func (t *stdioClientTransport) close() error {
 			}
 		}
 	}
+	t.process = nil
 
-	// Close all pending request channels.
-	t.pendingMutex.Lock()
-	for reqID, ch := range t.pendingRequests {
-		close(ch)
-		delete(t.pendingRequests, reqID)
-	}
-	t.pendingMutex.Unlock()
-
-	if len(errs) > 0 {
-		return fmt.Errorf("close errors: %v", errs)
-	}
-
-	return nil
+	return errs
 }
 
 // getSessionID returns the session ID (stdio doesn't use sessions typically).
@@ -698,6 +811,8 @@ func (t *stdioClientTransport) terminateSession(ctx context.Context) error {
 
 // getProcessID returns the process ID.
 func (t *stdioClientTransport) getProcessID() int {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
 	if t.process != nil && t.process.Process != nil {
 		return t.process.Process.Pid
 	}
@@ -713,6 +828,8 @@ func (t *stdioClientTransport) getCommandLine() []string {
 
 // isProcessRunning checks if the process is running.
 func (t *stdioClientTransport) isProcessRunning() bool {
+	t.processMu.Lock()
+	defer t.processMu.Unlock()
 	if t.process == nil || t.process.Process == nil {
 		return false
 	}
@@ -26,6 +26,11 @@ import (
 //   - Marshals the typed output to CallToolResult.StructuredContent
 //   - Handles binding errors gracefully
 //
+// Binding does not check the arguments against the input schema of the tool:
+// unknown fields are ignored and constraints are not enforced. Register the
+// tool on a server created with WithToolInputValidation to reject invalid
+// arguments before the handler runs.
+//
 // Example usage:
 //
 //	type WeatherInput struct {
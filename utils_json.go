@@ -50,6 +50,24 @@ func parseListResourcesResultFromJSON(rawMessage *json.RawMessage) (*ListResourc
 	return &result, nil
 }
 
+// parseListResourceTemplatesResultFromJSON parses a raw JSON message into a ListResourceTemplatesResult
+func parseListResourceTemplatesResultFromJSON(rawMessage *json.RawMessage) (*ListResourceTemplatesResult, error) {
+	var result ListResourceTemplatesResult
+	if err := json.Unmarshal(*rawMessage, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ListResourceTemplatesResult: %v", err)
+	}
+	return &result, nil
+}
+
+// parseCompleteResultFromJSON parses a raw JSON message into a CompleteResult
+func parseCompleteResultFromJSON(rawMessage *json.RawMessage) (*CompleteResult, error) {
+	var result CompleteResult
+	if err := json.Unmarshal(*rawMessage, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CompleteResult: %v", err)
+	}
+	return &result, nil
+}
+
 // parseReadResourceResultFromJSON parses a raw JSON message into a ReadResourceResult
 func parseReadResourceResultFromJSON(rawMessage *json.RawMessage) (*ReadResourceResult, error) {
 	// Parse JSON object using internal utility function.
@@ -115,6 +133,7 @@ func parseListToolsResultFromJSON(rawMessage *json.RawMessage) (*ListToolsResult
 
 				tool := Tool{
 					Name:            parsedItem.Name,
+					Title:           parsedItem.Title,
 					Description:     parsedItem.Description,
 					RawInputSchema:  parsedItem.RawInputSchema,
 					RawOutputSchema: parsedItem.RawOutputSchema,
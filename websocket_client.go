@@ -0,0 +1,338 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/websocket"
+)
+
+// errWebSocketClosed is returned when a message is sent or awaited on a
+// closed WebSocket connection.
+var errWebSocketClosed = errors.New("WebSocket connection closed")
+
+// NewWebSocketClient creates a new MCP client connected to a server over
+// WebSocket, such as the WebSocketHandler of a Server. The URL uses the ws or
+// wss scheme, or http or https. The connection is opened by the first request,
+// with the HTTP headers, HTTP client and token source of the options, and
+// holds one session: closing it closes the session.
+//
+// Example usage:
+//
+//	client, err := mcp.NewWebSocketClient("wss://mcp.example.com/mcp/ws",
+//	    mcp.Implementation{Name: "my-client", Version: "1.0.0"},
+//	    mcp.WithClientWebSocketPingInterval(15*time.Second))
+func NewWebSocketClient(serverURL string, clientInfo Implementation, options ...ClientOption) (*Client, error) {
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	switch parsedURL.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return nil, fmt.Errorf("invalid URL: unsupported scheme %q", parsedURL.Scheme)
+	}
+
+	config := extractTransportConfig(options)
+	if config.path != "" {
+		parsedURL.Path = config.path
+	}
+	return NewClientWithTransport(newWebSocketClientTransport(parsedURL, config), clientInfo, options...)
+}
+
+// webSocketClientTransport is the ClientTransport of a WebSocket client.
+type webSocketClientTransport struct {
+	url            *url.URL
+	httpClient     *http.Client
+	httpHeaders    http.Header
+	httpReqHandler HTTPReqHandler
+	pingInterval   time.Duration
+	maxMessageSize int64
+	logger         Logger
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	handler   ClientTransportHandler
+	sessionID string
+	closed    bool
+
+	pendingMu sync.Mutex
+	// pending holds the channels waiting for the responses to the requests
+	// sent to the server, by request ID.
+	pending map[string]chan *json.RawMessage
+}
+
+// newWebSocketClientTransport creates the transport of a WebSocket client.
+func newWebSocketClientTransport(serverURL *url.URL, config *transportConfig) *webSocketClientTransport {
+	reqHandler := config.httpReqHandler
+	if reqHandler == nil {
+		reqHandler = NewHTTPReqHandler(config.serviceName, config.httpReqHandlerOptions...)
+	}
+	return &webSocketClientTransport{
+		url:            serverURL,
+		httpClient:     config.httpClient,
+		httpHeaders:    config.httpHeaders,
+		httpReqHandler: newAuthHTTPReqHandler(reqHandler, config.tokenSource),
+		pingInterval:   config.webSocketPingInterval,
+		maxMessageSize: config.webSocketMaxMessageSize,
+		logger:         config.logger,
+		pending:        make(map[string]chan *json.RawMessage),
+	}
+}
+
+// Start implements ClientTransport, opening the connection. A connection
+// opened before is closed first, along with its session.
+func (t *webSocketClientTransport) Start(ctx context.Context, handler ClientTransportHandler) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return errWebSocketClosed
+	}
+	if t.conn != nil {
+		_ = t.conn.CloseWithReason(websocket.CloseNormalClosure, "")
+		t.conn = nil
+		t.sessionID = ""
+	}
+
+	subprotocols := []string{WebSocketSubprotocol}
+	req, key, err := websocket.NewClientRequest(ctx, t.url.String(), subprotocols)
+	if err != nil {
+		return err
+	}
+	for name, values := range t.httpHeaders {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	resp, err := t.httpReqHandler.Handle(ctx, t.httpClient, req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrHTTPRequestFailed, err)
+	}
+	conn, err := websocket.NewClientConn(resp, key, subprotocols)
+	if err != nil {
+		var handshakeErr *websocket.HandshakeError
+		if errors.As(err, &handshakeErr) && handshakeErr.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+		}
+		return err
+	}
+	conn.SetReadLimit(t.maxMessageSize)
+	conn.KeepAlive(t.pingInterval)
+
+	t.conn = conn
+	t.handler = handler
+	t.sessionID = resp.Header.Get(httputil.SessionIDHeader)
+	go t.readLoop(conn, handler)
+	return nil
+}
+
+// SendRequest implements ClientTransport.
+func (t *webSocketClientTransport) SendRequest(ctx context.Context, req *JSONRPCRequest) (*json.RawMessage, error) {
+	conn, err := t.getConn()
+	if err != nil {
+		return nil, err
+	}
+	if req.ID == nil {
+		return nil, errors.New("request has no ID")
+	}
+
+	id := fmt.Sprintf("%v", req.ID)
+	respChan := make(chan *json.RawMessage, 1)
+	t.pendingMu.Lock()
+	t.pending[id] = respChan
+	t.pendingMu.Unlock()
+	defer func() {
+		t.pendingMu.Lock()
+		delete(t.pending, id)
+		t.pendingMu.Unlock()
+	}()
+
+	if err := t.write(conn, req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-respChan:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-conn.Done():
+		return nil, errWebSocketClosed
+	}
+}
+
+// SendNotification implements ClientTransport.
+func (t *webSocketClientTransport) SendNotification(ctx context.Context, notification *JSONRPCNotification) error {
+	conn, err := t.getConn()
+	if err != nil {
+		return err
+	}
+	return t.write(conn, notification)
+}
+
+// SendResponse implements ClientTransport.
+func (t *webSocketClientTransport) SendResponse(ctx context.Context, resp *JSONRPCResponse) error {
+	conn, err := t.getConn()
+	if err != nil {
+		return err
+	}
+	return t.write(conn, resp)
+}
+
+// SessionID implements ClientSessionTransport.
+func (t *webSocketClientTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// TerminateSession implements ClientSessionTransport. The session ends with
+// the connection, so the connection is closed.
+func (t *webSocketClientTransport) TerminateSession(ctx context.Context) error {
+	t.mu.Lock()
+	hasSession := t.sessionID != ""
+	t.mu.Unlock()
+	if !hasSession {
+		return errors.New("no active session")
+	}
+	return t.Close()
+}
+
+// Close implements ClientTransport.
+func (t *webSocketClientTransport) Close() error {
+	t.mu.Lock()
+	conn := t.conn
+	t.closed = true
+	t.sessionID = ""
+	t.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.CloseWithReason(websocket.CloseNormalClosure, "")
+}
+
+// getConn returns the open connection.
+func (t *webSocketClientTransport) getConn() (*websocket.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || t.conn == nil {
+		return nil, errWebSocketClosed
+	}
+	return t.conn, nil
+}
+
+// write serializes a message and sends it on the connection.
+func (t *webSocketClientTransport) write(conn *websocket.Conn, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestSerialization, err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		if errors.Is(err, websocket.ErrClosed) {
+			return errWebSocketClosed
+		}
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// readLoop reads the messages of the server until the connection is closed.
+// Notifications are handled in order, requests in their own goroutine.
+func (t *webSocketClientTransport) readLoop(conn *websocket.Conn, handler ClientTransportHandler) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				t.logger.Debugf("WebSocket connection closed by the server: %v", err)
+			} else if !errors.Is(err, websocket.ErrClosed) {
+				t.logger.Errorf("Error reading WebSocket message: %v", err)
+			}
+			_ = conn.Close()
+			return
+		}
+
+		messageType, err := parseJSONRPCMessageType(data)
+		if err != nil {
+			t.logger.Errorf("Error parsing message type: %v", err)
+			continue
+		}
+		switch messageType {
+		case JSONRPCMessageTypeResponse:
+			var response struct {
+				ID     interface{}     `json:"id"`
+				Result json.RawMessage `json:"result"`
+			}
+			if err := json.Unmarshal(data, &response); err != nil {
+				t.logger.Errorf("Error unmarshaling response: %v", err)
+				continue
+			}
+			if response.Result == nil {
+				response.Result = json.RawMessage("{}")
+			}
+			t.deliverResponse(response.ID, &response.Result)
+		case JSONRPCMessageTypeError:
+			var response struct {
+				ID interface{} `json:"id"`
+			}
+			if err := json.Unmarshal(data, &response); err != nil {
+				t.logger.Errorf("Error unmarshaling error response: %v", err)
+				continue
+			}
+			rawMessage := json.RawMessage(data)
+			t.deliverResponse(response.ID, &rawMessage)
+		case JSONRPCMessageTypeNotification:
+			var notification JSONRPCNotification
+			if err := json.Unmarshal(data, &notification); err != nil {
+				t.logger.Errorf("Error unmarshaling notification: %v", err)
+				continue
+			}
+			handler.HandleNotification(context.Background(), &notification)
+		case JSONRPCMessageTypeRequest:
+			var req JSONRPCRequest
+			if err := json.Unmarshal(data, &req); err != nil {
+				t.logger.Errorf("Error unmarshaling request: %v", err)
+				continue
+			}
+			go func() {
+				response := handler.HandleRequest(context.Background(), &req)
+				if response == nil {
+					return
+				}
+				if err := t.write(conn, response); err != nil {
+					t.logger.Errorf("Failed to send response to request %v: %v", req.ID, err)
+				}
+			}()
+		default:
+			t.logger.Warnf("Unexpected message type: %s", messageType)
+		}
+	}
+}
+
+// deliverResponse hands a response to the request waiting for it.
+func (t *webSocketClientTransport) deliverResponse(id interface{}, message *json.RawMessage) {
+	key := fmt.Sprintf("%v", id)
+	t.pendingMu.Lock()
+	respChan, ok := t.pending[key]
+	t.pendingMu.Unlock()
+	if !ok {
+		t.logger.Debugf("Received response for unknown request ID: %s", key)
+		return
+	}
+	select {
+	case respChan <- message:
+	default:
+	}
+}
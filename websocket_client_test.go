@@ -0,0 +1,87 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webSocketHeaderKey struct{}
+
+func TestNewWebSocketClient_InvalidURL(t *testing.T) {
+	_, err := NewWebSocketClient("ftp://localhost/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	assert.Error(t, err)
+	_, err = NewWebSocketClient("://", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	assert.Error(t, err)
+}
+
+func TestWebSocketClient_HTTPHeaders(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+		return context.WithValue(ctx, webSocketHeaderKey{}, r.Header.Get("X-Tenant"))
+	}))
+	server.RegisterTool(NewTool("tenant"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		tenant, _ := ctx.Value(webSocketHeaderKey{}).(string)
+		return NewTextResult(tenant), nil
+	})
+	headers := http.Header{}
+	headers.Set("X-Tenant", "acme")
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server), WithHTTPHeaders(headers))
+
+	text, err := callTool(client, "tenant")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", text)
+}
+
+func TestWebSocketClient_Stateless(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithStatelessMode(true))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+	assert.Empty(t, client.GetSessionID())
+
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
+
+func TestWebSocketClient_ConnectionClosed(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("block"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		<-block
+		return NewTextResult("unblocked"), nil
+	})
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+
+	// A pending request fails once the session expires and the server closes
+	// the connection.
+	errs := make(chan error, 1)
+	go func() {
+		_, err := callTool(client, "block")
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	h := server.httpHandler
+	session, ok := h.sessionManager.getSession(client.GetSessionID())
+	require.True(t, ok)
+	require.True(t, h.sessionManager.terminateSession(session.GetID()))
+	h.closeSession(context.Background(), session, SessionCloseReasonExpired)
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending request not failed")
+	}
+	_, err := callTool(client, "block")
+	assert.Error(t, err)
+}
@@ -0,0 +1,355 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/websocket"
+)
+
+// WebSocketSubprotocol is the WebSocket subprotocol negotiated by the MCP
+// WebSocket transport. Every message is a JSON-RPC message sent as a text
+// message.
+const WebSocketSubprotocol = "mcp"
+
+const (
+	// defaultWebSocketPingInterval is the default time between the pings
+	// keeping a WebSocket connection alive.
+	defaultWebSocketPingInterval = 30 * time.Second
+
+	// defaultWebSocketMaxMessageSize is the default maximum size of a message
+	// read from a WebSocket connection, in bytes.
+	defaultWebSocketMaxMessageSize = websocket.DefaultReadLimit
+)
+
+// webSocketConfig configures the WebSocket transport of a server.
+type webSocketConfig struct {
+	// enabled serves upgrade requests on the server path.
+	enabled bool
+	// pingInterval is the time between pings, zero or less disables them.
+	pingInterval time.Duration
+	// maxMessageSize is the maximum size of a message read, zero or less for
+	// no limit.
+	maxMessageSize int64
+	// checkOrigin reports whether the origin of an upgrade request is allowed,
+	// nil allows the same host only.
+	checkOrigin func(r *http.Request) bool
+}
+
+// newDefaultWebSocketConfig creates the default WebSocket configuration.
+func newDefaultWebSocketConfig() webSocketConfig {
+	return webSocketConfig{
+		pingInterval:   defaultWebSocketPingInterval,
+		maxMessageSize: defaultWebSocketMaxMessageSize,
+	}
+}
+
+// WebSocketHandler returns the handler of the WebSocket transport, to mount
+// on a path of its own. Each connection is a session of the server: it is
+// created on upgrade, its ID is sent in the Mcp-Session-Id header of the
+// handshake response, and it is closed with the connection. Messages are
+// dispatched like those of the streamable HTTP transport, with the same
+// middlewares, HTTP context functions, applied to the upgrade request, and
+// authorization.
+//
+// Example usage:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/mcp", server.HTTPHandler())
+//	mux.Handle("/mcp/ws", server.WebSocketHandler())
+func (s *Server) WebSocketHandler() http.Handler {
+	h := s.httpHandler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.authorizer != nil {
+			if h.authorizer.serveMetadata(w, r) {
+				return
+			}
+			var ok bool
+			if r, ok = h.authorizer.authorize(w, r); !ok {
+				return
+			}
+		}
+		h.serveWebSocket(w, r)
+	})
+}
+
+// serveWebSocket upgrades a request to a WebSocket connection and serves it
+// until it is closed.
+func (h *httpServerHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+	for _, fn := range h.httpContextFuncs {
+		ctx = fn(ctx, r)
+	}
+
+	// The connection is served as an in-memory transport, whose client is
+	// the peer of the connection.
+	transport := newInMemoryTransport(h)
+	header := make(http.Header)
+	if !h.isStateless && h.enableSession {
+		session, err := h.sessionManager.createSession()
+		if err != nil {
+			h.logger.Errorf("Failed to create session: %v", err)
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
+		h.logger.Infof("Created new session ID: %s for WebSocket connection", session.GetID())
+		h.sessionHooks.sessionCreated(ctx, session)
+		transport.pendingSession = session
+		header.Set(httputil.SessionIDHeader, session.GetID())
+	}
+
+	conn, err := websocket.Upgrade(w, r, websocket.UpgradeOptions{
+		Subprotocols: []string{WebSocketSubprotocol},
+		CheckOrigin:  h.webSocket.checkOrigin,
+		Header:       header,
+	})
+	if err != nil {
+		h.logger.Infof("WebSocket upgrade failed: %v", err)
+		_ = transport.Close()
+		return
+	}
+	conn.SetReadLimit(h.webSocket.maxMessageSize)
+	conn.KeepAlive(h.webSocket.pingInterval)
+
+	transport.onSessionClosed = func() {
+		_ = conn.CloseWithReason(websocket.CloseGoingAway, "session closed")
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	c := &webSocketServerConn{
+		handler:   h,
+		conn:      conn,
+		transport: transport,
+		ctx:       connCtx,
+		pending:   make(map[string]chan JSONRPCMessage),
+	}
+	if err := transport.Start(connCtx, c); err != nil {
+		h.logger.Errorf("Failed to start WebSocket transport: %v", err)
+		cancel()
+		_ = conn.Close()
+		return
+	}
+	c.serve()
+	cancel()
+	if err := transport.Close(); err != nil {
+		h.logger.Errorf("Failed to close the session of WebSocket connection: %v", err)
+	}
+}
+
+// webSocketServerConn is a WebSocket connection of a client. It relays the
+// messages the server sends to the client, as the ClientTransportHandler of
+// the in-memory transport handling the messages of the client.
+type webSocketServerConn struct {
+	handler   *httpServerHandler
+	conn      *websocket.Conn
+	transport *InMemoryTransport
+	ctx       context.Context // Cancelled when the connection is closed.
+
+	mu sync.Mutex
+	// pending holds the channels waiting for the responses to the requests
+	// sent to the client, by request ID.
+	pending map[string]chan JSONRPCMessage
+}
+
+// serve reads the messages of the client until the connection is closed.
+func (c *webSocketServerConn) serve() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) && !errors.Is(err, websocket.ErrClosed) {
+				c.handler.logger.Debugf("WebSocket connection closed: %v", err)
+			}
+			_ = c.conn.Close()
+			return
+		}
+		c.handleMessage(data)
+	}
+}
+
+// isJSONRPCBatch reports whether the raw message is a JSON-RPC batch, i.e. an array of messages.
+func isJSONRPCBatch(rawMessage json.RawMessage) bool {
+	trimmed := bytes.TrimLeft(rawMessage, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleMessage handles a message of the client. Requests are handled in
+// their own goroutine, so that requests run concurrently as over HTTP.
+func (c *webSocketServerConn) handleMessage(data []byte) {
+	if isJSONRPCBatch(data) {
+		c.write(newJSONRPCErrorResponse(nil, ErrCodeInvalidRequest, "batches are not supported over WebSocket", nil))
+		return
+	}
+	messageType, err := parseJSONRPCMessageType(data)
+	if err != nil {
+		c.write(newJSONRPCErrorResponse(nil, ErrCodeParse, err.Error(), nil))
+		return
+	}
+
+	switch messageType {
+	case JSONRPCMessageTypeRequest:
+		var req JSONRPCRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.write(newJSONRPCErrorResponse(nil, ErrCodeInvalidRequest, err.Error(), nil))
+			return
+		}
+		go c.handleRequest(&req)
+	case JSONRPCMessageTypeNotification:
+		var notification JSONRPCNotification
+		if err := json.Unmarshal(data, &notification); err != nil {
+			c.handler.logger.Infof("Invalid notification on WebSocket connection: %v", err)
+			return
+		}
+		if err := c.transport.SendNotification(c.ctx, &notification); err != nil {
+			c.handler.logger.Infof("Notification processing failed: %v", err)
+		}
+	case JSONRPCMessageTypeResponse, JSONRPCMessageTypeError:
+		c.deliverResponse(data)
+	default:
+		c.write(newJSONRPCErrorResponse(nil, ErrCodeInvalidRequest, "Invalid JSON-RPC message", nil))
+	}
+}
+
+// handleRequest handles a request of the client and sends back the response.
+func (c *webSocketServerConn) handleRequest(req *JSONRPCRequest) {
+	rawResp, err := c.transport.SendRequest(c.ctx, req)
+	if err != nil {
+		if errors.Is(err, errRequestCancelled) || c.ctx.Err() != nil {
+			// The request was cancelled, so no response is sent.
+			return
+		}
+		c.write(newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil))
+		if errors.Is(err, ErrSessionNotFound) {
+			// The session expired, the client has to connect again.
+			_ = c.conn.CloseWithReason(websocket.ClosePolicyViolation, "session not found")
+		}
+		return
+	}
+	if isErrorResponse(rawResp) {
+		c.writeRaw(*rawResp)
+		return
+	}
+	c.write(JSONRPCResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: rawResp})
+}
+
+// HandleNotification implements ClientTransportHandler, sending a
+// notification of the server to the client.
+func (c *webSocketServerConn) HandleNotification(ctx context.Context, notification *JSONRPCNotification) {
+	c.write(notification)
+}
+
+// HandleRequest implements ClientTransportHandler, sending a request of the
+// server to the client and waiting for its response.
+func (c *webSocketServerConn) HandleRequest(ctx context.Context, req *JSONRPCRequest) JSONRPCMessage {
+	id, ok := requestIDKey(req.ID)
+	if !ok {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, fmt.Sprintf("invalid request ID: %v", req.ID), nil)
+	}
+	respChan := make(chan JSONRPCMessage, 1)
+	c.mu.Lock()
+	c.pending[id] = respChan
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.writeMessage(req); err != nil {
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, err.Error(), nil)
+	}
+	select {
+	case resp := <-respChan:
+		return resp
+	case <-ctx.Done():
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, ctx.Err().Error(), nil)
+	case <-c.conn.Done():
+		return newJSONRPCErrorResponse(req.ID, ErrCodeInternal, "WebSocket connection closed", nil)
+	}
+}
+
+// deliverResponse hands a response of the client to the request waiting for it.
+func (c *webSocketServerConn) deliverResponse(data []byte) {
+	var response struct {
+		ID interface{} `json:"id"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil {
+		c.handler.logger.Errorf("Invalid JSON-RPC response: %v", err)
+		return
+	}
+	id, ok := requestIDKey(response.ID)
+	if !ok {
+		c.handler.logger.Errorf("Invalid request ID in response: %v", response.ID)
+		return
+	}
+	c.mu.Lock()
+	respChan, ok := c.pending[id]
+	c.mu.Unlock()
+	if !ok {
+		c.handler.logger.Debugf("Received response for unknown request ID: %s", id)
+		return
+	}
+	select {
+	case respChan <- json.RawMessage(data):
+	default:
+	}
+}
+
+// requestIDKey returns the key of a request ID in pending, its JSON encoding,
+// so that a response answers a request only if their IDs have the same type
+// and value: the string ID "1" does not match the numeric ID 1. Only string
+// and numeric IDs have a key.
+func requestIDKey(id interface{}) (string, bool) {
+	switch id.(type) {
+	case string, json.Number, int, int64, uint64, float64:
+	default:
+		return "", false
+	}
+	data, err := json.Marshal(id)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// write sends a message to the client, logging failures.
+func (c *webSocketServerConn) write(message interface{}) {
+	if err := c.writeMessage(message); err != nil {
+		c.handler.logger.Debugf("Failed to send WebSocket message: %v", err)
+	}
+}
+
+// writeMessage serializes a message and sends it to the client.
+func (c *webSocketServerConn) writeMessage(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrResponseSerialization, err)
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// writeRaw sends a serialized message to the client, logging failures.
+func (c *webSocketServerConn) writeRaw(data []byte) {
+	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		c.handler.logger.Debugf("Failed to send WebSocket message: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Tencent is pleased to support the open source community by making trpc-mcp-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-mcp-go is licensed under the Apache License Version 2.0.
+
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-mcp-go/internal/httputil"
+	"trpc.group/trpc-go/trpc-mcp-go/internal/websocket"
+)
+
+// newWebSocketTestServer serves the WebSocket handler of server and returns its
+// ws URL.
+func newWebSocketTestServer(t *testing.T, server *Server) string {
+	t.Helper()
+	httpServer := httptest.NewServer(server.WebSocketHandler())
+	t.Cleanup(httpServer.Close)
+	return "ws" + strings.TrimPrefix(httpServer.URL, "http")
+}
+
+// newWebSocketTestClient creates a client connected to serverURL and
+// initializes it.
+func newWebSocketTestClient(t *testing.T, serverURL string, options ...ClientOption) *Client {
+	t.Helper()
+	client, err := NewWebSocketClient(serverURL, Implementation{Name: "Test-Client", Version: "1.0.0"}, options...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	// The connection outlives the context of the request opening it.
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = client.Initialize(ctx, &InitializeRequest{})
+	cancel()
+	require.NoError(t, err)
+	return client
+}
+
+func TestWebSocketServer_Tools(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	server.RegisterTool(NewTool("progress-tool"), progressTool)
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+	assert.NotEmpty(t, client.GetSessionID())
+
+	tools, err := client.ListTools(context.Background(), &ListToolsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, tools.Tools, 2)
+
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+	_, err = callTool(client, "unknown")
+	assert.Error(t, err)
+
+	collector := &progressCollector{}
+	result := callProgressTool(t, client, collector)
+	assert.Equal(t, "done", result.Content[0].(TextContent).Text)
+	assertProgressEvents(t, collector.get())
+}
+
+func TestWebSocketServer_ServerRequestsAndNotifications(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0")
+	server.RegisterTool(NewTool("roots"), func(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+		roots, err := server.ListRoots(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewTextResult(roots.Roots[0].URI), nil
+	})
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+	client.SetRootsProvider(memoryRoots{{URI: "file:///workspace", Name: "workspace"}})
+
+	text, err := callTool(client, "roots")
+	require.NoError(t, err)
+	assert.Equal(t, "file:///workspace", text)
+
+	// The registration of the roots tool is notified too, so the tools may be
+	// listed before the session tool is registered. The results are checked
+	// here, as a refetch may still be running when the client is closed.
+	type refetch struct {
+		tools []Tool
+		err   error
+	}
+	refetched := make(chan refetch, 10)
+	client.SetToolListChangedHandler(func(tools []Tool, err error) {
+		refetched <- refetch{tools: tools, err: err}
+	}, true)
+	require.NoError(t, server.RegisterSessionTool(client.GetSessionID(), NewTool("unlocked"), textTool("unlocked")))
+	for tools := 0; tools < 2; {
+		select {
+		case result := <-refetched:
+			require.NoError(t, result.err)
+			tools = len(result.tools)
+		case <-time.After(5 * time.Second):
+			t.Fatal("tools list_changed not received")
+		}
+	}
+	require.NoError(t, client.Close())
+}
+
+func TestWebSocketServerConn_DeliverResponse(t *testing.T) {
+	c := &webSocketServerConn{
+		handler: &httpServerHandler{logger: GetDefaultLogger()},
+		pending: make(map[string]chan JSONRPCMessage),
+	}
+	register := func(id interface{}) chan JSONRPCMessage {
+		key, ok := requestIDKey(id)
+		require.True(t, ok)
+		respChan := make(chan JSONRPCMessage, 1)
+		c.pending[key] = respChan
+		return respChan
+	}
+	numeric := register(uint64(1))
+	str := register("server_req_1")
+
+	// A response answers a request only if their IDs have the same type.
+	c.deliverResponse([]byte(`{"jsonrpc":"2.0","id":"1","result":{}}`))
+	assert.Empty(t, numeric)
+	c.deliverResponse([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	assert.Len(t, numeric, 1)
+	c.deliverResponse([]byte(`{"jsonrpc":"2.0","id":"server_req_1","result":{}}`))
+	assert.Len(t, str, 1)
+
+	_, ok := requestIDKey(nil)
+	assert.False(t, ok)
+	_, ok = requestIDKey(map[string]interface{}{})
+	assert.False(t, ok)
+}
+
+func TestWebSocketServer_SessionLifecycle(t *testing.T) {
+	closed := make(chan SessionCloseReason, 1)
+	server := NewServer("Test-Server", "1.0.0", WithSessionHooks(&SessionHooks{
+		OnSessionClosed: func(ctx context.Context, session Session, reason SessionCloseReason) {
+			closed <- reason
+		},
+	}))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+	sessionID := client.GetSessionID()
+	_, ok := server.httpHandler.sessionManager.getSession(sessionID)
+	assert.True(t, ok)
+
+	// Closing the connection closes the session.
+	require.NoError(t, client.Close())
+	select {
+	case reason := <-closed:
+		assert.Equal(t, SessionCloseReasonDeleted, reason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("session not closed")
+	}
+	_, ok = server.httpHandler.sessionManager.getSession(sessionID)
+	assert.False(t, ok)
+	_, err := callTool(client, "echo")
+	assert.Error(t, err)
+}
+
+func TestWebSocketServer_EnabledOnHTTPHandler(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithWebSocketEnabled(true))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	httpServer := httptest.NewServer(server.HTTPHandler())
+	defer httpServer.Close()
+
+	client := newWebSocketTestClient(t, httpServer.URL+"/mcp")
+	text, err := callTool(client, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+
+	// The streamable HTTP transport is still served on the same path.
+	httpClient, err := NewClient(httpServer.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer httpClient.Close()
+	_, err = httpClient.Initialize(context.Background(), &InitializeRequest{})
+	require.NoError(t, err)
+	text, err = callTool(httpClient, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+
+	disabled := httptest.NewServer(NewServer("Test-Server", "1.0.0").HTTPHandler())
+	defer disabled.Close()
+	client, err = NewWebSocketClient(disabled.URL+"/mcp", Implementation{Name: "Test-Client", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Initialize(context.Background(), &InitializeRequest{})
+	assert.Error(t, err)
+}
+
+func TestWebSocketServer_Handshake(t *testing.T) {
+	serverURL := newWebSocketTestServer(t, NewServer("Test-Server", "1.0.0"))
+
+	req, key, err := websocket.NewClientRequest(context.Background(), serverURL, []string{"other"})
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_, err = websocket.NewClientConn(resp, key, []string{"other"})
+	var handshakeErr *websocket.HandshakeError
+	require.ErrorAs(t, err, &handshakeErr)
+	assert.Equal(t, http.StatusBadRequest, handshakeErr.StatusCode)
+
+	req, key, err = websocket.NewClientRequest(context.Background(), serverURL, []string{WebSocketSubprotocol})
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	conn, err := websocket.NewClientConn(resp, key, []string{WebSocketSubprotocol})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, WebSocketSubprotocol, conn.Subprotocol())
+	assert.NotEmpty(t, resp.Header.Get(httputil.SessionIDHeader))
+}
+
+func TestWebSocketServer_MaxMessageSize(t *testing.T) {
+	server := NewServer("Test-Server", "1.0.0", WithServerWebSocketMaxMessageSize(1024))
+	server.RegisterTool(NewTool("echo"), textTool("echo"))
+	client := newWebSocketTestClient(t, newWebSocketTestServer(t, server))
+
+	_, err := callToolWithArguments(client, "echo", map[string]interface{}{"data": strings.Repeat("a", 2048)})
+	assert.Error(t, err)
+	_, err = callTool(client, "echo")
+	assert.Error(t, err, "the connection is closed")
+}